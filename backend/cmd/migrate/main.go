@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// migrate inspects and rolls back versioned schema migrations.
+// The server applies pending migrations on startup; use this to check status or revert.
+//
+//	go run ./cmd/migrate -status
+//	go run ./cmd/migrate -up
+//	go run ./cmd/migrate -down 0
+func main() {
+	status := flag.Bool("status", false, "list migrations and their applied/backfilled state")
+	up := flag.Bool("up", false, "apply pending migrations and backfills")
+	down := flag.Int("down", -1, "revert migrations newer than this version")
+	flag.Parse()
+
+	godotenv.Load()
+	if _, err := config.Init(); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	if err := db.Open(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	switch {
+	case *up:
+		if err := db.MigrateDatabase(db.DB); err != nil {
+			log.Fatal(err)
+		}
+	case *down >= 0:
+		if err := db.RollbackMigrations(db.DB, *down); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("✅ Rolled back to version %d\n", *down)
+	case *status:
+	default:
+		flag.Usage()
+		return
+	}
+
+	migrations, err := db.MigrationStatus(db.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, m := range migrations {
+		state := "pending"
+		if !m.AppliedAt.IsZero() {
+			state = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+			if m.BackfilledAt != nil {
+				state += ", backfilled " + m.BackfilledAt.Format("2006-01-02 15:04:05")
+			}
+		}
+		fmt.Printf("%4d  %-30s %s\n", m.Version, m.Name, state)
+	}
+}
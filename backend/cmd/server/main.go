@@ -1,75 +1,307 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/nolouch/alerts-platform-v2/internal/api"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/logging"
+	"github.com/nolouch/alerts-platform-v2/internal/metrics"
 )
 
 func main() {
 	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("⚠️  No .env file found or unable to load .env file")
+	envErr := godotenv.Load()
+
+	// Load and validate the config file and environment; a bad setting stops startup
+	cfg, err := config.Init()
+	if err != nil {
+		slog.Error("Invalid configuration", "err", err)
+		os.Exit(1)
+	}
+	if err := logging.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		slog.Error("Invalid logging configuration", "err", err)
+		os.Exit(1)
+	}
+	if cfg.ConfigFile != "" {
+		slog.Info("Loaded config file", "path", cfg.ConfigFile)
+	}
+	if envErr != nil {
+		slog.Warn("No .env file found or unable to load .env file")
 	} else {
-		log.Println("✅ Loaded environment variables from .env file")
+		slog.Info("Loaded environment variables from .env file")
 	}
 
 	// Initialize Database
 	if err := db.Init(); err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		slog.Error("Failed to connect to database", "err", err)
+		os.Exit(1)
 	}
 
-	r := gin.Default()
+	// Hot reload: refresh category mappings and the log level on config reload, and reload on SIGHUP
+	config.OnReload(func(cfg *config.Config) {
+		api.ReloadCategories()
+		if err := logging.SetLevel(cfg.LogLevel); err != nil {
+			slog.Warn("Keeping previous log level", "err", err)
+		}
+	})
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			slog.Info("SIGHUP received, reloading configuration")
+			if _, err := config.Reload(); err != nil {
+				slog.Error("Config reload failed, keeping previous config", "err", err)
+			}
+		}
+	}()
+
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("Route registered", "method", method, "path", path, "handler", handler)
+	}
+	r := gin.New()
+	// Client IPs (audit and request logs) come from X-Forwarded-For only behind these
+	// proxies; with none set they are the connection's address
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		slog.Error("Invalid trusted proxies", "err", err)
+		os.Exit(1)
+	}
+	r.Use(gin.Recovery())
+	r.Use(api.RequestLogger())
+	r.Use(api.Metrics())
 
 	// CORS Configuration (Allow Frontend)
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Allow all for dev simplicity (ports change)
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Readiness probe (database + name API breaker)
+	r.GET("/readyz", api.Readyz)
+
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// API Routes
 	v1 := r.Group("/api")
+	v1.Use(api.Authenticate())
+	v1.Use(api.RequestTimeout())
+	v1.Use(api.Anonymize())
 	{
 		v1.GET("/health", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
 
+		// API documentation (OpenAPI 3 and Swagger UI)
+		v1.GET("/openapi.json", api.OpenAPISpec(r))
+		v1.GET("/docs", api.SwaggerUI)
+
+		// Browser login (OIDC) and the caller's identity
+		v1.GET("/auth/login", api.AuthLogin)
+		v1.GET("/auth/callback", api.AuthCallback)
+		v1.GET("/auth/me", api.AuthMe)
+		v1.POST("/auth/logout", api.AuthLogout)
+
+		// Live updates over Server-Sent Events
+		v1.GET("/stream", api.StreamEvents)
+		v1.GET("/activity", api.GetActivity)
+
 		// Components Endpoints
 		v1.GET("/categories", api.GetCategories)
 		v1.GET("/components", api.GetComponents)
 		v1.GET("/components/:name/stats", api.GetComponentStats)
+		v1.GET("/components/:name/burn-rate", api.GetComponentBurnRate)
+		v1.GET("/components/summary", api.GetComponentsSummary)
+		v1.GET("/components/targets", api.GetComponentTargets)
+		v1.GET("/components/overrides", api.GetComponentOverrides)
+		v1.GET("/components/:name/target", api.GetComponentTarget)
+		v1.PUT("/components/:name/target", api.PutComponentTarget)
+		v1.DELETE("/components/:name/target", api.DeleteComponentTarget)
 		v1.GET("/components/:name/rules", api.GetComponentRules)
+		v1.POST("/components/:name/rules", api.CreateComponentRule)
 		v1.PUT("/components/:name/rules", api.UpdateComponentRule)
+		v1.DELETE("/components/:name/rules", api.DeleteComponentRule)
+		v1.GET("/components/:name/rules/history", api.GetComponentRuleHistory)
+		v1.GET("/components/:name/rules/history/:id/diff", api.GetComponentRuleRevisionDiff)
+		v1.GET("/rules/export", api.ExportRules)
+		v1.GET("/rules/drift", api.GetRulesDrift)
+		v1.GET("/rules/lint", api.GetRulesLint)
+		v1.GET("/rules/owners", api.GetRulesOwners)
+		v1.GET("/rules/fake-rates", api.GetRuleFakeRates)
+		v1.GET("/rules/search", api.SearchRules)
+		v1.GET("/rules/audits", api.GetRuleAudits)
+		v1.POST("/rules/audits/run", api.RunRuleAudit)
+		v1.POST("/rules/import", api.ImportRules)
+		v1.GET("/rules/templates", api.GetRuleTemplates)
+		v1.GET("/rules/templates/:name", api.GetRuleTemplate)
+		v1.POST("/rules/templates/:name/instantiate", api.InstantiateRuleTemplate)
+		v1.GET("/rules/:alert/threshold-suggestion", api.GetThresholdSuggestion)
+		v1.GET("/rules/:alert/impact", api.GetRuleChangeImpact)
 
 		// New Dashboard Route
 		v1.GET("/dashboard", api.GetDashboardData)
+		v1.GET("/dashboard/categories", api.GetDashboardCategories)
 		v1.GET("/dashboard/issues", api.GetDashboardIssues)
+		v1.GET("/dashboard/issues/export", api.ExportDashboardIssues)
+		v1.GET("/dashboard/governance", api.GetGovernanceBreakdown)
+		v1.GET("/dashboard/alert-groups", api.GetAlertGroupBreakdown)
+		v1.GET("/dashboard/tiers", api.GetTierBreakdown)
+		v1.GET("/dashboard/trend-by-region", api.GetTrendByRegion)
+		v1.POST("/dashboard/snapshots", api.CreateDashboardSnapshot)
+		v1.GET("/dashboard/snapshots", api.GetDashboardSnapshots)
+		v1.GET("/dashboard/snapshots/:id", api.GetDashboardSnapshot)
+
+		// Read-only share links (signed with SHARE_LINK_SECRET)
+		v1.POST("/share-links", api.CreateShareLink)
+		v1.GET("/shared/:token", api.GetSharedView)
+
+		// Saved views (named filter combinations)
+		v1.GET("/views", api.GetSavedViews)
+		v1.POST("/views", api.CreateSavedView)
+		v1.GET("/views/:id", api.GetSavedView)
+		v1.PUT("/views/:id", api.UpdateSavedView)
+		v1.DELETE("/views/:id", api.DeleteSavedView)
+
+		// Analytics
+		v1.GET("/analytics/flapping", api.GetFlappingSignatures)
+
+		// Tenant metadata (tier/plan)
+		v1.GET("/tenants", api.GetTenants)
+		v1.GET("/tenants/:id/stats", api.GetTenantStats)
+		v1.POST("/tenants/import", api.ImportTenants)
+		v1.POST("/tenants/sync", api.SyncTenants)
+		v1.GET("/tenants/digests", api.GetTenantDigests)
+		v1.GET("/tenants/digests/preview", api.PreviewTenantDigest)
+		v1.POST("/tenants/digests/run", api.RunTenantDigest)
+
+		// Cluster metadata (region/provider)
+		v1.GET("/clusters", api.GetClusters)
+		v1.POST("/clusters/import", api.ImportClusters)
+
+		// Reports
+		v1.GET("/reports/component-reconciliation", api.GetComponentReconciliation)
+		v1.GET("/reports/component-attribution", api.GetComponentAttributionReport)
+		v1.GET("/reports/unmapped-values", api.GetUnmappedValuesReport)
+		v1.GET("/reports", api.GetReports)
+		v1.POST("/reports/run", api.RunReport)
+		v1.GET("/reports/:id", api.GetReport)
 		v1.POST("/issues/:id/mute", api.MuteIssue)
+		v1.DELETE("/issues/:id/mute", api.UnmuteIssue)
+		v1.GET("/mute-suppressions", api.GetMuteSuppressions)
+		v1.DELETE("/mute-suppressions/:id", api.DeleteMuteSuppression)
+		v1.GET("/mute-rules", api.GetMuteRules)
+		v1.POST("/mute-rules", api.CreateMuteRule)
+		v1.PUT("/mute-rules/:id", api.UpdateMuteRule)
+		v1.DELETE("/mute-rules/:id", api.DeleteMuteRule)
+		v1.GET("/issues/:id/timeline", api.GetIssueTimeline)
+		v1.POST("/issues/:id/events", api.CreateIssueEvent)
+		v1.PATCH("/issues/:id/component", api.ReassignIssueComponent)
+		v1.DELETE("/issues/:id/component", api.ClearIssueComponentOverride)
+		v1.GET("/issues/:id/classification", api.GetIssueClassification)
+		v1.POST("/issues/:id/classification", api.ClassifyIssue)
+		v1.DELETE("/issues/:id/classification", api.DeleteIssueClassification)
+		v1.POST("/issues/:id/ack", api.AckIssue)
+		v1.GET("/issues/:id/transitions", api.GetIssueTransitions)
+		v1.POST("/issues/:id/transitions", api.TransitionIssue)
+		v1.PUT("/issues/:id/assignee", api.AssignIssue)
+		v1.POST("/issues/:id/comments", api.CommentOnIssue)
+		v1.POST("/issues/batch-get", api.BatchGetIssues)
+		v1.GET("/issues/search", api.SearchIssues)
+
+		// Feeds (token-authenticated via FEEDS_TOKEN)
+		v1.GET("/feeds/critical.atom", api.GetCriticalFeed)
 		// New Rules Notify Manager Routes
 		v1.GET("/rules-notify-manager", api.GetRulesNotifyConfig)
 		v1.PUT("/rules-notify-manager", api.UpdateRulesNotifyConfig)
+		v1.GET("/rules-notify-manager/evaluate", api.EvaluateRulesNotifyConfig)
 
 		// Rule Tasks Routes
 
 		v1.GET("/tasks", api.HandleGetTasks)
 		v1.POST("/tasks", api.HandleCreateTask)
+
+		// Admin Routes
+		v1.POST("/admin/reload", api.ReloadConfig)
+		v1.POST("/admin/re-enrich", api.ReEnrichIssues)
+		v1.GET("/admin/re-enrich", api.GetReEnrichStatus)
+		v1.POST("/admin/normalize", api.NormalizeIssueValues)
+		v1.POST("/admin/simulate", api.SimulateNotifications)
+		v1.POST("/admin/synthetic-alert", api.InjectSyntheticAlert)
+		v1.GET("/admin/search", api.GetSearchIndexStatus)
+		v1.POST("/admin/search/reindex", api.ReindexSearch)
+		v1.GET("/admin/query-stats", api.GetQueryStats)
+		v1.GET("/admin/name-cache", api.GetNameCache)
+		v1.DELETE("/admin/name-cache", api.DeleteNameCache)
+		v1.DELETE("/admin/name-cache/:id", api.DeleteNameCache)
+		v1.DELETE("/admin/query-stats", api.ResetQueryStats)
+		v1.GET("/admin/exports", api.GetWarehouseExports)
+		v1.POST("/admin/exports/run", api.RunWarehouseExport)
+		v1.GET("/admin/retention", api.GetRetention)
+		v1.GET("/admin/rollups", api.GetStatsRollup)
+		v1.POST("/admin/rollups/rebuild", api.RebuildStatsRollup)
+		v1.POST("/admin/retention/run", api.RunRetention)
+		v1.GET("/admin/failed-issues", api.GetFailedIssues)
+		v1.GET("/admin/failed-issues/:id", api.GetFailedIssue)
+		v1.POST("/admin/failed-issues/:id/requeue", api.RequeueFailedIssue)
+		v1.DELETE("/admin/failed-issues/:id", api.DeleteFailedIssue)
+		v1.GET("/admin/api-keys", api.GetAPIKeys)
+		v1.POST("/admin/api-keys", api.CreateAPIKey)
+		v1.PUT("/admin/api-keys/:id", api.UpdateAPIKey)
+		v1.DELETE("/admin/api-keys/:id", api.DeleteAPIKey)
+		v1.GET("/audit", api.GetAuditLog)
+		v1.GET("/admin/roles", api.GetUserRoles)
+		v1.PUT("/admin/roles/:email", api.AssignUserRole)
+		v1.DELETE("/admin/roles/:email", api.DeleteUserRole)
+
+		// Background jobs (exports, backfills, audits)
+		v1.GET("/jobs", api.GetJobs)
+		v1.POST("/jobs/:type", api.CreateJob)
+		v1.GET("/jobs/:id", api.GetJob)
+		v1.GET("/jobs/:id/artifact", api.DownloadJobArtifact)
+		v1.DELETE("/jobs/:id", api.DeleteJob)
+
+		// Notification thresholds and routing
+		v1.GET("/admin/notifications", api.GetNotificationRules)
+		v1.POST("/admin/notifications", api.CreateNotificationRule)
+		v1.GET("/admin/notifications/:id", api.GetNotificationRule)
+		v1.PUT("/admin/notifications/:id", api.UpdateNotificationRule)
+		v1.DELETE("/admin/notifications/:id", api.DeleteNotificationRule)
+		v1.POST("/admin/notifications/:id/test", api.TestNotificationRule)
+
+		// Outbound webhooks
+		v1.GET("/admin/webhooks", api.GetWebhooks)
+		v1.POST("/admin/webhooks", api.CreateWebhook)
+		v1.GET("/admin/webhooks/:id", api.GetWebhook)
+		v1.PUT("/admin/webhooks/:id", api.UpdateWebhook)
+		v1.DELETE("/admin/webhooks/:id", api.DeleteWebhook)
+		v1.POST("/admin/webhooks/:id/test", api.TestWebhook)
+		v1.GET("/admin/webhooks/:id/deliveries", api.GetWebhookDeliveries)
+		v1.GET("/admin/webhook-deliveries", api.GetWebhookDeliveries)
+
+		// Data sync (JIRA and other ingestion sources)
+		api.RegisterUpdateRoutes(v1, db.DB)
+
+		// Inbound webhooks pushing records of an ingestion source, verified per source
+		api.RegisterIngestWebhooks(v1)
 	}
 
 	// Serve Frontend Static Files (for production/release)
 	// Only serves if "public" directory exists (created by release process)
 	if _, err := os.Stat("./public"); err == nil {
-		log.Println("✅ Detected 'public' directory, serving static files")
+		slog.Info("Detected 'public' directory, serving static files")
 		r.Static("/assets", "./public/assets")
 
 		// Serve other root files if needed, or rely on NoRoute for SPA fallthrough
@@ -85,17 +317,17 @@ func main() {
 		})
 	}
 
-	// Register Update Routes (for JIRA data sync)
-	// Register Update Routes (for JIRA data sync)
-	api.RegisterUpdateRoutes(r, db.DB)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8818"
-	}
-	host := os.Getenv("HOST")
-	addr := host + ":" + port
+	// Periodic rule audit (lint, coverage, drift, noise)
+	api.StartRuleAuditScheduler(db.DB)
+	api.StartWarehouseExportScheduler(db.DB)
+	api.StartRetentionScheduler(db.DB)
+	api.StartTenantDigestScheduler(db.DB)
+	api.StartReportScheduler(db.DB)
+	api.StartSearchIndexer(db.DB)
+	api.StartJobSweeper(db.DB)
+	api.StartNameCache(db.DB)
+	api.StartQueueMetrics(db.DB)
 
-	log.Printf("Server running on %s", addr)
-	r.Run(addr)
+	slog.Info("Server running", "addr", cfg.Addr)
+	r.Run(cfg.Addr)
 }
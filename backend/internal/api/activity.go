@@ -0,0 +1,318 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Activity feed sources
+const (
+	activitySourceAudit      = "audit"
+	activitySourceEscalation = "escalation"
+	activitySourceHealth     = "health"
+	activitySourceSync       = "sync"
+	activitySourceTask       = "task"
+)
+
+const (
+	activityDefaultLimit = 50
+	activityMaxLimit     = 200
+)
+
+// ActivityEntry is one event in the platform-wide activity feed
+type ActivityEntry struct {
+	Time       time.Time `json:"time"`
+	Source     string    `json:"source"` // audit, task, sync, escalation, health
+	Type       string    `json:"type"`   // e.g. rule.update, issue.mute, task.merged, sync.failed, health.critical
+	Actor      string    `json:"actor,omitempty"`
+	Component  string    `json:"component,omitempty"`
+	Resource   string    `json:"resource,omitempty"`
+	ResourceID string    `json:"resource_id,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	id         uint
+}
+
+// activityFilter holds the feed's request filters and page position
+type activityFilter struct {
+	component string
+	actor     string
+	cursor    *activityCursor
+	limit     int
+}
+
+// keyset restricts a source's rows to those after the cursor in (time, source, id)
+// descending order
+func (f activityFilter) keyset(q *gorm.DB, source, timeColumn, idColumn string) *gorm.DB {
+	cur := f.cursor
+	switch {
+	case cur == nil:
+		return q
+	case source < cur.Source:
+		return q.Where(timeColumn+" <= ?", cur.Time)
+	case source > cur.Source:
+		return q.Where(timeColumn+" < ?", cur.Time)
+	}
+	return q.Where("("+timeColumn+" < ? OR ("+timeColumn+" = ? AND "+idColumn+" < ?))", cur.Time, cur.Time, cur.ID)
+}
+
+// after reports whether an entry comes after the cursor, for sources filtered in Go
+func (f activityFilter) after(e ActivityEntry) bool {
+	cur := f.cursor
+	if cur == nil {
+		return true
+	}
+	if !e.Time.Equal(cur.Time) {
+		return e.Time.Before(cur.Time)
+	}
+	if e.Source != cur.Source {
+		return e.Source < cur.Source
+	}
+	return e.id < cur.ID
+}
+
+// issueComponentCondition matches issue IDs in the filtered component
+const issueComponentCondition = "IN (" + componentIssueIDs + ")"
+
+// GetActivity returns a merged feed of recent events across the platform, newest first:
+// audited mutations (rule edits, mutes, task requests, triggers), task status changes,
+// finished syncs, escalations and component health transitions. Optional ?component= and
+// ?actor= filter it; sources without that attribute (e.g. syncs for ?actor=) drop out.
+// Pages with ?limit= (default 50) and the returned next_cursor passed as ?cursor=.
+func GetActivity(c *gin.Context) {
+	f := activityFilter{
+		component: strings.TrimSpace(c.Query("component")),
+		actor:     strings.TrimSpace(c.Query("actor")),
+		limit:     activityDefaultLimit,
+	}
+	if v := c.Query("limit"); v != "" {
+		fmt.Sscanf(v, "%d", &f.limit)
+		if f.limit <= 0 || f.limit > activityMaxLimit {
+			f.limit = activityDefaultLimit
+		}
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := decodeActivityCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		f.cursor = &cur
+	}
+
+	dbc := dbFor(c)
+	entries := []ActivityEntry{}
+	for _, load := range []func(*gorm.DB, activityFilter) ([]ActivityEntry, error){
+		auditActivity,
+		taskActivity,
+		syncActivity,
+		escalationActivity,
+		healthActivity,
+	} {
+		items, err := load(dbc, f)
+		if err != nil {
+			if requestTimedOut(c) {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		entries = append(entries, items...)
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		if a.Source != b.Source {
+			return a.Source > b.Source
+		}
+		return a.id > b.id
+	})
+
+	nextCursor := ""
+	if len(entries) > f.limit {
+		entries = entries[:f.limit]
+		last := entries[len(entries)-1]
+		nextCursor = encodeActivityCursor(activityCursor{Time: last.Time, Source: last.Source, ID: last.id})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":       entries,
+		"next_cursor": nextCursor,
+	})
+}
+
+// auditActivity lists audited mutations. Issue-level entries (mutes) match a component
+// through the issue's components.
+func auditActivity(dbc *gorm.DB, f activityFilter) ([]ActivityEntry, error) {
+	query := f.keyset(dbc.Model(&models.AuditLog{}), activitySourceAudit, "created_at", "id")
+	if f.actor != "" {
+		query = query.Where("actor = ?", f.actor)
+	}
+	if f.component != "" {
+		query = query.Where("(component = ? OR (resource_type = 'issue' AND resource_id "+issueComponentCondition+"))",
+			f.component, f.component)
+	}
+
+	var rows []models.AuditLog
+	if err := query.Select("id, created_at, actor, action, resource_type, resource_id, component").
+		Order("created_at DESC, id DESC").Limit(f.limit + 1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]ActivityEntry, 0, len(rows))
+	for _, r := range rows {
+		entries = append(entries, ActivityEntry{
+			Time:       r.CreatedAt.UTC(),
+			Source:     activitySourceAudit,
+			Type:       r.ResourceType + "." + r.Action,
+			Actor:      r.Actor,
+			Component:  r.Component,
+			Resource:   r.ResourceType,
+			ResourceID: r.ResourceID,
+			id:         r.ID,
+		})
+	}
+	return entries, nil
+}
+
+// taskActivity lists rule change tasks that moved past submitted, at their latest status;
+// the request itself is in the audit log
+func taskActivity(dbc *gorm.DB, f activityFilter) ([]ActivityEntry, error) {
+	if f.actor != "" {
+		return nil, nil
+	}
+	query := f.keyset(dbc.Model(&models.Task{}), activitySourceTask, "updated_at", "id").
+		Where("status != '' AND status != 'submitted'")
+	if f.component != "" {
+		query = query.Where("component = ?", f.component)
+	}
+
+	var tasks []models.Task
+	if err := query.Select("id, updated_at, rule_name, type, status, component, pr_link").
+		Order("updated_at DESC, id DESC").Limit(f.limit + 1).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]ActivityEntry, 0, len(tasks))
+	for _, t := range tasks {
+		detail := strings.TrimSpace(t.Type + " " + t.RuleName)
+		if t.PRLink != "" {
+			detail += " (" + t.PRLink + ")"
+		}
+		entries = append(entries, ActivityEntry{
+			Time:       t.UpdatedAt.UTC(),
+			Source:     activitySourceTask,
+			Type:       "task." + t.Status,
+			Component:  t.Component,
+			Resource:   "task",
+			ResourceID: fmt.Sprint(t.ID),
+			Detail:     detail,
+			id:         t.ID,
+		})
+	}
+	return entries, nil
+}
+
+// syncActivity lists the last finished run of each ingestion source. Only the latest run
+// per source is kept, so older syncs are not in the feed.
+func syncActivity(dbc *gorm.DB, f activityFilter) ([]ActivityEntry, error) {
+	if f.actor != "" || f.component != "" {
+		return nil, nil
+	}
+	var states []models.IngestSyncState
+	if err := dbc.Where("status != '' AND status != 'running'").Find(&states).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]ActivityEntry, 0, len(states))
+	for _, s := range states {
+		detail := fmt.Sprintf("fetched %d, stored %d", s.LastFetched, s.LastStored)
+		if s.LastError != "" {
+			detail += ": " + s.LastError
+		}
+		e := ActivityEntry{
+			Time:       s.UpdatedAt.UTC(),
+			Source:     activitySourceSync,
+			Type:       "sync." + s.Status,
+			Resource:   "sync",
+			ResourceID: s.Source,
+			Detail:     detail,
+		}
+		if f.after(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// escalationActivity lists escalations recorded on issue timelines
+func escalationActivity(dbc *gorm.DB, f activityFilter) ([]ActivityEntry, error) {
+	query := f.keyset(dbc.Model(&models.IssueEvent{}), activitySourceEscalation, "created_at", "id").
+		Where("type = ?", IssueEventEscalation)
+	if f.actor != "" {
+		query = query.Where("actor = ?", f.actor)
+	}
+	if f.component != "" {
+		query = query.Where("issue_id "+issueComponentCondition, f.component)
+	}
+
+	var events []models.IssueEvent
+	if err := query.Order("created_at DESC, id DESC").Limit(f.limit + 1).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]ActivityEntry, 0, len(events))
+	for _, e := range events {
+		entries = append(entries, ActivityEntry{
+			Time:       e.CreatedAt.UTC(),
+			Source:     activitySourceEscalation,
+			Type:       "issue.escalation",
+			Actor:      e.Actor,
+			Resource:   "issue",
+			ResourceID: e.IssueID,
+			Detail:     e.Detail,
+			id:         e.ID,
+		})
+	}
+	return entries, nil
+}
+
+// healthActivity lists component health changes; a component's first recorded status is
+// a baseline, not a transition
+func healthActivity(dbc *gorm.DB, f activityFilter) ([]ActivityEntry, error) {
+	if f.actor != "" {
+		return nil, nil
+	}
+	query := f.keyset(dbc.Model(&models.ComponentHealthTransition{}), activitySourceHealth, "created_at", "id").
+		Where("from_status != ''")
+	if f.component != "" {
+		query = query.Where("component = ?", f.component)
+	}
+
+	var transitions []models.ComponentHealthTransition
+	if err := query.Order("created_at DESC, id DESC").Limit(f.limit + 1).Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]ActivityEntry, 0, len(transitions))
+	for _, t := range transitions {
+		entries = append(entries, ActivityEntry{
+			Time:      t.CreatedAt.UTC(),
+			Source:    activitySourceHealth,
+			Type:      "health." + strings.ToLower(t.To),
+			Component: t.Component,
+			Detail:    t.From + " → " + t.To,
+			id:        t.ID,
+		})
+	}
+	return entries, nil
+}
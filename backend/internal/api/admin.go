@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// ReloadConfig re-reads configuration and applies it to running services
+func ReloadConfig(c *gin.Context) {
+	cfg, err := config.Reload()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"config": gin.H{
+			"config_file":               cfg.ConfigFile,
+			"component_categories_path": cfg.ComponentCategoriesPath,
+			"rules_categories_path":     cfg.RulesCategoriesPath,
+			"rules_notify_path":         cfg.RulesNotifyPath,
+			"runbooks_repo_path":        cfg.RunbooksRepoPath,
+			"scheduler_interval":        cfg.SchedulerInterval.String(),
+		},
+	})
+}
+
+// NormalizeIssueValues rewrites stored priorities and statuses through the current
+// normalization table, e.g. after adding aliases for values in the unmapped report
+func NormalizeIssueValues(c *gin.Context) {
+	updated, err := services.GetNormalizationConfig().ApplyToStored(db.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "updated": updated})
+		return
+	}
+	logFor(c).Info("Normalized stored issue values", "updated", updated)
+	c.JSON(http.StatusOK, gin.H{"success": true, "updated": updated})
+}
+
+// ReEnrichRequest selects the issues to re-process by created date (YYYY-MM-DD, end inclusive)
+type ReEnrichRequest struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// dateRange returns the half-open [start, end) range to re-process, defaulting to the last 30 days
+func (req ReEnrichRequest) dateRange() (time.Time, time.Time, error) {
+	endDate := time.Now().UTC()
+	if req.EndDate != "" {
+		t, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end_date, expected YYYY-MM-DD")
+		}
+		endDate = t
+	}
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	startDate := endDate.AddDate(0, 0, -30)
+	if req.StartDate != "" {
+		t, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start_date, expected YYYY-MM-DD")
+		}
+		startDate = t
+	}
+	if !startDate.Before(endDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start_date must not be after end_date")
+	}
+	return startDate, endDate, nil
+}
+
+var (
+	reEnrichMu       sync.Mutex
+	reEnrichProgress *services.ReEnrichProgress
+)
+
+func setReEnrichProgress(p services.ReEnrichProgress) {
+	reEnrichMu.Lock()
+	defer reEnrichMu.Unlock()
+	reEnrichProgress = &p
+}
+
+// ReEnrichIssues re-runs extraction over stored raw payloads in the background, so
+// improved extraction logic applies to existing issues without a JIRA re-import.
+// Defaults to the last 30 days.
+func ReEnrichIssues(c *gin.Context) {
+	var req ReEnrichRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	startDate, endDate, err := req.dateRange()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reEnrichMu.Lock()
+	if reEnrichProgress != nil && reEnrichProgress.Status == "running" {
+		current := *reEnrichProgress
+		reEnrichMu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "re-enrichment already in progress", "progress": current})
+		return
+	}
+	started := services.ReEnrichProgress{
+		Status:    "running",
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.AddDate(0, 0, -1).Format("2006-01-02"),
+		StartedAt: time.Now().UTC(),
+	}
+	reEnrichProgress = &started
+	reEnrichMu.Unlock()
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		setReEnrichProgress(services.ReEnrichProgress{Status: "failed", Error: err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	go func() {
+		updater := services.NewOfflineDataUpdater(sqlDB)
+		var result services.ReEnrichProgress
+
+		// Share the sync lock so re-enrichment never interleaves with a JIRA import
+		ran, err := services.RunExclusive(db.DB, syncLockName, syncLockTTL, func() error {
+			var runErr error
+			result, runErr = updater.ReEnrich(context.Background(), startDate, endDate, func(p services.ReEnrichProgress) {
+				p.StartDate, p.EndDate = started.StartDate, started.EndDate
+				setReEnrichProgress(p)
+			})
+			return runErr
+		})
+
+		now := time.Now().UTC()
+		result.StartDate, result.EndDate = started.StartDate, started.EndDate
+		if result.StartedAt.IsZero() {
+			result.StartedAt = started.StartedAt
+		}
+		result.FinishedAt = &now
+		switch {
+		case err != nil:
+			result.Status = "failed"
+			result.Error = err.Error()
+			slog.Error("Re-enrichment failed", "err", err)
+		case !ran:
+			result.Status = "failed"
+			result.Error = "another replica holds the sync lock"
+			slog.Warn("Skipping re-enrichment: another replica holds the sync lock")
+		default:
+			result.Status = "completed"
+			slog.Info("Re-enrichment completed", "updated", result.Updated, "skipped", result.Skipped, "failed", result.Failed)
+		}
+		setReEnrichProgress(result)
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success":  true,
+		"message":  "Re-enrichment started in background",
+		"progress": started,
+	})
+}
+
+// GetReEnrichStatus returns the progress of the current or last re-enrichment
+func GetReEnrichStatus(c *gin.Context) {
+	reEnrichMu.Lock()
+	defer reEnrichMu.Unlock()
+
+	if reEnrichProgress == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "idle"})
+		return
+	}
+	c.JSON(http.StatusOK, reEnrichProgress)
+}
+
+// GetQueryStats returns per-pattern query timings collected since start or the last reset.
+// Optional: ?sort=total|avg|max|count ?limit=50
+func GetQueryStats(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "50"), "%d", &limit)
+	if limit <= 0 {
+		limit = 50
+	}
+
+	stats, since := db.QueryStats(c.DefaultQuery("sort", "total"), limit)
+	c.JSON(http.StatusOK, gin.H{
+		"since":                since,
+		"slow_query_threshold": config.Get().SlowQueryThreshold.String(),
+		"items":                stats,
+	})
+}
+
+// ResetQueryStats clears the collected query timings
+func ResetQueryStats(c *gin.Context) {
+	db.ResetQueryStats()
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
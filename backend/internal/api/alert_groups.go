@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertGroupBreakdown summarizes alerts for one alertgroup
+type AlertGroupBreakdown struct {
+	AlertGroup string `json:"alert_group"`
+	BreakdownStats
+}
+
+// alertGroupUnassigned labels alerts without an alertgroup
+const alertGroupUnassigned = "unassigned"
+
+// buildAlertGroupFilterCondition filters by comma separated alertgroups;
+// "unassigned" matches alerts without one
+func buildAlertGroupFilterCondition(value string) string {
+	return buildOptionalInFilterCondition("alert_group", value, alertGroupUnassigned)
+}
+
+// GetAlertGroupBreakdown returns counts and trends per alertgroup.
+// Drill down with ?alert_group= here and on the dashboard/issue endpoints.
+func GetAlertGroupBreakdown(c *gin.Context) {
+	expr := "CASE WHEN alert_group IS NULL OR alert_group = '' THEN '" + alertGroupUnassigned + "' ELSE alert_group END"
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	groups, dateRange := breakdownBy(c, p, expr, buildStabilityGovernanceFilterCondition())
+
+	items := make([]AlertGroupBreakdown, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, AlertGroupBreakdown{AlertGroup: g.Value, BreakdownStats: g.Stats})
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":      items,
+		"date_range": dateRange,
+	})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// pseudonymFilters are the query parameters whose pseudonyms are mapped back to identities
+var pseudonymFilters = []string{"tenant_id", "cluster_id"}
+
+// anonymizedHeader marks responses that carry no raw identities, as set by the writer
+// and by handlers that pseudonymize their own output
+const anonymizedHeader = "X-Anonymized"
+
+// Anonymize pseudonymizes tenant and cluster identities in every JSON and CSV response
+// while ANONYMIZE is on. Other responses are refused unless their handler pseudonymized
+// them itself and said so with markAnonymized, so no format leaks identities by default.
+// Pseudonyms passed back as tenant_id/cluster_id filters are resolved, so drill-downs
+// keep working during a demo.
+func Anonymize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Get().Anonymize {
+			c.Next()
+			return
+		}
+		resolvePseudonymFilters(c)
+
+		w := &anonymizingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		w.flush()
+	}
+}
+
+func resolvePseudonymFilters(c *gin.Context) {
+	query := c.Request.URL.Query()
+	changed := false
+	for _, key := range pseudonymFilters {
+		values := query[key]
+		for i, v := range values {
+			parts := strings.Split(v, ",")
+			for j, p := range parts {
+				if id, ok := services.ResolvePseudonym(strings.TrimSpace(p)); ok {
+					parts[j] = id
+					changed = true
+				}
+			}
+			values[i] = strings.Join(parts, ",")
+		}
+	}
+	if changed {
+		c.Request.URL.RawQuery = query.Encode()
+	}
+}
+
+// markAnonymized lets a response the anonymizing writer can't rewrite through while
+// ANONYMIZE is on: the handler has pseudonymized it itself, or it holds no tenant or
+// cluster data
+func markAnonymized(c *gin.Context) {
+	if config.Get().Anonymize {
+		c.Header(anonymizedHeader, "true")
+	}
+}
+
+// anonymizingWriter holds the response back until the handler is done so the whole
+// document can be rewritten. Responses marked anonymized pass straight through; any other
+// content is dropped and answered with an error.
+type anonymizingWriter struct {
+	gin.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	passthrough bool
+	refused     bool
+}
+
+func (w *anonymizingWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *anonymizingWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *anonymizingWriter) Write(data []byte) (int, error) {
+	if !w.passthrough && !w.refused && !anonymizable(w.Header().Get("Content-Type")) {
+		if w.Header().Get(anonymizedHeader) == "" {
+			w.refused = true
+		} else {
+			w.passthrough = true
+			if w.status != 0 {
+				w.ResponseWriter.WriteHeader(w.status)
+			}
+		}
+	}
+	switch {
+	case w.passthrough:
+		return w.ResponseWriter.Write(data)
+	case w.refused:
+		return len(data), nil
+	}
+	return w.buf.Write(data)
+}
+
+func (w *anonymizingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *anonymizingWriter) Status() int {
+	if w.passthrough || w.status == 0 {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *anonymizingWriter) Written() bool {
+	return w.passthrough || w.status != 0 || w.buf.Len() > 0
+}
+
+func (w *anonymizingWriter) Flush() {
+	if w.passthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func anonymizable(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "text/csv")
+}
+
+func (w *anonymizingWriter) flush() {
+	if w.passthrough {
+		return
+	}
+	body := w.buf.Bytes()
+	if w.refused {
+		for _, h := range []string{"Content-Disposition", "Content-Encoding"} {
+			w.Header().Del(h)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.status = http.StatusForbidden
+		body = []byte(`{"error":"this response can't be anonymized and is unavailable while ANONYMIZE is on"}`)
+	} else if len(body) > 0 {
+		var out []byte
+		var err error
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+			out, err = services.AnonymizeCSV(body)
+		} else {
+			out, err = services.AnonymizeJSON(body)
+		}
+		if err != nil {
+			// Never fall back to the original identities
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.status = http.StatusInternalServerError
+			out = []byte(`{"error":"failed to anonymize response"}`)
+		}
+		body = out
+		w.Header().Set(anonymizedHeader, "true")
+	}
+	w.Header().Del("Content-Length")
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	w.ResponseWriter.Write(body)
+}
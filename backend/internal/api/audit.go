@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// audit records a mutation made by the caller
+func audit(c *gin.Context, action, resource, id string, before, after interface{}) {
+	auditComponent(c, "", action, resource, id, before, after)
+}
+
+// auditComponent records a mutation of a resource owned by a component, so the activity
+// feed can filter it by component
+func auditComponent(c *gin.Context, component, action, resource, id string, before, after interface{}) {
+	services.RecordAudit(dbFor(c), services.AuditEntry{
+		Actor:        currentActor(c),
+		RemoteAddr:   c.ClientIP(),
+		Action:       action,
+		ResourceType: resource,
+		ResourceID:   id,
+		Component:    component,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// AuditLogResponse is an audit entry with its payloads as JSON rather than strings
+type AuditLogResponse struct {
+	models.AuditLog
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// parseAuditTime accepts RFC 3339 or a date (UTC midnight)
+func parseAuditTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// GetAuditLog lists recorded mutations, newest first. Optional: ?actor=, ?resource=,
+// ?resource_id=, ?action=, ?since= and ?until= (RFC 3339 or YYYY-MM-DD), ?limit=100
+func GetAuditLog(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "100"), "%d", &limit)
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	query := dbFor(c).Order("created_at DESC, id DESC").Limit(limit)
+	for param, column := range map[string]string{
+		"actor":       "actor",
+		"resource":    "resource_type",
+		"resource_id": "resource_id",
+		"action":      "action",
+	} {
+		if v := c.Query(param); v != "" {
+			query = query.Where(column+" = ?", v)
+		}
+	}
+	for param, op := range map[string]string{"since": ">=", "until": "<"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := parseAuditTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s %q, want RFC 3339 or YYYY-MM-DD", param, v)})
+			return
+		}
+		query = query.Where("created_at "+op+" ?", t)
+	}
+
+	var entries []models.AuditLog
+	if err := query.Find(&entries).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]AuditLogResponse, 0, len(entries))
+	for _, e := range entries {
+		item := AuditLogResponse{AuditLog: e}
+		if e.Before != "" {
+			item.Before = json.RawMessage(e.Before)
+		}
+		if e.After != "" {
+			item.After = json.RawMessage(e.After)
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
@@ -0,0 +1,384 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// identityContextKey holds the *services.Identity that authenticated the request
+const identityContextKey = "identity"
+
+// authExemptRoutes carry their own credentials (share tokens, FEEDS_TOKEN), run the
+// login flow, must stay open for probes, or document the API
+var authExemptRoutes = []string{
+	"/api/health",
+	"/api/openapi.json",
+	"/api/docs",
+	"/api/shared/:token",
+	"/api/feeds/critical.atom",
+	"/api/auth/login",
+	"/api/auth/callback",
+	"/api/auth/me",
+	"/api/auth/logout",
+}
+
+// webhookRoutePrefix holds the inbound webhooks, which WebhookAuth verifies instead: their
+// bearer tokens are per integration, not API keys
+const webhookRoutePrefix = "/api/ingest/"
+
+// readOnlyPostRoutes only read data but take a body, so viewers may call them
+var readOnlyPostRoutes = []string{
+	"/api/issues/batch-get",
+}
+
+// editorRoutes are the writes editors may make: triaging issues, raising tasks and
+// sharing views. Every other write needs an admin.
+var editorRoutes = []string{
+	"POST /api/issues/:id/mute",
+	"DELETE /api/issues/:id/mute",
+	"DELETE /api/mute-suppressions/:id",
+	"POST /api/issues/:id/events",
+	"PATCH /api/issues/:id/component",
+	"DELETE /api/issues/:id/component",
+	"POST /api/issues/:id/classification",
+	"DELETE /api/issues/:id/classification",
+	"POST /api/issues/:id/ack",
+	"POST /api/issues/:id/transitions",
+	"PUT /api/issues/:id/assignee",
+	"POST /api/issues/:id/comments",
+	"POST /api/tasks",
+	"POST /api/dashboard/snapshots",
+	"POST /api/share-links",
+	"POST /api/views",
+	"PUT /api/views/:id",
+	"DELETE /api/views/:id",
+}
+
+// apiKeysInDB caches whether any key was issued through the admin API, so requests
+// don't count keys while auth is off
+var apiKeysInDB struct {
+	sync.Mutex
+	any     bool
+	checked time.Time
+}
+
+const apiKeysInDBTTL = 30 * time.Second
+
+// Authenticate identifies the caller by API key ("X-API-Key: <key>" or "Authorization:
+// Bearer <key>") or OIDC session cookie and attaches the identity to the context. Once
+// any API key exists or OIDC is configured, requests without one are rejected, and each
+// request needs the role requiredRole names. With API_ANONYMOUS_READ, requests a viewer
+// could make need no identity at all.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions || strings.HasPrefix(c.FullPath(), webhookRoutePrefix) {
+			c.Next()
+			return
+		}
+
+		cfg := config.Get()
+		var identity *services.Identity
+		if provided := apiKeyFromRequest(c); provided != "" {
+			name, role, ok := lookupAPIKey(cfg, provided)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+				return
+			}
+			identity = &services.Identity{Subject: name, Name: name, Method: "api_key", Role: role}
+		} else {
+			identity = sessionIdentity(c)
+		}
+		if identity != nil {
+			c.Set(identityContextKey, identity)
+		}
+
+		if containsString(authExemptRoutes, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		required := requiredRole(c)
+		if identity == nil {
+			if !authRequired(cfg) || (cfg.APIAnonymousRead && required == config.RoleViewer) {
+				c.Next()
+				return
+			}
+			resp := gin.H{"error": "authentication required"}
+			if cfg.OIDC.Enabled() {
+				resp["login_url"] = loginURL
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, resp)
+			return
+		}
+		if !config.RoleAllows(identity.Role, required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("%s has role %s, this needs %s", currentActor(c), identity.Role, required),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// currentIdentity returns who made the request, or nil if it is anonymous
+func currentIdentity(c *gin.Context) *services.Identity {
+	if v, ok := c.Get(identityContextKey); ok {
+		return v.(*services.Identity)
+	}
+	return nil
+}
+
+// currentActor names the caller for audit fields: the login email, else the key name
+func currentActor(c *gin.Context) string {
+	id := currentIdentity(c)
+	if id == nil {
+		return ""
+	}
+	if id.Email != "" {
+		return id.Email
+	}
+	return id.Name
+}
+
+func authRequired(cfg *config.Config) bool {
+	return cfg.OIDC.Enabled() || apiKeysConfigured(cfg)
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// requiredRole is the least role that may make the request
+func requiredRole(c *gin.Context) string {
+	switch {
+	case adminRead(c):
+		return config.RoleAdmin
+	case readOnlyRequest(c):
+		return config.RoleViewer
+	case containsString(editorRoutes, c.Request.Method+" "+c.FullPath()):
+		return config.RoleEditor
+	}
+	return config.RoleAdmin
+}
+
+// adminRead reports whether the request reads admin settings or the audit log, which
+// hold channel credentials and raw payloads, so it needs an admin like writing them does
+func adminRead(c *gin.Context) bool {
+	path := c.FullPath()
+	return strings.HasPrefix(path, "/api/admin/") || path == "/api/audit"
+}
+
+func readOnlyRequest(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return containsString(readOnlyPostRoutes, c.FullPath())
+	}
+	return false
+}
+
+func apiKeysConfigured(cfg *config.Config) bool {
+	if len(cfg.APIKeys) > 0 {
+		return true
+	}
+
+	apiKeysInDB.Lock()
+	defer apiKeysInDB.Unlock()
+	if time.Since(apiKeysInDB.checked) > apiKeysInDBTTL {
+		var count int64
+		if err := db.DB.Model(&models.APIKey{}).Count(&count).Error; err != nil {
+			// Fail closed: an unreadable key table must not switch auth off
+			slog.Warn("Failed to count API keys", "err", err)
+			return true
+		}
+		apiKeysInDB.any = count > 0
+		apiKeysInDB.checked = time.Now()
+	}
+	return apiKeysInDB.any
+}
+
+// invalidateAPIKeyCache makes the next request re-check the key table
+func invalidateAPIKeyCache() {
+	apiKeysInDB.Lock()
+	apiKeysInDB.checked = time.Time{}
+	apiKeysInDB.Unlock()
+}
+
+// lookupAPIKey matches a key against API_KEYS first, then the issued keys
+func lookupAPIKey(cfg *config.Config, provided string) (name, role string, ok bool) {
+	hash := services.HashAPIKey(provided)
+	for _, k := range cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(services.HashAPIKey(k.Key)), []byte(hash)) == 1 {
+			return k.Name, k.Role, true
+		}
+	}
+
+	var key models.APIKey
+	if err := db.DB.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Warn("Failed to look up API key", "err", err)
+		}
+		return "", "", false
+	}
+
+	// Record use at most once a minute to keep writes off the hot path
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		db.DB.Model(&key).UpdateColumn("last_used_at", now)
+	}
+	return key.Name, key.Role, true
+}
+
+// APIKeyResponse lists a key; Source is "config" for API_KEYS entries, which
+// can't be revoked through the API
+type APIKeyResponse struct {
+	models.APIKey
+	Source string `json:"source"`
+}
+
+// CreateAPIKeyRequest names a new key and gives it a role
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	Role string `json:"role"` // defaults to viewer
+}
+
+// UpdateAPIKeyRequest changes an issued key's role
+type UpdateAPIKeyRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// GetAPIKeys lists configured and issued keys without their secrets
+func GetAPIKeys(c *gin.Context) {
+	items := make([]APIKeyResponse, 0)
+	for _, k := range config.Get().APIKeys {
+		items = append(items, APIKeyResponse{APIKey: models.APIKey{Name: k.Name, Role: k.Role}, Source: "config"})
+	}
+
+	var keys []models.APIKey
+	if err := dbFor(c).Order("name").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, k := range keys {
+		items = append(items, APIKeyResponse{APIKey: k, Source: "database"})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// CreateAPIKey issues a key; the key itself is only returned in this response
+func CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = config.RoleViewer
+	}
+	role, ok := config.ParseRole(req.Role)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be viewer, editor or admin"})
+		return
+	}
+	for _, k := range config.Get().APIKeys {
+		if k.Name == req.Name {
+			c.JSON(http.StatusConflict, gin.H{"error": "API key " + req.Name + " already exists in API_KEYS"})
+			return
+		}
+	}
+
+	dbc := dbFor(c)
+	var existing int64
+	dbc.Model(&models.APIKey{}).Where("name = ?", req.Name).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "API key " + req.Name + " already exists"})
+		return
+	}
+
+	secret, prefix, hash, err := services.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	key := models.APIKey{Name: req.Name, Role: role, Prefix: prefix, KeyHash: hash}
+	if err := dbc.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateAPIKeyCache()
+	audit(c, services.AuditCreate, "api_key", key.Name, nil, key)
+
+	logFor(c).Info("API key created", "key", key.Name, "role", key.Role, "by", currentActor(c))
+	c.JSON(http.StatusCreated, gin.H{
+		"item": APIKeyResponse{APIKey: key, Source: "database"},
+		"key":  secret,
+	})
+}
+
+// UpdateAPIKey changes an issued key's role; it applies from the key's next request
+func UpdateAPIKey(c *gin.Context) {
+	var req UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, ok := config.ParseRole(req.Role)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be viewer, editor or admin"})
+		return
+	}
+
+	dbc := dbFor(c)
+	var key models.APIKey
+	if err := dbc.First(&key, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	before := key
+	if err := dbc.Model(&key).Update("role", role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditUpdate, "api_key", key.Name, before, key)
+	logFor(c).Info("API key role changed", "key", key.Name, "role", role, "by", currentActor(c))
+	c.JSON(http.StatusOK, gin.H{"item": APIKeyResponse{APIKey: key, Source: "database"}})
+}
+
+// DeleteAPIKey revokes an issued key
+func DeleteAPIKey(c *gin.Context) {
+	dbc := dbFor(c)
+	var key models.APIKey
+	if err := dbc.First(&key, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err := dbc.Delete(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateAPIKeyCache()
+	audit(c, services.AuditDelete, "api_key", key.Name, key, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
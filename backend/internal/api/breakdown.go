@@ -0,0 +1,241 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"gorm.io/gorm"
+)
+
+// BreakdownStats holds the per-group metrics shared by the breakdown endpoints
+type BreakdownStats struct {
+	Current       int          `json:"current"`
+	Previous      int          `json:"previous"`
+	Change        float64      `json:"change"`
+	Trend         string       `json:"trend"`
+	Handled       int          `json:"handled"`
+	HandlingRate  float64      `json:"handling_rate"`
+	FakeAlarms    int          `json:"fake_alarms"`
+	FakeAlarmRate float64      `json:"fake_alarm_rate"`
+	DailyTrend    []TrendPoint `json:"daily_trend"`
+}
+
+type TrendPoint struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// buildInFilterCondition builds " AND column IN (...)" from a comma separated query value
+func buildInFilterCondition(column, value string) string {
+	if value == "" {
+		return ""
+	}
+	var quoted []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(v, "'", "''")+"'")
+	}
+	if len(quoted) == 0 {
+		return ""
+	}
+	return " AND " + column + " IN (" + strings.Join(quoted, ",") + ")"
+}
+
+// componentIssueIDs selects the IDs of the issues attributed to the component bound to ?
+const componentIssueIDs = "SELECT issue_id FROM issue_components WHERE component = ?"
+
+// buildComponentFilterCondition matches issues attributed to component, an exact match
+// on issue_components rather than a pattern over the components JSON
+func buildComponentFilterCondition(component string) string {
+	if component == "" {
+		return ""
+	}
+	return " AND issues.id IN (SELECT issue_id FROM issue_components WHERE component = '" + strings.ReplaceAll(component, "'", "''") + "')"
+}
+
+// buildOptionalInFilterCondition is buildInFilterCondition for optional labels, where
+// the emptyLabel value matches rows with the column unset
+func buildOptionalInFilterCondition(column, value, emptyLabel string) string {
+	var known []string
+	includeEmpty := false
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == emptyLabel {
+			includeEmpty = true
+		} else if v != "" {
+			known = append(known, v)
+		}
+	}
+
+	var parts []string
+	if len(known) > 0 {
+		parts = append(parts, strings.TrimPrefix(buildInFilterCondition(column, strings.Join(known, ",")), " AND "))
+	}
+	if includeEmpty {
+		parts = append(parts, column+" = '' OR "+column+" IS NULL")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " AND (" + strings.Join(parts, " OR ") + ")"
+}
+
+type breakdownGroup struct {
+	Value string
+	Stats BreakdownStats
+}
+
+// breakdownBy groups alerts matching the request filters in period p by expr (a column
+// or SQL expression), returning current/previous counts, rates and a trend per group
+func breakdownBy(c *gin.Context, p period, expr, extraCondition string) ([]breakdownGroup, DateRange) {
+	dbc := dbFor(c)
+	envStr := c.DefaultQuery("env", "all")
+	step := c.DefaultQuery("step", "day") // day, week, month
+	startDate, endDate, prevStartDate, prevEndDate := p.start, p.end, p.prevStart, p.prevEnd
+
+	envCondition := ""
+	if envStr == "prod" {
+		envCondition = " AND alert_signature LIKE '[PROD]%'"
+	} else if envStr == "non_prod" {
+		envCondition = " AND alert_signature NOT LIKE '[PROD]%'"
+	}
+
+	filterCondition := buildComponentFilterCondition(c.Query("component"))
+	filterCondition += buildInFilterCondition("tenant_id", c.Query("tenant_id"))
+	filterCondition += buildInFilterCondition("cluster_id", c.Query("cluster_id"))
+	filterCondition += buildInFilterCondition("stability_governance", c.Query("stability_governance"))
+	filterCondition += buildAlertGroupFilterCondition(c.Query("alert_group"))
+	filterCondition += buildTierFilterCondition(c.Query("tier"))
+	filterCondition += buildRegionFilterCondition(c.Query("region"), c.Query("provider"))
+	filterCondition += buildVisibilityFilterCondition(c.Query("visibility"))
+	filterCondition += buildDedupFilterCondition(c.Query("dedup"))
+
+	where := "is_alert = TRUE" + envCondition + filterCondition + buildClusterFilterCondition() + buildMuteRuleCondition() + extraCondition
+
+	// Current period totals
+	var current []struct {
+		Value   string
+		Total   int
+		Handled int
+		Fake    int
+	}
+	dbc.Raw(`
+		SELECT `+expr+` as value,
+			COUNT(*) as total,
+			SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END) as handled,
+			SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END) as fake
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1
+		ORDER BY total DESC
+	`, startDate, endDate).Scan(&current)
+
+	// Previous period totals
+	var previous []struct {
+		Value string
+		Total int
+	}
+	dbc.Raw(`
+		SELECT `+expr+` as value, COUNT(*) as total
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1
+	`, prevStartDate, prevEndDate).Scan(&previous)
+	prevByValue := make(map[string]int)
+	for _, p := range previous {
+		prevByValue[p.Value] = p.Total
+	}
+
+	// Trend per group
+	dateSelect := "SUBSTR(REPLACE(created, ' UTC', ''), 1, 10)"
+	if step == "week" {
+		dateSelect = db.WeekKey("REPLACE(created, ' UTC', '')")
+	} else if step == "month" {
+		dateSelect = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 7)"
+	}
+	var trendRows []struct {
+		Value string
+		Date  string
+		Count int
+	}
+	dbc.Raw(`
+		SELECT `+expr+` as value, `+dateSelect+` as date, COUNT(*) as count
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1, 2
+		ORDER BY date ASC
+	`, startDate[:10]+" 00:00:00", endDate[:10]+" 23:59:59").Scan(&trendRows)
+	trendByValue := make(map[string][]TrendPoint)
+	for _, r := range trendRows {
+		trendByValue[r.Value] = append(trendByValue[r.Value], TrendPoint{Date: r.Date, Count: r.Count})
+	}
+
+	rate := func(n, d int) float64 {
+		if d == 0 {
+			return 0
+		}
+		return float64(n) / float64(d) * 100
+	}
+
+	groups := make([]breakdownGroup, 0, len(current))
+	for _, cur := range current {
+		change, trend := calculateChange(cur.Total, prevByValue[cur.Value])
+		groups = append(groups, breakdownGroup{
+			Value: cur.Value,
+			Stats: BreakdownStats{
+				Current:       cur.Total,
+				Previous:      prevByValue[cur.Value],
+				Change:        change,
+				Trend:         trend,
+				Handled:       cur.Handled,
+				HandlingRate:  rate(cur.Handled, cur.Total),
+				FakeAlarms:    cur.Fake,
+				FakeAlarmRate: rate(cur.Fake, cur.Total),
+				DailyTrend:    trendByValue[cur.Value],
+			},
+		})
+	}
+
+	return groups, p.dateRange()
+}
+
+// periodCount is a grouped value with its current and previous period counts
+type periodCount struct {
+	Value     string
+	Count     int
+	PrevCount int
+}
+
+// topWithPrevious returns the top 10 values of column by current-period count together with
+// their previous-period count, in one grouped query over both periods
+func topWithPrevious(dbc *gorm.DB, column, where string, args []interface{}, start, end, prevStart, prevEnd string) []periodCount {
+	queryArgs := []interface{}{start, end, prevStart, prevEnd}
+	queryArgs = append(queryArgs, args...)
+	queryArgs = append(queryArgs, start, end, prevStart, prevEnd)
+
+	rows := []periodCount{}
+	dbc.Raw(`
+		SELECT `+column+` as value,
+			SUM(CASE WHEN created_at_utc BETWEEN ? AND ? THEN 1 ELSE 0 END) as count,
+			SUM(CASE WHEN created_at_utc BETWEEN ? AND ? THEN 1 ELSE 0 END) as prev_count
+		FROM issues
+		WHERE `+where+`
+			AND `+column+` != '' AND `+column+` IS NOT NULL
+			AND (created_at_utc BETWEEN ? AND ? OR created_at_utc BETWEEN ? AND ?)
+		GROUP BY `+column+`
+		HAVING count > 0
+		ORDER BY count DESC
+		LIMIT 10
+	`, queryArgs...).Scan(&rows)
+	return rows
+}
+
+// topValues returns the values of rows, e.g. for resolving their names in one batch
+func topValues(rows []periodCount) []string {
+	values := make([]string, 0, len(rows))
+	for _, r := range rows {
+		values = append(values, r.Value)
+	}
+	return values
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Burn-rate windows and the multi-window thresholds: a fast burn (1h and 6h both over
+// their thresholds) is critical, a sustained one (6h, or 3d confirmed by 6h) a warning
+const (
+	burnFastThreshold = 14.4
+	burnSlowThreshold = 6.0
+	burnLongThreshold = 1.0
+)
+
+var burnRateWindows = []struct {
+	Name  string
+	Hours float64
+}{
+	{"1h", 1},
+	{"6h", 6},
+	{"3d", 72},
+}
+
+// BurnRateWindow is the alert arrival SLI over one window
+type BurnRateWindow struct {
+	Window     string  `json:"window"`
+	Alerts     int64   `json:"alerts"`
+	HourlyRate float64 `json:"hourly_rate"`
+	BurnRate   float64 `json:"burn_rate"` // hourly_rate / quiet_hourly_alerts
+	Threshold  float64 `json:"threshold"`
+	OverBudget bool    `json:"over_budget"`
+}
+
+// GetComponentBurnRate reports a component's alert arrival rate against its quiet baseline
+// over 1h, 6h and 3d. The baseline is the target's quiet_hourly_alerts, else
+// max_weekly_alerts spread over the week; ?quiet_rate= overrides both.
+// ?format=prometheus returns gauges for the platform's own alert rules.
+func GetComponentBurnRate(c *gin.Context) {
+	name := c.Param("name")
+	if getCategory(name) == "Other" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown component: " + name})
+		return
+	}
+	dbc := dbFor(c)
+
+	quiet, source := 0.0, ""
+	if t, ok := loadComponentTarget(dbc, name); ok {
+		switch {
+		case t.QuietHourlyAlerts != nil:
+			quiet, source = *t.QuietHourlyAlerts, "quiet_hourly_alerts"
+		case t.MaxWeeklyAlerts != nil && *t.MaxWeeklyAlerts > 0:
+			quiet, source = float64(*t.MaxWeeklyAlerts)/(7*24), "max_weekly_alerts"
+		}
+	}
+	if v := c.Query("quiet_rate"); v != "" {
+		q, err := strconv.ParseFloat(v, 64)
+		if err != nil || q <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quiet_rate must be a positive number of alerts per hour"})
+			return
+		}
+		quiet, source = q, "query"
+	}
+	if quiet <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no quiet baseline: set quiet_hourly_alerts or max_weekly_alerts in the component target, or pass quiet_rate"})
+		return
+	}
+
+	condition := componentTargetCondition(name)
+	now := time.Now().UTC()
+	columns := make([]string, len(burnRateWindows))
+	args := make([]interface{}, 0, len(burnRateWindows)+2)
+	for i, w := range burnRateWindows {
+		columns[i] = "COALESCE(SUM(CASE WHEN created_at_utc >= ? THEN 1 ELSE 0 END), 0)"
+		args = append(args, now.Add(-time.Duration(w.Hours*float64(time.Hour))).Format("2006-01-02 15:04:05"))
+	}
+	longest := burnRateWindows[len(burnRateWindows)-1].Hours
+	args = append(args, now.Add(-time.Duration(longest*float64(time.Hour))).Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05"))
+
+	rows, err := dbc.Raw(`SELECT `+strings.Join(columns, ", ")+` FROM issues
+		WHERE is_alert = TRUE`+condition+buildClusterFilterCondition()+buildMuteRuleCondition()+`
+			AND created_at_utc BETWEEN ? AND ?`, args...).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	counts := make([]int64, len(burnRateWindows))
+	ptrs := make([]interface{}, len(counts))
+	for i := range counts {
+		ptrs[i] = &counts[i]
+	}
+	if rows.Next() {
+		err = rows.Scan(ptrs...)
+	}
+	rows.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if requestTimedOut(c) {
+		return
+	}
+
+	thresholds := []float64{burnFastThreshold, burnSlowThreshold, burnLongThreshold}
+	windows := make([]BurnRateWindow, len(burnRateWindows))
+	for i, w := range burnRateWindows {
+		rate := float64(counts[i]) / w.Hours
+		windows[i] = BurnRateWindow{
+			Window:     w.Name,
+			Alerts:     counts[i],
+			HourlyRate: rate,
+			BurnRate:   rate / quiet,
+			Threshold:  thresholds[i],
+			OverBudget: rate/quiet >= thresholds[i],
+		}
+	}
+
+	status := TargetGreen
+	switch {
+	case windows[0].OverBudget && windows[1].OverBudget:
+		status = TargetRed
+	case windows[1].OverBudget || (windows[2].OverBudget && windows[1].BurnRate >= burnLongThreshold):
+		status = TargetAmber
+	}
+
+	if c.Query("format") == "prometheus" {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# HELP alert_dashboard_component_burn_rate Alert arrival rate over the quiet baseline.\n")
+		fmt.Fprintf(&b, "# TYPE alert_dashboard_component_burn_rate gauge\n")
+		for _, w := range windows {
+			fmt.Fprintf(&b, "alert_dashboard_component_burn_rate{component=%q,window=%q} %g\n", name, w.Window, w.BurnRate)
+		}
+		fmt.Fprintf(&b, "# HELP alert_dashboard_component_alert_rate Alerts per hour.\n")
+		fmt.Fprintf(&b, "# TYPE alert_dashboard_component_alert_rate gauge\n")
+		for _, w := range windows {
+			fmt.Fprintf(&b, "alert_dashboard_component_alert_rate{component=%q,window=%q} %g\n", name, w.Window, w.HourlyRate)
+		}
+		fmt.Fprintf(&b, "# HELP alert_dashboard_component_quiet_rate Quiet baseline in alerts per hour.\n")
+		fmt.Fprintf(&b, "# TYPE alert_dashboard_component_quiet_rate gauge\n")
+		fmt.Fprintf(&b, "alert_dashboard_component_quiet_rate{component=%q} %g\n", name, quiet)
+		markAnonymized(c) // component series only
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"component":           name,
+		"quiet_hourly_alerts": quiet,
+		"baseline_source":     source,
+		"status":              status,
+		"windows":             windows,
+		"evaluated_at":        now.Format("2006-01-02 15:04:05"),
+	})
+}
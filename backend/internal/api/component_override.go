@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// IssueEventReassign records a component handoff on the issue timeline
+const IssueEventReassign = "reassign"
+
+// ReassignComponentRequest moves an issue to other components. UpdateJira also
+// replaces the components on the JIRA issue.
+type ReassignComponentRequest struct {
+	Components []string `json:"components"`
+	Reason     string   `json:"reason"`
+	Actor      string   `json:"actor"`
+	UpdateJira bool     `json:"update_jira"`
+}
+
+// ReassignIssueComponent overrides the components an issue is attributed to. Every
+// stat reads issues.components or its issue_components rows, so the override applies
+// everywhere; it survives re-imports and re-enrichment, and each change is recorded on
+// the issue timeline.
+func ReassignIssueComponent(c *gin.Context) {
+	dbc := dbFor(c)
+	var req ReassignComponentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	components := []string{}
+	for _, comp := range req.Components {
+		if comp = strings.TrimSpace(comp); comp != "" && !containsString(components, comp) {
+			components = append(components, comp)
+		}
+	}
+	if len(components) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "components must list at least one component"})
+		return
+	}
+
+	var issue models.Issue
+	if err := dbc.Select("id, components, attributed_by").First(&issue, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+		return
+	}
+	previous := decodeComponents(issue.ComponentsJSON)
+
+	override := models.ComponentOverride{
+		IssueID:              issue.ID,
+		Components:           components,
+		PreviousComponents:   previous,
+		PreviousAttributedBy: issue.AttributedBy,
+		Reason:               strings.TrimSpace(req.Reason),
+		Actor:                strings.TrimSpace(req.Actor),
+	}
+	err := dbc.Transaction(func(tx *gorm.DB) error {
+		var existing models.ComponentOverride
+		if tx.First(&existing, "issue_id = ?", issue.ID).Error == nil {
+			// Keep what the pipeline attributed, not an earlier override
+			override.PreviousComponents = existing.PreviousComponents
+			override.PreviousAttributedBy = existing.PreviousAttributedBy
+			override.CreatedAt = existing.CreatedAt
+		}
+		if err := tx.Save(&override).Error; err != nil {
+			return err
+		}
+		encoded, _ := json.Marshal(components)
+		if err := tx.Model(&models.Issue{}).Where("id = ?", issue.ID).
+			Updates(map[string]interface{}{"components": string(encoded), "attributed_by": services.AttributeOverride}).Error; err != nil {
+			return err
+		}
+		if err := services.SetIssueComponents(tx, issue.ID, components); err != nil {
+			return err
+		}
+		return tx.Create(&models.IssueEvent{
+			IssueID: issue.ID,
+			Type:    IssueEventReassign,
+			Actor:   override.Actor,
+			Detail:  reassignDetail(previous, components, override.Reason),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	services.InvalidateDashboards()
+
+	resp := gin.H{"success": true, "override": override}
+	if req.UpdateJira {
+		resp["jira"] = updateJiraComponents(c.Request.Context(), issue.ID, components)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ClearIssueComponentOverride restores the components the issue had before it was reassigned
+func ClearIssueComponentOverride(c *gin.Context) {
+	dbc := dbFor(c)
+	var override models.ComponentOverride
+	if err := dbc.First(&override, "issue_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue has no component override"})
+		return
+	}
+
+	err := dbc.Transaction(func(tx *gorm.DB) error {
+		encoded, _ := json.Marshal(override.PreviousComponents)
+		if err := tx.Model(&models.Issue{}).Where("id = ?", override.IssueID).
+			Updates(map[string]interface{}{"components": string(encoded), "attributed_by": override.PreviousAttributedBy}).Error; err != nil {
+			return err
+		}
+		if err := services.SetIssueComponents(tx, override.IssueID, override.PreviousComponents); err != nil {
+			return err
+		}
+		if err := tx.Delete(&override).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.IssueEvent{
+			IssueID: override.IssueID,
+			Type:    IssueEventReassign,
+			Actor:   strings.TrimSpace(c.Query("actor")),
+			Detail:  reassignDetail(override.Components, override.PreviousComponents, "override removed"),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	services.InvalidateDashboards()
+	c.JSON(http.StatusOK, gin.H{"success": true, "components": override.PreviousComponents})
+}
+
+// GetComponentOverrides lists reassigned issues, newest first. Optional: ?component=
+func GetComponentOverrides(c *gin.Context) {
+	overrides := []models.ComponentOverride{}
+	if err := dbFor(c).Order("updated_at DESC").Find(&overrides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if component := c.Query("component"); component != "" {
+		filtered := []models.ComponentOverride{}
+		for _, o := range overrides {
+			if containsString(o.Components, component) || containsString(o.PreviousComponents, component) {
+				filtered = append(filtered, o)
+			}
+		}
+		overrides = filtered
+	}
+	c.JSON(http.StatusOK, gin.H{"items": overrides})
+}
+
+func reassignDetail(from, to []string, reason string) string {
+	detail := fmt.Sprintf("%s -> %s", strings.Join(from, ", "), strings.Join(to, ", "))
+	if reason != "" {
+		detail += ": " + reason
+	}
+	return detail
+}
+
+// updateJiraComponents mirrors a reassignment to JIRA; the local override stands either way
+func updateJiraComponents(ctx context.Context, id string, components []string) string {
+	client, err := services.NewJiraClient(config.Get().Jira)
+	if err != nil {
+		return "skipped: " + err.Error()
+	}
+	if err := client.SetComponents(ctx, id, components); err != nil {
+		return "failed: " + err.Error()
+	}
+	return "updated"
+}
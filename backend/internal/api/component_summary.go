@@ -0,0 +1,266 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Sidebar health: any critical alert in the window, or a red target, is Critical; a spike
+// of at least componentSpikeMin alerts and componentSpikeFactor times the previous window,
+// or an amber target, is Warning
+const (
+	componentHealthy     = "Healthy"
+	componentWarning     = "Warning"
+	componentCritical    = "Critical"
+	componentSpikeFactor = 2
+	componentSpikeMin    = 5
+	componentSummaryHrs  = 24
+)
+
+// ComponentSummary is a sidebar entry with its last-24h badge counts
+type ComponentSummary struct {
+	ComponentResponse
+	Alerts   int64   `json:"alerts"`
+	Critical int64   `json:"critical"`
+	Previous int64   `json:"previous"` // alerts in the 24h before
+	Change   float64 `json:"change"`
+	Trend    string  `json:"trend"`
+}
+
+// componentCounts holds one component's counts for the current and previous window
+type componentCounts struct {
+	alerts, critical, previous int64
+}
+
+// GetComponentsSummary returns every sidebar component with last-24h alert and critical
+// counts and a health status, replacing a stats call per component. Counts follow the
+// component stats rules: governed alerts only, test clusters and alerts claimed by
+// exclusive virtual components left out.
+func GetComponentsSummary(c *gin.Context) {
+	dbc := dbFor(c)
+	now := time.Now().UTC()
+	end := now.Format("2006-01-02 15:04:05")
+	start := now.Add(-componentSummaryHrs * time.Hour).Format("2006-01-02 15:04:05")
+	prevStart := now.Add(-2 * componentSummaryHrs * time.Hour).Format("2006-01-02 15:04:05")
+
+	components := sidebarComponents(dbc)
+	virtuals := listVirtualComponents()
+
+	regular, err := regularComponentCounts(dbc, virtuals, start, prevStart, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	virtual, err := virtualComponentCounts(dbc, virtuals, start, prevStart, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var targets []models.ComponentTarget
+	dbc.Find(&targets)
+	targetByComponent := make(map[string]models.ComponentTarget, len(targets))
+	for _, t := range targets {
+		targetByComponent[t.Component] = t
+	}
+
+	items := make([]ComponentSummary, 0, len(components))
+	for _, comp := range components {
+		var counts componentCounts
+		if vc, ok := getVirtualComponent(comp.Name); ok {
+			counts = virtual[vc.Name]
+		} else {
+			counts = regular.forCategory(comp.Name, getCategory(comp.Name), virtuals)
+		}
+		if t, ok := targetByComponent[comp.ID]; ok {
+			comp.TargetStatus = componentWeeklyTargetStatus(dbc, t)
+		}
+
+		item := ComponentSummary{
+			ComponentResponse: comp,
+			Alerts:            counts.alerts,
+			Critical:          counts.critical,
+			Previous:          counts.previous,
+		}
+		item.Change, item.Trend = calcCompChange(counts.alerts, counts.previous)
+		item.Status = componentHealth(counts, comp.TargetStatus)
+		items = append(items, item)
+	}
+	recordHealthTransitions(dbc, items)
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start": start,
+		"end":   end,
+		"items": items,
+	})
+}
+
+// recordHealthTransitions stores a transition for each component whose status differs
+// from the last one recorded. Health is only evaluated here, so transitions are as fresh
+// as the last sidebar load.
+func recordHealthTransitions(dbc *gorm.DB, items []ComponentSummary) {
+	var latest []models.ComponentHealthTransition
+	dbc.Where("id IN (SELECT MAX(id) FROM component_health_transitions GROUP BY component)").Find(&latest)
+	last := make(map[string]string, len(latest))
+	for _, t := range latest {
+		last[t.Component] = t.To
+	}
+
+	now := time.Now().UTC()
+	var changed []models.ComponentHealthTransition
+	for _, item := range items {
+		if prev, ok := last[item.Name]; ok && prev == item.Status {
+			continue
+		}
+		changed = append(changed, models.ComponentHealthTransition{Component: item.Name, From: last[item.Name], To: item.Status, CreatedAt: now})
+	}
+	if len(changed) == 0 {
+		return
+	}
+	if err := dbc.Create(&changed).Error; err != nil {
+		slog.Warn("Failed to record component health transitions", "err", err)
+	}
+}
+
+func componentHealth(counts componentCounts, targetStatus string) string {
+	switch {
+	case counts.critical > 0 || targetStatus == TargetRed:
+		return componentCritical
+	case (counts.alerts >= componentSpikeMin && counts.alerts >= componentSpikeFactor*counts.previous) || targetStatus == TargetAmber:
+		return componentWarning
+	}
+	return componentHealthy
+}
+
+// claimedCounts are counts per component, split by which virtual components' filters
+// the alerts match (bit i set: matches virtuals[i])
+type claimedCounts map[string]map[int64]componentCounts
+
+// forCategory sums a component's counts, dropping alerts claimed by exclusive virtual
+// components unless the component's category is exempt
+func (cc claimedCounts) forCategory(component, category string, virtuals []VirtualComponent) componentCounts {
+	var mask int64
+	for i, vc := range virtuals {
+		if vc.Exclusive && !containsString(vc.ExemptCategories, category) {
+			mask |= 1 << i
+		}
+	}
+	var total componentCounts
+	for claimed, counts := range cc[component] {
+		if claimed&mask != 0 {
+			continue
+		}
+		total.alerts += counts.alerts
+		total.critical += counts.critical
+		total.previous += counts.previous
+	}
+	return total
+}
+
+// regularComponentCounts counts governed alerts per component label over both windows in one pass
+func regularComponentCounts(dbc *gorm.DB, virtuals []VirtualComponent, start, prevStart, end string) (claimedCounts, error) {
+	claimed := "0"
+	for i, vc := range virtuals {
+		claimed += fmt.Sprintf(" | (CASE WHEN (%s) THEN %d ELSE 0 END)", vc.Filter, int64(1)<<i)
+	}
+
+	var rows []struct {
+		Component string
+		Claimed   int64
+		Current   bool
+		Critical  bool
+		Alerts    int64
+	}
+	// The flags are computed in a subquery so virtual filters never see json_each's columns
+	err := dbc.Raw(`
+		SELECT j.value as component, s.claimed, s.current, s.critical, COUNT(*) as alerts
+		FROM (
+			SELECT components,
+				`+claimed+` as claimed,
+				CASE WHEN created_at_utc >= ? THEN 1 ELSE 0 END as current,
+				CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END as critical
+			FROM issues
+			WHERE is_alert = TRUE`+buildClusterFilterCondition()+buildMuteRuleCondition()+buildStabilityGovernanceFilterCondition()+`
+				AND created_at_utc BETWEEN ? AND ?
+		) s, `+db.JSONEach("s.components", "j")+`
+		GROUP BY j.value, s.claimed, s.current, s.critical`, start, prevStart, end).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := claimedCounts{}
+	for _, r := range rows {
+		if out[r.Component] == nil {
+			out[r.Component] = map[int64]componentCounts{}
+		}
+		counts := out[r.Component][r.Claimed]
+		if r.Current {
+			counts.alerts += r.Alerts
+			if r.Critical {
+				counts.critical += r.Alerts
+			}
+		} else {
+			counts.previous += r.Alerts
+		}
+		out[r.Component][r.Claimed] = counts
+	}
+	return out, nil
+}
+
+// virtualComponentCounts counts each virtual component's alerts by its filter in one pass
+func virtualComponentCounts(dbc *gorm.DB, virtuals []VirtualComponent, start, prevStart, end string) (map[string]componentCounts, error) {
+	out := make(map[string]componentCounts, len(virtuals))
+	if len(virtuals) == 0 {
+		return out, nil
+	}
+
+	var columns []string
+	var args []interface{}
+	for _, vc := range virtuals {
+		cond := "(" + vc.Filter + ")"
+		if !vc.IncludeUngoverned {
+			cond += buildStabilityGovernanceFilterCondition()
+		}
+		columns = append(columns,
+			"COALESCE(SUM(CASE WHEN "+cond+" AND created_at_utc >= ? THEN 1 ELSE 0 END), 0)",
+			"COALESCE(SUM(CASE WHEN "+cond+" AND created_at_utc >= ? AND priority = 'Critical' THEN 1 ELSE 0 END), 0)",
+			"COALESCE(SUM(CASE WHEN "+cond+" AND created_at_utc < ? THEN 1 ELSE 0 END), 0)")
+		args = append(args, start, start, start)
+	}
+	args = append(args, prevStart, end)
+
+	rows, err := dbc.Raw(`SELECT `+strings.Join(columns, ", ")+` FROM issues
+		WHERE is_alert = TRUE`+buildClusterFilterCondition()+buildMuteRuleCondition()+` AND created_at_utc BETWEEN ? AND ?`, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]int64, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+	}
+	for i, vc := range virtuals {
+		out[vc.Name] = componentCounts{alerts: values[3*i], critical: values[3*i+1], previous: values[3*i+2]}
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// A passing metric within these margins of its target is amber rather than green
+	targetAlertsMargin = 0.1 // fraction of max_weekly_alerts
+	targetRateMargin   = 5.0 // percentage points
+)
+
+// Target statuses, shown as red/amber/green in the sidebar
+const (
+	TargetGreen = "green"
+	TargetAmber = "amber"
+	TargetRed   = "red"
+	TargetNA    = "n/a" // a rate over no alerts, which neither passes nor fails
+)
+
+// TargetCheck compares one metric against its target
+type TargetCheck struct {
+	Metric string  `json:"metric"` // weekly_alerts, fake_rate, handling_rate
+	Target float64 `json:"target"`
+	Actual float64 `json:"actual"`
+	Pass   bool    `json:"pass"`
+	Status string  `json:"status"` // green, amber, red, or n/a for a rate over no alerts
+}
+
+// TargetEvaluation is a component's target vs actual; Status is the worst of its checks
+type TargetEvaluation struct {
+	Pass   bool          `json:"pass"`
+	Status string        `json:"status"`
+	Checks []TargetCheck `json:"checks"`
+}
+
+// evaluateComponentTarget checks the configured targets; alerts is the period's alert
+// count, weeklyAlerts the same scaled to 7 days, rates are percentages. Without alerts
+// the rate checks are n/a and don't count towards the status.
+func evaluateComponentTarget(t models.ComponentTarget, alerts int64, weeklyAlerts, fakeRate, handlingRate float64) *TargetEvaluation {
+	eval := &TargetEvaluation{Pass: true, Status: TargetGreen, Checks: []TargetCheck{}}
+
+	add := func(metric string, target, actual float64, pass, near bool) {
+		check := TargetCheck{Metric: metric, Target: target, Actual: actual, Pass: pass, Status: TargetGreen}
+		switch {
+		case !pass:
+			check.Status = TargetRed
+			eval.Pass = false
+			eval.Status = TargetRed
+		case near:
+			check.Status = TargetAmber
+			if eval.Status == TargetGreen {
+				eval.Status = TargetAmber
+			}
+		}
+		eval.Checks = append(eval.Checks, check)
+	}
+	notApplicable := func(metric string, target float64) {
+		eval.Checks = append(eval.Checks, TargetCheck{Metric: metric, Target: target, Pass: true, Status: TargetNA})
+	}
+
+	if t.MaxWeeklyAlerts != nil {
+		target := float64(*t.MaxWeeklyAlerts)
+		add("weekly_alerts", target, weeklyAlerts, weeklyAlerts <= target, weeklyAlerts > target*(1-targetAlertsMargin))
+	}
+	switch {
+	case t.MaxFakeRate != nil && alerts == 0:
+		notApplicable("fake_rate", *t.MaxFakeRate)
+	case t.MaxFakeRate != nil:
+		add("fake_rate", *t.MaxFakeRate, fakeRate, fakeRate <= *t.MaxFakeRate, fakeRate > *t.MaxFakeRate-targetRateMargin)
+	}
+	switch {
+	case t.MinHandlingRate != nil && alerts == 0:
+		notApplicable("handling_rate", *t.MinHandlingRate)
+	case t.MinHandlingRate != nil:
+		add("handling_rate", *t.MinHandlingRate, handlingRate, handlingRate >= *t.MinHandlingRate, handlingRate < *t.MinHandlingRate+targetRateMargin)
+	}
+	return eval
+}
+
+// loadComponentTarget returns the component's target, if one is configured
+func loadComponentTarget(dbc *gorm.DB, name string) (models.ComponentTarget, bool) {
+	var t models.ComponentTarget
+	if err := dbc.First(&t, "component = ?", name).Error; err != nil {
+		return t, false
+	}
+	return t, true
+}
+
+// componentTargetCondition selects a component's governed alerts the way GetComponentStats
+// does without request filters
+func componentTargetCondition(name string) (condition string) {
+	vc, isVirtual := getVirtualComponent(name)
+	if isVirtual {
+		condition = " AND (" + vc.Filter + ")"
+	} else {
+		condition = exclusiveCondition(getCategory(name)) + buildComponentFilterCondition(name)
+	}
+	if !isVirtual || !vc.IncludeUngoverned {
+		condition += " AND stability_governance != '' AND stability_governance IS NOT NULL"
+	}
+	return condition
+}
+
+// componentWeeklyTargetStatus evaluates a component's target over the last 7 days,
+// using the same issue selection as GetComponentStats without request filters
+func componentWeeklyTargetStatus(dbc *gorm.DB, t models.ComponentTarget) string {
+	condition := componentTargetCondition(t.Component)
+
+	now := time.Now().UTC()
+	var counts struct {
+		Total   int64
+		Fake    int64
+		Handled int64
+	}
+	dbc.Raw(`
+		SELECT COUNT(*) as total,
+			COALESCE(SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END), 0) as fake,
+			COALESCE(SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END), 0) as handled
+		FROM issues
+		WHERE is_alert = TRUE`+condition+buildClusterFilterCondition()+buildMuteRuleCondition()+`
+			AND created_at_utc BETWEEN ? AND ?`,
+		now.AddDate(0, 0, -7).Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05")).
+		Scan(&counts)
+
+	var fakeRate, handlingRate float64
+	if counts.Total > 0 {
+		fakeRate = float64(counts.Fake) / float64(counts.Total) * 100
+		handlingRate = float64(counts.Handled) / float64(counts.Total) * 100
+	}
+	return evaluateComponentTarget(t, counts.Total, float64(counts.Total), fakeRate, handlingRate).Status
+}
+
+// GetComponentTargets lists all configured component targets
+func GetComponentTargets(c *gin.Context) {
+	targets := []models.ComponentTarget{}
+	if err := dbFor(c).Order("component").Find(&targets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": targets})
+}
+
+// GetComponentTarget returns one component's targets
+func GetComponentTarget(c *gin.Context) {
+	t, ok := loadComponentTarget(dbFor(c), c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no targets configured for component"})
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// PutComponentTarget creates or replaces a component's targets
+func PutComponentTarget(c *gin.Context) {
+	name := c.Param("name")
+	if getCategory(name) == "Other" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown component: " + name})
+		return
+	}
+
+	var t models.ComponentTarget
+	if err := c.ShouldBindJSON(&t); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t.Component = name
+
+	var problems []string
+	if t.MaxWeeklyAlerts == nil && t.MaxFakeRate == nil && t.MinHandlingRate == nil && t.QuietHourlyAlerts == nil {
+		problems = append(problems, "at least one of max_weekly_alerts, max_fake_rate, min_handling_rate, quiet_hourly_alerts is required")
+	}
+	if t.MaxWeeklyAlerts != nil && *t.MaxWeeklyAlerts < 0 {
+		problems = append(problems, "max_weekly_alerts must not be negative")
+	}
+	if t.MaxFakeRate != nil && (*t.MaxFakeRate < 0 || *t.MaxFakeRate > 100) {
+		problems = append(problems, "max_fake_rate must be a percentage between 0 and 100")
+	}
+	if t.MinHandlingRate != nil && (*t.MinHandlingRate < 0 || *t.MinHandlingRate > 100) {
+		problems = append(problems, "min_handling_rate must be a percentage between 0 and 100")
+	}
+	if t.QuietHourlyAlerts != nil && *t.QuietHourlyAlerts <= 0 {
+		problems = append(problems, "quiet_hourly_alerts must be positive")
+	}
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid component targets", "problems": problems})
+		return
+	}
+
+	if err := dbFor(c).Save(&t).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// DeleteComponentTarget removes a component's targets
+func DeleteComponentTarget(c *gin.Context) {
+	res := dbFor(c).Delete(&models.ComponentTarget{}, "component = ?", c.Param("name"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no targets configured for component"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -1,19 +1,25 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 	"github.com/nolouch/alerts-platform-v2/internal/db"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
 	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
 )
 
 // ComponentResponse tailored for the sidebar
@@ -22,6 +28,9 @@ type ComponentResponse struct {
 	Name     string `json:"name"`
 	Category string `json:"category"`
 	Status   string `json:"status"`
+	// TargetStatus is green/amber/red against the component's targets over the last 7 days,
+	// empty when none are configured
+	TargetStatus string `json:"target_status,omitempty"`
 }
 
 var (
@@ -49,40 +58,28 @@ func loadCategories() {
 		return
 	}
 
-	// Try to load from standard locations
-	paths := []string{
-		"../config/component_categories.yaml",
-		"../../config/component_categories.yaml",
-		"config/component_categories.yaml",
-	}
-
-	var data []byte
-	for _, p := range paths {
-		if d, e := os.ReadFile(p); e == nil {
-			data = d
-			break
-		}
-	}
-
-	if data == nil {
-		fmt.Println("Warning: Could not find component_categories.yaml")
+	data, err := os.ReadFile(config.Get().ComponentCategoriesPath)
+	if err != nil {
+		slog.Warn("Could not find component_categories.yaml", "err", err)
 		return
 	}
 
 	// Use yaml.Node to preserve order
 	var node yaml.Node
 	if err := yaml.Unmarshal(data, &node); err != nil {
-		fmt.Printf("Error unmarshaling config: %v\n", err)
+		slog.Error("Failed to parse component categories", "err", err)
 		return
 	}
 
 	newMap := make(map[string]string)
 	var newOrder []string
+	newVirtual := defaultVirtualComponents
 
 	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
 		root := node.Content[0]
 		for i := 0; i < len(root.Content); i += 2 {
-			if root.Content[i].Value == "categories" {
+			switch root.Content[i].Value {
+			case "categories":
 				catNode := root.Content[i+1]
 				for j := 0; j < len(catNode.Content); j += 2 {
 					catName := catNode.Content[j].Value
@@ -94,15 +91,25 @@ func loadCategories() {
 						newMap[comp.Value] = catName
 					}
 				}
-				break
+			case "virtual_components":
+				newVirtual = parseVirtualComponents(root.Content[i+1])
 			}
 		}
 	}
 
 	categoryMap = newMap
 	orderedCategories = newOrder
+	virtualComponents = newVirtual
 	lastLoaded = time.Now()
-	fmt.Printf("Loaded %d categories and %d components. Categories: %v\n", len(newOrder), len(newMap), newOrder)
+	slog.Info("Loaded component categories", "categories", len(newOrder), "components", len(newMap), "order", newOrder)
+}
+
+// ReloadCategories forces the next lookup to re-read component_categories.yaml
+func ReloadCategories() {
+	categoryLock.Lock()
+	lastLoaded = time.Time{}
+	categoryLock.Unlock()
+	loadCategories()
 }
 
 func GetCategories(c *gin.Context) {
@@ -118,8 +125,10 @@ func getCategory(name string) string {
 	categoryLock.RLock()
 	defer categoryLock.RUnlock()
 
-	if name == "Serverless" {
-		return "Serverless"
+	for _, vc := range virtualComponents {
+		if vc.Name == name {
+			return vc.Category
+		}
 	}
 
 	if cat, ok := categoryMap[name]; ok {
@@ -130,15 +139,39 @@ func getCategory(name string) string {
 
 // GetComponents fetches all distinct components found in the stats or issues
 func GetComponents(c *gin.Context) {
+	dbc := dbFor(c)
+	response := sidebarComponents(dbc)
+
+	// Red/amber/green against configured targets
+	var targets []models.ComponentTarget
+	dbc.Find(&targets)
+	for _, t := range targets {
+		for i := range response {
+			if response[i].ID == t.Component {
+				response[i].TargetStatus = componentWeeklyTargetStatus(dbc, t)
+			}
+		}
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// sidebarComponents lists the configured components that have alerts, plus the
+// virtual components that are always shown or currently match
+func sidebarComponents(dbc *gorm.DB) []ComponentResponse {
 	var componentNames []string
 
 	// 1. Try querying distinct components from component_stats
-	db.DB.Model(&models.ComponentStat{}).Distinct("component").Pluck("component", &componentNames)
+	dbc.Model(&models.ComponentStat{}).Where("alert_count > 0").Distinct("component").Pluck("component", &componentNames)
 
 	// 2. If empty, fallback to scanning issues table
 	if len(componentNames) == 0 {
 		var rawComponents []string
-		db.DB.Model(&models.Issue{}).
+		dbc.Model(&models.Issue{}).
 			Where("is_alert = ?", true).
 			Order("created DESC").
 			Limit(5000).
@@ -178,16 +211,16 @@ func GetComponents(c *gin.Context) {
 		}
 	}
 
-	// Always add "Serverless" as a specific component
-	foundServerless := false
+	// Virtual components shown regardless of matching issues
+	virtuals := listVirtualComponents()
+	present := make(map[string]bool)
 	for _, name := range componentNames {
-		if name == "Serverless" {
-			foundServerless = true
-			break
-		}
+		present[name] = true
 	}
-	if !foundServerless {
-		componentNames = append(componentNames, "Serverless")
+	for _, vc := range virtuals {
+		if vc.Show == "always" && !present[vc.Name] {
+			componentNames = append(componentNames, vc.Name)
+		}
 	}
 
 	// Build response with strict category filtering
@@ -218,23 +251,27 @@ func GetComponents(c *gin.Context) {
 		}
 	}
 
-	// Check if we need to add a single "old-rules" component for Resilience
-	// This aggregates ALL issues with empty stability_governance AND (biz_type NOT LIKE '%nextgen%')
-	var countEmpty int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND (stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%')", true).
-		Count(&countEmpty)
-
-	if countEmpty > 0 {
-		response = append(response, ComponentResponse{
-			ID:       "old-rules",
-			Name:     "old-rules",
-			Category: "Resilience",
-			Status:   "Healthy",
-		})
+	// Virtual components shown only when some alert matches their filter
+	for _, vc := range virtuals {
+		if vc.Show != "when_matching" || seen[vc.Name] {
+			continue
+		}
+		var count int64
+		dbc.Model(&models.Issue{}).
+			Where("is_alert = ? AND ("+vc.Filter+")", true).
+			Limit(1).
+			Count(&count)
+		if count > 0 {
+			response = append(response, ComponentResponse{
+				ID:       vc.Name,
+				Name:     vc.Name,
+				Category: vc.Category,
+				Status:   "Healthy",
+			})
+			seen[vc.Name] = true
+		}
 	}
-
-	c.JSON(http.StatusOK, response)
+	return response
 }
 
 // MetricStat reused from dashboard (define locally or import if package loop allows, here we redefine simpler)
@@ -262,94 +299,70 @@ func calcCompChange(curr, prev int64) (float64, string) {
 	return change, trend
 }
 
-func resolveNameInfo(componentName, id string) services.NameInfo {
-	if getCategory(componentName) == "Serverless" {
-		return services.NameInfo{ID: id, Name: id}
+// resolveNameInfos resolves ids in one batch, unless the component's category is a
+// virtual component that skips name resolution
+func resolveNameInfos(ctx context.Context, componentName string, ids []string) map[string]services.NameInfo {
+	cat := getCategory(componentName)
+	for _, vc := range listVirtualComponents() {
+		if vc.SkipNameResolution && vc.Category == cat {
+			names := make(map[string]services.NameInfo, len(ids))
+			for _, id := range ids {
+				names[id] = services.NameInfo{ID: id, Name: id}
+			}
+			return names
+		}
 	}
-	info, _ := services.GetNameResolver().Resolve(id)
-	return info
+	return services.GetNameResolver().ResolveBatch(ctx, ids)
 }
 
 // GetComponentStats returns aggregate stats
 func GetComponentStats(c *gin.Context) {
+	dbc := dbFor(c)
+	ctx := c.Request.Context()
 	name := c.Param("name")
-	daysStr := c.DefaultQuery("days", "30")
 	envStr := c.DefaultQuery("env", "all")        // all, prod, non_prod
 	categoryStr := c.DefaultQuery("category", "") // premium, dedicated, essential
 
-	var days int
-	fmt.Sscanf(daysStr, "%d", &days)
-	if days <= 0 {
-		days = 30
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	days := p.days
+	startDate, endDate, prevStartDate, prevEndDate := p.start, p.end, p.prevStart, p.prevEnd
+	periodLabel := fmt.Sprintf("%s to %s", startDate, endDate)
+	if !p.explicit {
+		// Align start date to beginning of the day (00:00:00) to match daily trend aggregation
+		startDate = startDate[:10] + " 00:00:00"
+		prevEndDate = startDate
+		// Previous period also needs to measure full days
+		prevStartDate = prevStartDate[:10] + " 00:00:00"
+		periodLabel = fmt.Sprintf("Last %d Days", days)
 	}
-
-	now := time.Now().UTC()
-
-	// Current Period
-	endDate := now.Format("2006-01-02 15:04:05")
-	// Align start date to beginning of the day (00:00:00) to match daily trend aggregation
-	startDateObj := now.AddDate(0, 0, -days)
-	startDate := fmt.Sprintf("%s 00:00:00", startDateObj.Format("2006-01-02"))
-
-	// Previous Period
-	prevEndDate := startDate
-	// Previous period also needs to measure full days
-	prevStartDateObj := startDateObj.AddDate(0, 0, -days)
-	prevStartDate := fmt.Sprintf("%s 00:00:00", prevStartDateObj.Format("2006-01-02"))
 
 	// Environment filtering is handled via envCondition string (see below)
 
-	// Build category condition based on biz_type field from raw alert data
-	// Category mapping:
-	// - Premium: biz_type contains "nextgen"
-	// - Serverless: biz_type contains "devtier"
-	// - Dedicated: all others
-	categoryCondition := ""
-	if categoryStr != "" {
-		switch categoryStr {
-		case "premium":
-			// Premium = nextgen (biz_type contains "nextgen")
-			categoryCondition = " AND (biz_type LIKE '%nextgen%')"
-		case "essential":
-			// Essential/Serverless = devtier (biz_type contains "devtier")
-			categoryCondition = " AND (biz_type LIKE '%devtier%' OR biz_type LIKE '%TiDB Serverless%')"
-		case "dedicated":
-			// Dedicated = everything else (not nextgen and not devtier)
-			categoryCondition = " AND (biz_type NOT LIKE '%nextgen%' AND biz_type NOT LIKE '%devtier%' AND biz_type NOT LIKE '%TiDB Serverless%')"
-		}
-	}
+	// Category condition based on biz_type from raw alert data (see businessCategories)
+	categoryCondition := businessCategoryCondition(categoryStr)
 
-	// Determine the actual component name and stability governance filter
-	targetName := name
+	// Visibility filter (internal/external, "unknown" for unlabeled alerts)
+	categoryCondition += buildVisibilityFilterCondition(c.Query("visibility"))
+	// Region/provider filter via cluster metadata
+	categoryCondition += buildRegionFilterCondition(c.Query("region"), c.Query("provider"))
+	// Repeats within the dedup window count once
+	categoryCondition += buildDedupFilterCondition(c.Query("dedup"))
+
+	// Determine the component filter and stability governance filter
+	componentCondition := ""
 	stabilityCondition := ""
 
-	if name == "old-rules" {
-		// Aggregation of all empty stability issues excluding premium (nextgen)
-		stabilityCondition = " AND (stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%')"
+	vc, isVirtual := getVirtualComponent(name)
+	if isVirtual {
+		// Virtual components select their issues by filter expression instead of the components label
+		stabilityCondition = " AND (" + vc.Filter + ")"
 	} else {
-		// Normal component logic
-		cat := getCategory(name)
-		// For non-Resilience and non-Serverless components, filter out issues that belong to "old-rules"
-		if cat != "Resilience" && cat != "Serverless" {
-			// Exclude (empty stability AND not premium)
-			stabilityCondition = " AND NOT ((stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%'))"
-		}
-	}
-
-	componentFilter := "%\"" + targetName + "\"%"
-
-	// Special handling for Serverless component
-	if name == "Serverless" {
-		// Serverless component aggregates all devtier issues
-		// Override componentFilter to match everything (since we use biz_type to filter)
-		componentFilter = "%"
-		// Force category condition to devtier
-		categoryCondition = " AND (biz_type LIKE '%devtier%' OR biz_type LIKE '%TiDB Serverless%')"
-	}
-
-	// Special handling for old-rules
-	if name == "old-rules" {
-		componentFilter = "%"
+		componentCondition = buildComponentFilterCondition(name)
+		// Regular components drop issues claimed by exclusive virtual components (e.g. old-rules)
+		stabilityCondition = exclusiveCondition(getCategory(name))
 	}
 
 	// Build environment condition
@@ -364,26 +377,26 @@ func GetComponentStats(c *gin.Context) {
 		envCondition = " AND (title LIKE '%[STAGING]%' OR title LIKE '%[STG]%' OR title LIKE '%STAGING%')"
 	}
 
-	// Build cluster filter to exclude test clusters
-	clusterFilter := buildClusterFilterCondition()
+	// Build cluster filter to exclude test clusters and alerts hidden by mute rules
+	clusterFilter := buildClusterFilterCondition() + buildMuteRuleCondition()
 	// Build stability governance filter to only include alerts with stability_governance label
-	// Note: For "old-rules" component, this filter is not applied as it specifically aggregates empty stability_governance
+	// Note: Not applied to virtual components with include_ungoverned (e.g. old-rules aggregates empty stability_governance)
 	stabilityFilter := ""
-	if name != "old-rules" {
+	if !isVirtual || !vc.IncludeUngoverned {
 		stabilityFilter = " AND stability_governance != '' AND stability_governance IS NOT NULL"
 	}
 
 	// 1. Total Alerts (Current & Previous)
 	var currTotal int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
-			true, componentFilter, startDate, endDate).
+	dbc.Model(&models.Issue{}).
+		Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND created_at_utc BETWEEN ? AND ?",
+			true, startDate, endDate).
 		Count(&currTotal)
 
 	var prevTotal int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
-			true, componentFilter, prevStartDate, prevEndDate).
+	dbc.Model(&models.Issue{}).
+		Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND created_at_utc BETWEEN ? AND ?",
+			true, prevStartDate, prevEndDate).
 		Count(&prevTotal)
 
 	change, trend := calcCompChange(currTotal, prevTotal)
@@ -398,15 +411,15 @@ func GetComponentStats(c *gin.Context) {
 
 	// 1.5 Rate Stats (Current)
 	var currFake int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'",
-			true, componentFilter, startDate, endDate).
+	dbc.Model(&models.Issue{}).
+		Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND created_at_utc BETWEEN ? AND ? AND status = 'FAKE ALARM'",
+			true, startDate, endDate).
 		Count(&currFake)
 
 	var currHandled int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'",
-			true, componentFilter, startDate, endDate).
+	dbc.Model(&models.Issue{}).
+		Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND created_at_utc BETWEEN ? AND ? AND status != 'Created'",
+			true, startDate, endDate).
 		Count(&currHandled)
 
 	currFakeRate := calcRate(currFake, currTotal)
@@ -414,15 +427,15 @@ func GetComponentStats(c *gin.Context) {
 
 	// 1.6 Rate Stats (Previous)
 	var prevFake int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'",
-			true, componentFilter, prevStartDate, prevEndDate).
+	dbc.Model(&models.Issue{}).
+		Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND created_at_utc BETWEEN ? AND ? AND status = 'FAKE ALARM'",
+			true, prevStartDate, prevEndDate).
 		Count(&prevFake)
 
 	var prevHandled int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'",
-			true, componentFilter, prevStartDate, prevEndDate).
+	dbc.Model(&models.Issue{}).
+		Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND created_at_utc BETWEEN ? AND ? AND status != 'Created'",
+			true, prevStartDate, prevEndDate).
 		Count(&prevHandled)
 
 	prevFakeRate := calcRate(prevFake, prevTotal)
@@ -450,7 +463,7 @@ func GetComponentStats(c *gin.Context) {
 	step := c.DefaultQuery("step", "day")
 	dateSelect := ""
 	if step == "week" {
-		dateSelect = "strftime('%Y-%W', REPLACE(created, ' UTC', '')) as date"
+		dateSelect = db.WeekKey("REPLACE(created, ' UTC', '')") + " as date"
 	} else if step == "month" {
 		dateSelect = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 7) as date"
 	} else {
@@ -458,7 +471,7 @@ func GetComponentStats(c *gin.Context) {
 	}
 
 	trendData := []DailyTrend{}
-	db.DB.Raw(`
+	dbc.Raw(`
 		SELECT 
 			`+dateSelect+`,
 			COUNT(*) as total_alerts,
@@ -466,16 +479,15 @@ func GetComponentStats(c *gin.Context) {
 			SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END) as major_count,
 			SUM(CASE WHEN priority = 'Warning' THEN 1 ELSE 0 END) as warning_count
 		FROM issues
-		WHERE is_alert = 1 
-			AND components LIKE ? `+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+`
-			AND SUBSTR(REPLACE(created, ' UTC', ''), 1, 10) BETWEEN ? AND ?
+		WHERE is_alert = TRUE`+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+`
+			AND created_at_utc BETWEEN ? AND ?
 		GROUP BY date
 		ORDER BY date ASC
-	`, componentFilter, startDate[:10], endDate[:10]).Scan(&trendData)
+	`, startDate[:10]+" 00:00:00", endDate[:10]+" 23:59:59").Scan(&trendData)
 
 	// 3. Recent Issues
 	recentIssues := []models.Issue{}
-	db.DB.Where("is_alert = ? AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter, true, componentFilter).
+	dbc.Where("is_alert = ?"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter, true).
 		Order("created DESC").
 		Limit(10).
 		Find(&recentIssues)
@@ -491,39 +503,26 @@ func GetComponentStats(c *gin.Context) {
 	}
 	tenants := []TenantCount{}
 
-	type TenantBasic struct {
-		TenantID string
-		Count    int
-	}
-	topTenants := []TenantBasic{}
-	db.DB.Raw(`
-		SELECT tenant_id, COUNT(*) as count
-		FROM issues
-		WHERE is_alert = 1 
-			AND components LIKE ? `+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+`
-			AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-			AND tenant_id != '' AND tenant_id IS NOT NULL
-		GROUP BY tenant_id
-		ORDER BY count DESC
-		LIMIT 10
-	`, componentFilter, startDate, endDate).Scan(&topTenants)
+	// Top N tenants/clusters with previous-period counts in one grouped query each
+	topWhere := "is_alert = TRUE" + componentCondition + envCondition + categoryCondition + stabilityCondition + clusterFilter + stabilityFilter
 
-	for _, t := range topTenants {
-		var prevCount int64
-		db.DB.Model(&models.Issue{}).
-			Where("is_alert = 1 AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND tenant_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
-				componentFilter, t.TenantID, prevStartDate, prevEndDate).
-			Count(&prevCount)
+	topTenants := topWithPrevious(dbc, "tenant_id", topWhere, nil, startDate, endDate, prevStartDate, prevEndDate)
+	topClusters := topWithPrevious(dbc, "cluster_id", topWhere, nil, startDate, endDate, prevStartDate, prevEndDate)
 
-		change, trend := calcCompChange(int64(t.Count), prevCount)
-		// Resolve Name
-		nameInfo := resolveNameInfo(targetName, t.TenantID)
+	// Resolve every tenant, cluster and recent issue cluster name in one batch
+	nameIDs := append(topValues(topTenants), topValues(topClusters)...)
+	for _, issue := range recentIssues {
+		nameIDs = append(nameIDs, issue.ClusterID)
+	}
+	names := resolveNameInfos(ctx, name, nameIDs)
 
+	for _, t := range topTenants {
+		change, trend := calcCompChange(int64(t.Count), int64(t.PrevCount))
 		tenants = append(tenants, TenantCount{
-			TenantID:   t.TenantID,
-			TenantName: nameInfo.Name,
+			TenantID:   t.Value,
+			TenantName: names[t.Value].Name,
 			Current:    t.Count,
-			Previous:   int(prevCount),
+			Previous:   t.PrevCount,
 			Change:     change,
 			Trend:      trend,
 		})
@@ -541,40 +540,15 @@ func GetComponentStats(c *gin.Context) {
 	}
 	clusters := []ClusterCount{}
 
-	type ClusterBasic struct {
-		ClusterID string
-		Count     int
-	}
-	topClusters := []ClusterBasic{}
-	db.DB.Raw(`
-		SELECT cluster_id, COUNT(*) as count
-		FROM issues
-		WHERE is_alert = 1 
-			AND components LIKE ? `+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+`
-			AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-			AND cluster_id != '' AND cluster_id IS NOT NULL
-		GROUP BY cluster_id
-		ORDER BY count DESC
-		LIMIT 10
-	`, componentFilter, startDate, endDate).Scan(&topClusters)
-
 	for _, c := range topClusters {
-		var prevCount int64
-		db.DB.Model(&models.Issue{}).
-			Where("is_alert = 1 AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+" AND cluster_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
-				componentFilter, c.ClusterID, prevStartDate, prevEndDate).
-			Count(&prevCount)
-
-		change, trend := calcCompChange(int64(c.Count), prevCount)
-
-		nameInfo := resolveNameInfo(targetName, c.ClusterID)
-
+		change, trend := calcCompChange(int64(c.Count), int64(c.PrevCount))
+		nameInfo := names[c.Value]
 		clusters = append(clusters, ClusterCount{
-			ClusterID:   c.ClusterID,
+			ClusterID:   c.Value,
 			ClusterName: nameInfo.Name,
 			TenantName:  nameInfo.TenantName,
 			Current:     c.Count,
-			Previous:    int(prevCount),
+			Previous:    c.PrevCount,
 			Change:      change,
 			Trend:       trend,
 		})
@@ -586,17 +560,16 @@ func GetComponentStats(c *gin.Context) {
 		Count     int    `json:"count"`
 	}
 	topRules := []RuleCount{}
-	db.DB.Raw(`
+	dbc.Raw(`
 		SELECT alert_signature as signature, COUNT(*) as count
 		FROM issues
-		WHERE is_alert = 1 
-			AND components LIKE ? `+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+`
-			AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
+		WHERE is_alert = TRUE`+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter+`
+			AND created_at_utc BETWEEN ? AND ?
 			AND alert_signature IS NOT NULL AND alert_signature != ''
 		GROUP BY alert_signature
 		ORDER BY count DESC
 		LIMIT 10
-	`, componentFilter, startDate, endDate).Scan(&topRules)
+	`, startDate, endDate).Scan(&topRules)
 
 	// Enrich Recent Issues
 	// Enrich Recent Issues
@@ -608,8 +581,7 @@ func GetComponentStats(c *gin.Context) {
 	for _, issue := range recentIssues {
 		clusterName := ""
 		if issue.ClusterID != "" {
-			ni := resolveNameInfo(targetName, issue.ClusterID)
-			clusterName = ni.Name
+			clusterName = names[issue.ClusterID].Name
 		}
 		recentIssuesEnriched = append(recentIssuesEnriched, IssueWithNames{
 			Issue:       issue,
@@ -617,9 +589,31 @@ func GetComponentStats(c *gin.Context) {
 		})
 	}
 
+	// Visibility and region breakdowns
+	visibilities := visibilityBreakdown(dbc, "is_alert = TRUE"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter,
+		nil, startDate, endDate, prevStartDate, prevEndDate)
+	regions := regionBreakdown(dbc, "is_alert = TRUE"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter,
+		nil, startDate, endDate, prevStartDate, prevEndDate)
+
+	// Time to acknowledge / resolve, per priority; the per-component split would list the
+	// other components these alerts are attributed to
+	responseTimes, _ := responseTimesBreakdown(dbc, "is_alert = TRUE"+componentCondition+envCondition+categoryCondition+stabilityCondition+clusterFilter+stabilityFilter,
+		nil, startDate, endDate)
+	responseTimes.ByComponent = nil
+
+	// Target vs actual, with the period's alerts scaled to a week
+	var target *TargetEvaluation
+	if t, ok := loadComponentTarget(dbc, name); ok {
+		target = evaluateComponentTarget(t, currTotal, float64(currTotal)/float64(days)*7, currFakeRate, currHandlingRate)
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"component":       name,
-		"period":          fmt.Sprintf("Last %d Days", days),
+		"period":          periodLabel,
 		"env":             envStr,
 		"total_alerts":    ComponentMetricStat{Current: currTotal, Previous: prevTotal, Change: change, Trend: trend},
 		"fake_alarm_rate": ComponentMetricStat{Current: int64(currFakeRate * 100), Previous: int64(prevFakeRate * 100), Change: fakeChange, Trend: fakeTrend},
@@ -635,11 +629,15 @@ func GetComponentStats(c *gin.Context) {
 			"change":   handlingChange,
 			"trend":    handlingTrend,
 		},
-		"daily_trend":   trendData,
-		"recent_issues": recentIssuesEnriched,
-		"top_tenants":   tenants,
-		"top_clusters":  clusters,
-		"top_rules":     topRules,
+		"daily_trend":    trendData,
+		"recent_issues":  recentIssuesEnriched,
+		"top_tenants":    tenants,
+		"top_clusters":   clusters,
+		"top_rules":      topRules,
+		"by_visibility":  visibilities,
+		"by_region":      regions,
+		"target":         target,
+		"response_times": responseTimes,
 	})
 }
 
@@ -650,15 +648,15 @@ func GetComponentRules(c *gin.Context) {
 	ruleType := c.Query("rule_type") // prometheus, logging, or empty for all
 
 	// Initialize service (in prod this should be injected or global)
-	svc := services.NewRulesService()
+	svc := services.NewRulesService(config.Get())
 
 	var rules []models.Rule
 	var err error
 
 	// If category is specified, use category-specific filtering
 	if category != "" {
-		if name == "Serverless" {
-			name = "*"
+		if vc, ok := getVirtualComponent(name); ok && vc.RulesComponent != "" {
+			name = vc.RulesComponent
 		}
 		rules, err = svc.GetRulesForComponentAndCategory(name, category)
 	} else {
@@ -711,6 +709,15 @@ type UpdateRuleRequest struct {
 	FilePath      string      `json:"file_path"`
 	OriginalAlert string      `json:"original_alert"`
 	Rule          models.Rule `json:"rule"`
+	TaskID        *uint       `json:"task_id,omitempty"` // the rule task the edit belongs to, if any
+}
+
+// CreateRuleRequest adds a rule to a group of a rule file; see RulesService.CreateRule
+type CreateRuleRequest struct {
+	FilePath string      `json:"file_path"`
+	Group    string      `json:"group"` // default the file's first group
+	Rule     models.Rule `json:"rule"`
+	TaskID   *uint       `json:"task_id,omitempty"`
 }
 
 // UpdateComponentRule updates a specific rule
@@ -725,12 +732,156 @@ func UpdateComponentRule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "file_path and original_alert are required"})
 		return
 	}
+	if !ruleTaskExists(c, req.TaskID) {
+		return
+	}
+
+	svc := services.NewRulesService(config.Get())
+	before, _ := svc.FindRule(req.FilePath, req.OriginalAlert)
+	pr, err := svc.UpdateRule(req.FilePath, req.OriginalAlert, req.Rule, currentActor(c))
+	if err != nil {
+		ruleChangeFailed(c, "update", err)
+		return
+	}
+	recordRuleChange(c, services.AuditUpdate, req.FilePath, req.OriginalAlert, req.TaskID, before, &req.Rule, pr)
+	ruleChangeDone(c, http.StatusOK, pr)
+}
+
+// CreateComponentRule adds a rule, creating its group or file if needed
+func CreateComponentRule(c *gin.Context) {
+	var req CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.FilePath == "" || req.Rule.Alert == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file_path and rule.alert are required"})
+		return
+	}
+	if !ruleTaskExists(c, req.TaskID) {
+		return
+	}
+
+	pr, err := services.NewRulesService(config.Get()).CreateRule(req.FilePath, req.Group, req.Rule, currentActor(c))
+	if err != nil {
+		ruleChangeFailed(c, "create", err)
+		return
+	}
+	recordRuleChange(c, services.AuditCreate, req.FilePath, req.Rule.Alert, req.TaskID, nil, &req.Rule, pr)
+	ruleChangeDone(c, http.StatusCreated, pr)
+}
+
+// DeleteComponentRule removes a rule. Required: ?file_path= and ?alert=; optional ?task_id=
+func DeleteComponentRule(c *gin.Context) {
+	filePath, alert := c.Query("file_path"), c.Query("alert")
+	if filePath == "" || alert == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file_path and alert are required"})
+		return
+	}
+	var taskID *uint
+	if v := c.Query("task_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id"})
+			return
+		}
+		taskID = new(uint)
+		*taskID = uint(id)
+	}
+	if !ruleTaskExists(c, taskID) {
+		return
+	}
+
+	svc := services.NewRulesService(config.Get())
+	before, _ := svc.FindRule(filePath, alert)
+	pr, err := svc.DeleteRule(filePath, alert, currentActor(c))
+	if err != nil {
+		ruleChangeFailed(c, "delete", err)
+		return
+	}
+	recordRuleChange(c, services.AuditDelete, filePath, alert, taskID, before, nil, pr)
+	ruleChangeDone(c, http.StatusOK, pr)
+}
+
+// ruleTaskExists checks the task a rule change refers to, if any, and writes a 400 if
+// it doesn't exist
+func ruleTaskExists(c *gin.Context, taskID *uint) bool {
+	if taskID == nil {
+		return true
+	}
+	var task models.Task
+	if err := dbFor(c).First(&task, *taskID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("task %d not found", *taskID)})
+		return false
+	}
+	return true
+}
 
-	svc := services.NewRulesService()
-	if err := svc.UpdateRule(req.FilePath, req.OriginalAlert, req.Rule); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update rule: %v", err)})
+// ruleChangeFailed writes the response for a rule create, update or delete that failed
+func ruleChangeFailed(c *gin.Context, verb string, err error) {
+	var validationErr *services.RuleValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error(), "problems": validationErr.Problems})
+		return
+	}
+	var fileErr *services.RuleFileValidationError
+	if errors.As(err, &fileErr) {
+		problems := make([]string, len(fileErr.Problems))
+		for i, p := range fileErr.Problems {
+			problems[i] = p.String()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     fileErr.Error(),
+			"validator": fileErr.Validator,
+			"problems":  problems,
+			"details":   fileErr.Problems,
+		})
 		return
 	}
+	switch {
+	case errors.Is(err, services.ErrRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRuleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to %s rule: %v", verb, err)})
+	}
+}
+
+// recordRuleChange audits a rule change and adds it to the rule history
+func recordRuleChange(c *gin.Context, action, filePath, alert string, taskID *uint, before, after *models.Rule, pr *services.RulePullRequest) {
+	var auditBefore, auditAfter interface{}
+	if before != nil {
+		auditBefore = before
+	}
+	if after != nil {
+		auditAfter = after
+	}
+	auditComponent(c, c.Param("name"), action, "rule", filePath+"#"+alert, auditBefore, auditAfter)
+
+	revision := services.RuleRevisionEntry{
+		Action:        action,
+		Component:     c.Param("name"),
+		FilePath:      filePath,
+		PreviousAlert: alert,
+		Actor:         currentActor(c),
+		TaskID:        taskID,
+		Before:        before,
+		After:         after,
+	}
+	if pr != nil {
+		revision.PullRequestURL = pr.URL
+	}
+	services.RecordRuleRevision(dbFor(c), revision)
+}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+// ruleChangeDone writes the response for a rule change that went through
+func ruleChangeDone(c *gin.Context, status int, pr *services.RulePullRequest) {
+	if pr != nil {
+		// The change waits for review; the rules repo itself is unchanged until it merges
+		c.JSON(status, gin.H{"success": true, "pull_request": pr})
+		return
+	}
+	c.JSON(status, gin.H{"success": true})
 }
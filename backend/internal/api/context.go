@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"gorm.io/gorm"
+)
+
+// streamingRoutes stay open for as long as the client listens or the response is
+// written out, so REQUEST_TIMEOUT does not apply to them
+var streamingRoutes = []string{
+	"/api/stream",
+	"/api/dashboard/issues/export",
+}
+
+// RequestTimeout bounds every request with the configured REQUEST_TIMEOUT so
+// slow aggregations are cancelled instead of holding a DB connection forever
+func RequestTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := config.Get().RequestTimeout
+		if timeout <= 0 || containsString(streamingRoutes, c.FullPath()) {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// dbFor returns the DB bound to the request context, so queries stop at the deadline
+func dbFor(c *gin.Context) *gorm.DB {
+	return db.DB.WithContext(c.Request.Context())
+}
+
+// requestTimedOut responds 504 if the request deadline passed; handlers check it
+// before writing a (possibly partial) result
+func requestTimedOut(c *gin.Context) bool {
+	if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "request timed out"})
+		return true
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -8,9 +10,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/cache"
 	"github.com/nolouch/alerts-platform-v2/internal/db"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
 )
 
 var (
@@ -72,49 +77,11 @@ func getTestClusterIDs() []string {
 			return
 		}
 
-		// Use concurrent workers to resolve cluster names
-		const maxWorkers = 10 // Limit concurrent API calls
-		type result struct {
-			clusterID string
-			isTest    bool
-		}
-
-		clusterChan := make(chan string, len(clusterIDs))
-		resultChan := make(chan result, len(clusterIDs))
-
-		// Start workers
-		var wg sync.WaitGroup
-		for i := 0; i < maxWorkers; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for clusterID := range clusterChan {
-					info, err := services.GetNameResolver().Resolve(clusterID)
-					isTest := err == nil && strings.Contains(strings.ToLower(info.Name), "test")
-					resultChan <- result{clusterID: clusterID, isTest: isTest}
-				}
-			}()
-		}
-
-		// Send all cluster IDs to workers
-		go func() {
-			for _, clusterID := range clusterIDs {
-				clusterChan <- clusterID
-			}
-			close(clusterChan)
-		}()
-
-		// Wait for all workers to finish
-		go func() {
-			wg.Wait()
-			close(resultChan)
-		}()
-
-		// Collect results
+		// Resolve cluster names concurrently
 		testClusterIDs := []string{}
-		for res := range resultChan {
-			if res.isTest {
-				testClusterIDs = append(testClusterIDs, res.clusterID)
+		for id, info := range services.GetNameResolver().ResolveBatch(context.Background(), clusterIDs) {
+			if strings.Contains(strings.ToLower(info.Name), "test") {
+				testClusterIDs = append(testClusterIDs, id)
 			}
 		}
 
@@ -154,19 +121,39 @@ func buildStabilityGovernanceFilterCondition() string {
 
 // DashboardDataResponse matches the frontend expectation
 type DashboardDataResponse struct {
-	TotalAlerts    MetricStat       `json:"totalAlerts"`
-	ProdAlerts     MetricStat       `json:"prodAlerts"`
-	NonProdAlerts  MetricStat       `json:"nonProdAlerts"`
-	CriticalAlerts MetricStat       `json:"criticalAlerts"`
-	FakeAlarmRate  MetricStat       `json:"fakeAlarmRate"`
-	HandlingRate   MetricStat       `json:"handlingRate"`
-	ByPriority     []PriorityCount  `json:"byPriority"`
-	BySignature    []SignatureCount `json:"bySignature"`
-	ByComponent    []ComponentCount `json:"byComponent"`
-	ByTenant       []TenantCount    `json:"byTenant"`
-	ByCluster      []ClusterCount   `json:"byCluster"` // NEW
-	DailyTrend     []DailyTrend     `json:"dailyTrend"`
-	DateRange      DateRange        `json:"dateRange"`
+	DashboardMetrics
+	ByPriority   []PriorityCount   `json:"byPriority"`
+	BySignature  []SignatureCount  `json:"bySignature"`
+	ByComponent  []ComponentCount  `json:"byComponent"`
+	ByTenant     []TenantCount     `json:"byTenant"`
+	ByCluster    []ClusterCount    `json:"byCluster"` // NEW
+	ByVisibility []VisibilityCount `json:"byVisibility"`
+	ByRegion     []RegionCount     `json:"byRegion"`
+	DailyTrend   []DailyTrend      `json:"dailyTrend"`
+	DateRange    DateRange         `json:"dateRange"`
+
+	// MTTA / MTTR of the period's alerts, overall, per component and per priority
+	ResponseTimes ResponseTimesBreakdown `json:"responseTimes"`
+}
+
+// DashboardMetrics are the headline stats, each compared with the previous period
+type DashboardMetrics struct {
+	TotalAlerts    MetricStat `json:"totalAlerts"`
+	ProdAlerts     MetricStat `json:"prodAlerts"`
+	NonProdAlerts  MetricStat `json:"nonProdAlerts"`
+	CriticalAlerts MetricStat `json:"criticalAlerts"`
+	FakeAlarmRate  MetricStat `json:"fakeAlarmRate"`
+	HandlingRate   MetricStat `json:"handlingRate"`
+}
+
+// dashboardCounts are the counts behind DashboardMetrics for one period
+type dashboardCounts struct {
+	Total    int
+	Prod     int
+	NonProd  int
+	Critical int
+	Fake     int
+	Handled  int
 }
 
 type TenantCount struct {
@@ -244,31 +231,150 @@ func calculateChange(current, previous int) (float64, string) {
 	return change, trend
 }
 
+// dashboardCacheTTL bounds how stale a cached dashboard response may be
+const dashboardCacheTTL = 60 * time.Second
+
+// dashboardQueryConcurrency bounds how many dashboard aggregations run at once per request
+const dashboardQueryConcurrency = 4
+
+// dashboardCacheKey normalizes the query string so parameter order doesn't matter. The
+// generation retires cached responses when mutes, verdicts, components or a sync change them.
+func dashboardCacheKey(c *gin.Context) string {
+	return "dashboard:" + services.DashboardGeneration() + ":" + c.Request.URL.Query().Encode()
+}
+
 // GetDashboardData aggregates data for the global dashboard
 func GetDashboardData(c *gin.Context) {
-	daysStr := c.DefaultQuery("days", "30")
-	envStr := c.DefaultQuery("env", "all") // all, prod, non_prod
+	if _, ok := bindPeriod(c); !ok {
+		return
+	}
+	raw, err := dashboardJSON(c)
+	if err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", raw)
+}
 
-	// NEW: Filter parameters
-	componentFilter := c.Query("component")
-	tenantFilter := c.Query("tenant_id")
-	signatureFilter := c.Query("signature")
+// dashboardJSON returns the encoded dashboard for the request's filters, from the
+// shared cache when the same view was computed recently
+func dashboardJSON(c *gin.Context) ([]byte, error) {
+	cacheKey := dashboardCacheKey(c)
+	if raw, ok := cache.Get().Get(cacheKey); ok {
+		return raw, nil
+	}
 
-	var days int
-	fmt.Sscanf(daysStr, "%d", &days)
-	if days <= 0 {
-		days = 30
+	resp, err := computeDashboardData(c)
+	if err != nil {
+		return nil, err
 	}
+	// Don't cache (or return) results computed from queries cut off by the deadline
+	if err := c.Request.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	cache.Get().Set(cacheKey, raw, dashboardCacheTTL)
+	return raw, nil
+}
+
+// period is the window a stats request covers and the equally long window before it,
+// which changes are computed against, as created_at_utc bounds
+type period struct {
+	start, end, prevStart, prevEnd string
+	days                           int // length rounded up to whole days
+	explicit                       bool
+}
+
+func newPeriod(start, end time.Time, explicit bool) period {
+	length := end.Sub(start)
+	return period{
+		start:     start.Format("2006-01-02 15:04:05"),
+		end:       end.Format("2006-01-02 15:04:05"),
+		prevStart: start.Add(-length).Format("2006-01-02 15:04:05"),
+		prevEnd:   start.Format("2006-01-02 15:04:05"),
+		days:      int((length + 24*time.Hour - time.Second) / (24 * time.Hour)),
+		explicit:  explicit,
+	}
+}
 
+func (p period) dateRange() DateRange {
+	return DateRange{Start: p.start, End: p.end, Days: p.days}
+}
+
+// requestPeriod reads the window from ?start= and ?end= (RFC 3339, "2006-01-02 15:04:05"
+// or a date, where an end date covers the whole day; end defaults to now), or else the
+// last ?days= (default 30)
+func requestPeriod(c *gin.Context) (period, error) {
 	now := time.Now().UTC()
+	startStr, endStr := c.Query("start"), c.Query("end")
+	if startStr == "" && endStr == "" {
+		var days int
+		fmt.Sscanf(c.DefaultQuery("days", "30"), "%d", &days)
+		if days <= 0 {
+			days = 30
+		}
+		return newPeriod(now.AddDate(0, 0, -days), now, false), nil
+	}
+	if startStr == "" {
+		return period{}, fmt.Errorf("end requires start")
+	}
 
-	// Current Period
-	endDate := now.Format("2006-01-02 15:04:05")
-	startDate := now.AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+	start, err := parseRangeBound("start", startStr, false)
+	if err != nil {
+		return period{}, err
+	}
+	end := now
+	if endStr != "" {
+		if end, err = parseRangeBound("end", endStr, true); err != nil {
+			return period{}, err
+		}
+	}
+	if !end.After(start) {
+		return period{}, fmt.Errorf("end must be after start")
+	}
+	return newPeriod(start, end, true), nil
+}
 
-	// Previous Period
-	prevEndDate := startDate
-	prevStartDate := now.AddDate(0, 0, -days*2).Format("2006-01-02 15:04:05")
+func parseRangeBound(name, value string, end bool) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected RFC 3339 or YYYY-MM-DD", name, value)
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
+
+// bindPeriod is requestPeriod answering 400 for an invalid range
+func bindPeriod(c *gin.Context) (period, bool) {
+	p, err := requestPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return p, false
+	}
+	return p, true
+}
+
+// dashboardWhere builds the dashboard's issue condition from the query filters, with
+// the arguments its placeholders bind
+func dashboardWhere(c *gin.Context) (string, []interface{}) {
+	envStr := c.DefaultQuery("env", "all") // all, prod, non_prod
+
+	// NEW: Filter parameters
+	componentFilter := c.Query("component")
 
 	// Base Condition for Environment
 	envCondition := ""
@@ -280,254 +386,227 @@ func GetDashboardData(c *gin.Context) {
 
 	// Build additional filter conditions
 	filterCondition := ""
-	if componentFilter != "" {
-		filterCondition += " AND components LIKE '%" + componentFilter + "%'"
-	}
-	if tenantFilter != "" {
-		filterCondition += " AND tenant_id = '" + tenantFilter + "'"
-	}
-	if signatureFilter != "" {
-		filterCondition += " AND alert_signature = '" + signatureFilter + "'"
+	filterCondition += buildComponentFilterCondition(componentFilter)
+	exactCondition, args := exactFilterCondition(c)
+	filterCondition += exactCondition
+	filterCondition += buildInFilterCondition("stability_governance", c.Query("stability_governance"))
+	filterCondition += buildAlertGroupFilterCondition(c.Query("alert_group"))
+	filterCondition += buildTierFilterCondition(c.Query("tier"))
+	filterCondition += buildRegionFilterCondition(c.Query("region"), c.Query("provider"))
+	filterCondition += buildVisibilityFilterCondition(c.Query("visibility"))
+	filterCondition += buildDedupFilterCondition(c.Query("dedup"))
+
+	// Build cluster filter to exclude test clusters and alerts hidden by mute rules
+	clusterFilter := buildClusterFilterCondition() + buildMuteRuleCondition()
+	// Build stability governance filter to only include alerts with stability_governance label
+	stabilityFilter := buildStabilityGovernanceFilterCondition()
+
+	return "is_alert = TRUE " + envCondition + filterCondition + clusterFilter + stabilityFilter, args
+}
+
+// computeDashboardData runs the dashboard aggregations for the request's query filters
+func computeDashboardData(c *gin.Context) (*DashboardDataResponse, error) {
+	dbc := dbFor(c)
+	ctx := c.Request.Context()
+
+	p, err := requestPeriod(c)
+	if err != nil {
+		return nil, err
 	}
-	if clusterFilter := c.Query("cluster_id"); clusterFilter != "" {
-		filterCondition += " AND cluster_id = '" + clusterFilter + "'"
+	startDate, endDate, prevStartDate, prevEndDate := p.start, p.end, p.prevStart, p.prevEnd
+
+	// The aggregations below are independent, so they run concurrently on the request
+	// context; the first failure (or the deadline) cancels the rest
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(dashboardQueryConcurrency)
+	gdb := dbc.WithContext(gctx)
+	where, whereArgs := dashboardWhere(c)
+	// bind returns the filter arguments followed by more; the aggregations run
+	// concurrently, so each gets its own slice
+	bind := func(more ...interface{}) []interface{} {
+		return append(append([]interface{}{}, whereArgs...), more...)
 	}
 
-	// Build cluster filter to exclude test clusters
-	clusterFilter := buildClusterFilterCondition()
-	// Build stability governance filter to only include alerts with stability_governance label
-	stabilityFilter := buildStabilityGovernanceFilterCondition()
+	// Unfiltered trends and top components read the daily rollups when they cover the
+	// whole period, and the raw issues otherwise
+	env := c.DefaultQuery("env", "all")
+	var rollup *services.StatsRollup
+	if r := dashboardRollup(c, gdb); r != nil {
+		if ok, err := r.Covers(gctx, startDate[:10], endDate[:10]); err == nil && ok {
+			rollup = r
+		}
+	}
 
 	// Helper to fetch basic stats for a range
-	fetchStats := func(start, end string) (total, prod, nonProd, critical int) {
-		queryBase := `FROM issues WHERE is_alert = 1 ` + envCondition + filterCondition + clusterFilter + stabilityFilter + ` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?`
-		var result struct {
-			Total    int
-			Prod     int
-			NonProd  int
-			Critical int
-		}
-		db.DB.Raw(`
+	fetchStats := func(start, end string, result *dashboardCounts) error {
+		queryBase := `FROM issues WHERE ` + where + ` AND created_at_utc BETWEEN ? AND ?`
+		return gdb.Raw(`
 			SELECT
 				COUNT(*) as total,
 				SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END) as prod,
 				SUM(CASE WHEN alert_signature NOT LIKE '[PROD]%' THEN 1 ELSE 0 END) as non_prod,
 				SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical
-		`+queryBase, start, end).Scan(&result)
-		return result.Total, result.Prod, result.NonProd, result.Critical
+		`+queryBase, bind(start, end)...).Scan(result).Error
 	}
 
-	currTotal, currProd, currNonProd, currCrit := fetchStats(startDate, endDate)
-	prevTotal, prevProd, prevNonProd, prevCrit := fetchStats(prevStartDate, prevEndDate)
-
-	// 1.5 Rate Stats (Current)
-	var currFake int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'", startDate, endDate).
-		Count(&currFake)
+	var curr, prev dashboardCounts
+	g.Go(func() error { return fetchStats(startDate, endDate, &curr) })
+	g.Go(func() error { return fetchStats(prevStartDate, prevEndDate, &prev) })
 
-	var currHandled int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'", startDate, endDate).
-		Count(&currHandled)
-
-	// 1.6 Rate Stats (Previous)
-	var prevFake int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'", prevStartDate, prevEndDate).
-		Count(&prevFake)
-
-	var prevHandled int64
-	db.DB.Model(&models.Issue{}).
-		Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'", prevStartDate, prevEndDate).
-		Count(&prevHandled)
-
-	calcRate := func(num, den int64) float64 {
-		if den == 0 {
-			return 0
+	// 1.5 Rate Stats (Current and Previous)
+	countWhere := func(start, end, statusCondition string, count *int64) func() error {
+		return func() error {
+			return gdb.Model(&models.Issue{}).
+				Where(where+" AND created_at_utc BETWEEN ? AND ? AND "+statusCondition, bind(start, end)...).
+				Count(count).Error
 		}
-		return float64(num) / float64(den) * 100
-	}
-
-	currFakeRate := calcRate(currFake, int64(currTotal))
-	currHandlingRate := calcRate(currHandled, int64(currTotal))
-	prevFakeRate := calcRate(prevFake, int64(prevTotal))
-	prevHandlingRate := calcRate(prevHandled, int64(prevTotal))
-
-	fakeChange := currFakeRate - prevFakeRate
-	handlingChange := currHandlingRate - prevHandlingRate
-
-	fakeTrend := "neutral"
-	if fakeChange > 0 {
-		fakeTrend = "up"
-	} else if fakeChange < 0 {
-		fakeTrend = "down"
-	}
-
-	handlingTrend := "neutral"
-	if handlingChange > 0 {
-		handlingTrend = "up"
-	} else if handlingChange < 0 {
-		handlingTrend = "down"
 	}
+	var currFake, currHandled, prevFake, prevHandled int64
+	g.Go(countWhere(startDate, endDate, "status = 'FAKE ALARM'", &currFake))
+	g.Go(countWhere(startDate, endDate, "status != 'Created'", &currHandled))
+	g.Go(countWhere(prevStartDate, prevEndDate, "status = 'FAKE ALARM'", &prevFake))
+	g.Go(countWhere(prevStartDate, prevEndDate, "status != 'Created'", &prevHandled))
 
 	// 2. Top Tenants (NEW - with Names)
 	var tenants []TenantCount
-
-	// First get top N current tenants
-	type TenantBasic struct {
-		TenantID string
-		Count    int
-	}
-	var topTenants []TenantBasic
-	db.DB.Raw(`
-		SELECT tenant_id, COUNT(*) as count
-		FROM issues
-		WHERE is_alert = 1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-		AND tenant_id != '' AND tenant_id IS NOT NULL
-		GROUP BY tenant_id
-		ORDER BY count DESC
-		LIMIT 10
-	`, startDate, endDate).Scan(&topTenants)
-
-	// For each top tenant, get previous stats and resolve name
-	for _, t := range topTenants {
-		var prevCount int64
-		db.DB.Model(&models.Issue{}).
-			Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND tenant_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?", t.TenantID, prevStartDate, prevEndDate).
-			Count(&prevCount)
-
-		change, trend := calculateChange(t.Count, int(prevCount))
-
-		// Resolve Name
-		info, _ := services.GetNameResolver().Resolve(t.TenantID)
-
-		tenants = append(tenants, TenantCount{
-			TenantID:   t.TenantID,
-			TenantName: info.Name,
-			Current:    t.Count,
-			Previous:   int(prevCount),
-			Change:     change,
-			Trend:      trend,
-		})
-	}
+	g.Go(func() error {
+		// Top N tenants with previous-period counts in one grouped query
+		top := topWithPrevious(gdb, "tenant_id", where, whereArgs, startDate, endDate, prevStartDate, prevEndDate)
+		names := services.GetNameResolver().ResolveBatch(gctx, topValues(top))
+		for _, t := range top {
+			change, trend := calculateChange(t.Count, t.PrevCount)
+			tenants = append(tenants, TenantCount{
+				TenantID:   t.Value,
+				TenantName: names[t.Value].Name,
+				Current:    t.Count,
+				Previous:   t.PrevCount,
+				Change:     change,
+				Trend:      trend,
+			})
+		}
+		return gctx.Err()
+	})
 
 	// 2.5 Top Clusters (NEW)
 	var clusters []ClusterCount
-
-	type ClusterBasic struct {
-		ClusterID string
-		Count     int
-	}
-	var topClusters []ClusterBasic
-	db.DB.Raw(`
-		SELECT cluster_id, COUNT(*) as count
-		FROM issues
-		WHERE is_alert = 1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-		AND cluster_id != '' AND cluster_id IS NOT NULL
-		GROUP BY cluster_id
-		ORDER BY count DESC
-		LIMIT 10
-	`, startDate, endDate).Scan(&topClusters)
-
-	for _, c := range topClusters {
-		var prevCount int64
-		db.DB.Model(&models.Issue{}).
-			Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND cluster_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?", c.ClusterID, prevStartDate, prevEndDate).
-			Count(&prevCount)
-
-		change, trend := calculateChange(c.Count, int(prevCount))
-
-		// Resolve Name
-		info, _ := services.GetNameResolver().Resolve(c.ClusterID)
-
-		clusters = append(clusters, ClusterCount{
-			ClusterID:   c.ClusterID,
-			ClusterName: info.Name,
-			TenantName:  info.TenantName,
-			Current:     c.Count,
-			Previous:    int(prevCount),
-			Change:      change,
-			Trend:       trend,
-		})
-	}
+	g.Go(func() error {
+		top := topWithPrevious(gdb, "cluster_id", where, whereArgs, startDate, endDate, prevStartDate, prevEndDate)
+		names := services.GetNameResolver().ResolveBatch(gctx, topValues(top))
+		for _, c := range top {
+			change, trend := calculateChange(c.Count, c.PrevCount)
+			info := names[c.Value]
+			clusters = append(clusters, ClusterCount{
+				ClusterID:   c.Value,
+				ClusterName: info.Name,
+				TenantName:  info.TenantName,
+				Current:     c.Count,
+				Previous:    c.PrevCount,
+				Change:      change,
+				Trend:       trend,
+			})
+		}
+		return gctx.Err()
+	})
 
 	// 3. Top Signatures (Current) with Fake Alert Rate
-	type SignatureRaw struct {
-		Signature  string
-		TotalCount int
-		FakeCount  int
-		LastSeen   string
-	}
-	var signaturesRaw []SignatureRaw
-	db.DB.Raw(`
-		SELECT 
-			alert_signature as signature,
-			COUNT(*) as total_count,
-			SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END) as fake_count,
-			MAX(created) as last_seen
-		FROM issues
-		WHERE is_alert = 1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+`
-			AND alert_signature IS NOT NULL 
-			AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-		GROUP BY alert_signature
-		ORDER BY total_count DESC
-		LIMIT 10
-	`, startDate, endDate).Scan(&signaturesRaw)
-
-	// Calculate fake alert rate and MTTR for each signature
-	signatures := make([]SignatureCount, len(signaturesRaw))
-	for i, sig := range signaturesRaw {
-		fakeRate := 0.0
-		if sig.TotalCount > 0 {
-			fakeRate = float64(sig.FakeCount) / float64(sig.TotalCount) * 100
-		}
-
-		// Calculate MTTR for this signature (average time to repair for handled alerts)
-		var mttrResult struct {
-			AvgHours float64
-		}
-		db.DB.Raw(`
+	var signatures []SignatureCount
+	g.Go(func() error {
+		type SignatureRaw struct {
+			Signature  string
+			TotalCount int
+			FakeCount  int
+			LastSeen   string
+		}
+		var signaturesRaw []SignatureRaw
+		err := gdb.Raw(`
 			SELECT 
-				AVG(
-					(julianday('now') - julianday(REPLACE(created, ' UTC', ''))) * 24
-				) as avg_hours
+				alert_signature as signature,
+				COUNT(*) as total_count,
+				SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END) as fake_count,
+				MAX(created) as last_seen
 			FROM issues
-			WHERE is_alert = 1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+`
-				AND alert_signature = ?
-				AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-				AND status != 'Created'
-				AND status != ''
-		`, sig.Signature, startDate, endDate).Scan(&mttrResult)
+			WHERE `+where+`
+				AND alert_signature IS NOT NULL 
+				AND created_at_utc BETWEEN ? AND ?
+			GROUP BY alert_signature
+			ORDER BY total_count DESC
+			LIMIT 10
+		`, bind(startDate, endDate)...).Scan(&signaturesRaw).Error
+		if err != nil {
+			return err
+		}
 
-		mttr := mttrResult.AvgHours
-		if mttr < 0 {
-			mttr = 0
+		// MTTR of each signature: mean hours from created to resolved_at of its resolved
+		// alerts, read in one query for all of them
+		sigNames := make([]string, len(signaturesRaw))
+		for i, sig := range signaturesRaw {
+			sigNames[i] = sig.Signature
+		}
+		var resolvedRows []struct {
+			Signature  string
+			Created    string
+			ResolvedAt string
+		}
+		if len(sigNames) > 0 {
+			err = gdb.Raw(`
+				SELECT alert_signature as signature, created, resolved_at
+				FROM issues
+				WHERE `+where+`
+					AND alert_signature IN ?
+					AND created_at_utc BETWEEN ? AND ?
+					AND COALESCE(resolved_at, '') != ''
+			`, bind(sigNames, startDate, endDate)...).Scan(&resolvedRows).Error
+			if err != nil {
+				return err
+			}
+		}
+		repairHours := map[string][]float64{}
+		for _, r := range resolvedRows {
+			if hours, ok := hoursBetween(r.Created, r.ResolvedAt); ok {
+				repairHours[r.Signature] = append(repairHours[r.Signature], hours)
+			}
 		}
 
-		signatures[i] = SignatureCount{
-			Signature:     sig.Signature,
-			TotalCount:    sig.TotalCount,
-			LastSeen:      sig.LastSeen,
-			FakeAlertRate: fakeRate,
-			MTTR:          mttr,
+		signatures = make([]SignatureCount, len(signaturesRaw))
+		for i, sig := range signaturesRaw {
+			fakeRate := 0.0
+			if sig.TotalCount > 0 {
+				fakeRate = float64(sig.FakeCount) / float64(sig.TotalCount) * 100
+			}
+			mttr, _ := meanMedian(repairHours[sig.Signature])
+
+			signatures[i] = SignatureCount{
+				Signature:     sig.Signature,
+				TotalCount:    sig.TotalCount,
+				LastSeen:      sig.LastSeen,
+				FakeAlertRate: fakeRate,
+				MTTR:          mttr,
+			}
 		}
-	}
+		return nil
+	})
 
 	// 4. Top Components (Current)
 	var components []ComponentCount
-	db.DB.Raw(`
-		SELECT 
-			CASE 
-				WHEN components IS NULL OR components = '[]' OR components = '' THEN 'No Component'
-				ELSE json_extract(components, '$[0]')
-			END as component,
-			COUNT(*) as count
-		FROM issues WHERE is_alert = 1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
-		GROUP BY component
-		ORDER BY count DESC
-		LIMIT 10
-	`, startDate, endDate).Scan(&components)
+	g.Go(func() error {
+		if rollup != nil {
+			var err error
+			components, err = rollupTopComponents(gdb, rollup.Hash(), where, whereArgs, env, startDate, endDate)
+			return err
+		}
+		return gdb.Raw(`
+			SELECT 
+				CASE 
+					WHEN components IS NULL OR components = '[]' OR components = '' THEN 'No Component'
+					ELSE json_extract(components, '$[0]')
+				END as component,
+				COUNT(*) as count
+			FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+			GROUP BY component
+			ORDER BY count DESC
+			LIMIT 10
+		`, bind(startDate, endDate)...).Scan(&components).Error
+	})
 
 	step := c.DefaultQuery("step", "day") // day, week, month
 
@@ -537,95 +616,144 @@ func GetDashboardData(c *gin.Context) {
 	// Determine time format for grouping
 	dateSelect := ""
 	if step == "week" {
-		// Week requires strftime
-		dateSelect = "strftime('%Y-%W', REPLACE(created, ' UTC', '')) as date"
+		dateSelect = db.WeekKey("REPLACE(created, ' UTC', '')") + " as date"
 	} else if step == "month" {
 		dateSelect = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 7) as date"
 	} else {
 		dateSelect = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 10) as date"
 	}
 
-	db.DB.Raw(`
-		SELECT 
-			`+dateSelect+`,
-			COUNT(*) as total_alerts,
-			SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical_count,
-			SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END) as major_count,
-			SUM(CASE WHEN priority = 'Warning' THEN 1 ELSE 0 END) as warning_count
-		FROM issues
-		WHERE is_alert = 1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+` AND SUBSTR(REPLACE(created, ' UTC', ''), 1, 10) BETWEEN ? AND ?
-		GROUP BY date
-		ORDER BY date ASC
-	`, startDate[:10], endDate[:10]).Scan(&trend)
+	g.Go(func() error {
+		if rollup != nil {
+			var err error
+			trend, err = rollupTrend(gdb, rollup.Hash(), env, step, startDate[:10], endDate[:10])
+			return err
+		}
+		return gdb.Raw(`
+			SELECT 
+				`+dateSelect+`,
+				COUNT(*) as total_alerts,
+				SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical_count,
+				SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END) as major_count,
+				SUM(CASE WHEN priority = 'Warning' THEN 1 ELSE 0 END) as warning_count
+			FROM issues
+			WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+			GROUP BY date
+			ORDER BY date ASC
+		`, bind(startDate[:10]+" 00:00:00", endDate[:10]+" 23:59:59")...).Scan(&trend).Error
+	})
 
 	// Priority Breakdown
 	var priorityCounts []PriorityCount
-	db.DB.Raw(`SELECT priority, COUNT(*) as count FROM issues WHERE is_alert=1 `+envCondition+filterCondition+clusterFilter+stabilityFilter+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? GROUP BY priority`, startDate, endDate).Scan(&priorityCounts)
+	g.Go(func() error {
+		return gdb.Raw(`SELECT priority, COUNT(*) as count FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ? GROUP BY priority`, bind(startDate, endDate)...).Scan(&priorityCounts).Error
+	})
+
+	// Visibility Breakdown
+	var visibilities []VisibilityCount
+	g.Go(func() error {
+		visibilities = visibilityBreakdown(gdb, where, whereArgs, startDate, endDate, prevStartDate, prevEndDate)
+		return gctx.Err()
+	})
+
+	// Region Breakdown
+	var regions []RegionCount
+	g.Go(func() error {
+		regions = regionBreakdown(gdb, where, whereArgs, startDate, endDate, prevStartDate, prevEndDate)
+		return gctx.Err()
+	})
+
+	// Time to acknowledge / resolve
+	var responseTimes ResponseTimesBreakdown
+	g.Go(func() error {
+		var err error
+		responseTimes, err = responseTimesBreakdown(gdb, where, whereArgs, startDate, endDate)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-	// Build MetricStats
-	totalChange, totalTrend := calculateChange(currTotal, prevTotal)
-	prodChange, prodTrend := calculateChange(currProd, prevProd)
-	nonProdChange, nonProdTrend := calculateChange(currNonProd, prevNonProd)
-	critChange, critTrend := calculateChange(currCrit, prevCrit)
+	curr.Fake, curr.Handled = int(currFake), int(currHandled)
+	prev.Fake, prev.Handled = int(prevFake), int(prevHandled)
 
 	resp := DashboardDataResponse{
-		TotalAlerts:    MetricStat{Current: float64(currTotal), Previous: float64(prevTotal), Change: totalChange, Trend: totalTrend},
-		ProdAlerts:     MetricStat{Current: float64(currProd), Previous: float64(prevProd), Change: prodChange, Trend: prodTrend},
-		NonProdAlerts:  MetricStat{Current: float64(currNonProd), Previous: float64(prevNonProd), Change: nonProdChange, Trend: nonProdTrend},
-		CriticalAlerts: MetricStat{Current: float64(currCrit), Previous: float64(prevCrit), Change: critChange, Trend: critTrend},
-		FakeAlarmRate:  MetricStat{Current: currFakeRate, Previous: prevFakeRate, Change: fakeChange, Trend: fakeTrend},
-		HandlingRate:   MetricStat{Current: currHandlingRate, Previous: prevHandlingRate, Change: handlingChange, Trend: handlingTrend},
-		ByPriority:     priorityCounts,
-		BySignature:    signatures,
-		ByComponent:    components,
-		ByTenant:       tenants,
-		ByCluster:      clusters,
-		DailyTrend:     trend,
-		DateRange: DateRange{
-			Start: startDate,
-			End:   endDate,
-			Days:  days,
-		},
+		DashboardMetrics: dashboardMetrics(curr, prev),
+		ByPriority:       priorityCounts,
+		BySignature:      signatures,
+		ByComponent:      components,
+		ByTenant:         tenants,
+		ByCluster:        clusters,
+		ByVisibility:     visibilities,
+		ByRegion:         regions,
+		DailyTrend:       trend,
+		ResponseTimes:    responseTimes,
+		DateRange:        p.dateRange(),
 	}
 
-	c.JSON(http.StatusOK, resp)
+	return &resp, nil
 }
 
-// GetDashboardIssues returns a list of issues matching the dashboard filters
-func GetDashboardIssues(c *gin.Context) {
-	daysStr := c.DefaultQuery("days", "30")
-	envStr := c.DefaultQuery("env", "all")
-	componentFilter := c.Query("component")
-	tenantFilter := c.Query("tenant_id")
-	signatureFilter := c.Query("signature")
-	metricType := c.Query("metric_type")
-	category := c.Query("category")
-	priorityFilter := c.Query("priority") // NEW: generic priority filter (e.g. "Critical,Major")
-
-	// Pagination
-	pageStr := c.DefaultQuery("page", "1")
-	pageSizeStr := c.DefaultQuery("page_size", "50")
-
-	var days int
-	fmt.Sscanf(daysStr, "%d", &days)
-	if days <= 0 {
-		days = 30
+// dashboardMetrics compares two periods: counts by relative change, rates (fake alarms,
+// handled alerts, as a percentage of the total) by the difference in points
+func dashboardMetrics(curr, prev dashboardCounts) DashboardMetrics {
+	calcRate := func(num, den int) float64 {
+		if den == 0 {
+			return 0
+		}
+		return float64(num) / float64(den) * 100
+	}
+	rateStat := func(currRate, prevRate float64) MetricStat {
+		change := currRate - prevRate
+		trend := "neutral"
+		if change > 0 {
+			trend = "up"
+		} else if change < 0 {
+			trend = "down"
+		}
+		return MetricStat{Current: currRate, Previous: prevRate, Change: change, Trend: trend}
+	}
+	countStat := func(currCount, prevCount int) MetricStat {
+		change, trend := calculateChange(currCount, prevCount)
+		return MetricStat{Current: float64(currCount), Previous: float64(prevCount), Change: change, Trend: trend}
 	}
 
-	var page, pageSize int
-	fmt.Sscanf(pageStr, "%d", &page)
-	if page < 1 {
-		page = 1
+	return DashboardMetrics{
+		TotalAlerts:    countStat(curr.Total, prev.Total),
+		ProdAlerts:     countStat(curr.Prod, prev.Prod),
+		NonProdAlerts:  countStat(curr.NonProd, prev.NonProd),
+		CriticalAlerts: countStat(curr.Critical, prev.Critical),
+		FakeAlarmRate:  rateStat(calcRate(curr.Fake, curr.Total), calcRate(prev.Fake, prev.Total)),
+		HandlingRate:   rateStat(calcRate(curr.Handled, curr.Total), calcRate(prev.Handled, prev.Total)),
 	}
-	fmt.Sscanf(pageSizeStr, "%d", &pageSize)
-	if pageSize < 1 {
-		pageSize = 50
+}
+
+// exactFilterCondition matches the tenant_id, signature and cluster_id filters, with
+// the values bound rather than written into the SQL
+func exactFilterCondition(c *gin.Context) (string, []interface{}) {
+	condition, args := "", []interface{}{}
+	for _, f := range []struct{ param, column string }{
+		{"tenant_id", "tenant_id"},
+		{"signature", "alert_signature"},
+		{"cluster_id", "cluster_id"},
+	} {
+		if value := c.Query(f.param); value != "" {
+			condition += " AND " + f.column + " = ?"
+			args = append(args, value)
+		}
 	}
-	offset := (page - 1) * pageSize
+	return condition, args
+}
 
-	now := time.Now().UTC()
-	endDate := now.Format("2006-01-02 15:04:05")
-	startDate := now.AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+// issueListQuery builds the filtered (but unordered and unpaginated) issue query
+// shared by the issue list endpoints, over the request's period p
+func issueListQuery(c *gin.Context, p period) *gorm.DB {
+	envStr := c.DefaultQuery("env", "all")
+	componentFilter := c.Query("component")
+	metricType := c.Query("metric_type")
+	category := c.Query("category")
+	priorityFilter := c.Query("priority") // NEW: generic priority filter (e.g. "Critical,Major")
 
 	envCondition := ""
 	if envStr == "prod" {
@@ -635,45 +763,27 @@ func GetDashboardIssues(c *gin.Context) {
 	}
 
 	filterCondition := ""
+	includeUngoverned := false
 	if componentFilter != "" {
-		if componentFilter == "Serverless" {
-			category = "essential"
-		} else if componentFilter == "old-rules" {
-			// Special handling for old-rules
-			filterCondition += " AND (stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%')"
+		if vc, ok := getVirtualComponent(componentFilter); ok {
+			filterCondition += " AND (" + vc.Filter + ")"
+			includeUngoverned = vc.IncludeUngoverned
 		} else {
-			// Normal component
-			// We need to know the category to apply strict filtering (exclude old-rules)
-			// Ideally we should import `api.getCategory` but it's private in same package.
-			// Since we represent same package `api`, we can use `getCategory`.
-			// However `getCategory` is in components.go.
-			cat := getCategory(componentFilter)
-			if cat != "Resilience" && cat != "Serverless" {
-				filterCondition += " AND NOT ((stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%'))"
-			}
-			filterCondition += " AND components LIKE '%" + componentFilter + "%'"
+			// Normal component, minus issues claimed by exclusive virtual components (e.g. old-rules)
+			filterCondition += exclusiveCondition(getCategory(componentFilter))
+			filterCondition += buildComponentFilterCondition(componentFilter)
 		}
 	}
-	if tenantFilter != "" {
-		filterCondition += " AND tenant_id = '" + tenantFilter + "'"
-	}
-	if signatureFilter != "" {
-		filterCondition += " AND alert_signature = '" + signatureFilter + "'"
-	}
-	if clusterFilter := c.Query("cluster_id"); clusterFilter != "" {
-		filterCondition += " AND cluster_id = '" + clusterFilter + "'"
-	}
+	exactCondition, args := exactFilterCondition(c)
+	filterCondition += exactCondition
+	filterCondition += buildInFilterCondition("stability_governance", c.Query("stability_governance"))
+	filterCondition += buildAlertGroupFilterCondition(c.Query("alert_group"))
+	filterCondition += buildTierFilterCondition(c.Query("tier"))
+	filterCondition += buildRegionFilterCondition(c.Query("region"), c.Query("provider"))
+	filterCondition += buildVisibilityFilterCondition(c.Query("visibility"))
+	filterCondition += buildDedupFilterCondition(c.Query("dedup"))
 
-	if category != "" {
-		switch category {
-		case "premium":
-			filterCondition += " AND (biz_type LIKE '%nextgen%')"
-		case "essential":
-			filterCondition += " AND (biz_type LIKE '%devtier%')"
-		case "dedicated":
-			filterCondition += " AND (biz_type NOT LIKE '%nextgen%' AND biz_type NOT LIKE '%devtier%')"
-		}
-	}
+	filterCondition += businessCategoryCondition(category)
 
 	// Filter by metric type
 	if metricType == "fake" {
@@ -692,49 +802,405 @@ func GetDashboardIssues(c *gin.Context) {
 	if priorityFilter != "" {
 		// Expects comma separated 'Critical,Major'
 		priorities := strings.Split(priorityFilter, ",")
-		quoted := make([]string, len(priorities))
 		for i, p := range priorities {
-			quoted[i] = "'" + strings.TrimSpace(p) + "'"
+			priorities[i] = strings.TrimSpace(p)
 		}
-		filterCondition += " AND priority IN (" + strings.Join(quoted, ",") + ")"
+		filterCondition += " AND priority IN ?"
+		args = append(args, priorities)
 	}
 
-	// Build cluster filter to exclude test clusters
-	clusterFilter := buildClusterFilterCondition()
+	// Build cluster filter to exclude test clusters and alerts hidden by mute rules
+	clusterFilter := buildClusterFilterCondition() + buildMuteRuleCondition()
 	// Build stability governance filter to only include alerts with stability_governance label
-	stabilityFilter := buildStabilityGovernanceFilterCondition()
+	stabilityFilter := ""
+	if !includeUngoverned {
+		stabilityFilter = buildStabilityGovernanceFilterCondition()
+	}
 
-	var issues []models.Issue
-	db.DB.Model(&models.Issue{}).
-		Select("issues.*").
-		Joins("LEFT JOIN muted_issues ON muted_issues.issue_id = issues.id").
+	query := dbFor(c).Model(&models.Issue{}).
+		Joins("LEFT JOIN muted_issues ON muted_issues.issue_id = issues.id AND "+services.MutedIssueActive, time.Now().UTC()).
 		Where("muted_issues.issue_id IS NULL").
-		Where("is_alert = 1 "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND REPLACE(issues.created, ' UTC', '') BETWEEN ? AND ?", startDate, endDate).
-		Order("issues.created DESC").
+		Where("is_alert = TRUE "+envCondition+filterCondition+clusterFilter+stabilityFilter+" AND issues.created_at_utc BETWEEN ? AND ?", append(args, p.start, p.end)...)
+	return applyIssueSearch(c, query, p.start, p.end)
+}
+
+// issueSortColumns whitelists the sort keys accepted by issue listings. Priority ranks
+// the normalized priorities by severity, unknown ones last when descending.
+var issueSortColumns = map[string]string{
+	"created":  "issues.created",
+	"priority": "CASE issues.priority WHEN 'Critical' THEN 5 WHEN 'Major' THEN 4 WHEN 'Medium' THEN 3 WHEN 'Warning' THEN 2 WHEN 'Low' THEN 1 ELSE 0 END",
+	"status":   "issues.status",
+	"cluster":  "issues.cluster_id",
+	"tenant":   "issues.tenant_id",
+}
+
+// issueListOrder validates sort/order and returns the ORDER BY clause.
+// Ties are broken by created and id so pages stay stable.
+func issueListOrder(c *gin.Context) (string, error) {
+	sortKey := c.DefaultQuery("sort", "created")
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+
+	column, ok := issueSortColumns[sortKey]
+	if !ok {
+		return "", fmt.Errorf("invalid sort %q: must be one of created, priority, status, cluster, tenant", sortKey)
+	}
+	if order != "asc" && order != "desc" {
+		return "", fmt.Errorf("invalid order %q: must be asc or desc", order)
+	}
+
+	if sortKey == "created" {
+		return column + " " + order + ", issues.id " + order, nil
+	}
+	return column + " " + order + ", issues.created DESC, issues.id DESC", nil
+}
+
+// IssuePage is a page of GetDashboardIssues with the totals under the same filters
+type IssuePage struct {
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	TotalPages int64       `json:"total_pages"`
+}
+
+func newIssuePage(items interface{}, total int64, page, pageSize int) IssuePage {
+	return IssuePage{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+	}
+}
+
+// GetDashboardIssues returns a page of the issues matching the dashboard filters
+// as an IssuePage. Passing a cursor parameter (empty for the first page) switches
+// to keyset pagination and returns {items, next_cursor}, without totals.
+// sort/order select the ordering (default created desc) and fields= limits
+// each row to the listed JSON fields; q= and label= search text and labels.
+// group=true collapses repeats into their dedup groups (see IssueGroup).
+func GetDashboardIssues(c *gin.Context) {
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+
+	// Pagination
+	pageStr := c.DefaultQuery("page", "1")
+	pageSizeStr := c.DefaultQuery("page_size", "50")
+
+	var page, pageSize int
+	fmt.Sscanf(pageStr, "%d", &page)
+	if page < 1 {
+		page = 1
+	}
+	fmt.Sscanf(pageSizeStr, "%d", &pageSize)
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	offset := (page - 1) * pageSize
+
+	if group := c.Query("group"); group == "true" || group == "1" {
+		getDashboardIssueGroups(c, p, page, pageSize)
+		return
+	}
+
+	orderBy, err := issueListOrder(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := parseIssueFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := issueListQuery(c, p).Select(issueSelectColumns(fields))
+
+	if rawCursor, ok := c.GetQuery("cursor"); ok {
+		// The keyset is (created, id), so cursors only work with the default ordering
+		if c.DefaultQuery("sort", "created") != "created" || strings.ToLower(c.DefaultQuery("order", "desc")) != "desc" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor pagination only supports sort=created&order=desc"})
+			return
+		}
+		if rawCursor != "" {
+			cursor, err := decodeIssueCursor(rawCursor)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+			query = query.Where("(issues.created < ? OR (issues.created = ? AND issues.id < ?))", cursor.Created, cursor.Created, cursor.ID)
+		}
+
+		// Fetch one extra row to know whether another page exists
+		var issues []models.Issue
+		err := query.Order("issues.created DESC, issues.id DESC").
+			Limit(pageSize + 1).
+			Find(&issues).Error
+
+		if requestTimedOut(c) {
+			return
+		}
+		if err != nil {
+			logFor(c).Error("Failed to list issues", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list issues"})
+			return
+		}
+
+		nextCursor := ""
+		if len(issues) > pageSize {
+			issues = issues[:pageSize]
+			last := issues[len(issues)-1]
+			nextCursor = encodeIssueCursor(issueCursor{Created: last.Created, ID: last.ID})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"items":       projectFields(issues, fields),
+			"next_cursor": nextCursor,
+		})
+		return
+	}
+
+	var total int64
+	if err := issueListQuery(c, p).Count(&total).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		logFor(c).Error("Failed to count issues", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list issues"})
+		return
+	}
+
+	issues := []models.Issue{}
+	err = query.Order(orderBy).
 		Limit(pageSize).
 		Offset(offset).
-		Find(&issues)
+		Find(&issues).Error
+
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		logFor(c).Error("Failed to list issues", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list issues"})
+		return
+	}
 
-	c.JSON(http.StatusOK, issues)
+	c.JSON(http.StatusOK, newIssuePage(projectFields(issues, fields), total, page, pageSize))
 }
 
-// MuteIssue mutes an issue
+// ExportDashboardIssues streams every issue matching the GetDashboardIssues filters and
+// ordering as a CSV download (?format=csv, the only format). Large pulls can also run as
+// an issues-export job.
+func ExportDashboardIssues(c *gin.Context) {
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format " + format + ", want csv"})
+		return
+	}
+	orderBy, err := issueListOrder(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", services.IssueExportFileName()))
+	c.Status(http.StatusOK)
+	rows, err := services.WriteIssuesCSV(issueListQuery(c, p).Order(orderBy), c.Writer, nil)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			logFor(c).Error("Issue CSV export failed", "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export issues"})
+			return
+		}
+		logFor(c).Warn("Issue CSV export aborted", "rows", rows, "err", err)
+	}
+}
+
+// MuteIssueRequest is the optional body of POST /issues/:id/mute
+type MuteIssueRequest struct {
+	// The mute lapses at ExpiresAt, or after Duration (e.g. "24h"); without either it
+	// lasts until the issue is unmuted
+	ExpiresAt *time.Time `json:"expires_at"`
+	Duration  string     `json:"duration"`
+
+	// Future also mutes later occurrences: alerts with the same signature and cluster
+	// ingested within TTLHours (default 7 days)
+	Future   bool `json:"future"`
+	TTLHours int  `json:"ttl_hours"`
+
+	// Silence also silences the issue's alert in Alertmanager until the mute expires
+	Silence bool `json:"silence"`
+}
+
+// muteExpiry resolves a mute's expires_at or duration to its expiry; nil for neither
+func muteExpiry(expiresAt *time.Time, duration string, now time.Time) (*time.Time, error) {
+	switch {
+	case expiresAt != nil && duration != "":
+		return nil, fmt.Errorf("set either expires_at or duration, not both")
+	case duration != "":
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q: expected a positive Go duration such as 30m or 24h", duration)
+		}
+		expires := now.Add(d)
+		return &expires, nil
+	case expiresAt != nil:
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("expires_at must be in the future")
+		}
+		expires := expiresAt.UTC()
+		return &expires, nil
+	}
+	return nil, nil
+}
+
+// Bounds for how long a mute carries over to future occurrences
+const (
+	muteSuppressionDefaultTTL = 7 * 24 * time.Hour
+	muteSuppressionMaxTTL     = 90 * 24 * time.Hour
+)
+
+// MuteIssue mutes an issue, until expires_at or for duration if given
 func MuteIssue(c *gin.Context) {
+	dbc := dbFor(c)
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id required"})
 		return
 	}
+	var req MuteIssueRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	now := time.Now().UTC()
+	expiresAt, err := muteExpiry(req.ExpiresAt, req.Duration, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var suppression *models.MuteSuppression
+	if req.Future {
+		ttl := time.Duration(req.TTLHours) * time.Hour
+		if req.TTLHours == 0 {
+			ttl = muteSuppressionDefaultTTL
+		}
+		if ttl <= 0 || ttl > muteSuppressionMaxTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ttl_hours must be between 1 and %d", int(muteSuppressionMaxTTL.Hours()))})
+			return
+		}
+		var issue models.Issue
+		if err := dbc.Select("id, alert_signature, cluster_id").First(&issue, "id = ?", id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+			return
+		}
+		if issue.AlertSignature == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "issue has no alert signature to match future occurrences on"})
+			return
+		}
+		suppression = &models.MuteSuppression{
+			AlertSignature: issue.AlertSignature,
+			ClusterID:      issue.ClusterID,
+			SourceIssueID:  id,
+			Actor:          currentActor(c),
+			CreatedAt:      now,
+			ExpiresAt:      now.Add(ttl),
+		}
+	}
+
+	var silenceMatchers []services.SilenceMatcher
+	if req.Silence {
+		var issue models.Issue
+		if err := dbc.Select("id, rule_name, cluster_id").First(&issue, "id = ?", id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+			return
+		}
+		if silenceMatchers, err = services.IssueSilenceMatchers(issue); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	muted := models.MutedIssue{
-		IssueID: id,
-		Reason:  "User muted via dashboard",
+		IssueID:   id,
+		MutedAt:   now,
+		Reason:    "User muted via dashboard",
+		ExpiresAt: expiresAt,
+	}
+	if req.Silence {
+		var ok bool
+		if muted.SilenceID, ok = createMuteSilence(c, silenceMatchers, expiresAt, fmt.Sprintf("Muted issue %s from the alerts dashboard", id)); !ok {
+			return
+		}
 	}
 
-	if err := db.DB.Create(&muted).Error; err != nil {
+	// Muting again replaces the earlier mute, and with it the expiry and silence
+	var previous models.MutedIssue
+	dbc.Select("silence_id").Limit(1).Find(&previous, "issue_id = ?", id)
+	if err := dbc.Save(&muted).Error; err != nil {
+		expireMuteSilence(c.Request.Context(), muted.SilenceID)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mute issue"})
 		return
 	}
+	expireMuteSilence(c.Request.Context(), previous.SilenceID)
+	detail := muted.Reason
+	if expiresAt != nil {
+		detail += fmt.Sprintf(" until %s", expiresAt.Format("2006-01-02 15:04 UTC"))
+	}
+	if muted.SilenceID != "" {
+		detail += fmt.Sprintf("; Alertmanager silence %s", muted.SilenceID)
+	}
+	if suppression != nil {
+		if err := dbc.Create(suppression).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "issue muted, but failed to mute future occurrences"})
+			return
+		}
+		detail += fmt.Sprintf("; future occurrences muted until %s", suppression.ExpiresAt.Format("2006-01-02 15:04 UTC"))
+	}
+	services.InvalidateDashboards()
+	dbc.Create(&models.IssueEvent{IssueID: id, Type: IssueEventMute, Actor: currentActor(c), Detail: detail})
+	audit(c, services.AuditMute, "issue", id, nil, gin.H{"reason": muted.Reason, "expires_at": expiresAt, "suppression": suppression, "silence_id": muted.SilenceID})
+
+	resp := gin.H{"success": true}
+	if expiresAt != nil {
+		resp["expires_at"] = expiresAt
+	}
+	if muted.SilenceID != "" {
+		resp["silence_id"] = muted.SilenceID
+	}
+	if suppression != nil {
+		resp["suppression"] = suppression
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UnmuteIssue lifts an issue's mute, expired or not, and expires its Alertmanager silence.
+// Mutes of its future occurrences stay until deleted through /mute-suppressions.
+func UnmuteIssue(c *gin.Context) {
+	dbc := dbFor(c)
+	id := c.Param("id")
+
+	var muted models.MutedIssue
+	if err := dbc.First(&muted, "issue_id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue is not muted"})
+		return
+	}
+	if err := dbc.Delete(&models.MutedIssue{}, "issue_id = ?", id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unmute issue"})
+		return
+	}
+	expireMuteSilence(c.Request.Context(), muted.SilenceID)
+	services.InvalidateDashboards()
+	dbc.Create(&models.IssueEvent{IssueID: id, Type: IssueEventUnmute, Actor: currentActor(c), Detail: "User unmuted via dashboard"})
+	audit(c, services.AuditUnmute, "issue", id, muted, nil)
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
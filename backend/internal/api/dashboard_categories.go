@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/nolouch/alerts-platform-v2/internal/cache"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// BusinessCategory is a product line; alerts are assigned by biz_type
+type BusinessCategory struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// businessCategories in display order. Premium is nextgen, Serverless (essential)
+// is devtier, Dedicated is everything else.
+var businessCategories = []BusinessCategory{
+	{Key: "premium", Label: "Premium"},
+	{Key: "dedicated", Label: "Dedicated"},
+	{Key: "essential", Label: "Serverless"},
+}
+
+// businessCategoryExpr evaluates to the category key of an issue row
+const businessCategoryExpr = `CASE
+	WHEN biz_type LIKE '%nextgen%' THEN 'premium'
+	WHEN biz_type LIKE '%devtier%' OR biz_type LIKE '%TiDB Serverless%' THEN 'essential'
+	ELSE 'dedicated' END`
+
+// businessCategoryCondition restricts a query to one category; unknown keys match everything
+func businessCategoryCondition(category string) string {
+	switch category {
+	case "premium":
+		return " AND (biz_type LIKE '%nextgen%')"
+	case "essential":
+		return " AND (biz_type LIKE '%devtier%' OR biz_type LIKE '%TiDB Serverless%')"
+	case "dedicated":
+		return " AND (biz_type NOT LIKE '%nextgen%' AND biz_type NOT LIKE '%devtier%' AND biz_type NOT LIKE '%TiDB Serverless%')"
+	}
+	return ""
+}
+
+// CategoryMetrics are the dashboard headline stats of one business category
+type CategoryMetrics struct {
+	BusinessCategory
+	DashboardMetrics
+}
+
+// DashboardCategoriesResponse compares the business categories over the same window
+type DashboardCategoriesResponse struct {
+	Categories []CategoryMetrics `json:"categories"`
+	DateRange  DateRange         `json:"dateRange"`
+}
+
+// GetDashboardCategories returns the dashboard's headline MetricStats for each business
+// category side by side. It takes the same filters as GET /dashboard.
+func GetDashboardCategories(c *gin.Context) {
+	cacheKey := "dashboard-categories:" + services.DashboardGeneration() + ":" + c.Request.URL.Query().Encode()
+	if raw, ok := cache.Get().Get(cacheKey); ok {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", raw)
+		return
+	}
+
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	where, whereArgs := dashboardWhere(c)
+
+	// One grouped query per period covers every category
+	fetch := func(g *errgroup.Group, start, end string, out map[string]dashboardCounts) {
+		g.Go(func() error {
+			var rows []struct {
+				Category                                      string
+				Total, Prod, NonProd, Critical, Fake, Handled int
+			}
+			err := dbFor(c).Raw(`
+				SELECT
+					`+businessCategoryExpr+` AS category,
+					COUNT(*) AS total,
+					SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END) AS prod,
+					SUM(CASE WHEN alert_signature NOT LIKE '[PROD]%' THEN 1 ELSE 0 END) AS non_prod,
+					SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) AS critical,
+					SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END) AS fake,
+					SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END) AS handled
+				FROM issues
+				WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+				GROUP BY category`, append(append([]interface{}{}, whereArgs...), start, end)...).Scan(&rows).Error
+			for _, r := range rows {
+				out[r.Category] = dashboardCounts{r.Total, r.Prod, r.NonProd, r.Critical, r.Fake, r.Handled}
+			}
+			return err
+		})
+	}
+	curr, prev := map[string]dashboardCounts{}, map[string]dashboardCounts{}
+	var g errgroup.Group
+	fetch(&g, p.start, p.end, curr)
+	fetch(&g, p.prevStart, p.prevEnd, prev)
+	if err := g.Wait(); err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := DashboardCategoriesResponse{
+		Categories: make([]CategoryMetrics, 0, len(businessCategories)),
+		DateRange:  p.dateRange(),
+	}
+	for _, cat := range businessCategories {
+		resp.Categories = append(resp.Categories, CategoryMetrics{
+			BusinessCategory: cat,
+			DashboardMetrics: dashboardMetrics(curr[cat.Key], prev[cat.Key]),
+		})
+	}
+
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Request.Context().Err() == nil {
+		cache.Get().Set(cacheKey, raw, dashboardCacheTTL)
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", raw)
+}
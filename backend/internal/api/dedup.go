@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// buildDedupFilterCondition drops repeats linked to an earlier occurrence when
+// dedup=true, so each burst of identical alerts counts once
+func buildDedupFilterCondition(value string) string {
+	if value != "true" && value != "1" {
+		return ""
+	}
+	return " AND (duplicate_of = '' OR duplicate_of IS NULL)"
+}
+
+// issueGroupKey is the first occurrence an alert's burst is linked to at ingest, or the
+// alert itself
+const issueGroupKey = "COALESCE(NULLIF(issues.duplicate_of, ''), issues.id)"
+
+// IssueGroup is one burst of an alert signature on a cluster: the first occurrence and
+// its repeats within the dedup window (DEDUP_WINDOW), counting those matching the filters
+type IssueGroup struct {
+	ID             string `json:"id"` // the first occurrence
+	Title          string `json:"title"`
+	AlertSignature string `json:"alert_signature"`
+	ClusterID      string `json:"cluster_id"`
+	TenantID       string `json:"tenant_id"`
+	FirstSeen      string `json:"first_seen"`
+	LastSeen       string `json:"last_seen"`
+	Count          int    `json:"count"`
+}
+
+// issueGroupSortColumns are the sort keys of the grouped issue list; created sorts by
+// the latest occurrence
+var issueGroupSortColumns = map[string]string{
+	"created": "last_seen",
+	"count":   "count",
+}
+
+// getDashboardIssueGroups serves GetDashboardIssues with group=true: the matching issues
+// collapsed into their dedup groups, paged by page/page_size into an IssuePage
+func getDashboardIssueGroups(c *gin.Context, p period, page, pageSize int) {
+	if _, ok := c.GetQuery("cursor"); ok || c.Query("fields") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group=true does not support cursor or fields"})
+		return
+	}
+	column, ok := issueGroupSortColumns[c.DefaultQuery("sort", "created")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort: with group=true must be created or count"})
+		return
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid order %q: must be asc or desc", order)})
+		return
+	}
+
+	groupBy := issueGroupKey + ", issues.alert_signature, issues.cluster_id"
+	var total int64
+	if err := issueListQuery(c, p).Group(groupBy).Count(&total).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups := []IssueGroup{}
+	issueListQuery(c, p).
+		Select(issueGroupKey + ` as id, MAX(issues.title) as title, issues.alert_signature, issues.cluster_id,
+			MAX(issues.tenant_id) as tenant_id, MIN(issues.created) as first_seen, MAX(issues.created) as last_seen,
+			COUNT(*) as count`).
+		Group(groupBy).
+		Order(column + " " + order + ", id " + order).
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Scan(&groups)
+
+	if requestTimedOut(c) {
+		return
+	}
+	c.JSON(http.StatusOK, newIssuePage(groups, total, page, pageSize))
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// GetWarehouseExports lists recorded daily exports, newest first. Optional: ?limit=30
+func GetWarehouseExports(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "30"), "%d", &limit)
+	if limit <= 0 || limit > 365 {
+		limit = 30
+	}
+
+	var runs []models.WarehouseExport
+	if err := dbFor(c).Order("date DESC").Limit(limit).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"target":   config.Get().ExportTarget,
+		"interval": config.Get().ExportInterval.String(),
+		"items":    runs,
+	})
+}
+
+// RunWarehouseExportRequest optionally re-exports a single day (YYYY-MM-DD); without
+// it, all pending days are exported
+type RunWarehouseExportRequest struct {
+	Date string `json:"date"`
+}
+
+// day parses the requested day, which must be finished; zero when unset
+func (req RunWarehouseExportRequest) day() (time.Time, error) {
+	if req.Date == "" {
+		return time.Time{}, nil
+	}
+	day, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date, expected YYYY-MM-DD")
+	}
+	if !day.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+		return time.Time{}, fmt.Errorf("only finished days can be exported")
+	}
+	return day, nil
+}
+
+// RunWarehouseExport exports now instead of waiting for the scheduler
+func RunWarehouseExport(c *gin.Context) {
+	var req RunWarehouseExportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	day, err := req.day()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exporter, err := services.NewWarehouseExporter(dbFor(c), config.Get().ExportTarget)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	var runs []models.WarehouseExport
+	ran, err := services.RunExclusive(db.DB, services.WarehouseExportLockName, services.WarehouseExportLockTTL, func() error {
+		if req.Date == "" {
+			var runErr error
+			runs, runErr = exporter.RunPending(c.Request.Context())
+			return runErr
+		}
+		run, runErr := exporter.ExportDay(c.Request.Context(), day)
+		if run != nil {
+			runs = append(runs, *run)
+		}
+		return runErr
+	})
+	if requestTimedOut(c) {
+		return
+	}
+	if !ran && err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "a warehouse export is already running"})
+		return
+	}
+	if runs == nil {
+		runs = []models.WarehouseExport{}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "items": runs})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": runs})
+}
+
+// StartWarehouseExportScheduler exports finished days every EXPORT_INTERVAL (default 6h)
+// while EXPORT_TARGET is set. The target is re-read on each tick, so enabling it only
+// needs a config reload; the lease lock keeps replicas from exporting concurrently.
+func StartWarehouseExportScheduler(database *gorm.DB) {
+	interval := config.Get().ExportInterval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var intervalMu sync.Mutex
+		config.OnReload(func(cfg *config.Config) {
+			intervalMu.Lock()
+			defer intervalMu.Unlock()
+			if cfg.ExportInterval != interval {
+				interval = cfg.ExportInterval
+				ticker.Reset(interval)
+				slog.Info("Warehouse export interval changed", "interval", interval)
+			}
+		})
+
+		slog.Info("Warehouse export scheduler started", "interval", interval)
+
+		for range ticker.C {
+			target := config.Get().ExportTarget
+			if target == "" {
+				continue
+			}
+			exporter, err := services.NewWarehouseExporter(database, target)
+			if err != nil {
+				slog.Error("Warehouse export misconfigured", "err", err)
+				continue
+			}
+
+			var runs []models.WarehouseExport
+			ran, err := services.RunExclusive(database, services.WarehouseExportLockName, services.WarehouseExportLockTTL, func() error {
+				var runErr error
+				runs, runErr = exporter.RunPending(context.Background())
+				return runErr
+			})
+			if err != nil {
+				slog.Error("Scheduled warehouse export failed", "err", err)
+			} else if !ran {
+				slog.Warn("Skipping warehouse export: another replica holds the export lock")
+			} else if len(runs) > 0 {
+				slog.Info("Warehouse export completed", "days", len(runs), "target", target, "last", runs[len(runs)-1].Date)
+			}
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// FailedIssueResponse is a dead-lettered issue; Exhausted means automatic retries stopped
+type FailedIssueResponse struct {
+	models.FailedIssue
+	Exhausted bool            `json:"exhausted"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+func toFailedIssueResponse(f models.FailedIssue, withPayload bool) FailedIssueResponse {
+	resp := FailedIssueResponse{FailedIssue: f, Exhausted: f.Attempts >= services.FailedIssueMaxAttempts}
+	if withPayload && json.Valid([]byte(f.Payload)) {
+		resp.Payload = json.RawMessage(f.Payload)
+	}
+	return resp
+}
+
+// GetFailedIssues lists issues whose insert failed during sync, oldest first
+func GetFailedIssues(c *gin.Context) {
+	var failed []models.FailedIssue
+	if err := dbFor(c).Order("first_failed_at").Find(&failed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]FailedIssueResponse, 0, len(failed))
+	for _, f := range failed {
+		items = append(items, toFailedIssueResponse(f, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "max_attempts": services.FailedIssueMaxAttempts})
+}
+
+// GetFailedIssue returns one dead-lettered issue with its stored JIRA payload
+func GetFailedIssue(c *gin.Context) {
+	var f models.FailedIssue
+	if err := dbFor(c).First(&f, "issue_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "failed issue not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toFailedIssueResponse(f, true))
+}
+
+// RequeueFailedIssue resets the issue's attempts and retries the insert right away.
+// If it fails again it stays queued for the next sync.
+func RequeueFailedIssue(c *gin.Context) {
+	id := c.Param("id")
+	res := dbFor(c).Model(&models.FailedIssue{}).Where("issue_id = ?", id).Update("attempts", 0)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "failed issue not found"})
+		return
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.NewOfflineDataUpdater(sqlDB).RetryFailedIssue(id); err != nil {
+		c.JSON(http.StatusOK, gin.H{"recovered": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovered": true})
+}
+
+// DeleteFailedIssue discards a dead-lettered issue
+func DeleteFailedIssue(c *gin.Context) {
+	res := dbFor(c).Delete(&models.FailedIssue{}, "issue_id = ?", c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "failed issue not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
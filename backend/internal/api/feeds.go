@@ -0,0 +1,177 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID       string     `xml:"id"`
+	Title    string     `xml:"title"`
+	Updated  string     `xml:"updated"`
+	Link     atomLink   `xml:"link"`
+	Category []atomCat  `xml:"category"`
+	Summary  string     `xml:"summary"`
+	Author   atomAuthor `xml:"author"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomCat struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// feedTokenValid checks ?token= or a Bearer header against FEEDS_TOKEN
+func feedTokenValid(c *gin.Context) bool {
+	expected := config.Lookup("FEEDS_TOKEN")
+	if expected == "" {
+		return false
+	}
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// parseIssueCreated parses the stored "YYYY-MM-DD HH:MM:SS UTC" timestamp
+func parseIssueCreated(created string) (time.Time, error) {
+	return time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(created, " UTC"))
+}
+
+// GetCriticalFeed serves recent critical alerts as an Atom feed, optionally per component
+func GetCriticalFeed(c *gin.Context) {
+	dbc := dbFor(c)
+	if config.Lookup("FEEDS_TOKEN") == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "feeds are disabled (FEEDS_TOKEN not configured)"})
+		return
+	}
+	if !feedTokenValid(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing feed token"})
+		return
+	}
+
+	component := c.Query("component")
+
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "50"), "%d", &limit)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -7).Format("2006-01-02 15:04:05")
+
+	query := dbc.Model(&models.Issue{}).
+		Joins("LEFT JOIN muted_issues ON muted_issues.issue_id = issues.id AND "+services.MutedIssueActive, time.Now().UTC()).
+		Where("muted_issues.issue_id IS NULL").
+		Where("is_alert = TRUE AND priority = 'Critical' AND issues.created_at_utc >= ?", since)
+	if component != "" {
+		query = query.Where("issues.id IN ("+componentIssueIDs+")", component)
+	}
+
+	var issues []models.Issue
+	query.Order("issues.created DESC").Limit(limit).Find(&issues)
+	if requestTimedOut(c) {
+		return
+	}
+
+	jiraServer := config.Get().Jira.Server
+	anonymize := config.Get().Anonymize
+
+	title := "Critical alerts"
+	feedID := "urn:alerts-platform:feeds:critical"
+	if component != "" {
+		title += " - " + component
+		feedID += ":" + component
+	}
+
+	feed := atomFeed{
+		XMLNS:   "http://www.w3.org/2005/Atom",
+		ID:      feedID,
+		Title:   title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for i, issue := range issues {
+		updated := time.Now().UTC()
+		if t, err := parseIssueCreated(issue.Created); err == nil {
+			updated = t
+		}
+		if i == 0 {
+			feed.Updated = updated.Format(time.RFC3339)
+		}
+		// The anonymizing writer can't rewrite XML, so pseudonymize each entry here
+		if anonymize {
+			if err := anonymizeFeedIssue(&issue); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to anonymize feed"})
+				return
+			}
+		}
+
+		entry := atomEntry{
+			ID:      "urn:jira:" + issue.ID,
+			Title:   issue.Title,
+			Updated: updated.Format(time.RFC3339),
+			Link:    atomLink{Href: strings.TrimRight(jiraServer, "/") + "/browse/" + issue.ID},
+			Summary: fmt.Sprintf("%s | cluster: %s | tenant: %s | status: %s", issue.Priority, issue.ClusterID, issue.TenantID, issue.Status),
+			Author:  atomAuthor{Name: "alerts-platform"},
+		}
+		for _, comp := range decodeComponents(issue.ComponentsJSON) {
+			entry.Category = append(entry.Category, atomCat{Term: comp})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render feed"})
+		return
+	}
+
+	markAnonymized(c)
+	c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), out...))
+}
+
+// anonymizeFeedIssue pseudonymizes an issue's cluster and tenant and scrubs them from its
+// title, the way the anonymizing writer treats a JSON issue
+func anonymizeFeedIssue(issue *models.Issue) error {
+	doc, err := json.Marshal(map[string]string{"cluster_id": issue.ClusterID, "tenant_id": issue.TenantID, "title": issue.Title})
+	if err != nil {
+		return err
+	}
+	out, err := services.AnonymizeJSON(doc)
+	if err != nil {
+		return err
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(out, &fields); err != nil {
+		return err
+	}
+	issue.ClusterID, issue.TenantID, issue.Title = fields["cluster_id"], fields["tenant_id"], fields["title"]
+	return nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// issueFieldColumns maps the JSON field names clients may request to issue columns
+var issueFieldColumns = map[string]string{
+	"id":                   "id",
+	"title":                "title",
+	"description":          "description",
+	"created":              "created",
+	"priority":             "priority",
+	"labels":               "labels",
+	"issuetype":            "issue_type",
+	"project":              "project",
+	"is_alert":             "is_alert",
+	"alert_signature":      "alert_signature",
+	"cluster_id":           "cluster_id",
+	"tenant_id":            "tenant_id",
+	"biz_type":             "biz_type",
+	"status":               "status",
+	"is_subtask":           "is_subtask",
+	"stability_governance": "stability_governance",
+	"visibility":           "visibility",
+	"component_name":       "component_name",
+	"source_component":     "source_component",
+	"alert_group":          "alert_group",
+	"attributed_by":        "attributed_by",
+	"rule_name":            "rule_name",
+	"rule_revision":        "rule_revision",
+	"acknowledged_at":      "acknowledged_at",
+	"resolved_at":          "resolved_at",
+	"duplicate_of":         "duplicate_of",
+	"occurrence_count":     "occurrence_count",
+	"created_at":           "created_at",
+}
+
+// enrichedIssueFields are the fields batch-get adds to issues, with the columns each is
+// derived from
+var enrichedIssueFields = map[string][]string{
+	"components":   {"components"},
+	"cluster_name": {"cluster_id", "tenant_id"},
+	"tenant_name":  {"cluster_id", "tenant_id"},
+}
+
+// searchHitFields are the fields of an IssueSearchHit
+var searchHitFields = []string{"id", "title", "created", "priority", "status", "project",
+	"is_alert", "alert_signature", "cluster_id", "title_html", "snippet_html"}
+
+// parseIssueFields reads the fields= parameter of issue listings; nil means "all fields"
+func parseIssueFields(c *gin.Context) ([]string, error) {
+	return parseFields(c, func(f string) bool {
+		_, ok := issueFieldColumns[f]
+		return ok
+	})
+}
+
+// parseEnrichedIssueFields reads the fields= parameter of enriched issue responses
+func parseEnrichedIssueFields(c *gin.Context) ([]string, error) {
+	return parseFields(c, func(f string) bool {
+		_, column := issueFieldColumns[f]
+		_, enriched := enrichedIssueFields[f]
+		return column || enriched
+	})
+}
+
+// parseSearchHitFields reads the fields= parameter of issue search
+func parseSearchHitFields(c *gin.Context) ([]string, error) {
+	return parseFields(c, func(f string) bool {
+		return containsString(searchHitFields, f)
+	})
+}
+
+// parseFields reads the fields= parameter, rejecting fields the response doesn't have
+func parseFields(c *gin.Context, known func(string) bool) ([]string, error) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !known(f) {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// issueSelectColumns returns the SELECT list for the requested fields, including the
+// columns enriched fields are derived from. id and created are always loaded since
+// pagination cursors depend on them.
+func issueSelectColumns(fields []string) []string {
+	if fields == nil {
+		return []string{"issues.*"}
+	}
+	seen := map[string]bool{"id": true, "created": true}
+	columns := []string{"issues.id", "issues.created"}
+	add := func(col string) {
+		if !seen[col] {
+			seen[col] = true
+			columns = append(columns, "issues."+col)
+		}
+	}
+	for _, f := range fields {
+		if col, ok := issueFieldColumns[f]; ok {
+			add(col)
+		}
+		for _, col := range enrichedIssueFields[f] {
+			add(col)
+		}
+	}
+	return columns
+}
+
+// projectFields trims each item of a slice to the requested fields of its JSON form;
+// nil fields returns items as-is
+func projectFields(items interface{}, fields []string) interface{} {
+	if fields == nil {
+		return items
+	}
+
+	raw, _ := json.Marshal(items)
+	var full []map[string]interface{}
+	json.Unmarshal(raw, &full)
+
+	projected := make([]map[string]interface{}, 0, len(full))
+	for _, item := range full {
+		row := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			row[f] = item[f]
+		}
+		projected = append(projected, row)
+	}
+	return projected
+}
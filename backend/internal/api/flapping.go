@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+const (
+	flappingDefaultMinPerDay = 3
+	flappingDefaultLimit     = 50
+	flappingMaxLimit         = 500
+)
+
+// FlappingSignature is an alert signature that fired repeatedly on one cluster: its
+// flapping days are the days with at least min_per_day occurrences there
+type FlappingSignature struct {
+	AlertSignature string `json:"alert_signature"`
+	RuleName       string `json:"rule_name,omitempty"`
+	ClusterID      string `json:"cluster_id"`
+	ClusterName    string `json:"cluster_name"`
+	FlappingDays   int    `json:"flapping_days"`
+	Occurrences    int    `json:"occurrences"` // on flapping days
+	Resolved       int    `json:"resolved"`    // of those, resolved since
+	MaxPerDay      int    `json:"max_per_day"`
+	LastDay        string `json:"last_day"`
+}
+
+// FlappingResponse is the flapping ranking with the threshold and window it used
+type FlappingResponse struct {
+	Items     []FlappingSignature `json:"items"`
+	MinPerDay int                 `json:"min_per_day"`
+	DateRange DateRange           `json:"date_range"`
+}
+
+// GetFlappingSignatures ranks signatures that keep firing and resolving on the same
+// cluster, candidates for a longer rule `for:` duration. Takes the dashboard filters
+// (days default 30, or start/end) plus ?min_per_day= (default 3) and ?limit= (default 50). Repeats
+// linked by the dedup window count, since they are the flaps.
+func GetFlappingSignatures(c *gin.Context) {
+	minPerDay := flappingDefaultMinPerDay
+	if v := c.Query("min_per_day"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &minPerDay); err != nil || minPerDay < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_per_day must be an integer of at least 2"})
+			return
+		}
+	}
+	limit := flappingDefaultLimit
+	if v := c.Query("limit"); v != "" {
+		fmt.Sscanf(v, "%d", &limit)
+		if limit <= 0 || limit > flappingMaxLimit {
+			limit = flappingDefaultLimit
+		}
+	}
+
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	day := "SUBSTR(REPLACE(created, ' UTC', ''), 1, 10)"
+
+	where, whereArgs := dashboardWhere(c)
+	items := []FlappingSignature{}
+	err := dbFor(c).Raw(`
+		SELECT alert_signature, MAX(rule_name) as rule_name, cluster_id,
+			COUNT(*) as flapping_days, SUM(n) as occurrences, SUM(resolved) as resolved,
+			MAX(n) as max_per_day, MAX(day) as last_day
+		FROM (
+			SELECT alert_signature, cluster_id, MAX(rule_name) as rule_name, `+day+` as day,
+				COUNT(*) as n,
+				SUM(CASE WHEN COALESCE(resolved_at, '') != '' THEN 1 ELSE 0 END) as resolved
+			FROM issues
+			WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+				AND alert_signature != '' AND cluster_id != ''
+			GROUP BY alert_signature, cluster_id, `+day+`
+			HAVING COUNT(*) >= ?
+		) d
+		GROUP BY alert_signature, cluster_id
+		ORDER BY flapping_days DESC, occurrences DESC, alert_signature, cluster_id
+		LIMIT ?
+	`, append(whereArgs, p.start, p.end, minPerDay, limit)...).Scan(&items).Error
+	if err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for i := range items {
+		info, _ := services.GetNameResolver().ResolveContext(ctx, items[i].ClusterID)
+		items[i].ClusterName = info.Name
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+	c.JSON(http.StatusOK, FlappingResponse{
+		Items:     items,
+		MinPerDay: minPerDay,
+		DateRange: p.dateRange(),
+	})
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GovernanceBreakdown summarizes alerts for one stability_governance value
+type GovernanceBreakdown struct {
+	Governance string `json:"stability_governance"`
+	BreakdownStats
+}
+
+// GetGovernanceBreakdown returns counts, trend and handling rate per stability_governance value
+func GetGovernanceBreakdown(c *gin.Context) {
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	groups, dateRange := breakdownBy(c, p, "stability_governance", buildStabilityGovernanceFilterCondition())
+
+	items := make([]GovernanceBreakdown, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, GovernanceBreakdown{Governance: g.Value, BreakdownStats: g.Stats})
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":      items,
+		"date_range": dateRange,
+	})
+}
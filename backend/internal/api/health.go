@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Readyz reports whether the server can serve traffic. The database is required;
+// the name API is only reported, since lookups fall back to cached names or IDs.
+func Readyz(c *gin.Context) {
+	status := http.StatusOK
+	checks := gin.H{}
+
+	if sqlDB, err := db.DB.DB(); err != nil {
+		status = http.StatusServiceUnavailable
+		checks["database"] = gin.H{"status": "down", "error": err.Error()}
+	} else if err := sqlDB.PingContext(c.Request.Context()); err != nil {
+		status = http.StatusServiceUnavailable
+		checks["database"] = gin.H{"status": "down", "error": err.Error()}
+	} else {
+		checks["database"] = gin.H{"status": "ok"}
+	}
+
+	breaker := services.GetNameResolver().Health()
+	nameStatus := "ok"
+	if breaker.State != services.BreakerClosed {
+		nameStatus = "degraded"
+	}
+	checks["name_api"] = gin.H{"status": nameStatus, "breaker": breaker}
+
+	overall := "ok"
+	if status != http.StatusOK {
+		overall = "unavailable"
+	} else if nameStatus != "ok" {
+		overall = "degraded"
+	}
+
+	c.JSON(status, gin.H{"status": overall, "checks": checks})
+}
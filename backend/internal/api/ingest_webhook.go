@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Bounds of one inbound webhook delivery
+const (
+	maxPushedBytes   = 10 << 20
+	maxPushedRecords = 500
+)
+
+// RegisterIngestWebhooks mounts POST /ingest/<source> for every registered ingestion
+// source, each behind WebhookAuth for that source, so WEBHOOK_<SOURCE>_SECRET or _TOKEN
+// must be set before a source accepts pushes
+func RegisterIngestWebhooks(api *gin.RouterGroup) {
+	for _, source := range services.RegisteredIngesters() {
+		api.POST("/ingest/"+source, WebhookAuth(source), PushIngest(source))
+	}
+}
+
+// PushIngest stores records pushed by a source instead of waiting for the next sync. The
+// body is one record or a JSON array of them, in the format the source's sync fetches;
+// records that fail to store are dead-lettered like synced ones.
+func PushIngest(source string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPushedBytes+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+			return
+		}
+		if len(body) > maxPushedBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("body exceeds %d bytes", maxPushedBytes)})
+			return
+		}
+
+		var records []json.RawMessage
+		body = bytes.TrimSpace(body)
+		if bytes.HasPrefix(body, []byte("[")) {
+			if err := json.Unmarshal(body, &records); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON array: " + err.Error()})
+				return
+			}
+		} else if json.Valid(body) {
+			records = []json.RawMessage{body}
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "body must be a JSON record or an array of records"})
+			return
+		}
+		if len(records) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no records"})
+			return
+		}
+		if len(records) > maxPushedRecords {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d records per request", maxPushedRecords)})
+			return
+		}
+
+		sqlDB, err := dbFor(c).DB()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		stored, err := services.NewOfflineDataUpdater(sqlDB).IngestPushed(c.Request.Context(), source, records)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"source": source, "received": len(records), "stored": stored})
+	}
+}
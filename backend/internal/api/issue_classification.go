@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Verdicts of an issue classification
+const (
+	ClassificationFake = "fake"
+	ClassificationReal = "real"
+)
+
+// IssueEventClassify records a fake/real verdict on the issue timeline
+const IssueEventClassify = "classify"
+
+// ClassifyIssueRequest marks an issue as a fake alarm or a real one
+type ClassifyIssueRequest struct {
+	Verdict string `json:"verdict" binding:"required"` // fake or real
+	Reason  string `json:"reason"`
+	Actor   string `json:"actor"` // only used while auth is off
+}
+
+// ClassifyIssue records whether an issue was a fake alarm, replacing an earlier verdict.
+// The verdict is stored apart from the JIRA status, so re-imports keep it, and it takes
+// precedence over a FAKE ALARM status in GET /rules/fake-rates.
+func ClassifyIssue(c *gin.Context) {
+	dbc := dbFor(c)
+	var req ClassifyIssueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	verdict := strings.ToLower(strings.TrimSpace(req.Verdict))
+	if verdict != ClassificationFake && verdict != ClassificationReal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verdict must be fake or real"})
+		return
+	}
+
+	var count int64
+	dbc.Model(&models.Issue{}).Where("id = ?", c.Param("id")).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+		return
+	}
+
+	classification := models.IssueClassification{
+		IssueID: c.Param("id"),
+		Verdict: verdict,
+		Reason:  strings.TrimSpace(req.Reason),
+		Actor:   requestActor(c, req.Actor),
+	}
+	var before interface{} // the verdict replaced, if any
+	err := dbc.Transaction(func(tx *gorm.DB) error {
+		var existing models.IssueClassification
+		if tx.First(&existing, "issue_id = ?", classification.IssueID).Error == nil {
+			classification.CreatedAt = existing.CreatedAt
+			before = existing
+		}
+		if err := tx.Save(&classification).Error; err != nil {
+			return err
+		}
+		detail := classification.Verdict
+		if classification.Reason != "" {
+			detail += ": " + classification.Reason
+		}
+		return tx.Create(&models.IssueEvent{
+			IssueID: classification.IssueID,
+			Type:    IssueEventClassify,
+			Actor:   classification.Actor,
+			Detail:  detail,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	services.InvalidateDashboards()
+	audit(c, services.AuditUpdate, "issue", classification.IssueID, before, classification)
+	c.JSON(http.StatusOK, classification)
+}
+
+// GetIssueClassification returns the fake/real verdict of an issue
+func GetIssueClassification(c *gin.Context) {
+	var classification models.IssueClassification
+	err := dbFor(c).First(&classification, "issue_id = ?", c.Param("id")).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue is not classified"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, classification)
+}
+
+// DeleteIssueClassification withdraws an issue's verdict; fake-rate reports fall back to
+// its JIRA status
+func DeleteIssueClassification(c *gin.Context) {
+	dbc := dbFor(c)
+	var classification models.IssueClassification
+	if err := dbc.First(&classification, "issue_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue is not classified"})
+		return
+	}
+	err := dbc.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&classification).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.IssueEvent{
+			IssueID: classification.IssueID,
+			Type:    IssueEventClassify,
+			Actor:   currentActor(c),
+			Detail:  "verdict " + classification.Verdict + " withdrawn",
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	services.InvalidateDashboards()
+	audit(c, services.AuditUpdate, "issue", classification.IssueID, classification, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RuleFakeRate counts a rule's alerts by verdict. Fake counts alerts classified fake,
+// plus unclassified ones JIRA marks FAKE ALARM.
+type RuleFakeRate struct {
+	Rule        string  `json:"rule"`
+	Alerts      int     `json:"alerts"`
+	Classified  int     `json:"classified"`
+	LabeledFake int     `json:"labeled_fake"`
+	LabeledReal int     `json:"labeled_real"`
+	JiraFake    int     `json:"jira_fake"`
+	Fake        int     `json:"fake"`
+	FakeRate    float64 `json:"fake_rate"` // percent of alerts
+}
+
+// GetRuleFakeRates lists fake-alarm rates per alert rule (rule_name, or the signature of
+// alerts without one) over the period, highest first. Takes days or start/end and
+// component, plus ?min_alerts= (default 1) and ?limit= (default 50, max 500).
+func GetRuleFakeRates(c *gin.Context) {
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	minAlerts, limit := 1, 50
+	fmt.Sscanf(c.DefaultQuery("min_alerts", "1"), "%d", &minAlerts)
+	fmt.Sscanf(c.DefaultQuery("limit", "50"), "%d", &limit)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	where := "issues.is_alert = TRUE AND issues.created_at_utc BETWEEN ? AND ?" +
+		buildComponentFilterCondition(c.Query("component")) + buildClusterFilterCondition() + buildMuteRuleCondition()
+	rates := []RuleFakeRate{}
+	err := dbFor(c).Raw(`
+		SELECT COALESCE(NULLIF(issues.rule_name, ''), issues.alert_signature) as rule,
+			COUNT(*) as alerts,
+			SUM(CASE WHEN ic.verdict IS NOT NULL THEN 1 ELSE 0 END) as classified,
+			SUM(CASE WHEN ic.verdict = ? THEN 1 ELSE 0 END) as labeled_fake,
+			SUM(CASE WHEN ic.verdict = ? THEN 1 ELSE 0 END) as labeled_real,
+			SUM(CASE WHEN issues.status = 'FAKE ALARM' THEN 1 ELSE 0 END) as jira_fake,
+			SUM(CASE WHEN ic.verdict = ? OR (ic.verdict IS NULL AND issues.status = 'FAKE ALARM') THEN 1 ELSE 0 END) as fake
+		FROM issues LEFT JOIN issue_classifications ic ON ic.issue_id = issues.id
+		WHERE `+where+`
+		GROUP BY rule
+		HAVING COUNT(*) >= ?
+	`, ClassificationFake, ClassificationReal, ClassificationFake, p.start, p.end, minAlerts).Scan(&rates).Error
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range rates {
+		rates[i].FakeRate = float64(rates[i].Fake) / float64(rates[i].Alerts) * 100
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].FakeRate != rates[j].FakeRate {
+			return rates[i].FakeRate > rates[j].FakeRate
+		}
+		if rates[i].Alerts != rates[j].Alerts {
+			return rates[i].Alerts > rates[j].Alerts
+		}
+		return rates[i].Rule < rates[j].Rule
+	})
+	if len(rates) > limit {
+		rates = rates[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"items": rates, "date_range": p.dateRange()})
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Highlight markers; the text is HTML-escaped before they become <mark> tags
+const (
+	searchMarkOpen  = "\x02"
+	searchMarkClose = "\x03"
+)
+
+// IssueSearchHit is an issue matching /issues/search. TitleHTML and SnippetHTML are
+// escaped HTML with the matched terms in <mark>; SnippetHTML is the best-matching
+// excerpt of the description.
+type IssueSearchHit struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Created        string `json:"created"`
+	Priority       string `json:"priority"`
+	Status         string `json:"status"`
+	Project        string `json:"project"`
+	IsAlert        bool   `json:"is_alert"`
+	AlertSignature string `json:"alert_signature"`
+	ClusterID      string `json:"cluster_id"`
+	TitleHTML      string `json:"title_html"`
+	SnippetHTML    string `json:"snippet_html,omitempty"`
+}
+
+// searchTerm is a word or a quoted phrase of a search query
+type searchTerm struct {
+	text   string
+	prefix bool // word* matches words starting with text
+}
+
+// parseSearchTerms splits q into "quoted phrases" and words, all of which must match
+func parseSearchTerms(q string) []searchTerm {
+	var terms []searchTerm
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			// Inside quotes (an unclosed quote runs to the end)
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, searchTerm{text: phrase})
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			prefix := strings.HasSuffix(word, "*")
+			if word = strings.TrimRight(word, "*"); word != "" {
+				terms = append(terms, searchTerm{text: word, prefix: prefix})
+			}
+		}
+	}
+	return terms
+}
+
+// ftsMatch writes terms as an FTS5 query. Every term is quoted so punctuation in the
+// input (cluster-1, a.b) is tokenized rather than read as query syntax.
+func ftsMatch(terms []searchTerm) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(t.text, `"`, `""`) + `"`
+		if t.prefix {
+			quoted[i] += "*"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// searchHTML escapes text and turns the highlight markers into <mark> tags
+func searchHTML(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, searchMarkOpen, "<mark>")
+	return strings.ReplaceAll(text, searchMarkClose, "</mark>")
+}
+
+// SearchIssues finds issues by words and "quoted phrases" in their title, description
+// and labels, best matches first, highlighting what matched. Backed by the search index
+// when SEARCH_URL is set (newest first, nothing highlighted), else the SQLite FTS5 index
+// issues_fts; without either (other databases, builds without FTS5) it falls back to
+// LIKE matching in created order with nothing highlighted.
+// Optional: ?days= (default all time), ?page=, ?page_size= (default 20, max 100), ?fields=
+func SearchIssues(c *gin.Context) {
+	terms := parseSearchTerms(c.Query("q"))
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	fields, err := parseSearchHitFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var days, page, pageSize int
+	fmt.Sscanf(c.DefaultQuery("days", "0"), "%d", &days)
+	fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &page)
+	if page < 1 {
+		page = 1
+	}
+	fmt.Sscanf(c.DefaultQuery("page_size", "20"), "%d", &pageSize)
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	columns := `issues.id, issues.title, issues.created, issues.priority, issues.status, issues.project,
+		issues.is_alert, issues.alert_signature, issues.cluster_id`
+	from, where, args := "issues", "1 = 1", []interface{}{}
+	order := "issues.created DESC, issues.id DESC"
+	var since string
+	if days > 0 {
+		since = time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+	}
+	indexed := false
+	if idx := services.GetSearchIndex(); idx != nil {
+		ids, err := idx.Search(c.Request.Context(), services.SearchQuery{Text: strings.TrimSpace(c.Query("q")), Start: since})
+		if err == nil {
+			if len(ids) == services.SearchMaxHits {
+				c.Header("X-Search-Truncated", "true")
+			}
+			where, args = "issues.id IN ?", []interface{}{append(ids, "")}
+			indexed = true
+		} else {
+			logFor(c).Warn("Search index query failed, falling back to SQL", "err", err)
+		}
+	}
+	switch {
+	case indexed:
+		// The index already matched the terms
+	case db.IssueFTSEnabled():
+		columns += `,
+			highlight(issues_fts, 0, '` + searchMarkOpen + `', '` + searchMarkClose + `') as title_html,
+			snippet(issues_fts, 1, '` + searchMarkOpen + `', '` + searchMarkClose + `', '…', 24) as snippet_html`
+		from = "issues_fts JOIN issues ON issues.rowid = issues_fts.rowid"
+		where, args = "issues_fts MATCH ?", []interface{}{ftsMatch(terms)}
+		// Title matches weigh most, then labels
+		order = "bm25(issues_fts, 10.0, 1.0, 5.0), issues.created DESC"
+	default:
+		// Substring matches, which also covers prefix terms
+		for _, t := range terms {
+			pattern := "%" + t.text + "%"
+			where += " AND (issues.title LIKE ? OR issues.description LIKE ? OR issues.labels LIKE ?)"
+			args = append(args, pattern, pattern, pattern)
+		}
+	}
+	if since != "" {
+		where += " AND issues.created_at_utc >= ?"
+		args = append(args, since)
+	}
+
+	dbc := dbFor(c)
+	var total int64
+	if err := dbc.Raw("SELECT COUNT(*) FROM "+from+" WHERE "+where, args...).Scan(&total).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	hits := []IssueSearchHit{}
+	err = dbc.Raw("SELECT "+columns+" FROM "+from+" WHERE "+where+" ORDER BY "+order+" LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...).Scan(&hits).Error
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range hits {
+		if hits[i].TitleHTML == "" {
+			hits[i].TitleHTML = hits[i].Title
+		}
+		hits[i].TitleHTML = searchHTML(hits[i].TitleHTML)
+		hits[i].SnippetHTML = searchHTML(hits[i].SnippetHTML)
+	}
+	c.JSON(http.StatusOK, newIssuePage(projectFields(hits, fields), total, page, pageSize))
+}
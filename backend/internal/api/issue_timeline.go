@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Issue event types recorded locally
+const (
+	IssueEventMute         = "mute"
+	IssueEventUnmute       = "unmute"
+	IssueEventAck          = "ack"
+	IssueEventTriage       = "triage"
+	IssueEventNote         = "note"
+	IssueEventNotification = "notification"
+	IssueEventEscalation   = "escalation"
+)
+
+// postableIssueEvents are the types clients may record; mutes and unmutes go through /mute
+var postableIssueEvents = []string{IssueEventAck, IssueEventTriage, IssueEventNote, IssueEventNotification, IssueEventEscalation}
+
+// Timeline sources
+const (
+	timelineSourceJira  = "jira"
+	timelineSourceLocal = "local"
+	timelineSourceRules = "rules"
+)
+
+// jiraTimelineTimeout bounds the changelog fetch so a slow JIRA doesn't hold the timeline
+const jiraTimelineTimeout = 10 * time.Second
+
+// TimelineEntry is one event in an issue's lifecycle
+type TimelineEntry struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"` // jira, local, rules
+	Type   string    `json:"type"`
+	Actor  string    `json:"actor,omitempty"`
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+	Link   string    `json:"link,omitempty"`
+}
+
+// GetIssueTimeline merges JIRA status transitions, local actions (mutes, acks, triage,
+// notes), notification/escalation events and rule change tasks for the alert's rule
+// into one chronological view
+func GetIssueTimeline(c *gin.Context) {
+	dbc := dbFor(c)
+	var issue models.Issue
+	if err := dbc.Select("id, title, created, status, alert_signature").First(&issue, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+		return
+	}
+
+	entries := []TimelineEntry{}
+	sources := gin.H{}
+
+	created, err := time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(issue.Created, " UTC"))
+	if err == nil {
+		entries = append(entries, TimelineEntry{Time: created, Source: timelineSourceJira, Type: "created", Detail: issue.Title})
+	}
+
+	// JIRA status transitions, fetched live since they are not imported
+	if client, err := services.NewJiraClient(config.Get().Jira); err != nil {
+		sources[timelineSourceJira] = "not configured"
+	} else {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), jiraTimelineTimeout)
+		changes, err := client.StatusTransitions(ctx, issue.ID)
+		cancel()
+		if err != nil {
+			sources[timelineSourceJira] = "unavailable: " + err.Error()
+		} else {
+			sources[timelineSourceJira] = "ok"
+			for _, ch := range changes {
+				entries = append(entries, TimelineEntry{Time: ch.At, Source: timelineSourceJira, Type: "status_change", Actor: ch.Author, From: ch.From, To: ch.To})
+			}
+		}
+	}
+
+	// Local actions and deliveries
+	var events []models.IssueEvent
+	dbc.Where("issue_id = ?", issue.ID).Order("created_at, id").Find(&events)
+	recordedMute := false
+	for _, e := range events {
+		recordedMute = recordedMute || e.Type == IssueEventMute
+		entries = append(entries, TimelineEntry{Time: e.CreatedAt.UTC(), Source: timelineSourceLocal, Type: e.Type, Actor: e.Actor, Detail: e.Detail})
+	}
+	// Mutes from before events were recorded only exist in muted_issues
+	var muted models.MutedIssue
+	if !recordedMute && dbc.First(&muted, "issue_id = ?", issue.ID).Error == nil {
+		entries = append(entries, TimelineEntry{Time: muted.MutedAt.UTC(), Source: timelineSourceLocal, Type: IssueEventMute, Detail: muted.Reason})
+	}
+	sources[timelineSourceLocal] = "ok"
+
+	// Rule change tasks raised for this alert's rule since the issue was created
+	var ruleNames []string
+	dbc.Model(&models.Task{}).Distinct("rule_name").Where("rule_name != ''").Pluck("rule_name", &ruleNames)
+	if rule := services.MatchAlertName(issue.AlertSignature, ruleNames); rule != "" {
+		var tasks []models.Task
+		dbc.Where("rule_name = ? AND created_at >= ?", rule, created).Order("created_at").Find(&tasks)
+		for _, t := range tasks {
+			entries = append(entries, TimelineEntry{
+				Time:   t.CreatedAt.UTC(),
+				Source: timelineSourceRules,
+				Type:   "task_created",
+				Actor:  t.Owner,
+				Detail: fmt.Sprintf("%s %s (%s)", t.Type, t.RuleName, t.Status),
+				Link:   t.PRLink,
+			})
+		}
+	}
+	sources[timelineSourceRules] = "ok"
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	c.JSON(http.StatusOK, gin.H{
+		"issue_id": issue.ID,
+		"title":    issue.Title,
+		"status":   issue.Status,
+		"sources":  sources,
+		"items":    entries,
+	})
+}
+
+// IssueEventRequest records an action on an issue
+type IssueEventRequest struct {
+	Type   string `json:"type" binding:"required"`
+	Actor  string `json:"actor"`
+	Detail string `json:"detail"`
+}
+
+// CreateIssueEvent records an ack, triage note, note or notification/escalation delivery
+// on an issue's timeline
+func CreateIssueEvent(c *gin.Context) {
+	dbc := dbFor(c)
+	var req IssueEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	if !containsString(postableIssueEvents, req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of " + strings.Join(postableIssueEvents, ", ")})
+		return
+	}
+
+	var count int64
+	dbc.Model(&models.Issue{}).Where("id = ?", c.Param("id")).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+		return
+	}
+
+	event := models.IssueEvent{
+		IssueID: c.Param("id"),
+		Type:    req.Type,
+		Actor:   strings.TrimSpace(req.Actor),
+		Detail:  strings.TrimSpace(req.Detail),
+	}
+	if err := dbc.Create(&event).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, event)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// maxBatchGetIDs caps a single batch-get request
+const maxBatchGetIDs = 200
+
+// EnrichedIssue is an issue with decoded components and resolved names
+type EnrichedIssue struct {
+	models.Issue
+	Components  []string `json:"components"`
+	ClusterName string   `json:"cluster_name"`
+	TenantName  string   `json:"tenant_name"`
+}
+
+type BatchGetIssuesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// decodeComponents parses the stored components JSON array
+func decodeComponents(raw string) []string {
+	components := []string{}
+	if raw != "" {
+		json.Unmarshal([]byte(raw), &components)
+	}
+	return components
+}
+
+// enrichIssue decodes components and fills in cluster/tenant names from names, as
+// resolved by ResolveBatch; names is nil when they weren't asked for
+func enrichIssue(issue models.Issue, names map[string]services.NameInfo) EnrichedIssue {
+	enriched := EnrichedIssue{Issue: issue, Components: decodeComponents(issue.ComponentsJSON)}
+	if info, ok := names[issue.ClusterID]; ok && issue.ClusterID != "" {
+		enriched.ClusterName = info.Name
+		enriched.TenantName = info.TenantName
+	}
+	if info, ok := names[issue.TenantID]; ok && enriched.TenantName == "" && issue.TenantID != "" {
+		enriched.TenantName = info.Name
+	}
+	return enriched
+}
+
+// issueNames resolves the cluster and tenant names of issues in one batch, bounded by
+// the request; it skips the lookups when fields leaves the names out
+func issueNames(c *gin.Context, issues []models.Issue, fields []string) map[string]services.NameInfo {
+	if fields != nil && !containsString(fields, "cluster_name") && !containsString(fields, "tenant_name") {
+		return nil
+	}
+	ids := make([]string, 0, 2*len(issues))
+	for _, issue := range issues {
+		ids = append(ids, issue.ClusterID, issue.TenantID)
+	}
+	return services.GetNameResolver().ResolveBatch(c.Request.Context(), ids)
+}
+
+// BatchGetIssues returns enriched issues for a list of IDs (JIRA keys) in request order.
+// Optional: ?fields= to return only some fields of each issue
+func BatchGetIssues(c *gin.Context) {
+	dbc := dbFor(c)
+	var req BatchGetIssuesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	fields, err := parseEnrichedIssueFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids is required"})
+		return
+	}
+	if len(req.IDs) > maxBatchGetIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d ids per request", maxBatchGetIDs)})
+		return
+	}
+
+	var issues []models.Issue
+	if err := dbc.Select(issueSelectColumns(fields)).Where("id IN ?", req.IDs).Find(&issues).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch issues"})
+		return
+	}
+
+	names := issueNames(c, issues, fields)
+	if requestTimedOut(c) {
+		return
+	}
+
+	byID := make(map[string]models.Issue, len(issues))
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+	}
+
+	items := []EnrichedIssue{}
+	missing := []string{}
+	seen := make(map[string]bool)
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if issue, ok := byID[id]; ok {
+			items = append(items, enrichIssue(issue, names))
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":   projectFields(items, fields),
+		"missing": missing,
+	})
+}
@@ -0,0 +1,317 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Issue event types of changes written back to JIRA
+const (
+	IssueEventTransition = "transition"
+	IssueEventAssign     = "assign"
+	IssueEventComment    = "comment"
+)
+
+// jiraWriteTimeout bounds the JIRA calls of one write-back request
+const jiraWriteTimeout = 20 * time.Second
+
+// errNoSuchTransition means the requested transition is not available from the issue's status
+var errNoSuchTransition = errors.New("transition not available")
+
+// TransitionIssueRequest moves an issue through its JIRA workflow. Transition is a
+// transition ID or name, or the status to move to; Comment is posted along with it.
+type TransitionIssueRequest struct {
+	Transition string `json:"transition" binding:"required"`
+	Comment    string `json:"comment"`
+	Actor      string `json:"actor"` // only used while auth is off
+}
+
+// AckIssueRequest acknowledges an issue with a JIRA comment, optionally moving it
+// through a transition (e.g. "In Progress") at the same time
+type AckIssueRequest struct {
+	Comment    string `json:"comment"`
+	Transition string `json:"transition"`
+	Actor      string `json:"actor"` // only used while auth is off
+}
+
+// AssignIssueRequest assigns an issue to a JIRA account; an empty AccountID unassigns it
+type AssignIssueRequest struct {
+	AccountID string `json:"account_id"`
+	Actor     string `json:"actor"` // only used while auth is off
+}
+
+// IssueCommentRequest posts a comment on an issue
+type IssueCommentRequest struct {
+	Body  string `json:"body" binding:"required"`
+	Actor string `json:"actor"` // only used while auth is off
+}
+
+// TransitionResult is the outcome of a transition written back to JIRA
+type TransitionResult struct {
+	IssueID    string                  `json:"issue_id"`
+	Transition services.JiraTransition `json:"transition"`
+	From       string                  `json:"from"`
+	Status     string                  `json:"status"` // local status after the transition
+}
+
+// jiraWriteback loads the issue and a JIRA client for a write-back, responding 404 when
+// the issue is unknown and 503 when JIRA is not configured
+func jiraWriteback(c *gin.Context) (models.Issue, *services.JiraClient, bool) {
+	var issue models.Issue
+	if err := dbFor(c).Select("id, status, acknowledged_at, resolved_at").First(&issue, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "issue not found"})
+		return issue, nil, false
+	}
+	client, err := services.NewJiraClient(config.Get().Jira)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return issue, nil, false
+	}
+	return issue, client, true
+}
+
+// requestActor is the authenticated caller. The actor a request names is only taken
+// while auth is off, since it is shown in JIRA and on the timeline as who made the change.
+func requestActor(c *gin.Context, actor string) string {
+	if identity := currentActor(c); identity != "" || authRequired(config.Get()) {
+		return identity
+	}
+	return strings.TrimSpace(actor)
+}
+
+// dashboardComment attributes a comment posted with the shared JIRA account to the
+// dashboard user who wrote it
+func dashboardComment(body, actor string) string {
+	if actor == "" {
+		return body
+	}
+	return body + "\n\n_Posted from the alert dashboard by " + actor + "_"
+}
+
+// findTransition picks the transition matching an ID, a name or a target status
+func findTransition(transitions []services.JiraTransition, want string) (services.JiraTransition, bool) {
+	want = strings.TrimSpace(want)
+	for _, t := range transitions {
+		if t.ID == want {
+			return t, true
+		}
+	}
+	for _, t := range transitions {
+		if strings.EqualFold(t.Name, want) || strings.EqualFold(t.To, want) {
+			return t, true
+		}
+	}
+	return services.JiraTransition{}, false
+}
+
+// transitionIssue moves the issue in JIRA and mirrors the new status locally, so the
+// dashboard reflects it before the next sync; the sync derives the same status and
+// lifecycle times from the JIRA changelog afterwards.
+func transitionIssue(ctx context.Context, c *gin.Context, client *services.JiraClient, issue models.Issue, want, actor string) (TransitionResult, []services.JiraTransition, error) {
+	transitions, err := client.Transitions(ctx, issue.ID)
+	if err != nil {
+		return TransitionResult{}, nil, err
+	}
+	t, ok := findTransition(transitions, want)
+	if !ok {
+		return TransitionResult{}, transitions, errNoSuchTransition
+	}
+	if err := client.TransitionIssue(ctx, issue.ID, t.ID); err != nil {
+		return TransitionResult{}, transitions, err
+	}
+
+	result := TransitionResult{IssueID: issue.ID, Transition: t, From: issue.Status, Status: services.NormalizeStatus(t.To)}
+	now := time.Now().UTC().Format("2006-01-02 15:04:05 UTC")
+	updates := map[string]interface{}{"status": result.Status}
+	if issue.AcknowledgedAt == "" {
+		updates["acknowledged_at"] = now
+	}
+	switch {
+	case !services.IsResolvedStatus(result.Status):
+		updates["resolved_at"] = "" // reopened
+	case !services.IsResolvedStatus(issue.Status):
+		updates["resolved_at"] = now
+	}
+	err = dbFor(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Issue{}).Where("id = ?", issue.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.IssueEvent{
+			IssueID: issue.ID,
+			Type:    IssueEventTransition,
+			Actor:   actor,
+			Detail:  issue.Status + " -> " + t.To + " (" + t.Name + ")",
+		}).Error
+	})
+	// If this fails JIRA still has the change, and the next sync brings the status in line
+	if err == nil {
+		audit(c, services.AuditUpdate, "issue", issue.ID, gin.H{"status": issue.Status}, gin.H{"status": result.Status, "transition": t.Name})
+	}
+	return result, transitions, nil
+}
+
+// respondTransitionError reports a failed transition: 409 with the available transitions
+// when the requested one does not apply, 502 when JIRA fails
+func respondTransitionError(c *gin.Context, err error, transitions []services.JiraTransition) {
+	if errors.Is(err, errNoSuchTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": "transition not available from the issue's current status", "available": transitions})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+}
+
+// GetIssueTransitions lists the JIRA workflow transitions available on an issue
+func GetIssueTransitions(c *gin.Context) {
+	issue, client, ok := jiraWriteback(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraWriteTimeout)
+	defer cancel()
+	transitions, err := client.Transitions(ctx, issue.ID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"issue_id": issue.ID, "status": issue.Status, "items": transitions})
+}
+
+// TransitionIssue moves an issue through its JIRA workflow, optionally commenting on it,
+// and updates the local status right away
+func TransitionIssue(c *gin.Context) {
+	var req TransitionIssueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	issue, client, ok := jiraWriteback(c)
+	if !ok {
+		return
+	}
+	actor := requestActor(c, req.Actor)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraWriteTimeout)
+	defer cancel()
+
+	result, transitions, err := transitionIssue(ctx, c, client, issue, req.Transition, actor)
+	if err != nil {
+		respondTransitionError(c, err, transitions)
+		return
+	}
+	resp := gin.H{"success": true, "result": result}
+	if body := strings.TrimSpace(req.Comment); body != "" {
+		// The transition stands even if the comment fails
+		if comment, err := client.AddComment(ctx, issue.ID, dashboardComment(body, actor)); err != nil {
+			resp["comment_error"] = err.Error()
+		} else {
+			resp["comment"] = comment
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AckIssue acknowledges an issue: it comments on the JIRA issue, optionally moves it
+// through a transition first, and records the ack on the issue timeline
+func AckIssue(c *gin.Context) {
+	var req AckIssueRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	issue, client, ok := jiraWriteback(c)
+	if !ok {
+		return
+	}
+	actor := requestActor(c, req.Actor)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraWriteTimeout)
+	defer cancel()
+
+	resp := gin.H{"success": true}
+	if strings.TrimSpace(req.Transition) != "" {
+		result, transitions, err := transitionIssue(ctx, c, client, issue, req.Transition, actor)
+		if err != nil {
+			respondTransitionError(c, err, transitions)
+			return
+		}
+		resp["result"] = result
+	}
+
+	body := "Acknowledged"
+	detail := "acknowledged in JIRA"
+	if note := strings.TrimSpace(req.Comment); note != "" {
+		body += ": " + note
+		detail += ": " + note
+	}
+	comment, err := client.AddComment(ctx, issue.ID, dashboardComment(body, actor))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	resp["comment"] = comment
+	dbFor(c).Create(&models.IssueEvent{IssueID: issue.ID, Type: IssueEventAck, Actor: actor, Detail: detail})
+	c.JSON(http.StatusOK, resp)
+}
+
+// AssignIssue sets or clears an issue's JIRA assignee
+func AssignIssue(c *gin.Context) {
+	var req AssignIssueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	issue, client, ok := jiraWriteback(c)
+	if !ok {
+		return
+	}
+	accountID := strings.TrimSpace(req.AccountID)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraWriteTimeout)
+	defer cancel()
+	if err := client.AssignIssue(ctx, issue.ID, accountID); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	detail := "unassigned"
+	if accountID != "" {
+		detail = "assigned to " + accountID
+	}
+	dbFor(c).Create(&models.IssueEvent{IssueID: issue.ID, Type: IssueEventAssign, Actor: requestActor(c, req.Actor), Detail: detail})
+	audit(c, services.AuditUpdate, "issue", issue.ID, nil, gin.H{"assignee": accountID})
+	c.JSON(http.StatusOK, gin.H{"success": true, "issue_id": issue.ID, "account_id": accountID})
+}
+
+// CommentOnIssue posts a comment on the JIRA issue and records it on the issue timeline
+func CommentOnIssue(c *gin.Context) {
+	var req IssueCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
+		return
+	}
+	issue, client, ok := jiraWriteback(c)
+	if !ok {
+		return
+	}
+	actor := requestActor(c, req.Actor)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraWriteTimeout)
+	defer cancel()
+	comment, err := client.AddComment(ctx, issue.ID, dashboardComment(body, actor))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	dbFor(c).Create(&models.IssueEvent{IssueID: issue.ID, Type: IssueEventComment, Actor: actor, Detail: body})
+	c.JSON(http.StatusCreated, comment)
+}
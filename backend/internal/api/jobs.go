@@ -0,0 +1,336 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Job types accepted by POST /jobs/:type
+const (
+	JobIssuesExport    = "issues-export"
+	JobRulesExport     = "rules-export"
+	JobReEnrich        = "re-enrich"
+	JobWarehouseExport = "warehouse-export"
+	JobRuleAudit       = "rule-audit"
+	JobRetention       = "retention"
+	JobStatsRollup     = "stats-rollup"
+)
+
+// JobResponse is a job with its decoded summary and, once finished, its download link
+type JobResponse struct {
+	models.Job
+	Result      json.RawMessage `json:"result,omitempty"`
+	ArtifactURL string          `json:"artifact_url,omitempty"`
+}
+
+func toJobResponse(job models.Job) JobResponse {
+	resp := JobResponse{Job: job}
+	if job.Result != "" {
+		resp.Result = json.RawMessage(job.Result)
+	}
+	if job.Status == services.JobCompleted && job.Size > 0 {
+		resp.ArtifactURL = "/api/jobs/" + job.ID + "/artifact"
+	}
+	return resp
+}
+
+// CreateJob starts a background job and returns 202 with the job to poll.
+//   - issues-export: CSV of the issues matching the /dashboard/issues query parameters
+//   - rules-export: tar.gz of rule files (?component, ?category, ?normalize as /rules/export)
+//   - re-enrich: backfill over stored payloads (body as POST /admin/re-enrich)
+//   - warehouse-export: pending days or one day (body as POST /admin/exports/run)
+//   - rule-audit: the scheduled rule audit
+//   - retention: archive issues past the retention policy (as POST /admin/retention/run)
+func CreateJob(c *gin.Context) {
+	kind := c.Param("type")
+	var params interface{}
+	var fn services.JobFunc
+
+	switch kind {
+	case JobIssuesExport:
+		order, err := issueListOrder(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		p, ok := bindPeriod(c)
+		if !ok {
+			return
+		}
+		query := issueListQuery(c, p).Order(order)
+		params = c.Request.URL.Query()
+		fn = func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+			return services.ExportIssuesCSV(ctx, query, progress)
+		}
+
+	case JobRulesExport:
+		component, category := c.Query("component"), c.Query("category")
+		normalize := c.Query("normalize") == "true"
+		rulesComponent := component
+		if vc, ok := getVirtualComponent(component); ok && vc.RulesComponent != "" {
+			rulesComponent = vc.RulesComponent
+		}
+		params = c.Request.URL.Query()
+		fn = func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+			var buf bytes.Buffer
+			count, err := services.NewRulesService(config.Get()).ExportRules(&buf, rulesComponent, category, normalize)
+			if err != nil {
+				return nil, nil, err
+			}
+			if count == 0 {
+				return nil, nil, fmt.Errorf("no rules matched")
+			}
+			parts := []string{"rules"}
+			for _, p := range []string{component, category} {
+				if p != "" {
+					parts = append(parts, strings.NewReplacer("/", "-", "*", "all", " ", "-").Replace(p))
+				}
+			}
+			parts = append(parts, time.Now().UTC().Format("20060102"))
+			progress(count, count, fmt.Sprintf("exported %d rule files", count))
+			return &services.JobArtifact{
+				FileName:    strings.Join(parts, "-") + ".tar.gz",
+				ContentType: "application/gzip",
+				Data:        buf.Bytes(),
+			}, gin.H{"files": count}, nil
+		}
+
+	case JobReEnrich:
+		var req ReEnrichRequest
+		if !bindOptionalJSON(c, &req) {
+			return
+		}
+		startDate, endDate, err := req.dateRange()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sqlDB, err := db.DB.DB()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		params = req
+		fn = func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+			updater := services.NewOfflineDataUpdater(sqlDB)
+			var result services.ReEnrichProgress
+			// Share the sync lock so re-enrichment never interleaves with a JIRA import
+			ran, err := services.RunExclusive(db.DB, syncLockName, syncLockTTL, func() error {
+				var runErr error
+				result, runErr = updater.ReEnrich(ctx, startDate, endDate, func(p services.ReEnrichProgress) {
+					progress(p.Processed, p.Total, fmt.Sprintf("%d updated, %d skipped, %d failed", p.Updated, p.Skipped, p.Failed))
+				})
+				return runErr
+			})
+			if err == nil && !ran {
+				err = fmt.Errorf("another replica holds the sync lock")
+			}
+			return nil, result, err
+		}
+
+	case JobWarehouseExport:
+		var req RunWarehouseExportRequest
+		if !bindOptionalJSON(c, &req) {
+			return
+		}
+		day, err := req.day()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		exporter, err := services.NewWarehouseExporter(db.DB, config.Get().ExportTarget)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		params = req
+		fn = func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+			var runs []models.WarehouseExport
+			ran, err := services.RunExclusive(db.DB, services.WarehouseExportLockName, services.WarehouseExportLockTTL, func() error {
+				if req.Date == "" {
+					var runErr error
+					runs, runErr = exporter.RunPending(ctx)
+					return runErr
+				}
+				run, runErr := exporter.ExportDay(ctx, day)
+				if run != nil {
+					runs = append(runs, *run)
+				}
+				return runErr
+			})
+			if err == nil && !ran {
+				err = fmt.Errorf("a warehouse export is already running")
+			}
+			return nil, gin.H{"items": runs}, err
+		}
+
+	case JobRuleAudit:
+		fn = func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+			var audit *models.RuleAudit
+			ran, err := services.RunExclusive(db.DB, services.RuleAuditLockName, services.RuleAuditLockTTL, func() error {
+				var runErr error
+				audit, _, runErr = services.NewRuleAuditService(db.DB).Run(ctx)
+				return runErr
+			})
+			if err == nil && !ran {
+				err = fmt.Errorf("a rule audit is already running")
+			}
+			if audit == nil {
+				return nil, nil, err
+			}
+			return nil, toRuleAuditResponse(*audit, false), err
+		}
+
+	case JobRetention:
+		policy := config.Get().Retention
+		if !policy.Enabled() {
+			c.JSON(http.StatusConflict, gin.H{"error": "retention is disabled (RETENTION_DAYS and RETENTION_RULES keep issues forever)"})
+			return
+		}
+		fn = retentionJob(policy, "manual", currentActor(c))
+
+	case JobStatsRollup:
+		fn = statsRollupJob()
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown job type %q: must be one of %s", kind,
+			strings.Join([]string{JobIssuesExport, JobRulesExport, JobReEnrich, JobWarehouseExport, JobRuleAudit, JobRetention, JobStatsRollup}, ", "))})
+		return
+	}
+
+	// Jobs outlive the request, so they run on the plain DB
+	job, err := services.StartJob(db.DB, kind, params, fn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Location", "/api/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// bindOptionalJSON binds a request body if one was sent, responding 400 on bad JSON
+func bindOptionalJSON(c *gin.Context, req interface{}) bool {
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+	return true
+}
+
+// GetJobs lists recent jobs, newest first. Optional: ?type=, ?status=, ?limit=50
+func GetJobs(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "50"), "%d", &limit)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := dbFor(c).Omit("artifact").Order("created_at DESC").Limit(limit)
+	if kind := c.Query("type"); kind != "" {
+		query = query.Where("type = ?", kind)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var jobs []models.Job
+	if err := query.Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, toJobResponse(job))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetJob reports a job's status and progress
+func GetJob(c *gin.Context) {
+	var job models.Job
+	if err := dbFor(c).Omit("artifact").First(&job, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toJobResponse(job))
+}
+
+// DownloadJobArtifact serves the file produced by a completed job
+func DownloadJobArtifact(c *gin.Context) {
+	var job models.Job
+	if err := dbFor(c).First(&job, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if job.Status != services.JobCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "job is " + job.Status, "status": job.Status})
+		return
+	}
+	if len(job.Artifact) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job produced no artifact"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.FileName))
+	c.Data(http.StatusOK, job.ContentType, job.Artifact)
+}
+
+// DeleteJob cancels a queued or running job, or removes a finished one with its artifact.
+// A running job can only be cancelled on the replica running it.
+func DeleteJob(c *gin.Context) {
+	var job models.Job
+	if err := dbFor(c).Omit("artifact").First(&job, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if job.Status == services.JobQueued || job.Status == services.JobRunning {
+		if err := services.CancelJob(job.ID); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "owner": job.Owner})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"success": true, "message": "cancellation requested"})
+		return
+	}
+
+	if err := dbFor(c).Delete(&models.Job{}, "id = ?", job.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// StartJobSweeper periodically fails jobs lost with their replica and prunes finished
+// jobs older than JOB_RETENTION (default 24h)
+func StartJobSweeper(database *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			lost, pruned, err := services.SweepJobs(database, config.Get().JobRetention)
+			if err != nil {
+				slog.Error("Job sweep failed", "err", err)
+				continue
+			}
+			if lost > 0 || pruned > 0 {
+				slog.Info("Jobs swept", "lost", lost, "pruned", pruned)
+			}
+		}
+	}()
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/logging"
+)
+
+// maxRequestIDLen bounds client-supplied X-Request-ID values before they are logged
+const maxRequestIDLen = 64
+
+// RequestLogger gives every request an ID (the client's X-Request-ID, or a new one, echoed
+// back) and a logger carrying it, then logs the request with its route, status and duration
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > maxRequestIDLen {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		c.Header("X-Request-ID", id)
+
+		logger := slog.Default().With("request_id", id)
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if actor := currentActor(c); actor != "" {
+			attrs = append(attrs, slog.String("actor", actor))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// logFor returns the request's logger, carrying its request ID
+func logFor(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/metrics"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// Metrics records the latency and status of every request by its matched route
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// StartQueueMetrics exports the depth of the review task and background job queues
+func StartQueueMetrics(database *gorm.DB) {
+	metrics.RegisterQueueDepth("task_queue_depth", "Review tasks not yet reviewed, by status.", func() map[string]float64 {
+		return countByStatus(database, &models.Task{}, "submitted", "processing", "waiting_for_review")
+	})
+	metrics.RegisterQueueDepth("job_queue_depth", "Background jobs not yet finished, by status.", func() map[string]float64 {
+		return countByStatus(database, &models.Job{}, "queued", "running")
+	})
+}
+
+// countByStatus counts model rows in each of statuses, reporting zero for empty ones
+func countByStatus(database *gorm.DB, model interface{}, statuses ...string) map[string]float64 {
+	var rows []struct {
+		Status string
+		N      int64
+	}
+	if err := database.Model(model).Select("status, COUNT(*) AS n").
+		Where("status IN ?", statuses).Group("status").Scan(&rows).Error; err != nil {
+		slog.Warn("Failed to read queue depth", "err", err)
+		return nil
+	}
+
+	depth := make(map[string]float64, len(statuses))
+	for _, s := range statuses {
+		depth[s] = 0
+	}
+	for _, row := range rows {
+		depth[row.Status] = float64(row.N)
+	}
+	return depth
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// MuteRuleRequest creates or replaces a mute rule; at least one matcher is required.
+// The rule lapses at ExpiresAt, or after Duration (e.g. "72h"), if either is set.
+// Silence also creates a matching Alertmanager silence; on update, leaving it out keeps
+// the rule silenced if it was.
+type MuteRuleRequest struct {
+	Reason         string     `json:"reason"`
+	AlertSignature string     `json:"alert_signature"` // SQL LIKE pattern
+	ClusterID      string     `json:"cluster_id"`
+	TenantID       string     `json:"tenant_id"`
+	Component      string     `json:"component"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Duration       string     `json:"duration"`
+	Silence        *bool      `json:"silence"`
+}
+
+// muteRules caches the mute rules behind buildMuteRuleCondition; writes through the API
+// invalidate it, other replicas pick changes up within muteRulesTTL
+var muteRules struct {
+	sync.Mutex
+	rules  []models.MuteRule
+	loaded time.Time
+}
+
+const muteRulesTTL = 30 * time.Second
+
+func invalidateMuteRuleCache() {
+	muteRules.Lock()
+	muteRules.loaded = time.Time{}
+	muteRules.Unlock()
+}
+
+// buildMuteRuleCondition builds the SQL condition excluding alerts matched by an active
+// mute rule. It goes wherever buildClusterFilterCondition does, so every dashboard and
+// component view hides the same alerts.
+func buildMuteRuleCondition() string {
+	muteRules.Lock()
+	if time.Since(muteRules.loaded) > muteRulesTTL && db.DB != nil {
+		var rules []models.MuteRule
+		if err := db.DB.Find(&rules).Error; err != nil {
+			slog.Warn("Failed to load mute rules", "err", err)
+		} else {
+			muteRules.rules, muteRules.loaded = rules, time.Now()
+		}
+	}
+	rules := muteRules.rules
+	muteRules.Unlock()
+
+	now := time.Now()
+	var matches []string
+	for _, r := range rules {
+		if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+			continue
+		}
+		if m := muteRuleMatch(r); m != "" {
+			matches = append(matches, m)
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	return " AND NOT (" + strings.Join(matches, " OR ") + ")"
+}
+
+// muteRuleMatch is the rule's matchers ANDed as SQL; NULL columns compare as empty so
+// the NOT around it never turns NULL
+func muteRuleMatch(r models.MuteRule) string {
+	quote := func(v string) string { return "'" + strings.ReplaceAll(v, "'", "''") + "'" }
+	var conds []string
+	if r.AlertSignature != "" {
+		conds = append(conds, "COALESCE(alert_signature, '') LIKE "+quote(r.AlertSignature))
+	}
+	if r.ClusterID != "" {
+		conds = append(conds, "COALESCE(cluster_id, '') = "+quote(r.ClusterID))
+	}
+	if r.TenantID != "" {
+		conds = append(conds, "COALESCE(tenant_id, '') = "+quote(r.TenantID))
+	}
+	if r.Component != "" {
+		conds = append(conds, "COALESCE(components, '') LIKE "+quote(`%"`+r.Component+`"%`))
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(conds, " AND ") + ")"
+}
+
+// muteRuleFromRequest validates a request into a rule
+func muteRuleFromRequest(req MuteRuleRequest) (models.MuteRule, error) {
+	rule := models.MuteRule{
+		Reason:         strings.TrimSpace(req.Reason),
+		AlertSignature: strings.TrimSpace(req.AlertSignature),
+		ClusterID:      strings.TrimSpace(req.ClusterID),
+		TenantID:       strings.TrimSpace(req.TenantID),
+		Component:      strings.TrimSpace(req.Component),
+	}
+	if rule.AlertSignature == "" && rule.ClusterID == "" && rule.TenantID == "" && rule.Component == "" {
+		return rule, fmt.Errorf("set at least one of alert_signature, cluster_id, tenant_id or component")
+	}
+	expiresAt, err := muteExpiry(req.ExpiresAt, req.Duration, time.Now().UTC())
+	if err != nil {
+		return rule, err
+	}
+	rule.ExpiresAt = expiresAt
+	return rule, nil
+}
+
+// GetMuteRules lists mute rules, newest first. Expired ones are left out unless ?all=true.
+func GetMuteRules(c *gin.Context) {
+	query := dbFor(c).Order("created_at DESC, id DESC")
+	if c.Query("all") != "true" {
+		query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC())
+	}
+	rules := []models.MuteRule{}
+	if err := query.Find(&rules).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// muteRuleSilence creates the Alertmanager silence for a rule. On failure it writes the
+// error response and returns false.
+func muteRuleSilence(c *gin.Context, rule models.MuteRule) (string, bool) {
+	matchers, err := services.MuteRuleSilenceMatchers(dbFor(c), rule)
+	if err != nil {
+		if requestTimedOut(c) {
+			return "", false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	comment := "Mute rule from the alerts dashboard"
+	if rule.Reason != "" {
+		comment += ": " + rule.Reason
+	}
+	return createMuteSilence(c, matchers, rule.ExpiresAt, comment)
+}
+
+// CreateMuteRule adds a mute rule; views pick it up once cached responses expire
+func CreateMuteRule(c *gin.Context) {
+	var req MuteRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := muteRuleFromRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule.Actor = currentActor(c)
+	if req.Silence != nil && *req.Silence {
+		var ok bool
+		if rule.SilenceID, ok = muteRuleSilence(c, rule); !ok {
+			return
+		}
+	}
+	if err := dbFor(c).Create(&rule).Error; err != nil {
+		expireMuteSilence(c.Request.Context(), rule.SilenceID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	invalidateMuteRuleCache()
+	auditComponent(c, rule.Component, services.AuditCreate, "mute_rule", fmt.Sprint(rule.ID), nil, rule)
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateMuteRule replaces a mute rule's matchers, reason and expiry. A silenced rule gets
+// a new silence for the new matchers and the old one is expired.
+func UpdateMuteRule(c *gin.Context) {
+	var req MuteRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update, err := muteRuleFromRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dbc := dbFor(c)
+	var rule models.MuteRule
+	if err := dbc.First(&rule, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "mute rule not found"})
+		return
+	}
+	before := rule
+	rule.Reason, rule.AlertSignature, rule.ClusterID = update.Reason, update.AlertSignature, update.ClusterID
+	rule.TenantID, rule.Component, rule.ExpiresAt = update.TenantID, update.Component, update.ExpiresAt
+	rule.Actor = currentActor(c)
+	rule.SilenceID = ""
+	silence := before.SilenceID != ""
+	if req.Silence != nil {
+		silence = *req.Silence
+	}
+	if silence {
+		var ok bool
+		if rule.SilenceID, ok = muteRuleSilence(c, rule); !ok {
+			return
+		}
+	}
+	if err := dbc.Save(&rule).Error; err != nil {
+		expireMuteSilence(c.Request.Context(), rule.SilenceID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expireMuteSilence(c.Request.Context(), before.SilenceID)
+	invalidateMuteRuleCache()
+	auditComponent(c, rule.Component, services.AuditUpdate, "mute_rule", fmt.Sprint(rule.ID), before, rule)
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteMuteRule removes a mute rule and expires its silence; the alerts it hid show up again
+func DeleteMuteRule(c *gin.Context) {
+	dbc := dbFor(c)
+	var rule models.MuteRule
+	if err := dbc.First(&rule, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "mute rule not found"})
+		return
+	}
+	if err := dbc.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expireMuteSilence(c.Request.Context(), rule.SilenceID)
+	invalidateMuteRuleCache()
+	auditComponent(c, rule.Component, services.AuditDelete, "mute_rule", fmt.Sprint(rule.ID), rule, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// GetMuteSuppressions lists the suppressions created by muting with future set, newest
+// first. Expired ones are left out unless ?all=true.
+func GetMuteSuppressions(c *gin.Context) {
+	query := dbFor(c).Order("created_at DESC")
+	if c.Query("all") != "true" {
+		query = query.Where("expires_at > ?", time.Now().UTC())
+	}
+	var suppressions []models.MuteSuppression
+	if err := query.Find(&suppressions).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, suppressions)
+}
+
+// DeleteMuteSuppression stops muting future occurrences; issues it already muted stay muted
+func DeleteMuteSuppression(c *gin.Context) {
+	dbc := dbFor(c)
+	var suppression models.MuteSuppression
+	if err := dbc.First(&suppression, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "suppression not found"})
+		return
+	}
+	if err := dbc.Delete(&suppression).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditDelete, "mute_suppression", c.Param("id"), suppression, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
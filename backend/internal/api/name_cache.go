@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/gorm"
+)
+
+// StartNameCache persists tenant/cluster name resolutions to the database
+func StartNameCache(database *gorm.DB) {
+	services.GetNameResolver().SetDB(database)
+}
+
+// GetNameCache reports the name API settings and how many names are persisted
+func GetNameCache(c *gin.Context) {
+	stats, err := services.GetNameResolver().CacheStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// DeleteNameCache forgets cached names so they are fetched again: the :id one, the
+// comma-separated ?id= ones, or all of them
+func DeleteNameCache(c *gin.Context) {
+	var ids []string
+	if id := c.Param("id"); id != "" {
+		ids = append(ids, id)
+	}
+	for _, id := range strings.Split(c.Query("id"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	forgotten, err := services.GetNameResolver().Forget(c.Request.Context(), ids...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "forgotten": forgotten})
+		return
+	}
+	logFor(c).Info("Forgot cached names", "count", forgotten)
+	audit(c, services.AuditDelete, "name_cache", strings.Join(ids, ","), nil, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "forgotten": forgotten})
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// NotificationRuleRequest is the create/update payload; enabled defaults to true. Events
+// defaults to alert. Template is a Go text/template for the message body over the event's
+// payload, e.g. {{.Title}} and {{join .Components ", "}} for alerts; it has to render for
+// each of the rule's events. Severities and threshold only apply to alerts.
+type NotificationRuleRequest struct {
+	Name          string   `json:"name"`
+	Enabled       *bool    `json:"enabled"`
+	Channel       string   `json:"channel"`
+	Target        string   `json:"target"` // left out on update keeps the stored one
+	Secret        *string  `json:"secret"` // Lark bot signing secret; left out keeps the stored one
+	Severities    []string `json:"severities"`
+	Components    []string `json:"components"`
+	Events        []string `json:"events"`
+	Template      string   `json:"template"`
+	Threshold     int      `json:"threshold"`
+	WindowMinutes int      `json:"window_minutes"`
+}
+
+func (r NotificationRuleRequest) apply(rule *models.NotificationRule) {
+	rule.Name = r.Name
+	rule.Enabled = r.Enabled == nil || *r.Enabled
+	rule.Channel = r.Channel
+	if r.Target != "" || rule.ID == 0 {
+		rule.Target = r.Target
+	}
+	if r.Secret != nil {
+		rule.Secret = *r.Secret
+	}
+	rule.Severities = r.Severities
+	rule.Components = r.Components
+	rule.Events = r.Events
+	rule.Template = r.Template
+	rule.Threshold = r.Threshold
+	rule.WindowMinutes = r.WindowMinutes
+}
+
+// NotificationRuleResponse is a rule as the API returns it. Its target grants posting to
+// the channel, so only enough of it to tell rules apart is shown, like the secret is left out.
+type NotificationRuleResponse struct {
+	models.NotificationRule
+	Target string `json:"target"`
+}
+
+func toNotificationRuleResponse(rule models.NotificationRule) NotificationRuleResponse {
+	return NotificationRuleResponse{NotificationRule: rule, Target: redactTarget(rule.Target)}
+}
+
+// redactTarget keeps a webhook URL's host and the last characters of its path, or of a
+// routing key
+func redactTarget(target string) string {
+	if target == "" {
+		return ""
+	}
+	tail := ""
+	if len(target) > 12 {
+		tail = target[len(target)-4:]
+	}
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host + "/***" + tail
+	}
+	return "***" + tail
+}
+
+// bindNotificationRule decodes and validates the payload into rule, writing a 400 on failure
+func bindNotificationRule(c *gin.Context, rule *models.NotificationRule) bool {
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	req.apply(rule)
+
+	if problems := services.ValidateNotificationRule(rule); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification rule", "problems": problems})
+		return false
+	}
+
+	var count int64
+	dbFor(c).Model(&models.NotificationRule{}).Where("name = ? AND id != ?", rule.Name, rule.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "a notification rule named '" + rule.Name + "' already exists"})
+		return false
+	}
+	return true
+}
+
+// GetNotificationRules lists notification thresholds and routing rules
+func GetNotificationRules(c *gin.Context) {
+	rules := []models.NotificationRule{}
+	if err := dbFor(c).Order("id").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]NotificationRuleResponse, len(rules))
+	for i, rule := range rules {
+		items[i] = toNotificationRuleResponse(rule)
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetNotificationRule returns one notification rule
+func GetNotificationRule(c *gin.Context) {
+	var rule models.NotificationRule
+	if err := dbFor(c).First(&rule, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification rule not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toNotificationRuleResponse(rule))
+}
+
+// CreateNotificationRule validates and stores a new notification rule
+func CreateNotificationRule(c *gin.Context) {
+	var rule models.NotificationRule
+	if !bindNotificationRule(c, &rule) {
+		return
+	}
+	if err := dbFor(c).Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditCreate, "notification_rule", fmt.Sprint(rule.ID), nil, rule)
+	c.JSON(http.StatusCreated, toNotificationRuleResponse(rule))
+}
+
+// UpdateNotificationRule replaces an existing notification rule
+func UpdateNotificationRule(c *gin.Context) {
+	var rule models.NotificationRule
+	if err := dbFor(c).First(&rule, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification rule not found"})
+		return
+	}
+	before := rule
+	if !bindNotificationRule(c, &rule) {
+		return
+	}
+	if err := dbFor(c).Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditUpdate, "notification_rule", fmt.Sprint(rule.ID), before, rule)
+	c.JSON(http.StatusOK, toNotificationRuleResponse(rule))
+}
+
+// DeleteNotificationRule removes a notification rule
+func DeleteNotificationRule(c *gin.Context) {
+	dbc := dbFor(c)
+	var rule models.NotificationRule
+	if err := dbc.First(&rule, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification rule not found"})
+		return
+	}
+	if err := dbc.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditDelete, "notification_rule", fmt.Sprint(rule.ID), rule, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestNotificationRequest is the optional body of a test send; event defaults to the
+// rule's first event
+type TestNotificationRequest struct {
+	Event string `json:"event"`
+}
+
+// TestNotificationRule sends a sample message through a rule's template to its channel
+func TestNotificationRule(c *gin.Context) {
+	var rule models.NotificationRule
+	if err := dbFor(c).First(&rule, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification rule not found"})
+		return
+	}
+	var req TestNotificationRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	event := strings.ToLower(strings.TrimSpace(req.Event))
+	if event == "" {
+		event = services.NotifyAlert
+		if len(rule.Events) > 0 {
+			event = rule.Events[0]
+		}
+	}
+	if !services.RuleNotifies(rule, event) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("rule %s does not notify about %q", rule.Name, event)})
+		return
+	}
+
+	sqlDB, err := dbFor(c).DB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	msg, err := services.NewNotifier(sqlDB).TestSend(c.Request.Context(), rule, event)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "event": event, "title": msg.Title, "text": msg.Text})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "event": event, "title": msg.Title, "text": msg.Text})
+}
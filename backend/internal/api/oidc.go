@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Cookies set by the login flow; the login cookie only lives until the callback
+const (
+	sessionCookie  = "alert_dashboard_session"
+	loginCookie    = "alert_dashboard_login"
+	loginCookieTTL = 10 * time.Minute
+)
+
+// loginURL is where the frontend sends browsers without a session
+const loginURL = "/api/auth/login"
+
+// Session and login cookies are signed with keys derived from SESSION_SECRET so
+// one can't be passed off as the other
+func sessionKey(cfg config.OIDCConfig) string { return cfg.SessionSecret + ":session" }
+func loginKey(cfg config.OIDCConfig) string   { return cfg.SessionSecret + ":login" }
+
+// sessionIdentity returns the identity from a valid session cookie, with the user's
+// current role, or nil
+func sessionIdentity(c *gin.Context) *services.Identity {
+	cfg := config.Get().OIDC
+	if !cfg.Enabled() {
+		return nil
+	}
+	value, err := c.Cookie(sessionCookie)
+	if err != nil || value == "" {
+		return nil
+	}
+	var id services.Identity
+	if services.ParseSession(sessionKey(cfg), value, &id) != nil || id.Subject == "" {
+		return nil
+	}
+	id.Role = userRole(cfg, id.Email)
+	return &id
+}
+
+// setCookie writes an HttpOnly cookie for the whole site, Secure when served over TLS
+func setCookie(c *gin.Context, name, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, "/", "", secure, true)
+}
+
+// safeRedirect only allows app-relative paths so the login can't be used as an open redirect
+func safeRedirect(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "\\") {
+		return "/"
+	}
+	return path
+}
+
+// AuthLogin redirects the browser to the OIDC provider. ?redirect= is the app path
+// to come back to after login.
+func AuthLogin(c *gin.Context) {
+	provider, err := services.GetOIDCProvider()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, login, err := provider.NewLogin(c.Request.Context(), safeRedirect(c.DefaultQuery("redirect", "/")))
+	if err != nil {
+		logFor(c).Error("OIDC login failed", "err", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	value, _, err := services.SignSession(loginKey(provider.Config()), login, loginCookieTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setCookie(c, loginCookie, value, int(loginCookieTTL.Seconds()))
+	c.Redirect(http.StatusFound, target)
+}
+
+// AuthCallback completes the login: it checks the state against the login cookie,
+// redeems the code, verifies the ID token and starts a session
+func AuthCallback(c *gin.Context) {
+	provider, err := services.GetOIDCProvider()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	cfg := provider.Config()
+
+	if msg := c.Query("error"); msg != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed: " + msg, "detail": c.Query("error_description")})
+		return
+	}
+
+	var login services.OIDCLogin
+	value, _ := c.Cookie(loginCookie)
+	if services.ParseSession(loginKey(cfg), value, &login) != nil || login.State == "" || login.State != c.Query("state") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login expired or state mismatch, please log in again", "login_url": loginURL})
+		return
+	}
+	setCookie(c, loginCookie, "", -1)
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), login)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, services.ErrOIDCForbidden) {
+			status = http.StatusForbidden
+		}
+		logFor(c).Error("OIDC callback failed", "err", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, _, err := services.SignSession(sessionKey(cfg), identity, cfg.SessionTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setCookie(c, sessionCookie, session, int(cfg.SessionTTL.Seconds()))
+	logFor(c).Info("Logged in via OIDC", "email", identity.Email)
+	c.Redirect(http.StatusFound, login.Redirect)
+}
+
+// AuthMe returns the caller's identity, from a session or API key
+func AuthMe(c *gin.Context) {
+	if id := currentIdentity(c); id != nil {
+		c.JSON(http.StatusOK, id)
+		return
+	}
+	resp := gin.H{"error": "not logged in"}
+	if config.Get().OIDC.Enabled() {
+		resp["login_url"] = loginURL
+	}
+	c.JSON(http.StatusUnauthorized, resp)
+}
+
+// AuthLogout ends the session
+func AuthLogout(c *gin.Context) {
+	setCookie(c, sessionCookie, "", -1)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
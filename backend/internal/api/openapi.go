@@ -0,0 +1,369 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The OpenAPI document is built from the routes registered on the engine, so every route
+// is listed; routeDocs adds summaries, query parameters and body/response types, whose
+// schemas are derived from the Go types by reflection.
+
+// routeDoc describes one route for the OpenAPI document
+type routeDoc struct {
+	Summary  string
+	Query    []queryParam
+	Body     interface{} // a value of the JSON request body type
+	Response interface{} // a value of the response type; nil for an untyped object
+	Status   int         // success status, 200 unless set
+	Content  string      // success content type, JSON unless set
+}
+
+// queryParam is a query string parameter: Type is string, integer or boolean
+type queryParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+func q(name, description string) queryParam {
+	return queryParam{Name: name, Type: "string", Description: description}
+}
+
+func qInt(name, description string) queryParam {
+	return queryParam{Name: name, Type: "integer", Description: description}
+}
+
+func qBool(name, description string) queryParam {
+	return queryParam{Name: name, Type: "boolean", Description: description}
+}
+
+func params(groups ...[]queryParam) []queryParam {
+	var all []queryParam
+	for _, g := range groups {
+		all = append(all, g...)
+	}
+	return all
+}
+
+// listOf documents a {"items": [...]} response; arrayOf a bare JSON array
+type listOf struct{ Item interface{} }
+type arrayOf struct{ Item interface{} }
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  []byte
+)
+
+// OpenAPISpec serves the OpenAPI 3 document for the routes registered on r. It is built
+// on the first request, once every route is registered.
+func OpenAPISpec(r *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		openAPIOnce.Do(func() {
+			openAPIDoc, _ = json.Marshal(buildOpenAPI(r.Routes()))
+		})
+		c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIDoc)
+	}
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the spec
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Alerts Platform API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui", withCredentials: true });
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUI serves an interactive browser for /api/openapi.json
+func SwaggerUI(c *gin.Context) {
+	markAnonymized(c) // static page
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// buildOpenAPI documents every route under /api plus the probes
+func buildOpenAPI(routes gin.RoutesInfo) gin.H {
+	schemas := openAPISchemas{defs: gin.H{}, names: map[reflect.Type]string{}}
+	paths := gin.H{}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") && route.Path != "/readyz" {
+			continue
+		}
+		doc := routeDocs[route.Method+" "+route.Path]
+		path, pathParams := openAPIPath(route.Path)
+		op := gin.H{
+			"operationId": openAPIOperationID(route),
+			"tags":        []string{openAPITag(route.Path)},
+		}
+		if doc.Summary != "" {
+			op["summary"] = doc.Summary
+		}
+
+		var parameters []gin.H
+		for _, p := range pathParams {
+			parameters = append(parameters, gin.H{"name": p, "in": "path", "required": true, "schema": gin.H{"type": "string"}})
+		}
+		for _, p := range doc.Query {
+			param := gin.H{"name": p.Name, "in": "query", "schema": gin.H{"type": p.Type}}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			parameters = append(parameters, param)
+		}
+		if len(parameters) > 0 {
+			op["parameters"] = parameters
+		}
+
+		if doc.Body != nil {
+			op["requestBody"] = gin.H{
+				"required": true,
+				"content":  gin.H{"application/json": gin.H{"schema": schemas.value(doc.Body)}},
+			}
+		}
+
+		status, content := doc.Status, doc.Content
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if content == "" {
+			content = "application/json"
+		}
+		var schema gin.H
+		if content == "application/json" {
+			schema = gin.H{"type": "object"}
+			if doc.Response != nil {
+				schema = schemas.value(doc.Response)
+			}
+		} else {
+			schema = gin.H{"type": "string"}
+		}
+		op["responses"] = gin.H{
+			strconv.Itoa(status): gin.H{
+				"description": http.StatusText(status),
+				"content":     gin.H{content: gin.H{"schema": schema}},
+			},
+			"default": gin.H{
+				"description": "Error",
+				"content":     gin.H{"application/json": gin.H{"schema": schemas.value(ErrorResponse{})}},
+			},
+		}
+		if route.Path == "/readyz" || containsString(authExemptRoutes, route.Path) {
+			op["security"] = []gin.H{}
+		}
+
+		item, _ := paths[path].(gin.H)
+		if item == nil {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":       "Alerts Platform API",
+			"version":     "2",
+			"description": "Alert analytics, rule management and administration. Authenticate with an API key (X-API-Key or Authorization: Bearer) or a browser session.",
+		},
+		"servers": []gin.H{{"url": "/"}},
+		"paths":   paths,
+		"components": gin.H{
+			"schemas": schemas.defs,
+			"securitySchemes": gin.H{
+				"apiKey":  gin.H{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer":  gin.H{"type": "http", "scheme": "bearer"},
+				"session": gin.H{"type": "apiKey", "in": "cookie", "name": sessionCookie},
+			},
+		},
+		"security": []gin.H{{"apiKey": []string{}}, {"bearer": []string{}}, {"session": []string{}}},
+	}
+}
+
+// openAPIPath turns /issues/:id into /issues/{id} and returns the parameter names
+func openAPIPath(path string) (string, []string) {
+	var names []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			names = append(names, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), names
+}
+
+// openAPITag groups routes by their first path segment after /api (admin routes by the
+// second, e.g. admin/notifications)
+func openAPITag(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	if parts[0] == "admin" && len(parts) > 1 {
+		return "admin/" + parts[1]
+	}
+	if parts[0] == "" || path == "/readyz" {
+		return "health"
+	}
+	return parts[0]
+}
+
+// openAPIOperationID is the handler's name, or method and path for closures
+func openAPIOperationID(route gin.RouteInfo) string {
+	name := route.Handler[strings.LastIndex(route.Handler, ".")+1:]
+	if strings.HasPrefix(name, "func") || strings.Contains(route.Handler, "main.main") {
+		name = strings.ToLower(route.Method) + strings.NewReplacer("/", "_", ":", "", "-", "_").Replace(route.Path)
+	}
+	// Methods of UpdateController come through as bound method values
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// openAPISchemas collects named schemas under components/schemas
+type openAPISchemas struct {
+	defs  gin.H
+	names map[reflect.Type]string
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// value returns the schema for v's type, unwrapping listOf and arrayOf
+func (s openAPISchemas) value(v interface{}) gin.H {
+	switch x := v.(type) {
+	case listOf:
+		return gin.H{"type": "object", "properties": gin.H{"items": gin.H{"type": "array", "items": s.value(x.Item)}}}
+	case arrayOf:
+		return gin.H{"type": "array", "items": s.value(x.Item)}
+	}
+	return s.of(reflect.TypeOf(v))
+}
+
+// of returns the schema for t, a $ref for named structs
+func (s openAPISchemas) of(t reflect.Type) gin.H {
+	switch t {
+	case timeType:
+		return gin.H{"type": "string", "format": "date-time"}
+	case rawJSONType:
+		return gin.H{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.of(t.Elem())
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return gin.H{"type": "string", "format": "byte"}
+		}
+		return gin.H{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Interface:
+		return gin.H{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name, ok := s.names[t]
+		if !ok {
+			name = s.schemaName(t)
+			s.names[t] = name
+			s.defs[name] = gin.H{} // placeholder for recursive types
+			s.defs[name] = s.object(t)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + name}
+	}
+	return gin.H{}
+}
+
+// schemaName is the type's name, qualified by package when two packages share it
+func (s openAPISchemas) schemaName(t reflect.Type) string {
+	name := t.Name()
+	for other, used := range s.names {
+		if used == name && other != t {
+			pkg := t.PkgPath()
+			return pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+		}
+	}
+	return name
+}
+
+// object builds a struct's schema from its JSON fields, flattening embedded structs
+func (s openAPISchemas) object(t reflect.Type) gin.H {
+	props := gin.H{}
+	var required []string
+	s.fields(t, props, &required)
+	schema := gin.H{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s openAPISchemas) fields(t reflect.Type, props gin.H, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = s.of(f.Type)
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Query parameters shared by the dashboard endpoints
+var dashboardFilterParams = []queryParam{
+	qInt("days", "Look-back window in days (default 30); ignored with start"),
+	q("start", "Start of an explicit range, RFC 3339 or YYYY-MM-DD; changes compare with the equally long range before it"),
+	q("end", "End of an explicit range (default now); a date covers the whole day"),
+	q("env", "all (default), prod or non_prod, by the [PROD] signature prefix"),
+	q("component", "Component or virtual component"),
+	q("cluster_id", "Cluster ID"),
+	q("tenant_id", "Tenant ID"),
+	q("signature", "Alert signature"),
+	q("stability_governance", "Comma separated stability governance values"),
+	q("alert_group", "Comma separated alert groups; unassigned matches alerts without one"),
+	q("tier", "Comma separated tenant tiers; unknown matches tenants without tier metadata"),
+	q("visibility", "Comma separated visibility values; unknown matches alerts without the label"),
+	q("region", "Comma separated cluster regions"),
+	q("provider", "Comma separated cloud providers"),
+	qBool("dedup", "Count each burst of repeated alerts once"),
+}
+
+var trendStepParam = q("step", "Trend bucket: day (default), week or month")
+
+// Query parameters of the issue list, on top of the dashboard filters
+var issueListParams = []queryParam{
+	q("category", "Component category"),
+	q("metric_type", "Metric type"),
+	q("priority", "Comma separated priorities, e.g. Critical,Major"),
+	q("q", "Full-text search over titles and descriptions"),
+	q("label", "Comma separated labels an issue must all have"),
+	q("sort", "created (default), priority (by severity), status, cluster or tenant"),
+	q("order", "desc (default) or asc"),
+}
+
+var limitParam = qInt("limit", "Maximum number of items")
+
+// routeDocs documents the routes registered in cmd/server/main.go, keyed by method and
+// gin path. Routes without an entry are still listed, without parameters or schemas.
+var routeDocs = map[string]routeDoc{
+	"GET /readyz":     {Summary: "Readiness: database and name API breaker"},
+	"GET /api/health": {Summary: "Liveness probe"},
+
+	"GET /api/openapi.json": {Summary: "This OpenAPI document"},
+	"GET /api/docs":         {Summary: "Swagger UI for this document", Content: "text/html"},
+
+	"GET /api/auth/login":    {Summary: "Start OIDC login", Query: []queryParam{q("redirect", "Path to return to after login")}, Status: http.StatusFound, Content: "text/html"},
+	"GET /api/auth/callback": {Summary: "OIDC redirect target", Query: []queryParam{q("code", ""), q("state", ""), q("error", ""), q("error_description", "")}, Status: http.StatusFound, Content: "text/html"},
+	"GET /api/auth/me":       {Summary: "The caller's identity and role", Response: services.Identity{}},
+	"POST /api/auth/logout":  {Summary: "End the browser session"},
+
+	"GET /api/stream": {
+		Summary: "Server-Sent Events: issue.ingested, sync.finished and task.status",
+		Query:   []queryParam{q("types", "Comma separated event types to receive (default all)")},
+		Content: "text/event-stream",
+	},
+	"GET /api/activity": {
+		Summary:  "Merged activity feed: audited changes, task status, syncs, escalations and health transitions",
+		Query:    []queryParam{q("component", "Only events of this component"), q("actor", "Only events by this actor"), q("cursor", "next_cursor of the previous page"), limitParam},
+		Response: listOf{ActivityEntry{}},
+	},
+
+	"GET /api/categories":                 {Summary: "Component categories in sidebar order", Response: arrayOf{""}},
+	"GET /api/components":                 {Summary: "Sidebar components", Response: arrayOf{ComponentResponse{}}},
+	"GET /api/components/summary":         {Summary: "Sidebar components with last-24h counts and health", Response: listOf{ComponentSummary{}}},
+	"GET /api/components/targets":         {Summary: "Configured component targets", Response: listOf{models.ComponentTarget{}}},
+	"GET /api/components/overrides":       {Summary: "Manual component reassignments", Query: []queryParam{q("component", "Only overrides to or from this component")}, Response: listOf{models.ComponentOverride{}}},
+	"GET /api/components/:name/stats":     {Summary: "Component statistics and trend", Query: params(dashboardFilterParams, []queryParam{trendStepParam, q("category", "Component category")})},
+	"GET /api/components/:name/burn-rate": {Summary: "Alert burn rate against the quiet baseline", Query: []queryParam{q("format", "json (default) or prometheus"), q("quiet_rate", "Quiet alerts/hour baseline, overriding the target")}},
+	"GET /api/components/:name/target":    {Summary: "A component's targets", Response: models.ComponentTarget{}},
+	"PUT /api/components/:name/target":    {Summary: "Set a component's targets", Body: models.ComponentTarget{}, Response: models.ComponentTarget{}},
+	"DELETE /api/components/:name/target": {Summary: "Remove a component's targets"},
+	"GET /api/components/:name/rules": {
+		Summary:  "Alert rules of a component",
+		Query:    []queryParam{q("category", "premium, dedicated or essential"), q("rule_type", "prometheus or logging (default both)")},
+		Response: arrayOf{models.Rule{}},
+	},
+	"POST /api/components/:name/rules": {
+		Summary: "Add an alert rule to a rule file, or open a pull request for it when rules git is configured",
+		Body:    CreateRuleRequest{},
+		Status:  http.StatusCreated,
+	},
+	"PUT /api/components/:name/rules": {Summary: "Update an alert rule in the rules repository, or open a pull request for it when rules git is configured", Body: UpdateRuleRequest{}},
+	"DELETE /api/components/:name/rules": {
+		Summary: "Delete an alert rule, or open a pull request for it when rules git is configured",
+		Query:   []queryParam{q("file_path", "Required"), q("alert", "Required"), qInt("task_id", "")},
+	},
+	"GET /api/components/:name/rules/history": {
+		Summary:  "Changes made to the component's rules, newest first",
+		Query:    []queryParam{q("alert", "Rule name before or after the edit"), q("file_path", ""), qInt("task_id", ""), qInt("limit", "Default 100, max 1000")},
+		Response: arrayOf{models.RuleRevision{}},
+	},
+	"GET /api/components/:name/rules/history/:id/diff": {Summary: "A rule revision with the diff of its YAML", Response: RuleRevisionDiff{}},
+
+	"GET /api/rules/export": {
+		Summary: "Rule files as tar.gz",
+		Query:   []queryParam{q("component", ""), q("category", ""), qBool("normalize", "Re-serialize files in canonical form")},
+		Content: "application/gzip",
+	},
+	"GET /api/rules/drift": {
+		Summary: "Rules that differ across categories",
+		Query:   []queryParam{q("component", ""), q("categories", "Comma separated categories to compare"), q("ignore_labels", "Comma separated labels to ignore")},
+	},
+	"GET /api/rules/lint":   {Summary: "Lint problems in the rules repository"},
+	"GET /api/rules/owners": {Summary: "Alert volume by rule owner", Query: []queryParam{qInt("days", "Look-back window in days")}},
+	"GET /api/rules/fake-rates": {
+		Summary: "Fake-alarm rate per alert rule, from user verdicts and otherwise the JIRA status",
+		Query: params(dashboardFilterParams[:3], []queryParam{
+			q("component", "Component"),
+			qInt("min_alerts", "Leave out rules with fewer alerts (default 1)"),
+			qInt("limit", "Max rules (default 50, max 500)"),
+		}),
+		Response: listOf{RuleFakeRate{}},
+	},
+	"GET /api/rules/audits":      {Summary: "Scheduled rule audit results", Query: []queryParam{limitParam, qBool("details", "Include full reports")}, Response: listOf{RuleAuditResponse{}}},
+	"POST /api/rules/audits/run": {Summary: "Run the rule audit now", Response: RuleAuditResponse{}},
+	"GET /api/rules/search": {
+		Summary: "Search alert rules across every configured rule directory",
+		Query: []queryParam{
+			q("q", "Text in alert names, expressions, labels or annotations"), q("severity", ""),
+			q("label", "Comma separated name or name=value labels a rule must all have"), qInt("limit", "Default 100, max 1000"),
+		},
+	},
+	"POST /api/rules/import": {
+		Summary: "Import a rule file (YAML body) as a rule change task",
+		Query:   []queryParam{q("component", ""), q("owner", ""), q("description", ""), qBool("dry_run", "Only return the plan")},
+	},
+	"GET /api/rules/templates":                    {Summary: "Rule templates", Response: arrayOf{services.RuleTemplate{}}},
+	"GET /api/rules/templates/:name":              {Summary: "One rule template", Response: services.RuleTemplate{}},
+	"POST /api/rules/templates/:name/instantiate": {Summary: "Render a template into a rule change task", Body: InstantiateTemplateRequest{}},
+	"GET /api/rules/:alert/threshold-suggestion": {
+		Summary: "Suggested threshold from the metric's history",
+		Query:   []queryParam{q("component", ""), qInt("days", ""), q("percentile", ""), q("step", "")},
+	},
+	"GET /api/rules/:alert/impact": {
+		Summary: "Alert volume before and after each change to the rule",
+		Query:   []queryParam{qInt("window_days", "Days compared on each side of a change (default 7)"), limitParam},
+	},
+
+	"GET /api/dashboard":            {Summary: "Dashboard metrics, trends and top lists", Query: params(dashboardFilterParams, []queryParam{trendStepParam}), Response: DashboardDataResponse{}},
+	"GET /api/dashboard/categories": {Summary: "Dashboard metrics per business category", Query: dashboardFilterParams, Response: DashboardCategoriesResponse{}},
+	"GET /api/dashboard/issues": {
+		Summary: "A page of the issues matching the dashboard filters with total and total_pages; with cursor, keyset pages as {items, next_cursor}; with group=true, IssueGroup items",
+		Query: params(dashboardFilterParams, issueListParams, []queryParam{
+			q("fields", "Comma separated fields to return, e.g. id,title,priority,status to skip descriptions"),
+			qBool("group", "Collapse repeats of a signature on a cluster within the dedup window into groups; sort is then created (latest occurrence) or count"),
+			qInt("page", "Page number (default 1)"),
+			qInt("page_size", "Page size (default 50)"),
+			q("cursor", "Empty for the first keyset page, then next_cursor"),
+		}),
+		Response: IssuePage{},
+	},
+	"GET /api/dashboard/issues/export": {
+		Summary: "Issues matching the dashboard filters as CSV",
+		Query:   params(dashboardFilterParams, issueListParams, []queryParam{q("format", "csv (default, the only format)")}),
+		Content: "text/csv",
+	},
+	"GET /api/dashboard/governance":      {Summary: "Breakdown by stability governance", Query: params(dashboardFilterParams, []queryParam{trendStepParam})},
+	"GET /api/dashboard/alert-groups":    {Summary: "Breakdown by alert group", Query: params(dashboardFilterParams, []queryParam{trendStepParam})},
+	"GET /api/dashboard/tiers":           {Summary: "Breakdown by tenant tier", Query: params(dashboardFilterParams, []queryParam{trendStepParam})},
+	"GET /api/dashboard/trend-by-region": {Summary: "Trend per region or provider", Query: params(dashboardFilterParams, []queryParam{trendStepParam, q("group_by", "region (default) or provider")})},
+	"POST /api/dashboard/snapshots": {
+		Summary:  "Freeze the current dashboard as a snapshot",
+		Query:    params(dashboardFilterParams, []queryParam{trendStepParam}),
+		Body:     CreateSnapshotRequest{},
+		Response: DashboardSnapshotResponse{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/dashboard/snapshots":     {Summary: "Dashboard snapshots", Query: []queryParam{limitParam}, Response: listOf{DashboardSnapshotResponse{}}},
+	"GET /api/dashboard/snapshots/:id": {Summary: "One snapshot with its data", Response: DashboardSnapshotResponse{}},
+
+	"GET /api/analytics/flapping": {
+		Summary: "Signatures firing repeatedly on the same cluster, ranked by flapping days",
+		Query: params(dashboardFilterParams, []queryParam{
+			qInt("min_per_day", "Occurrences on a cluster that make a flapping day (default 3)"),
+			qInt("limit", "Maximum number of items (default 50)"),
+		}),
+		Response: FlappingResponse{},
+	},
+
+	"POST /api/share-links":  {Summary: "Create a signed read-only link to a dashboard view", Body: CreateShareLinkRequest{}},
+	"GET /api/shared/:token": {Summary: "The dashboard view behind a share link", Query: params(dashboardFilterParams, []queryParam{trendStepParam})},
+
+	"GET /api/views":        {Summary: "Saved views, by name", Response: listOf{SavedViewResponse{}}},
+	"POST /api/views":       {Summary: "Save a named combination of dashboard and issue list filters", Body: SavedViewRequest{}, Response: SavedViewResponse{}, Status: http.StatusCreated},
+	"GET /api/views/:id":    {Summary: "One saved view, with its filters as a dashboard query string", Response: SavedViewResponse{}},
+	"PUT /api/views/:id":    {Summary: "Replace a saved view", Body: SavedViewRequest{}, Response: SavedViewResponse{}},
+	"DELETE /api/views/:id": {Summary: "Delete a saved view"},
+	"GET /api/tenants":      {Summary: "Tenant metadata", Query: []queryParam{q("tier", "")}, Response: arrayOf{models.Tenant{}}},
+	"GET /api/tenants/:id/stats": {
+		Summary: "A tenant's alert totals, trend, top clusters and signatures, and recent issues, compared with the previous period",
+		Query: params(dashboardFilterParams[:4], []queryParam{
+			q("component", "Component"),
+			q("visibility", "Comma separated visibility values"),
+			q("region", "Comma separated cluster regions"),
+			q("provider", "Comma separated cloud providers"),
+			qBool("dedup", "Count each burst of repeated alerts once"),
+			trendStepParam,
+		}),
+		Response: TenantStatsResponse{},
+	},
+	"POST /api/tenants/import": {Summary: "Import tenant metadata from CSV (body or \"file\" upload)"},
+	"POST /api/tenants/sync":   {Summary: "Sync tenant metadata from the tenant API"},
+	"GET /api/tenants/digests": {
+		Summary:  "Weekly noisy-tenant digest runs",
+		Query:    []queryParam{limitParam, qBool("details", "Include the per-tenant digests")},
+		Response: listOf{TenantDigestRunResponse{}},
+	},
+	"GET /api/tenants/digests/preview": {Summary: "Build a week's digest without sending it", Query: []queryParam{q("week_start", "Any date in the week, YYYY-MM-DD")}},
+	"POST /api/tenants/digests/run":    {Summary: "Send a week's digest now", Body: RunTenantDigestRequest{}},
+	"GET /api/clusters":                {Summary: "Cluster metadata", Query: []queryParam{q("region", ""), q("provider", "")}, Response: arrayOf{models.Cluster{}}},
+	"POST /api/clusters/import":        {Summary: "Import cluster metadata from CSV (body or \"file\" upload)"},
+
+	"GET /api/reports/component-reconciliation": {
+		Summary: "JIRA components compared with the alert payload",
+		Query:   []queryParam{q("component", ""), qInt("days", ""), limitParam, qBool("mismatch", "Only mismatches")},
+	},
+	"GET /api/reports/component-attribution": {Summary: "Where component attribution strategies disagree", Query: []queryParam{qInt("days", ""), limitParam}},
+	"GET /api/reports/unmapped-values":       {Summary: "Stored priorities and statuses without a normalization mapping"},
+	"GET /api/reports": {
+		Summary:  "Generated weekly and monthly alert reports",
+		Query:    []queryParam{q("period", "weekly or monthly"), limitParam},
+		Response: listOf{models.Report{}},
+	},
+	"POST /api/reports/run": {Summary: "Generate a report now", Body: RunReportRequest{}, Response: ReportResponse{}},
+	"GET /api/reports/:id":  {Summary: "One report", Query: []queryParam{q("format", "json (default), markdown or html")}, Response: ReportResponse{}},
+
+	"POST /api/issues/:id/mute":             {Summary: "Mute an issue, optionally until a time or for a duration, its future occurrences and its alert in Alertmanager", Body: MuteIssueRequest{}},
+	"DELETE /api/issues/:id/mute":           {Summary: "Unmute an issue and expire its Alertmanager silence"},
+	"GET /api/mute-suppressions":            {Summary: "Active mutes of future occurrences", Query: []queryParam{qBool("all", "Include expired ones")}, Response: arrayOf{models.MuteSuppression{}}},
+	"DELETE /api/mute-suppressions/:id":     {Summary: "Stop muting future occurrences"},
+	"GET /api/mute-rules":                   {Summary: "Pattern mute rules", Query: []queryParam{qBool("all", "Include expired ones")}, Response: arrayOf{models.MuteRule{}}},
+	"POST /api/mute-rules":                  {Summary: "Hide alerts matching a signature pattern, cluster, tenant or component from every view, optionally silencing them in Alertmanager", Body: MuteRuleRequest{}, Response: models.MuteRule{}, Status: http.StatusCreated},
+	"PUT /api/mute-rules/:id":               {Summary: "Replace a mute rule", Body: MuteRuleRequest{}, Response: models.MuteRule{}},
+	"DELETE /api/mute-rules/:id":            {Summary: "Delete a mute rule"},
+	"GET /api/issues/:id/timeline":          {Summary: "JIRA transitions, local actions and rule changes of an issue"},
+	"POST /api/issues/:id/events":           {Summary: "Record an ack, triage note, note or delivery", Body: IssueEventRequest{}, Response: models.IssueEvent{}, Status: http.StatusCreated},
+	"PATCH /api/issues/:id/component":       {Summary: "Reassign an issue to other components", Body: ReassignComponentRequest{}},
+	"DELETE /api/issues/:id/component":      {Summary: "Undo a component reassignment", Query: []queryParam{q("actor", "")}},
+	"GET /api/issues/:id/classification":    {Summary: "The fake/real verdict recorded for an issue", Response: models.IssueClassification{}},
+	"POST /api/issues/:id/classification":   {Summary: "Mark an issue as a fake alarm or a real one, independently of its JIRA status", Body: ClassifyIssueRequest{}, Response: models.IssueClassification{}},
+	"DELETE /api/issues/:id/classification": {Summary: "Withdraw an issue's fake/real verdict"},
+	"POST /api/issues/:id/ack":              {Summary: "Acknowledge an issue with a JIRA comment, optionally moving it through a transition", Body: AckIssueRequest{}},
+	"GET /api/issues/:id/transitions":       {Summary: "JIRA workflow transitions available from the issue's current status", Response: listOf{services.JiraTransition{}}},
+	"POST /api/issues/:id/transitions":      {Summary: "Move an issue through a JIRA workflow transition, by ID, name or target status", Body: TransitionIssueRequest{}},
+	"PUT /api/issues/:id/assignee":          {Summary: "Assign an issue to a JIRA account, or unassign it with an empty account_id", Body: AssignIssueRequest{}},
+	"POST /api/issues/:id/comments":         {Summary: "Post a comment on an issue in JIRA", Body: IssueCommentRequest{}, Response: services.JiraComment{}},
+	"POST /api/issues/batch-get": {
+		Summary: "Look up several issues by ID",
+		Query:   []queryParam{q("fields", "Comma separated fields to return, issue fields plus components, cluster_name and tenant_name")},
+		Body:    BatchGetIssuesRequest{},
+	},
+	"GET /api/issues/search": {
+		Summary: "Full-text search over issue titles, descriptions and labels, with matches highlighted",
+		Query: []queryParam{
+			q("q", `Words, "quoted phrases" and prefix* terms, all of which must match`),
+			qInt("days", "Only issues created in the last N days (default all time)"),
+			qInt("page", "1-based page (default 1)"),
+			qInt("page_size", "Page size (default 20, max 100)"),
+			q("fields", "Comma separated fields of each hit to return, e.g. id,title_html"),
+		},
+		Response: IssuePage{},
+	},
+
+	"GET /api/feeds/critical.atom": {
+		Summary: "Atom feed of critical alerts",
+		Query:   []queryParam{q("token", "FEEDS_TOKEN"), q("component", ""), limitParam},
+		Content: "application/atom+xml",
+	},
+	"GET /api/rules-notify-manager": {Summary: "Rules notification config", Response: services.RulesNotifyConfig{}},
+	"PUT /api/rules-notify-manager": {Summary: "Replace the rules notification config", Body: services.RulesNotifyConfig{}},
+	"GET /api/rules-notify-manager/evaluate": {
+		Summary: "Whether the rules notification config notifies an alert, and why not",
+		Query:   []queryParam{q("biz_type", ""), q("tenant_id", ""), q("cluster_id", "")},
+	},
+	"GET /api/tasks":  {Summary: "Rule change tasks", Query: []queryParam{q("component", "")}, Response: arrayOf{models.Task{}}},
+	"POST /api/tasks": {Summary: "Raise a rule change task", Body: models.Task{}, Response: models.Task{}, Status: http.StatusCreated},
+
+	"POST /api/admin/reload":                    {Summary: "Reload configuration"},
+	"POST /api/admin/re-enrich":                 {Summary: "Backfill enrichment from stored payloads", Body: ReEnrichRequest{}},
+	"GET /api/admin/re-enrich":                  {Summary: "Backfill progress", Response: services.ReEnrichProgress{}},
+	"POST /api/admin/normalize":                 {Summary: "Re-apply priority and status normalization"},
+	"POST /api/admin/simulate":                  {Summary: "Replay history against a proposed notification policy", Body: SimulateRequest{}, Response: services.SimulationResult{}},
+	"POST /api/admin/synthetic-alert":           {Summary: "Inject a synthetic alert through the ingest path", Body: services.SyntheticAlertRequest{}, Response: services.SyntheticAlertResult{}},
+	"GET /api/admin/search":                     {Summary: "Search index status"},
+	"POST /api/admin/search/reindex":            {Summary: "Rebuild the search index"},
+	"GET /api/admin/query-stats":                {Summary: "Slow and frequent queries", Query: []queryParam{limitParam, q("sort", "")}},
+	"DELETE /api/admin/query-stats":             {Summary: "Reset query statistics"},
+	"GET /api/admin/name-cache":                 {Summary: "Name API settings, breaker state, persisted and fallback names", Response: services.NameCacheStats{}},
+	"DELETE /api/admin/name-cache":              {Summary: "Forget cached tenant/cluster names, all or the given ones", Query: []queryParam{q("id", "Comma separated IDs")}},
+	"DELETE /api/admin/name-cache/:id":          {Summary: "Forget the cached name of one tenant or cluster"},
+	"GET /api/admin/exports":                    {Summary: "Warehouse export runs", Query: []queryParam{limitParam}, Response: listOf{models.WarehouseExport{}}},
+	"POST /api/admin/exports/run":               {Summary: "Export pending days, or one day", Body: RunWarehouseExportRequest{}, Response: listOf{models.WarehouseExport{}}},
+	"GET /api/admin/rollups":                    {Summary: "Days covered by the daily and component stats rollups", Response: StatsRollupStatus{}},
+	"POST /api/admin/rollups/rebuild":           {Summary: "Roll up every day's stats again as a background job", Response: JobResponse{}, Status: http.StatusAccepted},
+	"GET /api/admin/retention":                  {Summary: "Retention policy, issues eligible for archiving and recent runs", Query: []queryParam{limitParam}, Response: listOf{models.RetentionRun{}}},
+	"POST /api/admin/retention/run":             {Summary: "Archive expired issues now as a background job", Response: JobResponse{}, Status: http.StatusAccepted},
+	"GET /api/admin/failed-issues":              {Summary: "Issues that failed to ingest", Response: listOf{FailedIssueResponse{}}},
+	"GET /api/admin/failed-issues/:id":          {Summary: "A failed issue with its payload", Response: FailedIssueResponse{}},
+	"POST /api/admin/failed-issues/:id/requeue": {Summary: "Retry ingesting a failed issue"},
+	"DELETE /api/admin/failed-issues/:id":       {Summary: "Drop a failed issue"},
+	"GET /api/admin/api-keys":                   {Summary: "API keys from API_KEYS and the database", Response: listOf{APIKeyResponse{}}},
+	"POST /api/admin/api-keys":                  {Summary: "Issue an API key; the key is only returned once", Body: CreateAPIKeyRequest{}, Status: http.StatusCreated},
+	"PUT /api/admin/api-keys/:id":               {Summary: "Change an API key's role", Body: UpdateAPIKeyRequest{}},
+	"DELETE /api/admin/api-keys/:id":            {Summary: "Revoke an API key"},
+	"GET /api/admin/roles":                      {Summary: "Roles assigned to login users", Response: listOf{models.UserRole{}}},
+	"PUT /api/admin/roles/:email":               {Summary: "Assign a role to a login user", Body: AssignRoleRequest{}},
+	"DELETE /api/admin/roles/:email":            {Summary: "Remove a user's role assignment"},
+	"GET /api/admin/notifications":              {Summary: "Notification rules", Response: listOf{NotificationRuleResponse{}}},
+	"POST /api/admin/notifications":             {Summary: "Create a notification rule", Body: NotificationRuleRequest{}, Response: NotificationRuleResponse{}, Status: http.StatusCreated},
+	"GET /api/admin/notifications/:id":          {Summary: "One notification rule", Response: NotificationRuleResponse{}},
+	"PUT /api/admin/notifications/:id":          {Summary: "Update a notification rule", Body: NotificationRuleRequest{}, Response: NotificationRuleResponse{}},
+	"DELETE /api/admin/notifications/:id":       {Summary: "Delete a notification rule"},
+	"POST /api/admin/notifications/:id/test":    {Summary: "Send a sample message through a notification rule", Body: TestNotificationRequest{}},
+	"GET /api/admin/webhooks":                   {Summary: "Outbound webhooks", Response: listOf{models.Webhook{}}},
+	"POST /api/admin/webhooks":                  {Summary: "Create an outbound webhook", Body: WebhookRequest{}, Response: models.Webhook{}, Status: http.StatusCreated},
+	"GET /api/admin/webhooks/:id":               {Summary: "One outbound webhook", Response: models.Webhook{}},
+	"PUT /api/admin/webhooks/:id":               {Summary: "Update an outbound webhook", Body: WebhookRequest{}, Response: models.Webhook{}},
+	"DELETE /api/admin/webhooks/:id":            {Summary: "Delete an outbound webhook"},
+	"POST /api/admin/webhooks/:id/test":         {Summary: "Send a signed ping delivery to a webhook"},
+	"GET /api/admin/webhooks/:id/deliveries":    {Summary: "Delivery log of one webhook", Query: []queryParam{q("event", ""), q("status", "pending, succeeded or failed"), limitParam}, Response: listOf{models.WebhookDelivery{}}},
+	"GET /api/admin/webhook-deliveries":         {Summary: "Delivery log of every webhook", Query: []queryParam{q("webhook_id", ""), q("event", ""), q("status", "pending, succeeded or failed"), limitParam}, Response: listOf{models.WebhookDelivery{}}},
+	"GET /api/audit": {
+		Summary: "Audit log of mutations",
+		Query: []queryParam{
+			q("actor", ""), q("resource", ""), q("resource_id", ""), q("action", ""),
+			q("since", "RFC 3339 or YYYY-MM-DD"), q("until", "RFC 3339 or YYYY-MM-DD"), limitParam,
+		},
+		Response: listOf{AuditLogResponse{}},
+	},
+
+	"GET /api/jobs": {Summary: "Background jobs", Query: []queryParam{q("type", ""), q("status", ""), limitParam}, Response: listOf{JobResponse{}}},
+	"POST /api/jobs/:type": {
+		Summary:  "Start a job: issues-export (dashboard issue filters), rules-export, re-enrich, warehouse-export, rule-audit, retention or stats-rollup",
+		Query:    params(dashboardFilterParams, issueListParams, []queryParam{qBool("normalize", "rules-export only")}),
+		Response: JobResponse{},
+		Status:   http.StatusAccepted,
+	},
+	"GET /api/jobs/:id":          {Summary: "Job status and progress", Response: JobResponse{}},
+	"GET /api/jobs/:id/artifact": {Summary: "Download a finished job's artifact", Content: "application/octet-stream"},
+	"DELETE /api/jobs/:id":       {Summary: "Cancel a job or delete a finished one"},
+
+	"POST /api/update":       {Summary: "Start a JIRA sync", Body: UpdateRequest{}},
+	"GET /api/update/status": {Summary: "Sync status per ingestion source", Response: UpdateStatus{}},
+	"POST /api/ingest/jira":  {Summary: "Push JIRA issue records to store right away; authenticated by WEBHOOK_JIRA_SECRET or WEBHOOK_JIRA_TOKEN, not API keys"},
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// issueCursor is the keyset position of the last row returned: (created, id)
+type issueCursor struct {
+	Created string `json:"c"`
+	ID      string `json:"i"`
+}
+
+// encodeIssueCursor produces the opaque next_cursor token
+func encodeIssueCursor(cur issueCursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeIssueCursor(token string) (issueCursor, error) {
+	var cur issueCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cur, err
+	}
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return cur, err
+	}
+	return cur, nil
+}
+
+// activityCursor is the keyset position of the last activity entry returned. Entries are
+// ordered by (time, source, id) descending, so ties across sources stay stable.
+type activityCursor struct {
+	Time   time.Time `json:"t"`
+	Source string    `json:"s"`
+	ID     uint      `json:"i"`
+}
+
+func encodeActivityCursor(cur activityCursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeActivityCursor(token string) (activityCursor, error) {
+	var cur activityCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cur, err
+	}
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return cur, err
+	}
+	return cur, nil
+}
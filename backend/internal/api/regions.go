@@ -0,0 +1,206 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/gorm"
+)
+
+// regionUnknown labels alerts whose cluster has no region/provider metadata
+const regionUnknown = "unknown"
+
+const (
+	regionExpr   = "COALESCE(NULLIF((SELECT region FROM clusters WHERE clusters.id = issues.cluster_id), ''), '" + regionUnknown + "')"
+	providerExpr = "COALESCE(NULLIF((SELECT provider FROM clusters WHERE clusters.id = issues.cluster_id), ''), '" + regionUnknown + "')"
+)
+
+// RegionCount breaks alerts down by cloud provider and region
+type RegionCount struct {
+	Provider string  `json:"provider"`
+	Region   string  `json:"region"`
+	Current  int     `json:"current"`
+	Previous int     `json:"previous"`
+	Change   float64 `json:"change"`
+	Trend    string  `json:"trend"`
+	Critical int     `json:"critical"`
+}
+
+// buildRegionFilterCondition filters by comma separated regions and providers via cluster metadata
+func buildRegionFilterCondition(region, provider string) string {
+	clusterCondition := ""
+	if region != "" {
+		clusterCondition += buildInFilterCondition("region", strings.ToLower(region))
+	}
+	if provider != "" {
+		clusterCondition += buildInFilterCondition("provider", strings.ToLower(provider))
+	}
+	if clusterCondition == "" {
+		return ""
+	}
+	return " AND issues.cluster_id IN (SELECT id FROM clusters WHERE 1=1" + clusterCondition + ")"
+}
+
+// regionBreakdown counts alerts per provider/region for the current and previous periods.
+// where is the issue condition without the date range, args its placeholders.
+func regionBreakdown(dbc *gorm.DB, where string, args []interface{}, start, end, prevStart, prevEnd string) []RegionCount {
+	var current []struct {
+		Provider string
+		Region   string
+		Total    int
+		Critical int
+	}
+	dbc.Raw(`
+		SELECT `+providerExpr+` as provider, `+regionExpr+` as region,
+			COUNT(*) as total,
+			SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1, 2
+		ORDER BY total DESC
+	`, append(append([]interface{}{}, args...), start, end)...).Scan(&current)
+
+	var previous []struct {
+		Provider string
+		Region   string
+		Total    int
+	}
+	dbc.Raw(`
+		SELECT `+providerExpr+` as provider, `+regionExpr+` as region, COUNT(*) as total
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1, 2
+	`, append(append([]interface{}{}, args...), prevStart, prevEnd)...).Scan(&previous)
+	prevByRegion := make(map[string]int)
+	for _, p := range previous {
+		prevByRegion[p.Provider+"/"+p.Region] = p.Total
+	}
+
+	result := make([]RegionCount, 0, len(current))
+	for _, cur := range current {
+		prev := prevByRegion[cur.Provider+"/"+cur.Region]
+		change, trend := calculateChange(cur.Total, prev)
+		result = append(result, RegionCount{
+			Provider: cur.Provider,
+			Region:   cur.Region,
+			Current:  cur.Total,
+			Previous: prev,
+			Change:   change,
+			Trend:    trend,
+			Critical: cur.Critical,
+		})
+	}
+	return result
+}
+
+// GetClusters lists cluster metadata, optionally filtered by ?region= and ?provider=
+func GetClusters(c *gin.Context) {
+	clusters, err := services.NewClusterService(db.DB).List(c.Request.Context(), c.Query("region"), c.Query("provider"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, clusters)
+}
+
+// ImportClusters loads cluster metadata from CSV, either as the request body or a "file" upload
+func ImportClusters(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	count, err := services.NewClusterService(db.DB).ImportCSV(c.Request.Context(), body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "imported": count})
+}
+
+// RegionTrendSeries is one provider/region line of the trend comparison, with Points
+// aligned to the response's dates
+type RegionTrendSeries struct {
+	Provider string `json:"provider"`
+	Region   string `json:"region,omitempty"`
+	Total    int    `json:"total"`
+	Points   []int  `json:"points"`
+}
+
+// GetTrendByRegion returns parallel trends per provider/region (or per provider with
+// group_by=provider) for the selected filters, so region-scoped surges stand out
+func GetTrendByRegion(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "region")
+	if groupBy != "region" && groupBy != "provider" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be region or provider"})
+		return
+	}
+
+	expr := db.Concat(providerExpr, "'/'", regionExpr)
+	if groupBy == "provider" {
+		expr = providerExpr
+	}
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	groups, dateRange := breakdownBy(c, p, expr, buildStabilityGovernanceFilterCondition())
+
+	// Align every series on the same dates; daily steps cover the whole range so quiet days show as 0
+	dateSet := make(map[string]bool)
+	if c.DefaultQuery("step", "day") == "day" {
+		start, _ := time.Parse("2006-01-02", dateRange.Start[:10])
+		end, _ := time.Parse("2006-01-02", dateRange.End[:10])
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			dateSet[d.Format("2006-01-02")] = true
+		}
+	}
+	for _, g := range groups {
+		for _, p := range g.Stats.DailyTrend {
+			dateSet[p.Date] = true
+		}
+	}
+	dates := make([]string, 0, len(dateSet))
+	for d := range dateSet {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+	index := make(map[string]int, len(dates))
+	for i, d := range dates {
+		index[d] = i
+	}
+
+	series := make([]RegionTrendSeries, 0, len(groups))
+	for _, g := range groups {
+		s := RegionTrendSeries{Provider: g.Value, Total: g.Stats.Current, Points: make([]int, len(dates))}
+		if groupBy == "region" {
+			if i := strings.Index(g.Value, "/"); i >= 0 {
+				s.Provider, s.Region = g.Value[:i], g.Value[i+1:]
+			}
+		}
+		for _, p := range g.Stats.DailyTrend {
+			s.Points[index[p.Date]] = p.Count
+		}
+		series = append(series, s)
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dates":      dates,
+		"series":     series,
+		"date_range": dateRange,
+	})
+}
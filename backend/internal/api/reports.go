@@ -0,0 +1,354 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// Mismatch kinds reported by the component reconciliation report
+const (
+	mismatchNoJiraComponent        = "missing_jira_component"
+	mismatchComponentNameNotInJira = "component_name_not_in_jira"
+	mismatchSourceNotInJira        = "source_component_not_in_jira"
+	mismatchNameVsSource           = "component_name_vs_source_component"
+)
+
+// ComponentMismatch compares the three places an alert's component is recorded
+type ComponentMismatch struct {
+	ID              string   `json:"id"`
+	Title           string   `json:"title"`
+	Created         string   `json:"created"`
+	AlertSignature  string   `json:"alert_signature"`
+	JiraComponents  []string `json:"jira_components"`
+	ComponentName   string   `json:"component_name"`
+	SourceComponent string   `json:"source_component"`
+	Mismatches      []string `json:"mismatches"`
+}
+
+// RuleMismatchSummary groups mismatching issues by alert rule, which is what gets fixed
+type RuleMismatchSummary struct {
+	AlertSignature  string   `json:"alert_signature"`
+	Count           int      `json:"count"`
+	JiraComponents  []string `json:"jira_components"`
+	ComponentName   string   `json:"component_name"`
+	SourceComponent string   `json:"source_component"`
+	Mismatches      []string `json:"mismatches"`
+}
+
+// reconcileComponents returns the mismatch kinds for one issue. Empty raw-data labels
+// aren't mismatches, they just carry no information.
+func reconcileComponents(jira []string, componentName, sourceComponent string) []string {
+	inJira := func(name string) bool {
+		for _, c := range jira {
+			if strings.EqualFold(c, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var mismatches []string
+	if len(jira) == 0 {
+		mismatches = append(mismatches, mismatchNoJiraComponent)
+	} else {
+		if componentName != "" && !inJira(componentName) {
+			mismatches = append(mismatches, mismatchComponentNameNotInJira)
+		}
+		if sourceComponent != "" && !inJira(sourceComponent) {
+			mismatches = append(mismatches, mismatchSourceNotInJira)
+		}
+	}
+	if componentName != "" && sourceComponent != "" && !strings.EqualFold(componentName, sourceComponent) {
+		mismatches = append(mismatches, mismatchNameVsSource)
+	}
+	return mismatches
+}
+
+// GetComponentReconciliation compares the JIRA components field, the raw-data component
+// label and source_component per alert and reports disagreements
+func GetComponentReconciliation(c *gin.Context) {
+	dbc := dbFor(c)
+
+	var days int
+	fmt.Sscanf(c.DefaultQuery("days", "30"), "%d", &days)
+	if days <= 0 {
+		days = 30
+	}
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "200"), "%d", &limit)
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	kindFilter := c.Query("mismatch")
+
+	startDate := time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+
+	query := dbc.Model(&models.Issue{}).
+		Select("id, title, created, alert_signature, components, jira_components, component_name, source_component").
+		Where("is_alert = ? AND created_at_utc >= ?", true, startDate)
+	if component := c.Query("component"); component != "" {
+		// Match the component in any of the three places so both sides of a mismatch show up
+		query = query.Where("id IN ("+componentIssueIDs+") OR component_name = ? OR source_component = ?", component, component, component)
+	}
+
+	var issues []models.Issue
+	query.Order("created DESC").Find(&issues)
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	items := []ComponentMismatch{}
+	summary := make(map[string]int)
+	byRule := make(map[string]*RuleMismatchSummary)
+	total := 0
+
+	for _, issue := range issues {
+		jira := jiraComponentsOf(issue)
+		mismatches := reconcileComponents(jira, issue.ComponentName, issue.SourceComponent)
+		if len(mismatches) == 0 {
+			continue
+		}
+		if kindFilter != "" && !containsString(mismatches, kindFilter) {
+			continue
+		}
+
+		total++
+		for _, m := range mismatches {
+			summary[m]++
+		}
+
+		if rule, ok := byRule[issue.AlertSignature]; ok {
+			rule.Count++
+		} else {
+			byRule[issue.AlertSignature] = &RuleMismatchSummary{
+				AlertSignature:  issue.AlertSignature,
+				Count:           1,
+				JiraComponents:  jira,
+				ComponentName:   issue.ComponentName,
+				SourceComponent: issue.SourceComponent,
+				Mismatches:      mismatches,
+			}
+		}
+
+		if len(items) < limit {
+			items = append(items, ComponentMismatch{
+				ID:              issue.ID,
+				Title:           issue.Title,
+				Created:         issue.Created,
+				AlertSignature:  issue.AlertSignature,
+				JiraComponents:  jira,
+				ComponentName:   issue.ComponentName,
+				SourceComponent: issue.SourceComponent,
+				Mismatches:      mismatches,
+			})
+		}
+	}
+
+	rules := make([]RuleMismatchSummary, 0, len(byRule))
+	for _, r := range byRule {
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Count != rules[j].Count {
+			return rules[i].Count > rules[j].Count
+		}
+		return rules[i].AlertSignature < rules[j].AlertSignature
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"checked":    len(issues),
+		"mismatched": total,
+		"summary":    summary,
+		"by_rule":    rules,
+		"items":      items,
+	})
+}
+
+// jiraComponentsOf returns the JIRA components as fetched; issues imported before
+// component attribution only have the components column
+func jiraComponentsOf(issue models.Issue) []string {
+	if issue.JiraComponents != "" {
+		return decodeComponents(issue.JiraComponents)
+	}
+	return decodeComponents(issue.ComponentsJSON)
+}
+
+// AttributionDisagreement is an alert whose attribution strategies pick different components
+type AttributionDisagreement struct {
+	ID             string              `json:"id"`
+	Title          string              `json:"title"`
+	Created        string              `json:"created"`
+	AlertSignature string              `json:"alert_signature"`
+	AttributedBy   string              `json:"attributed_by"`
+	Components     []string            `json:"components"`
+	Candidates     map[string][]string `json:"candidates"`
+	Conflicts      []string            `json:"conflicts"` // "<strategy> vs <strategy>"
+}
+
+// attributionConflicts pairs up the configured strategies whose answers share no component.
+// The fallback is left out since it only applies when nothing else answers.
+func attributionConflicts(candidates map[string][]string, strategies []string) []string {
+	var answered []string
+	for _, s := range strategies {
+		if _, ok := candidates[s]; ok && s != services.AttributeFallback {
+			answered = append(answered, s)
+		}
+	}
+
+	overlap := func(a, b []string) bool {
+		for _, x := range a {
+			for _, y := range b {
+				if strings.EqualFold(x, y) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var conflicts []string
+	for i := 0; i < len(answered); i++ {
+		for j := i + 1; j < len(answered); j++ {
+			if !overlap(candidates[answered[i]], candidates[answered[j]]) {
+				conflicts = append(conflicts, answered[i]+" vs "+answered[j])
+			}
+		}
+	}
+	return conflicts
+}
+
+// GetComponentAttributionReport runs the attribution strategies over stored alerts and
+// reports the ones where they disagree, plus how many alerts each strategy attributed
+func GetComponentAttributionReport(c *gin.Context) {
+	dbc := dbFor(c)
+	pipeline := services.GetAttributionConfig()
+
+	var days int
+	fmt.Sscanf(c.DefaultQuery("days", "30"), "%d", &days)
+	if days <= 0 {
+		days = 30
+	}
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "200"), "%d", &limit)
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	startDate := time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+
+	var issues []models.Issue
+	dbc.Model(&models.Issue{}).
+		Select("id, title, created, alert_signature, components, jira_components, attributed_by, component_name, source_component, alert_group").
+		Where("is_alert = ? AND created_at_utc >= ?", true, startDate).
+		Order("created DESC").
+		Find(&issues)
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	items := []AttributionDisagreement{}
+	byConflict := make(map[string]int)
+	byStrategy := make(map[string]int)
+	byRule := make(map[string]int)
+	total := 0
+
+	for _, issue := range issues {
+		strategy := issue.AttributedBy
+		if strategy == "" && issue.JiraComponents == "" {
+			strategy = "not_recorded" // imported before attribution, re-enrich to fill in
+		} else if strategy == "" {
+			strategy = "unattributed"
+		}
+		byStrategy[strategy]++
+
+		candidates := pipeline.Candidates(services.AttributionInput{
+			JiraComponents:  jiraComponentsOf(issue),
+			ComponentName:   issue.ComponentName,
+			SourceComponent: issue.SourceComponent,
+			AlertGroup:      issue.AlertGroup,
+		})
+		conflicts := attributionConflicts(candidates, pipeline.Strategies)
+		if len(conflicts) == 0 {
+			continue
+		}
+
+		total++
+		byRule[issue.AlertSignature]++
+		for _, cf := range conflicts {
+			byConflict[cf]++
+		}
+		if len(items) < limit {
+			items = append(items, AttributionDisagreement{
+				ID:             issue.ID,
+				Title:          issue.Title,
+				Created:        issue.Created,
+				AlertSignature: issue.AlertSignature,
+				AttributedBy:   issue.AttributedBy,
+				Components:     decodeComponents(issue.ComponentsJSON),
+				Candidates:     candidates,
+				Conflicts:      conflicts,
+			})
+		}
+	}
+
+	type ruleCount struct {
+		AlertSignature string `json:"alert_signature"`
+		Count          int    `json:"count"`
+	}
+	rules := make([]ruleCount, 0, len(byRule))
+	for sig, n := range byRule {
+		rules = append(rules, ruleCount{AlertSignature: sig, Count: n})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Count != rules[j].Count {
+			return rules[i].Count > rules[j].Count
+		}
+		return rules[i].AlertSignature < rules[j].AlertSignature
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"strategies":  pipeline.Strategies,
+		"checked":     len(issues),
+		"disagreeing": total,
+		"by_strategy": byStrategy,
+		"by_conflict": byConflict,
+		"by_rule":     rules,
+		"items":       items,
+	})
+}
+
+// GetUnmappedValuesReport lists stored priorities and statuses that the value
+// normalization table has no canonical form for, so they can be added as aliases
+func GetUnmappedValuesReport(c *gin.Context) {
+	table := services.GetNormalizationConfig()
+	values, err := table.UnmappedValues(dbFor(c))
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byField := map[string]int{"priority": 0, "status": 0}
+	for _, v := range values {
+		byField[v.Field]++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"canonical": gin.H{
+			"priority": table.Priority.Canonical,
+			"status":   table.Status.Canonical,
+		},
+		"unmapped": byField,
+		"items":    values,
+	})
+}
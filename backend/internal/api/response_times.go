@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// ResponseTimes are the mean and median hours from an alert's creation to its
+// acknowledgment (MTTA) and to its resolution (MTTR), over the alerts that got there
+type ResponseTimes struct {
+	Acknowledged int     `json:"acknowledged"`
+	MTTAMean     float64 `json:"mtta_mean_hours"`
+	MTTAMedian   float64 `json:"mtta_median_hours"`
+	Resolved     int     `json:"resolved"`
+	MTTRMean     float64 `json:"mttr_mean_hours"`
+	MTTRMedian   float64 `json:"mttr_median_hours"`
+}
+
+type ComponentResponseTimes struct {
+	Component string `json:"component"`
+	ResponseTimes
+}
+
+type PriorityResponseTimes struct {
+	Priority string `json:"priority"`
+	ResponseTimes
+}
+
+// ResponseTimesBreakdown is the period's response times overall, per component and per
+// priority
+type ResponseTimesBreakdown struct {
+	ResponseTimes
+	ByComponent []ComponentResponseTimes `json:"by_component,omitempty"`
+	ByPriority  []PriorityResponseTimes  `json:"by_priority"`
+}
+
+// responseSamples collects the hours to acknowledgment and resolution of a group
+type responseSamples struct {
+	ack, resolve []float64
+}
+
+func (s *responseSamples) times() ResponseTimes {
+	mean, median := meanMedian(s.ack)
+	rt := ResponseTimes{Acknowledged: len(s.ack), MTTAMean: mean, MTTAMedian: median, Resolved: len(s.resolve)}
+	rt.MTTRMean, rt.MTTRMedian = meanMedian(s.resolve)
+	return rt
+}
+
+func meanMedian(hours []float64) (float64, float64) {
+	if len(hours) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(hours)
+	sum := 0.0
+	for _, h := range hours {
+		sum += h
+	}
+	median := hours[len(hours)/2]
+	if len(hours)%2 == 0 {
+		median = (hours[len(hours)/2-1] + median) / 2
+	}
+	return sum / float64(len(hours)), median
+}
+
+// hoursBetween is the time from created to a lifecycle timestamp, both stored as text;
+// ok is false when either is missing or unreadable
+func hoursBetween(created, at string) (float64, bool) {
+	const layout = "2006-01-02 15:04:05 UTC"
+	if at == "" {
+		return 0, false
+	}
+	from, err := time.Parse(layout, created)
+	if err != nil {
+		return 0, false
+	}
+	to, err := time.Parse(layout, at)
+	if err != nil {
+		return 0, false
+	}
+	return math.Max(to.Sub(from).Hours(), 0), true
+}
+
+// responseTimesBreakdown computes MTTA and MTTR for the alerts created in [start, end).
+// where is the issue condition without the date range, args its placeholders. Alerts
+// count towards each of their components.
+func responseTimesBreakdown(dbc *gorm.DB, where string, args []interface{}, start, end string) (ResponseTimesBreakdown, error) {
+	var rows []struct {
+		Components     string
+		Priority       string
+		Created        string
+		AcknowledgedAt string
+		ResolvedAt     string
+	}
+	err := dbc.Raw(`
+		SELECT components, priority, created,
+			COALESCE(acknowledged_at, '') as acknowledged_at, COALESCE(resolved_at, '') as resolved_at
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+			AND (COALESCE(acknowledged_at, '') != '' OR COALESCE(resolved_at, '') != '')
+	`, append(append([]interface{}{}, args...), start, end)...).Scan(&rows).Error
+	if err != nil {
+		return ResponseTimesBreakdown{}, err
+	}
+
+	var overall responseSamples
+	byComponent := map[string]*responseSamples{}
+	byPriority := map[string]*responseSamples{}
+	group := func(m map[string]*responseSamples, key string) *responseSamples {
+		if m[key] == nil {
+			m[key] = &responseSamples{}
+		}
+		return m[key]
+	}
+	for _, r := range rows {
+		var components []string
+		json.Unmarshal([]byte(r.Components), &components)
+		if len(components) == 0 {
+			components = []string{"No Component"}
+		}
+		groups := []*responseSamples{&overall, group(byPriority, r.Priority)}
+		for _, comp := range components {
+			groups = append(groups, group(byComponent, comp))
+		}
+
+		ack, hasAck := hoursBetween(r.Created, r.AcknowledgedAt)
+		resolve, hasResolve := hoursBetween(r.Created, r.ResolvedAt)
+		for _, g := range groups {
+			if hasAck {
+				g.ack = append(g.ack, ack)
+			}
+			if hasResolve {
+				g.resolve = append(g.resolve, resolve)
+			}
+		}
+	}
+
+	breakdown := ResponseTimesBreakdown{
+		ResponseTimes: overall.times(),
+		ByComponent:   []ComponentResponseTimes{},
+		ByPriority:    []PriorityResponseTimes{},
+	}
+	for comp, s := range byComponent {
+		breakdown.ByComponent = append(breakdown.ByComponent, ComponentResponseTimes{Component: comp, ResponseTimes: s.times()})
+	}
+	sort.Slice(breakdown.ByComponent, func(i, j int) bool {
+		return breakdown.ByComponent[i].Component < breakdown.ByComponent[j].Component
+	})
+
+	// Priorities in their canonical order, unknown ones after
+	rank := func(p string) int {
+		for i, canonical := range services.GetNormalizationConfig().Priority.Canonical {
+			if p == canonical {
+				return i
+			}
+		}
+		return math.MaxInt
+	}
+	for p, s := range byPriority {
+		breakdown.ByPriority = append(breakdown.ByPriority, PriorityResponseTimes{Priority: p, ResponseTimes: s.times()})
+	}
+	sort.Slice(breakdown.ByPriority, func(i, j int) bool {
+		a, b := breakdown.ByPriority[i].Priority, breakdown.ByPriority[j].Priority
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		return a < b
+	})
+	return breakdown, nil
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// GetRetention shows the retention policy, the issues it would archive now, the size of
+// the archive and recent runs. Optional: ?limit=20 runs
+func GetRetention(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "20"), "%d", &limit)
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+
+	cfg := config.Get()
+	dbc := dbFor(c)
+	eligible, err := services.NewRetentionService(dbc, cfg.Retention).Plan(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if eligible == nil {
+		eligible = []models.RetentionGroup{}
+	}
+
+	var archived int64
+	if err := dbc.Model(&models.ArchivedIssue{}).Count(&archived).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var runs []models.RetentionRun
+	if err := dbc.Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  cfg.Retention.Enabled(),
+		"policy":   cfg.Retention,
+		"interval": cfg.RetentionInterval.String(),
+		"eligible": eligible,
+		"archived": archived,
+		"items":    runs,
+	})
+}
+
+// RunRetention archives expired issues now as a background job and returns 202 with the
+// job to poll
+func RunRetention(c *gin.Context) {
+	policy := config.Get().Retention
+	if !policy.Enabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "retention is disabled (RETENTION_DAYS and RETENTION_RULES keep issues forever)"})
+		return
+	}
+
+	// Jobs outlive the request, so they run on the plain DB
+	job, err := services.StartJob(db.DB, JobRetention, nil, retentionJob(policy, "manual", currentActor(c)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditTrigger, "retention", job.ID, nil, gin.H{"policy": policy})
+	c.Header("Location", "/api/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// retentionJob runs the retention pass under its lease lock
+func retentionJob(policy config.RetentionPolicy, trigger, actor string) services.JobFunc {
+	return func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+		var run *models.RetentionRun
+		ran, err := services.RunExclusive(db.DB, services.RetentionLockName, services.RetentionLockTTL, func() error {
+			var runErr error
+			run, runErr = services.NewRetentionService(db.DB, policy).Run(ctx, trigger, actor, progress)
+			return runErr
+		})
+		if err == nil && !ran {
+			err = fmt.Errorf("a retention run is already in progress")
+		}
+		if run == nil {
+			return nil, nil, err
+		}
+		return nil, run, err
+	}
+}
+
+// StartRetentionScheduler archives expired issues every RETENTION_INTERVAL (default 24h)
+// while a retention policy is set. The policy is re-read on each tick, so enabling it
+// only needs a config reload; the lease lock keeps replicas from archiving concurrently.
+func StartRetentionScheduler(database *gorm.DB) {
+	interval := config.Get().RetentionInterval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var intervalMu sync.Mutex
+		config.OnReload(func(cfg *config.Config) {
+			intervalMu.Lock()
+			defer intervalMu.Unlock()
+			if cfg.RetentionInterval != interval {
+				interval = cfg.RetentionInterval
+				ticker.Reset(interval)
+				slog.Info("Retention interval changed", "interval", interval)
+			}
+		})
+
+		slog.Info("Retention scheduler started", "interval", interval)
+
+		for range ticker.C {
+			policy := config.Get().Retention
+			if !policy.Enabled() {
+				continue
+			}
+
+			var run *models.RetentionRun
+			ran, err := services.RunExclusive(database, services.RetentionLockName, services.RetentionLockTTL, func() error {
+				var runErr error
+				run, runErr = services.NewRetentionService(database, policy).Run(context.Background(), "scheduled", "", nil)
+				return runErr
+			})
+			if err != nil {
+				slog.Error("Scheduled retention run failed", "err", err)
+			} else if !ran {
+				slog.Warn("Skipping retention run: another replica holds the retention lock")
+			} else if run.Archived > 0 {
+				slog.Info("Retention run completed", "archived", run.Archived, "groups", len(run.Groups))
+			}
+		}
+	}()
+}
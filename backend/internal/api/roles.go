@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// userRole resolves an OIDC user's role on every request, so assignments apply without
+// logging in again. OIDC_ADMIN_EMAILS win over assignments; if the table can't be read
+// the user gets viewer rather than the default.
+func userRole(cfg config.OIDCConfig, email string) string {
+	email = strings.ToLower(email)
+	if email == "" {
+		return cfg.DefaultRole
+	}
+	if containsString(cfg.AdminEmails, email) {
+		return config.RoleAdmin
+	}
+	var assigned models.UserRole
+	err := db.DB.First(&assigned, "email = ?", email).Error
+	switch {
+	case err == nil:
+		return assigned.Role
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return cfg.DefaultRole
+	}
+	slog.Warn("Failed to look up role", "email", email, "err", err)
+	return config.RoleViewer
+}
+
+// AssignRoleRequest is the body of PUT /admin/roles/:email
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// GetUserRoles lists role assignments, along with the role everyone else gets
+func GetUserRoles(c *gin.Context) {
+	var roles []models.UserRole
+	if err := dbFor(c).Order("email").Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	cfg := config.Get().OIDC
+	c.JSON(http.StatusOK, gin.H{
+		"items":        roles,
+		"default_role": cfg.DefaultRole,
+		"admin_emails": cfg.AdminEmails,
+	})
+}
+
+// AssignUserRole sets the role of a user, by email
+func AssignUserRole(c *gin.Context) {
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, ok := config.ParseRole(req.Role)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be viewer, editor or admin"})
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(c.Param("email")))
+	if !strings.Contains(email, "@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a user is identified by email"})
+		return
+	}
+
+	dbc := dbFor(c)
+	var before *models.UserRole
+	var existing models.UserRole
+	if dbc.First(&existing, "email = ?", email).Error == nil {
+		before = &existing
+	}
+	assigned := models.UserRole{Email: email, Role: role, AssignedBy: currentActor(c)}
+	if err := dbc.Save(&assigned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditUpdate, "user_role", email, before, assigned)
+	logFor(c).Info("User role assigned", "email", email, "role", role, "by", assigned.AssignedBy)
+	resp := gin.H{"item": assigned}
+	if containsString(config.Get().OIDC.AdminEmails, email) {
+		resp["warning"] = email + " is listed in OIDC_ADMIN_EMAILS and stays admin"
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteUserRole drops an assignment; the user falls back to the default role
+func DeleteUserRole(c *gin.Context) {
+	dbc := dbFor(c)
+	var assigned models.UserRole
+	if err := dbc.First(&assigned, "email = ?", strings.ToLower(c.Param("email"))).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no role assigned to " + c.Param("email")})
+		return
+	}
+	if err := dbc.Delete(&assigned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditDelete, "user_role", assigned.Email, assigned, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// rollupFilterParams are the dashboard query parameters that narrow its issues beyond
+// env; the rollups only hold the unfiltered counts
+var rollupFilterParams = []string{"component", "tenant_id", "signature", "cluster_id", "stability_governance",
+	"alert_group", "tier", "region", "provider", "visibility"}
+
+// statsRollupWhere is the dashboard's issue condition without request filters, the one
+// the rollups are taken under
+func statsRollupWhere() string {
+	return "is_alert = TRUE " + buildClusterFilterCondition() + buildMuteRuleCondition() + buildStabilityGovernanceFilterCondition()
+}
+
+// dashboardRollup returns the stats rollup when the request's dashboard can be read from
+// it, nil when it filters issues the rollups don't break down
+func dashboardRollup(c *gin.Context, dbc *gorm.DB) *services.StatsRollup {
+	for _, p := range rollupFilterParams {
+		if c.Query(p) != "" {
+			return nil
+		}
+	}
+	if buildDedupFilterCondition(c.Query("dedup")) != "" {
+		return nil
+	}
+	return services.NewStatsRollup(dbc, statsRollupWhere())
+}
+
+// rollupStatsAfterSync rolls up the days a sync stored alerts for, plus the recent ones.
+// Failures are logged only: dashboards fall back to raw data for days not rolled up.
+func rollupStatsAfterSync(database *gorm.DB, days []string) {
+	var rolled int
+	ran, err := services.RunExclusive(database, services.StatsRollupLockName, services.StatsRollupLockTTL, func() error {
+		var rollErr error
+		rolled, rollErr = services.NewStatsRollup(database, statsRollupWhere()).Update(context.Background(), days)
+		return rollErr
+	})
+	if err != nil {
+		slog.Error("Stats rollup failed", "err", err)
+	} else if !ran {
+		slog.Warn("Skipping stats rollup: another replica holds the rollup lock")
+	} else {
+		slog.Info("Stats rollup completed", "days", rolled)
+	}
+}
+
+// rollupTrend is the dashboard trend from daily_stats; days without alerts are left out
+// like the raw query does
+func rollupTrend(dbc *gorm.DB, hash, env, step, start, end string) ([]DailyTrend, error) {
+	var rows []models.DailyStat
+	if err := dbc.Where("filter_hash = ? AND date BETWEEN ? AND ?", hash, start, end).
+		Order("date ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var trend []DailyTrend
+	index := map[string]int{}
+	for _, r := range rows {
+		point := DailyTrend{TotalAlerts: r.TotalAlerts, CriticalCount: r.CriticalCount, MajorCount: r.MajorCount, WarningCount: r.WarningCount}
+		switch env {
+		case "prod":
+			point = DailyTrend{TotalAlerts: r.ProdAlerts, CriticalCount: r.ProdCritical, MajorCount: r.ProdMajor, WarningCount: r.ProdWarning}
+		case "non_prod":
+			point = DailyTrend{
+				TotalAlerts:   r.TotalAlerts - r.ProdAlerts,
+				CriticalCount: r.CriticalCount - r.ProdCritical,
+				MajorCount:    r.MajorCount - r.ProdMajor,
+				WarningCount:  r.WarningCount - r.ProdWarning,
+			}
+		}
+		if point.TotalAlerts == 0 {
+			continue
+		}
+
+		point.Date = stepKey(r.Date, step)
+		if i, ok := index[point.Date]; ok {
+			trend[i].TotalAlerts += point.TotalAlerts
+			trend[i].CriticalCount += point.CriticalCount
+			trend[i].MajorCount += point.MajorCount
+			trend[i].WarningCount += point.WarningCount
+			continue
+		}
+		index[point.Date] = len(trend)
+		trend = append(trend, point)
+	}
+	return trend, nil
+}
+
+// stepKey buckets a YYYY-MM-DD date by step the way the raw trend queries do: the date,
+// YYYY-MM, or YYYY-WW with Monday-first week numbers (see db.WeekKey)
+func stepKey(date, step string) string {
+	switch step {
+	case "month":
+		return date[:7]
+	case "week":
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return date
+		}
+		monday := (int(t.Weekday()) + 6) % 7
+		return fmt.Sprintf("%d-%02d", t.Year(), (t.YearDay()-1+7-monday)/7)
+	}
+	return date
+}
+
+// rollupTopComponents ranks the top 10 components by the alerts listing them first. The
+// period starts mid-day, so its first day is counted from issues (where is the request's
+// dashboard condition) and the whole days after it from component_stats.
+func rollupTopComponents(dbc *gorm.DB, hash, where string, args []interface{}, env, startDate, endDate string) ([]ComponentCount, error) {
+	var partial []ComponentCount
+	err := dbc.Raw(`
+		SELECT
+			CASE
+				WHEN components IS NULL OR components = '[]' OR components = '' THEN 'No Component'
+				ELSE json_extract(components, '$[0]')
+			END as component,
+			COUNT(*) as count
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY component
+	`, append(append([]interface{}{}, args...), startDate, startDate[:10]+" 23:59:59")...).Scan(&partial).Error
+	if err != nil {
+		return nil, err
+	}
+
+	count := "SUM(primary_count)"
+	switch env {
+	case "prod":
+		count = "SUM(primary_prod_count)"
+	case "non_prod":
+		count = "SUM(primary_count - primary_prod_count)"
+	}
+	var whole []ComponentCount
+	err = dbc.Model(&models.ComponentStat{}).
+		Select("component, "+count+" as count").
+		Where("filter_hash = ? AND date > ? AND date <= ?", hash, startDate[:10], endDate[:10]).
+		Group("component").
+		Scan(&whole).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, c := range append(partial, whole...) {
+		counts[c.Component] += c.Count
+	}
+	components := make([]ComponentCount, 0, len(counts))
+	for name, n := range counts {
+		if n > 0 {
+			components = append(components, ComponentCount{Component: name, Count: n})
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Count != components[j].Count {
+			return components[i].Count > components[j].Count
+		}
+		return components[i].Component < components[j].Component
+	})
+	if len(components) > 10 {
+		components = components[:10]
+	}
+	return components, nil
+}
+
+// StatsRollupStatus describes the rollups under the current dashboard condition
+type StatsRollupStatus struct {
+	FilterHash string     `json:"filter_hash"`
+	Days       int64      `json:"days"`
+	FirstDate  string     `json:"first_date,omitempty"`
+	LastDate   string     `json:"last_date,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	StaleDays  int64      `json:"stale_days"` // rolled up under an earlier condition
+}
+
+// GetStatsRollup shows how far the daily and component rollups reach
+func GetStatsRollup(c *gin.Context) {
+	dbc := dbFor(c)
+	status := StatsRollupStatus{FilterHash: services.StatsFilterHash(statsRollupWhere())}
+
+	var span struct {
+		Days      int64
+		FirstDate string
+		LastDate  string
+	}
+	err := dbc.Model(&models.DailyStat{}).
+		Select("COUNT(*) as days, COALESCE(MIN(date), '') as first_date, COALESCE(MAX(date), '') as last_date").
+		Where("filter_hash = ?", status.FilterHash).Scan(&span).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	status.Days, status.FirstDate, status.LastDate = span.Days, span.FirstDate, span.LastDate
+
+	var latest models.DailyStat
+	if err := dbc.Where("filter_hash = ?", status.FilterHash).Order("updated_at DESC").Limit(1).Find(&latest).Error; err == nil && latest.Date != "" {
+		status.UpdatedAt = &latest.UpdatedAt
+	}
+	dbc.Model(&models.DailyStat{}).Where("filter_hash <> ?", status.FilterHash).Count(&status.StaleDays)
+
+	c.JSON(http.StatusOK, status)
+}
+
+// RebuildStatsRollup rolls up every day again as a background job, e.g. after
+// re-enrichment or component overrides changed older alerts, and returns 202 with the
+// job to poll
+func RebuildStatsRollup(c *gin.Context) {
+	// Jobs outlive the request, so they run on the plain DB
+	job, err := services.StartJob(db.DB, JobStatsRollup, nil, statsRollupJob())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditTrigger, "stats_rollup", job.ID, nil, nil)
+	c.Header("Location", "/api/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, toJobResponse(*job))
+}
+
+// statsRollupJob rebuilds every day's rollup under the stats rollup lease lock
+func statsRollupJob() services.JobFunc {
+	return func(ctx context.Context, progress services.JobProgress) (*services.JobArtifact, interface{}, error) {
+		var days int
+		ran, err := services.RunExclusive(db.DB, services.StatsRollupLockName, services.StatsRollupLockTTL, func() error {
+			var rollErr error
+			days, rollErr = services.NewStatsRollup(db.DB, statsRollupWhere()).Rebuild(ctx)
+			return rollErr
+		})
+		if err == nil && !ran {
+			err = fmt.Errorf("a stats rollup is already running")
+		}
+		return nil, gin.H{"days": days}, err
+	}
+}
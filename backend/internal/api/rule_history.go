@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// RuleRevisionDiff is a rule revision with the unified diff of its YAML
+type RuleRevisionDiff struct {
+	models.RuleRevision
+	Diff string `json:"diff"`
+}
+
+// GetComponentRuleHistory lists the changes made to a component's rules through the API,
+// newest first. Optional: ?alert= (matches the name before or after the edit),
+// ?file_path=, ?task_id=, ?limit=100
+func GetComponentRuleHistory(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "100"), "%d", &limit)
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	query := dbFor(c).Where("component = ?", c.Param("name")).Order("created_at DESC, id DESC").Limit(limit)
+	if v := c.Query("alert"); v != "" {
+		query = query.Where("alert = ? OR previous_alert = ?", v, v)
+	}
+	if v := c.Query("file_path"); v != "" {
+		query = query.Where("file_path = ?", v)
+	}
+	if v := c.Query("task_id"); v != "" {
+		query = query.Where("task_id = ?", v)
+	}
+
+	revisions := []models.RuleRevision{}
+	if err := query.Find(&revisions).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetComponentRuleRevisionDiff returns one revision of a component's rule with the diff
+// between its old and new YAML
+func GetComponentRuleRevisionDiff(c *gin.Context) {
+	var rev models.RuleRevision
+	if err := dbFor(c).First(&rev, "id = ? AND component = ?", c.Param("id"), c.Param("name")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule revision not found"})
+		return
+	}
+	oldName, newName := "a/"+rev.PreviousAlert, "b/"+rev.Alert
+	if rev.OldYAML == "" {
+		oldName = "/dev/null"
+	}
+	if rev.NewYAML == "" {
+		newName = "/dev/null"
+	}
+	c.JSON(http.StatusOK, RuleRevisionDiff{
+		RuleRevision: rev,
+		Diff:         services.UnifiedDiff(oldName, newName, rev.OldYAML, rev.NewYAML),
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// RuleChangeImpact compares a rule's alert volume in equal windows either side of a change.
+// The windows shrink for recent changes so both sides always cover the same time.
+type RuleChangeImpact struct {
+	services.RuleChange
+	WindowHours float64 `json:"window_hours"`
+	Before      int     `json:"before"`
+	After       int     `json:"after"`
+	Change      float64 `json:"change"`
+	Trend       string  `json:"trend"`
+}
+
+// RuleRevisionVolume counts the alerts stamped with one revision of the rule
+type RuleRevisionVolume struct {
+	Revision  string `json:"revision"`
+	Alerts    int    `json:"alerts"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// GetRuleChangeImpact shows whether tuning a rule worked: alert volume before vs after each
+// change to the files defining it, and how many alerts each revision raised.
+// Optional: ?window_days=7 ?limit=20 (changes, newest first)
+func GetRuleChangeImpact(c *gin.Context) {
+	alert := c.Param("alert")
+	windowDays, err := strconv.Atoi(c.DefaultQuery("window_days", "7"))
+	if err != nil || windowDays <= 0 || windowDays > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window_days must be between 1 and 90"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+
+	changes, err := services.RuleChanges(alert)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+
+	dbc := dbFor(c)
+	count := func(from, to time.Time) (int, error) {
+		var n int64
+		err := dbc.Model(&models.Issue{}).
+			Where("rule_name = ? AND created_at_utc >= ? AND created_at_utc < ?",
+				alert, from.Format("2006-01-02 15:04:05"), to.Format("2006-01-02 15:04:05")).
+			Count(&n).Error
+		return int(n), err
+	}
+
+	fail := func(err error) {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	now := time.Now().UTC()
+	impacts := make([]RuleChangeImpact, 0, len(changes))
+	for _, change := range changes {
+		window := time.Duration(windowDays) * 24 * time.Hour
+		if since := now.Sub(change.ChangedAt); since < window {
+			window = since
+		}
+		before, err := count(change.ChangedAt.Add(-window), change.ChangedAt)
+		if err != nil {
+			fail(fmt.Errorf("failed to count alerts before %s: %w", change.Revision, err))
+			return
+		}
+		after, err := count(change.ChangedAt, change.ChangedAt.Add(window))
+		if err != nil {
+			fail(fmt.Errorf("failed to count alerts after %s: %w", change.Revision, err))
+			return
+		}
+		impact := RuleChangeImpact{RuleChange: change, WindowHours: window.Hours(), Before: before, After: after}
+		impact.Change, impact.Trend = calculateChange(after, before)
+		impacts = append(impacts, impact)
+	}
+
+	var revisions []RuleRevisionVolume
+	err = dbc.Model(&models.Issue{}).
+		Select("rule_revision AS revision, COUNT(*) AS alerts, MIN(created) AS first_seen, MAX(created) AS last_seen").
+		Where("rule_name = ?", alert).
+		Group("rule_revision").
+		Order("first_seen DESC").
+		Scan(&revisions).Error
+	if err != nil {
+		fail(err)
+		return
+	}
+	if revisions == nil {
+		revisions = []RuleRevisionVolume{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alert":       alert,
+		"window_days": windowDays,
+		"changes":     impacts,
+		"revisions":   revisions,
+	})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// InstantiateTemplateRequest fills a rule template's parameters
+type InstantiateTemplateRequest struct {
+	Params      map[string]string `json:"params"`
+	Owner       string            `json:"owner"`
+	Description string            `json:"description"`
+	DryRun      bool              `json:"dry_run"`
+}
+
+// GetRuleTemplates lists the available rule templates
+func GetRuleTemplates(c *gin.Context) {
+	templates, err := services.LoadRuleTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetRuleTemplate returns a single rule template
+func GetRuleTemplate(c *gin.Context) {
+	tmpl, err := services.GetRuleTemplate(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if tmpl == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// InstantiateRuleTemplate renders a template and submits it as a rule task (PR).
+// With dry_run the rendered file and diff are returned without creating a task.
+func InstantiateRuleTemplate(c *gin.Context) {
+	var req InstantiateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tmpl, err := services.GetRuleTemplate(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if tmpl == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return
+	}
+
+	path, content, err := tmpl.Render(req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rulesService := services.NewRulesService(config.Get())
+	diff := services.UnifiedDiff("/dev/null", "b/"+path, "", content)
+	if current, err := os.ReadFile(filepath.Join(rulesService.RepoPath, filepath.FromSlash(path))); err == nil {
+		diff = services.UnifiedDiff("a/"+path, "b/"+path, string(current), content)
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{"path": path, "content": content, "diff": diff})
+		return
+	}
+	if diff == "" {
+		c.JSON(http.StatusOK, gin.H{"path": path, "content": content, "message": "rules already up to date"})
+		return
+	}
+
+	component := req.Params["component"]
+	if component == "" {
+		component = tmpl.Name
+	}
+	task := models.Task{
+		RuleName:    fmt.Sprintf("%s (%s)", tmpl.Name, component),
+		RuleContent: content,
+		Type:        "ADD",
+		Component:   component,
+		Owner:       req.Owner,
+		Description: req.Description,
+		Diff:        diff,
+	}
+	taskService := services.NewTaskService(db.DB, rulesService)
+	if err := taskService.CreateTask(c.Request.Context(), &task); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	auditComponent(c, task.Component, services.AuditCreate, "task", fmt.Sprint(task.ID), nil, task)
+
+	c.JSON(http.StatusCreated, gin.H{"path": path, "task": task})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// RuleAuditResponse is a stored audit with its regressions and, on request, the full report
+type RuleAuditResponse struct {
+	models.RuleAudit
+	Regressions []string                  `json:"regressions"`
+	Report      *services.RuleAuditReport `json:"report,omitempty"`
+}
+
+func toRuleAuditResponse(audit models.RuleAudit, withReport bool) RuleAuditResponse {
+	resp := RuleAuditResponse{RuleAudit: audit, Regressions: []string{}}
+	if audit.Regressions != "" {
+		json.Unmarshal([]byte(audit.Regressions), &resp.Regressions)
+	}
+	if withReport && audit.Report != "" {
+		var report services.RuleAuditReport
+		if err := json.Unmarshal([]byte(audit.Report), &report); err == nil {
+			resp.Report = &report
+		}
+	}
+	return resp
+}
+
+// GetRuleAudits lists stored rule audits, newest first.
+// Optional: ?limit=30 ?details=true to include the full reports
+func GetRuleAudits(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "30"), "%d", &limit)
+	if limit <= 0 || limit > 365 {
+		limit = 30
+	}
+	withReport := c.Query("details") == "true"
+
+	audits, err := services.NewRuleAuditService(dbFor(c)).List(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]RuleAuditResponse, 0, len(audits))
+	for _, audit := range audits {
+		items = append(items, toRuleAuditResponse(audit, withReport))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// RunRuleAudit runs the audit now instead of waiting for the scheduler
+func RunRuleAudit(c *gin.Context) {
+	svc := services.NewRuleAuditService(dbFor(c))
+
+	var audit *models.RuleAudit
+	ran, err := services.RunExclusive(db.DB, services.RuleAuditLockName, services.RuleAuditLockTTL, func() error {
+		var runErr error
+		audit, _, runErr = svc.Run(c.Request.Context())
+		return runErr
+	})
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ran {
+		c.JSON(http.StatusConflict, gin.H{"error": "a rule audit is already running"})
+		return
+	}
+	c.JSON(http.StatusOK, toRuleAuditResponse(*audit, true))
+}
+
+// StartRuleAuditScheduler runs the rule audit periodically (RULES_AUDIT_INTERVAL, default 24h).
+// The lease lock keeps replicas from auditing concurrently.
+func StartRuleAuditScheduler(database *gorm.DB) {
+	interval := config.Get().RuleAuditInterval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var intervalMu sync.Mutex
+		config.OnReload(func(cfg *config.Config) {
+			intervalMu.Lock()
+			defer intervalMu.Unlock()
+			if cfg.RuleAuditInterval != interval {
+				interval = cfg.RuleAuditInterval
+				ticker.Reset(interval)
+				slog.Info("Rule audit interval changed", "interval", interval)
+			}
+		})
+
+		slog.Info("Rule audit scheduler started", "interval", interval)
+
+		svc := services.NewRuleAuditService(database)
+		for range ticker.C {
+			var audit *models.RuleAudit
+			ran, err := services.RunExclusive(database, services.RuleAuditLockName, services.RuleAuditLockTTL, func() error {
+				var runErr error
+				audit, _, runErr = svc.Run(context.Background())
+				return runErr
+			})
+			if err != nil {
+				slog.Error("Scheduled rule audit failed", "err", err)
+			} else if !ran {
+				slog.Warn("Skipping rule audit: another replica holds the audit lock")
+			} else {
+				slog.Info("Rule audit completed", "date", audit.Date, "lint", audit.LintCount,
+					"uncovered", audit.UncoveredCount, "drift", audit.DriftCount, "noisy", audit.NoisyCount)
+			}
+		}
+	}()
+}
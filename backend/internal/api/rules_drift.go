@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// GetRulesDrift reports alerts that exist in several category rule sets
+// (premium/dedicated/essential) with diverging expr, for or labels.
+// Optional: ?categories=premium,dedicated ?component= ?ignore_labels=a,b
+func GetRulesDrift(c *gin.Context) {
+	splitList := func(v string) []string {
+		var out []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	svc := services.NewRulesService(config.Get())
+	categories := splitList(c.Query("categories"))
+	for _, category := range categories {
+		if _, ok := svc.CategoryPathsMap[category]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown category: " + category})
+			return
+		}
+	}
+
+	drifts := svc.DetectDrift(categories, c.Query("component"), splitList(c.Query("ignore_labels")))
+	if drifts == nil {
+		drifts = []services.RuleDrift{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":  len(drifts),
+		"drifts": drifts,
+	})
+}
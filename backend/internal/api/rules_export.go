@@ -0,0 +1,50 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// ExportRules packages the rule files matching ?component= and ?category= into a tar.gz.
+// ?normalize=true keeps only the matching rules and re-encodes the YAML.
+func ExportRules(c *gin.Context) {
+	component := c.Query("component")
+	category := c.Query("category")
+	normalize := c.Query("normalize") == "true"
+
+	if vc, ok := getVirtualComponent(component); ok && vc.RulesComponent != "" {
+		component = vc.RulesComponent
+	}
+
+	// Build in memory so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	count, err := services.NewRulesService(config.Get()).ExportRules(&buf, component, category, normalize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no rules matched"})
+		return
+	}
+
+	parts := []string{"rules"}
+	for _, p := range []string{c.Query("component"), category} {
+		if p != "" {
+			parts = append(parts, strings.NewReplacer("/", "-", "*", "all", " ", "-").Replace(p))
+		}
+	}
+	parts = append(parts, time.Now().UTC().Format("20060102"))
+	filename := strings.Join(parts, "-") + ".tar.gz"
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("X-Rule-Files", fmt.Sprintf("%d", count))
+	c.Data(http.StatusOK, "application/gzip", buf.Bytes())
+}
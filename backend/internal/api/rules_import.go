@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// ImportRules validates a rules bundle (tar.gz, as the request body or a "file" upload)
+// and returns a dry-run diff against the repo. With ?dry_run=false a valid bundle is
+// submitted as an IMPORT task that goes through the usual PR review.
+func ImportRules(c *gin.Context) {
+	dryRun := c.DefaultQuery("dry_run", "true") != "false"
+	component := c.DefaultQuery("component", "rules-import")
+
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	rulesService := services.NewRulesService(config.Get())
+	plan, err := rulesService.PlanImport(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "plan": plan})
+		return
+	}
+	if !plan.Valid {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "bundle failed validation", "plan": plan})
+		return
+	}
+	if plan.Changed == 0 {
+		c.JSON(http.StatusOK, gin.H{"dry_run": false, "plan": plan, "message": "nothing to change"})
+		return
+	}
+
+	contents, _ := json.Marshal(plan.Contents())
+	task := models.Task{
+		RuleName:    fmt.Sprintf("bundle import (%d files)", plan.Changed),
+		RuleContent: string(contents),
+		Type:        "IMPORT",
+		Component:   component,
+		Owner:       c.Query("owner"),
+		Description: c.Query("description"),
+		Diff:        plan.Diff(),
+	}
+	taskService := services.NewTaskService(db.DB, rulesService)
+	if err := taskService.CreateTask(c.Request.Context(), &task); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	auditComponent(c, task.Component, services.AuditCreate, "task", fmt.Sprint(task.ID), nil, task)
+
+	c.JSON(http.StatusCreated, gin.H{"dry_run": false, "plan": plan, "task": task})
+}
@@ -25,10 +25,24 @@ func UpdateRulesNotifyConfig(c *gin.Context) {
 	}
 
 	service := services.GetRulesNotifyManager()
+	before, _ := service.GetRules()
 	if err := service.UpdateRules(config); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	audit(c, services.AuditUpdate, "rules_notify", "", before, config)
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Rules notify config updated successfully"})
 }
+
+// EvaluateRulesNotifyConfig reports whether the saved config lets an alert through.
+// Query: biz_type, tenant_id, cluster_id
+func EvaluateRulesNotifyConfig(c *gin.Context) {
+	rules, err := services.GetRulesNotifyManager().GetRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reason := rules.Suppression(c.Query("biz_type"), c.Query("tenant_id"), c.Query("cluster_id"))
+	c.JSON(http.StatusOK, gin.H{"notified": reason == "", "reason": reason})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+const ownerUnowned = "unowned"
+
+// OwnerSummary aggregates rules and their recent alert volume per owning team
+type OwnerSummary struct {
+	Owner      string   `json:"owner"`
+	RuleCount  int      `json:"rule_count"`
+	AlertCount int64    `json:"alert_count"`
+	NoisyRules []string `json:"noisy_rules"`
+	Categories []string `json:"categories"`
+}
+
+// GetRulesLint lists rules failing validation (bad expr, missing required or owner labels)
+func GetRulesLint(c *gin.Context) {
+	issues := services.NewRulesService(config.Get()).LintRules()
+	c.JSON(http.StatusOK, gin.H{
+		"count":  len(issues),
+		"issues": issues,
+	})
+}
+
+// GetRulesOwners summarizes rule counts and recent alert noise per owning team.
+// Optional: ?days=7 window for alert counts
+func GetRulesOwners(c *gin.Context) {
+	dbc := dbFor(c)
+
+	var days int
+	fmt.Sscanf(c.DefaultQuery("days", "7"), "%d", &days)
+	if days <= 0 {
+		days = 7
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+
+	rules := services.NewRulesService(config.Get()).AllRules()
+	alertNames := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		alertNames = append(alertNames, rule.Alert)
+	}
+	noise, err := services.RuleNoise(dbc, alertNames, since)
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byOwner := make(map[string]*OwnerSummary)
+	ruleNoise := make(map[string]map[string]int64)
+	for _, rule := range rules {
+		owner := services.RuleOwner(rule)
+		if owner == "" {
+			owner = ownerUnowned
+		}
+		summary, ok := byOwner[owner]
+		if !ok {
+			summary = &OwnerSummary{Owner: owner, NoisyRules: []string{}, Categories: []string{}}
+			byOwner[owner] = summary
+			ruleNoise[owner] = make(map[string]int64)
+		}
+		summary.RuleCount++
+		if rule.Category != "" && !containsString(summary.Categories, rule.Category) {
+			summary.Categories = append(summary.Categories, rule.Category)
+		}
+		// Alerts shared by several rule sets are counted once per owner
+		if _, counted := ruleNoise[owner][rule.Alert]; !counted {
+			ruleNoise[owner][rule.Alert] = noise[rule.Alert]
+			summary.AlertCount += noise[rule.Alert]
+		}
+	}
+
+	owners := make([]OwnerSummary, 0, len(byOwner))
+	for owner, summary := range byOwner {
+		var noisy []string
+		for alert, count := range ruleNoise[owner] {
+			if count > 0 {
+				noisy = append(noisy, alert)
+			}
+		}
+		counts := ruleNoise[owner]
+		sort.Slice(noisy, func(i, j int) bool {
+			if counts[noisy[i]] != counts[noisy[j]] {
+				return counts[noisy[i]] > counts[noisy[j]]
+			}
+			return noisy[i] < noisy[j]
+		})
+		if len(noisy) > 5 {
+			noisy = noisy[:5]
+		}
+		if noisy != nil {
+			summary.NoisyRules = noisy
+		}
+		sort.Strings(summary.Categories)
+		owners = append(owners, *summary)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].AlertCount != owners[j].AlertCount {
+			return owners[i].AlertCount > owners[j].AlertCount
+		}
+		return owners[i].Owner < owners[j].Owner
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":   days,
+		"owners": owners,
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// SearchRules searches alert rules across all configured rule directories, for when the
+// owning component isn't known. ?q= matches alert names, expressions, labels and
+// annotations; ?severity= and ?label= (comma separated name or name=value, all required)
+// narrow it. ?limit= caps the matches returned (default 100, max 1000); count is the total.
+func SearchRules(c *gin.Context) {
+	query := services.RuleSearchQuery{
+		Text:     c.Query("q"),
+		Severity: strings.TrimSpace(c.Query("severity")),
+	}
+	for _, l := range strings.Split(c.Query("label"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			query.Labels = append(query.Labels, l)
+		}
+	}
+	if strings.TrimSpace(query.Text) == "" && query.Severity == "" && len(query.Labels) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "set at least one of q, severity or label"})
+		return
+	}
+
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "100"), "%d", &limit)
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	matches := services.NewRulesService(config.Get()).SearchRules(query)
+	count := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"count": count,
+		"rules": matches,
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// savedViewFilters are the query parameters a saved view may carry: the dashboard filters
+// a share link takes plus those of the issue list
+var savedViewFilters = append(append([]string{}, shareableFilters...),
+	"category", "metric_type", "priority", "label", "q", "sort")
+
+// SavedViewRequest creates or replaces a saved view. Filters maps query parameters
+// (days, env, component, tenant_id, priority, category, ...) to their values.
+type SavedViewRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	Description string            `json:"description"`
+	Filters     map[string]string `json:"filters"`
+}
+
+// SavedViewResponse is a saved view with its filters encoded as the query string to
+// pass to /dashboard, /dashboard/issues and the other dashboard endpoints
+type SavedViewResponse struct {
+	models.SavedView
+	Query string `json:"query"`
+}
+
+func toSavedViewResponse(view models.SavedView) SavedViewResponse {
+	query := url.Values{}
+	for k, v := range view.Filters {
+		query.Set(k, v)
+	}
+	return SavedViewResponse{SavedView: view, Query: query.Encode()}
+}
+
+// savedViewFromRequest validates a request into a view, dropping empty filters
+func savedViewFromRequest(req SavedViewRequest) (models.SavedView, error) {
+	view := models.SavedView{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Filters:     map[string]string{},
+	}
+	if view.Name == "" {
+		return view, fmt.Errorf("name is required")
+	}
+	for k, v := range req.Filters {
+		if !containsString(savedViewFilters, k) {
+			return view, fmt.Errorf("unsupported filter: %s", k)
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			view.Filters[k] = v
+		}
+	}
+	if days, ok := view.Filters["days"]; ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err != nil || n <= 0 {
+			return view, fmt.Errorf("days must be a positive number")
+		}
+	}
+	return view, nil
+}
+
+// savedViewNameTaken reports whether another view already has the name
+func savedViewNameTaken(c *gin.Context, name string, id uint) (bool, error) {
+	var count int64
+	err := dbFor(c).Model(&models.SavedView{}).Where("name = ? AND id != ?", name, id).Count(&count).Error
+	return count > 0, err
+}
+
+// GetSavedViews lists saved views by name
+func GetSavedViews(c *gin.Context) {
+	views := []models.SavedView{}
+	if err := dbFor(c).Order("name").Find(&views).Error; err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]SavedViewResponse, len(views))
+	for i, v := range views {
+		items[i] = toSavedViewResponse(v)
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetSavedView returns one saved view; its ID is the stable link to share
+func GetSavedView(c *gin.Context) {
+	var view models.SavedView
+	if err := dbFor(c).First(&view, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved view not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toSavedViewResponse(view))
+}
+
+// CreateSavedView saves a named filter combination; names are unique
+func CreateSavedView(c *gin.Context) {
+	var req SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	view, err := savedViewFromRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	taken, err := savedViewNameTaken(c, view.Name, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "a saved view named " + view.Name + " already exists"})
+		return
+	}
+	view.Actor = currentActor(c)
+	if err := dbFor(c).Create(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditCreate, "saved_view", fmt.Sprint(view.ID), nil, view)
+	c.JSON(http.StatusCreated, toSavedViewResponse(view))
+}
+
+// UpdateSavedView replaces a saved view's name, description and filters; its ID, and
+// so links to it, stay the same
+func UpdateSavedView(c *gin.Context) {
+	var req SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update, err := savedViewFromRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dbc := dbFor(c)
+	var view models.SavedView
+	if err := dbc.First(&view, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved view not found"})
+		return
+	}
+	taken, err := savedViewNameTaken(c, update.Name, view.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "a saved view named " + update.Name + " already exists"})
+		return
+	}
+	before := view
+	view.Name, view.Description, view.Filters = update.Name, update.Description, update.Filters
+	view.Actor = currentActor(c)
+	if err := dbc.Save(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditUpdate, "saved_view", fmt.Sprint(view.ID), before, view)
+	c.JSON(http.StatusOK, toSavedViewResponse(view))
+}
+
+// DeleteSavedView removes a saved view
+func DeleteSavedView(c *gin.Context) {
+	dbc := dbFor(c)
+	var view models.SavedView
+	if err := dbc.First(&view, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved view not found"})
+		return
+	}
+	if err := dbc.Delete(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditDelete, "saved_view", fmt.Sprint(view.ID), view, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
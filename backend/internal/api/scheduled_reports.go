@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// reportCheckInterval is how often the scheduler checks whether the last finished week
+// and month have a report
+const reportCheckInterval = time.Hour
+
+// ReportResponse is a stored report with its figures and Markdown
+type ReportResponse struct {
+	models.Report
+	Summary  json.RawMessage `json:"summary,omitempty"`
+	Markdown string          `json:"markdown,omitempty"`
+}
+
+// scheduledReportPeriods reads REPORT_PERIODS (comma-separated, default weekly,monthly);
+// "none" turns scheduled reports off
+func scheduledReportPeriods() []string {
+	v := strings.TrimSpace(config.Lookup("REPORT_PERIODS"))
+	if v == "" {
+		return services.ReportPeriods
+	}
+	var periods []string
+	for _, p := range strings.Split(v, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if containsString(services.ReportPeriods, p) && !containsString(periods, p) {
+			periods = append(periods, p)
+		}
+	}
+	return periods
+}
+
+// GetReports lists generated reports, newest first. Optional: ?period=weekly|monthly ?limit=20
+func GetReports(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "20"), "%d", &limit)
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+
+	query := dbFor(c).Omit("summary", "markdown", "html").Order("period_start DESC, period").Limit(limit)
+	if period := c.Query("period"); period != "" {
+		if !containsString(services.ReportPeriods, period) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period must be one of " + strings.Join(services.ReportPeriods, ", ")})
+			return
+		}
+		query = query.Where("period = ?", period)
+	}
+
+	reports := []models.Report{}
+	if err := query.Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": reports})
+}
+
+// GetReport returns one report as JSON (figures and Markdown), or rendered with
+// ?format=markdown or ?format=html
+func GetReport(c *gin.Context) {
+	var report models.Report
+	if err := dbFor(c).First(&report, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, ReportResponse{Report: report, Summary: json.RawMessage(report.Summary), Markdown: report.Markdown})
+	case "markdown":
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(report.Markdown))
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(report.HTML))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, markdown or html"})
+	}
+}
+
+// RunReportRequest picks the period and a date in it (default: the last finished one)
+type RunReportRequest struct {
+	Period string `json:"period" binding:"required"`
+	Date   string `json:"date"`
+}
+
+// RunReport generates (or regenerates) a report now
+func RunReport(c *gin.Context) {
+	var req RunReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, err := services.LastCompletePeriod(req.Period, time.Now())
+	if err == nil && req.Date != "" {
+		day, parseErr := time.Parse("2006-01-02", req.Date)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, expected YYYY-MM-DD"})
+			return
+		}
+		start, err = services.ReportPeriodStart(req.Period, day)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var report *models.Report
+	ran, err := services.RunExclusive(db.DB, services.ReportLockName, services.ReportLockTTL, func() error {
+		var runErr error
+		report, runErr = services.NewReportService(dbFor(c)).Generate(c.Request.Context(), req.Period, start)
+		return runErr
+	})
+	if requestTimedOut(c) {
+		return
+	}
+	if !ran && err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "a report is already being generated"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditTrigger, "report", fmt.Sprint(report.ID), nil, gin.H{"period": report.Period, "period_start": report.PeriodStart})
+	c.JSON(http.StatusOK, ReportResponse{Report: *report, Summary: json.RawMessage(report.Summary), Markdown: report.Markdown})
+}
+
+// StartReportScheduler generates the report of each finished week and month once, for
+// the periods in REPORT_PERIODS
+func StartReportScheduler(database *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(reportCheckInterval)
+		defer ticker.Stop()
+
+		slog.Info("Report scheduler started", "check_interval", reportCheckInterval)
+
+		svc := services.NewReportService(database)
+		var notifier *services.Notifier
+		if sqlDB, err := database.DB(); err == nil {
+			notifier = services.NewNotifier(sqlDB)
+		}
+		for range ticker.C {
+			periods := scheduledReportPeriods()
+			if len(periods) == 0 {
+				continue
+			}
+			var generated []models.Report
+			_, err := services.RunExclusive(database, services.ReportLockName, services.ReportLockTTL, func() error {
+				var runErr error
+				generated, runErr = svc.GenerateDue(context.Background(), periods, time.Now())
+				return runErr
+			})
+			for _, r := range generated {
+				slog.Info("Scheduled report generated", "period", r.Period, "period_start", r.PeriodStart, "alerts", r.Alerts)
+				var summary services.ReportSummary
+				if notifier != nil && json.Unmarshal([]byte(r.Summary), &summary) == nil {
+					notifier.NotifyReport(summary)
+				}
+			}
+			if err != nil {
+				slog.Error("Scheduled report failed", "err", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// applyIssueSearch narrows an issue query by ?q= (full text over title, signature and
+// description) and ?label= (comma separated, all required). With a search index
+// configured the match runs there and only the matching IDs reach SQL; if the index
+// fails the query falls back to LIKE matching.
+func applyIssueSearch(c *gin.Context, query *gorm.DB, startDate, endDate string) *gorm.DB {
+	text := strings.TrimSpace(c.Query("q"))
+	var labels []string
+	for _, l := range strings.Split(c.Query("label"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	if text == "" && len(labels) == 0 {
+		return query
+	}
+
+	if idx := services.GetSearchIndex(); idx != nil {
+		ids, err := idx.Search(c.Request.Context(), services.SearchQuery{Text: text, Labels: labels, Start: startDate, End: endDate})
+		if err == nil {
+			if len(ids) == services.SearchMaxHits {
+				c.Header("X-Search-Truncated", "true")
+			}
+			return query.Where("issues.id IN ?", append(ids, ""))
+		}
+		logFor(c).Warn("Search index query failed, falling back to SQL", "err", err)
+	}
+
+	for _, word := range strings.Fields(text) {
+		pattern := "%" + word + "%"
+		query = query.Where("(issues.title LIKE ? OR issues.alert_signature LIKE ? OR issues.description LIKE ?)", pattern, pattern, pattern)
+	}
+	for _, l := range labels {
+		query = query.Where("issues.labels LIKE ?", `%"`+l+`"%`)
+	}
+	return query
+}
+
+var (
+	searchReindexMu     sync.Mutex
+	searchReindexStatus gin.H
+)
+
+func setSearchReindexStatus(status gin.H) {
+	searchReindexMu.Lock()
+	defer searchReindexMu.Unlock()
+	searchReindexStatus = status
+}
+
+// GetSearchIndexStatus reports whether a search index is configured and how far it
+// trails the issues table
+func GetSearchIndexStatus(c *gin.Context) {
+	idx := services.GetSearchIndex()
+	if idx == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	var issues int64
+	dbFor(c).Model(&models.Issue{}).Count(&issues)
+	resp := gin.H{"enabled": true, "index": idx.Name(), "issues": issues}
+
+	documents, err := idx.Count(c.Request.Context())
+	if err != nil {
+		resp["error"] = err.Error()
+	} else {
+		resp["documents"] = documents
+	}
+
+	searchReindexMu.Lock()
+	if searchReindexStatus != nil {
+		resp["reindex"] = searchReindexStatus
+	}
+	searchReindexMu.Unlock()
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReindexSearch rebuilds the search index from the issues table in the background
+func ReindexSearch(c *gin.Context) {
+	idx := services.GetSearchIndex()
+	if idx == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "search index is disabled (SEARCH_URL is not set)"})
+		return
+	}
+
+	searchReindexMu.Lock()
+	if searchReindexStatus != nil && searchReindexStatus["status"] == "running" {
+		current := searchReindexStatus
+		searchReindexMu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "reindex already in progress", "reindex": current})
+		return
+	}
+	searchReindexStatus = gin.H{"status": "running", "indexed": 0, "started_at": time.Now().UTC()}
+	searchReindexMu.Unlock()
+
+	go runSearchReindex(db.DB, idx)
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "message": "reindex started"})
+}
+
+func runSearchReindex(database *gorm.DB, idx *services.SearchIndex) {
+	started := time.Now().UTC()
+	sqlDB, err := database.DB()
+	if err != nil {
+		setSearchReindexStatus(gin.H{"status": "failed", "error": err.Error(), "started_at": started})
+		return
+	}
+
+	var indexed int
+	ran, err := services.RunExclusive(database, services.SearchReindexLockName, services.SearchReindexLockTTL, func() error {
+		var runErr error
+		indexed, runErr = idx.Reindex(context.Background(), sqlDB, func(n int) {
+			setSearchReindexStatus(gin.H{"status": "running", "indexed": n, "started_at": started})
+		})
+		return runErr
+	})
+
+	status := gin.H{"status": "completed", "indexed": indexed, "started_at": started, "finished_at": time.Now().UTC()}
+	if err != nil {
+		slog.Error("Search reindex failed", "err", err)
+		status["status"], status["error"] = "failed", err.Error()
+	} else if !ran {
+		status["status"], status["error"] = "skipped", "another replica is reindexing"
+	}
+	setSearchReindexStatus(status)
+}
+
+// StartSearchIndexer creates the search index on startup and backfills it when new.
+// Issues are mirrored as they are imported afterwards.
+func StartSearchIndexer(database *gorm.DB) {
+	idx := services.GetSearchIndex()
+	if idx == nil {
+		return
+	}
+	go func() {
+		created, err := idx.EnsureIndex(context.Background())
+		if err != nil {
+			slog.Warn("Search index unavailable, searches use SQL", "index", idx.Name(), "err", err)
+			return
+		}
+		slog.Info("Search index enabled", "index", idx.Name())
+		if created {
+			setSearchReindexStatus(gin.H{"status": "running", "indexed": 0, "started_at": time.Now().UTC()})
+			runSearchReindex(database, idx)
+		}
+	}()
+}
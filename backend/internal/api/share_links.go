@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+const (
+	defaultShareTTL = 7 * 24 * time.Hour
+	maxShareTTL     = 30 * 24 * time.Hour
+)
+
+// shareableFilters are the dashboard query parameters a view link may carry
+var shareableFilters = []string{
+	"days", "start", "end", "env", "step", "component", "tenant_id", "signature", "cluster_id",
+	"stability_governance", "alert_group", "tier", "region", "provider", "visibility", "dedup",
+}
+
+// CreateShareLinkRequest selects either a snapshot or a filtered dashboard view
+type CreateShareLinkRequest struct {
+	SnapshotID uint              `json:"snapshot_id"`
+	Filters    map[string]string `json:"filters"`
+	ExpiresIn  string            `json:"expires_in"` // Go duration, default 168h, max 720h
+	Anonymize  bool              `json:"anonymize"`  // pseudonymize tenants and clusters in the shared view
+}
+
+// CreateShareLink issues a signed, expiring token granting read-only access to a
+// snapshot or a filtered dashboard view
+func CreateShareLink(c *gin.Context) {
+	var req CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl := defaultShareTTL
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_in, expected a positive duration such as 72h"})
+			return
+		}
+		if d > maxShareTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must not exceed " + maxShareTTL.String()})
+			return
+		}
+		ttl = d
+	}
+
+	var scope services.ShareScope
+	if req.SnapshotID != 0 {
+		if len(req.Filters) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "specify either snapshot_id or filters, not both"})
+			return
+		}
+		var count int64
+		dbFor(c).Model(&models.DashboardSnapshot{}).Where("id = ?", req.SnapshotID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "snapshot not found"})
+			return
+		}
+		scope = services.ShareScope{Kind: services.ShareKindSnapshot, SnapshotID: req.SnapshotID}
+	} else {
+		query := url.Values{}
+		for k, v := range req.Filters {
+			if !containsString(shareableFilters, k) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported filter: " + k})
+				return
+			}
+			// The token payload is readable, so it must not carry the identities it hides
+			if req.Anonymize && v != "" && containsString(pseudonymFilters, k) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": k + " cannot be used in an anonymized share link"})
+				return
+			}
+			if v != "" {
+				query.Set(k, v)
+			}
+		}
+		scope = services.ShareScope{Kind: services.ShareKindView, Query: query.Encode()}
+	}
+	scope.Anonymize = req.Anonymize
+
+	token, expires, err := services.NewShareToken(scope, ttl)
+	if err != nil {
+		if errors.Is(err, services.ErrShareLinksDisabled) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      token,
+		"path":       "/api/shared/" + token,
+		"kind":       scope.Kind,
+		"anonymize":  scope.Anonymize,
+		"expires_at": expires,
+	})
+}
+
+// GetSharedView serves the snapshot or dashboard view a share token grants access to
+func GetSharedView(c *gin.Context) {
+	scope, expires, err := services.ParseShareToken(c.Param("token"))
+	switch {
+	case errors.Is(err, services.ErrShareLinksDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrShareTokenExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch scope.Kind {
+	case services.ShareKindSnapshot:
+		var snapshot models.DashboardSnapshot
+		if err := dbFor(c).First(&snapshot, "id = ?", scope.SnapshotID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "snapshot not found"})
+			return
+		}
+		sharedJSON(c, scope, gin.H{
+			"kind":       scope.Kind,
+			"expires_at": expires,
+			"snapshot":   toSnapshotResponse(snapshot, true),
+		})
+
+	case services.ShareKindView:
+		// The token's filters replace whatever the caller put in the query string
+		c.Request.URL.RawQuery = scope.Query
+		if _, ok := bindPeriod(c); !ok {
+			return
+		}
+		raw, err := dashboardJSON(c)
+		if err != nil {
+			if requestTimedOut(c) {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filters := map[string]string{}
+		for k := range c.Request.URL.Query() {
+			filters[k] = c.Query(k)
+		}
+		sharedJSON(c, scope, gin.H{
+			"kind":       scope.Kind,
+			"expires_at": expires,
+			"filters":    filters,
+			"data":       json.RawMessage(raw),
+		})
+
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrInvalidShareToken.Error()})
+	}
+}
+
+// sharedJSON writes a shared view, pseudonymized if the link asks for it (with ANONYMIZE
+// on, the middleware already does)
+func sharedJSON(c *gin.Context, scope services.ShareScope, body gin.H) {
+	if !scope.Anonymize || config.Get().Anonymize {
+		c.JSON(http.StatusOK, body)
+		return
+	}
+	raw, err := json.Marshal(body)
+	if err == nil {
+		raw, err = services.AnonymizeJSON(raw)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to anonymize response"})
+		return
+	}
+	c.Header("X-Anonymized", "true")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", raw)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// createMuteSilence creates the Alertmanager silence behind a mute, lasting until the
+// mute expires. On failure it writes the error response and returns false.
+func createMuteSilence(c *gin.Context, matchers []services.SilenceMatcher, expiresAt *time.Time, comment string) (string, bool) {
+	am, err := services.NewAlertmanagerClient()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return "", false
+	}
+	createdBy := currentActor(c)
+	if createdBy == "" {
+		createdBy = "alerts-dashboard"
+	}
+	now := time.Now().UTC()
+	id, err := am.CreateSilence(c.Request.Context(), services.Silence{
+		Matchers:  matchers,
+		StartsAt:  now,
+		EndsAt:    services.SilenceEnd(expiresAt, now),
+		CreatedBy: createdBy,
+		Comment:   comment,
+	})
+	if err != nil {
+		if requestTimedOut(c) {
+			return "", false
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to create Alertmanager silence: " + err.Error()})
+		return "", false
+	}
+	return id, true
+}
+
+// expireMuteSilence ends the silence behind a mute that was lifted or replaced. The mute
+// itself is already gone, so a failure is only logged; the silence still ends on its own.
+func expireMuteSilence(ctx context.Context, id string) {
+	if id == "" {
+		return
+	}
+	am, err := services.NewAlertmanagerClient()
+	if err == nil {
+		err = am.ExpireSilence(ctx, id)
+	}
+	if err != nil {
+		slog.Warn("Failed to expire Alertmanager silence", "silence_id", id, "err", err)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// SimulateRequest describes the proposed policy. Omitted parts keep the current behavior:
+// the stored notification rules, no auto-mute rules and the notify config not enforced.
+type SimulateRequest struct {
+	Days int `json:"days"`
+	// NotifyConfig is enforced as proposed; EnforceNotifyConfig enforces the saved one instead
+	NotifyConfig        *services.RulesNotifyConfig `json:"notify_config"`
+	EnforceNotifyConfig bool                        `json:"enforce_notify_config"`
+	// NotificationRules replaces the stored rules (enabled defaults to true)
+	NotificationRules []NotificationRuleRequest `json:"notification_rules"`
+	MuteRules         []services.MuteRule       `json:"mute_rules"`
+}
+
+// SimulateNotifications replays the last N days (default 7, max 90) of ingested alerts
+// through the proposed notify config, notification rules and auto-mute rules, and reports
+// how many notifications and suppressions would have changed. Nothing is saved.
+func SimulateNotifications(c *gin.Context) {
+	var req SimulateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Days <= 0 {
+		req.Days = 7
+	}
+	if req.Days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be at most 90"})
+		return
+	}
+
+	dbc := dbFor(c)
+	var stored []models.NotificationRule
+	if err := dbc.Order("id").Find(&stored).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	current := &services.NotificationPolicy{NotificationRules: stored}
+	proposed := &services.NotificationPolicy{NotificationRules: stored, MuteRules: req.MuteRules}
+
+	problems := services.ValidateMuteRules(req.MuteRules)
+	if req.NotificationRules != nil {
+		proposed.NotificationRules = make([]models.NotificationRule, 0, len(req.NotificationRules))
+		for _, r := range req.NotificationRules {
+			var rule models.NotificationRule
+			r.apply(&rule)
+			for _, p := range services.ValidateNotificationRule(&rule) {
+				problems = append(problems, "notification rule '"+rule.Name+"': "+p)
+			}
+			proposed.NotificationRules = append(proposed.NotificationRules, rule)
+		}
+	}
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid simulation request", "problems": problems})
+		return
+	}
+
+	switch {
+	case req.NotifyConfig != nil:
+		proposed.NotifyConfig = req.NotifyConfig
+	case req.EnforceNotifyConfig:
+		saved, err := services.GetRulesNotifyManager().GetRules()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		proposed.NotifyConfig = saved
+	}
+
+	result, err := services.SimulateNotifications(c.Request.Context(), dbc, req.Days, current, proposed)
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// CreateSnapshotRequest names a snapshot; the dashboard filters come from the query string
+type CreateSnapshotRequest struct {
+	Name string `json:"name"`
+	Note string `json:"note"`
+}
+
+// DashboardSnapshotResponse is a snapshot with decoded filters and, when fetched by id, its data
+type DashboardSnapshotResponse struct {
+	models.DashboardSnapshot
+	Filters map[string]string `json:"filters"`
+	Data    json.RawMessage   `json:"data,omitempty"`
+}
+
+func toSnapshotResponse(snapshot models.DashboardSnapshot, withData bool) DashboardSnapshotResponse {
+	resp := DashboardSnapshotResponse{DashboardSnapshot: snapshot, Filters: map[string]string{}}
+	if values, err := url.ParseQuery(snapshot.Filters); err == nil {
+		for k := range values {
+			resp.Filters[k] = values.Get(k)
+		}
+	}
+	if withData {
+		resp.Data = json.RawMessage(snapshot.Data)
+	}
+	return resp
+}
+
+// CreateDashboardSnapshot persists the dashboard as GET /api/dashboard would return it for
+// the same query string (days, env, component, tenant_id, ...)
+func CreateDashboardSnapshot(c *gin.Context) {
+	var req CreateSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, ok := bindPeriod(c); !ok {
+		return
+	}
+
+	raw, err := dashboardJSON(c)
+	if err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "Snapshot " + time.Now().UTC().Format("2006-01-02 15:04")
+	}
+	snapshot := models.DashboardSnapshot{
+		Name:    name,
+		Note:    req.Note,
+		Filters: c.Request.URL.Query().Encode(),
+		Data:    string(raw),
+	}
+	if err := dbFor(c).Create(&snapshot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toSnapshotResponse(snapshot, false))
+}
+
+// GetDashboardSnapshots lists snapshots newest first, without their data. Optional: ?limit=50
+func GetDashboardSnapshots(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "50"), "%d", &limit)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	var snapshots []models.DashboardSnapshot
+	err := dbFor(c).Select("id, name, note, filters, created_at").
+		Order("created_at DESC").Limit(limit).Find(&snapshots).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]DashboardSnapshotResponse, 0, len(snapshots))
+	for _, s := range snapshots {
+		items = append(items, toSnapshotResponse(s, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetDashboardSnapshot returns one snapshot including the stored dashboard data
+func GetDashboardSnapshot(c *gin.Context) {
+	var snapshot models.DashboardSnapshot
+	if err := dbFor(c).First(&snapshot, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "snapshot not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toSnapshotResponse(snapshot, true))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// streamHeartbeat keeps idle streams from being closed by proxies
+const streamHeartbeat = 25 * time.Second
+
+// StreamEvents pushes live events (issue.ingested, sync.finished, task.status) as
+// Server-Sent Events so dashboards can refresh without polling. Optional:
+// ?types=sync.finished,task.status to receive only some event types.
+func StreamEvents(c *gin.Context) {
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	events, cancel := services.SubscribeEvents()
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx would otherwise buffer the stream
+	// Events are pseudonymized one by one below
+	markAnonymized(c)
+	c.Status(http.StatusOK)
+	// Writing a comment sends the headers now; buffering middleware only lets a stream
+	// through once it sees a body
+	io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if len(types) > 0 && !containsString(types, event.Type) {
+				return true
+			}
+			// Event payloads carry tenant and cluster IDs; the anonymizing writer
+			// can't rewrite a stream, so pseudonymize each event here
+			if config.Get().Anonymize {
+				data, err := services.AnonymizeJSON(event.Data)
+				if err != nil {
+					return true
+				}
+				event.Data = data
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		}
+	})
+}
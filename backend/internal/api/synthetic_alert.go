@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// InjectSyntheticAlert sends a marked test alert through ingestion, enrichment, the
+// search mirror and notification routing, and reports each stage. The alert is removed
+// afterwards unless "keep" is set; "deliver" also sends a test message per matched rule.
+func InjectSyntheticAlert(c *gin.Context) {
+	var req services.SyntheticAlertRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	result, err := services.InjectSyntheticAlert(c.Request.Context(), dbFor(c), req)
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 	"github.com/nolouch/alerts-platform-v2/internal/db"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
@@ -17,8 +19,8 @@ func HandleGetTasks(c *gin.Context) {
 		return
 	}
 
-	taskService := services.NewTaskService(db.DB, services.NewRulesService())
-	tasks, err := taskService.GetTasksByComponent(componentName)
+	taskService := services.NewTaskService(db.DB, services.NewRulesService(config.Get()))
+	tasks, err := taskService.GetTasksByComponent(c.Request.Context(), componentName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -40,11 +42,12 @@ func HandleCreateTask(c *gin.Context) {
 		return
 	}
 
-	taskService := services.NewTaskService(db.DB, services.NewRulesService())
-	if err := taskService.CreateTask(&task); err != nil {
+	taskService := services.NewTaskService(db.DB, services.NewRulesService(config.Get()))
+	if err := taskService.CreateTask(c.Request.Context(), &task); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	auditComponent(c, task.Component, services.AuditCreate, "task", fmt.Sprint(task.ID), nil, task)
 
 	c.JSON(http.StatusCreated, task)
 }
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// tenantDigestCheckInterval is how often the scheduler checks whether last week's digest went out
+const tenantDigestCheckInterval = time.Hour
+
+// TenantDigestRunResponse is a digest run with, on request, the per-tenant digests
+type TenantDigestRunResponse struct {
+	models.TenantDigestRun
+	Digests []services.TenantDigest `json:"digests,omitempty"`
+}
+
+func toTenantDigestRunResponse(run models.TenantDigestRun, withDigests bool) TenantDigestRunResponse {
+	resp := TenantDigestRunResponse{TenantDigestRun: run}
+	if withDigests && run.Report != "" {
+		json.Unmarshal([]byte(run.Report), &resp.Digests)
+	}
+	return resp
+}
+
+// parseWeekStart reads ?week_start / the body's week_start (any date in the week),
+// defaulting to the last complete week
+func parseWeekStart(value string) (time.Time, error) {
+	if value == "" {
+		return services.LastCompleteWeek(time.Now()), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid week_start, expected YYYY-MM-DD")
+	}
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset), nil
+}
+
+// GetTenantDigests lists digest runs, newest first. Optional: ?limit=12 ?details=true
+func GetTenantDigests(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "12"), "%d", &limit)
+	if limit <= 0 || limit > 104 {
+		limit = 12
+	}
+
+	var runs []models.TenantDigestRun
+	if err := dbFor(c).Order("week_start DESC").Limit(limit).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]TenantDigestRunResponse, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, toTenantDigestRunResponse(run, c.Query("details") == "true"))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// PreviewTenantDigest builds the digest for a week without sending it. Optional: ?week_start=YYYY-MM-DD
+func PreviewTenantDigest(c *gin.Context) {
+	weekStart, err := parseWeekStart(c.Query("week_start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	digests, err := services.NewTenantDigestService(dbFor(c)).Build(c.Request.Context(), weekStart)
+	if requestTimedOut(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"week_start": weekStart.Format("2006-01-02"), "digests": digests})
+}
+
+// RunTenantDigestRequest picks the week (default: last complete week); force resends it
+type RunTenantDigestRequest struct {
+	WeekStart string `json:"week_start"`
+	Force     bool   `json:"force"`
+}
+
+// RunTenantDigest generates and sends a week's digest now
+func RunTenantDigest(c *gin.Context) {
+	var req RunTenantDigestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	weekStart, err := parseWeekStart(req.WeekStart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := services.NewTenantDigestService(dbFor(c))
+	var run *models.TenantDigestRun
+	ran, err := services.RunExclusive(db.DB, services.TenantDigestLockName, services.TenantDigestLockTTL, func() error {
+		var runErr error
+		run, runErr = svc.Run(c.Request.Context(), weekStart, req.Force)
+		return runErr
+	})
+	if requestTimedOut(c) {
+		return
+	}
+	if !ran && err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "a tenant digest is already running"})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := gin.H{"run": toTenantDigestRunResponse(*run, true)}
+	if err != nil {
+		resp["error"] = err.Error()
+		c.JSON(http.StatusBadGateway, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// StartTenantDigestScheduler sends last week's digest once it is complete, retrying
+// tenants whose delivery failed. Nothing runs until TENANT_DIGEST_CHANNEL is set.
+func StartTenantDigestScheduler(database *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(tenantDigestCheckInterval)
+		defer ticker.Stop()
+
+		slog.Info("Tenant digest scheduler started", "check_interval", tenantDigestCheckInterval)
+
+		svc := services.NewTenantDigestService(database)
+		for range ticker.C {
+			if config.Lookup("TENANT_DIGEST_CHANNEL") == "" {
+				continue
+			}
+			weekStart := services.LastCompleteWeek(time.Now())
+			var last models.TenantDigestRun
+			if database.Where("week_start = ?", weekStart.Format("2006-01-02")).First(&last).Error == nil && last.Status == "sent" {
+				continue
+			}
+
+			var run *models.TenantDigestRun
+			ran, err := services.RunExclusive(database, services.TenantDigestLockName, services.TenantDigestLockTTL, func() error {
+				var runErr error
+				run, runErr = svc.Run(context.Background(), weekStart, false)
+				return runErr
+			})
+			if err != nil {
+				slog.Error("Tenant digest failed", "err", err)
+			} else if ran {
+				slog.Info("Tenant digest sent", "week_start", run.WeekStart, "tenants", run.TenantCount)
+			}
+		}
+	}()
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// tenantRecentIssues bounds the recent issues of GET /tenants/:id/stats
+const tenantRecentIssues = 10
+
+// SignatureChange is an alert signature's count in a period and the one before it
+type SignatureChange struct {
+	Signature string  `json:"signature"`
+	Current   int     `json:"current"`
+	Previous  int     `json:"previous"`
+	Change    float64 `json:"change"`
+	Trend     string  `json:"trend"`
+}
+
+// TenantIssue is a recent issue of a tenant with its cluster name
+type TenantIssue struct {
+	models.Issue
+	ClusterName string `json:"cluster_name"`
+}
+
+// TenantStatsResponse is one tenant's alerts over a period, compared with the equally
+// long period before it like the dashboard and component stats
+type TenantStatsResponse struct {
+	TenantID      string            `json:"tenant_id"`
+	TenantName    string            `json:"tenant_name"`
+	Tier          string            `json:"tier,omitempty"`
+	Plan          string            `json:"plan,omitempty"`
+	Metrics       DashboardMetrics  `json:"metrics"`
+	DailyTrend    []DailyTrend      `json:"daily_trend"`
+	TopClusters   []ClusterCount    `json:"top_clusters"`
+	TopSignatures []SignatureChange `json:"top_signatures"`
+	RecentIssues  []TenantIssue     `json:"recent_issues"`
+	DateRange     DateRange         `json:"date_range"`
+}
+
+// GetTenantStats returns a tenant's alert totals and rates, trend, top clusters and
+// signatures, and most recent issues of the period. Takes days or start/end, env, step,
+// component, visibility, region, provider and dedup like the dashboard; test clusters,
+// muted and ungoverned alerts are left out the same way.
+func GetTenantStats(c *gin.Context) {
+	dbc := dbFor(c)
+	tenantID := c.Param("id")
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+
+	var tenant models.Tenant
+	err := dbc.First(&tenant, "id = ?", tenantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Tenants without metadata still count if they have alerts
+		var n int64
+		err = dbc.Model(&models.Issue{}).Where("tenant_id = ?", tenantID).Limit(1).Count(&n).Error
+		if err == nil && n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			return
+		}
+	}
+	if err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	where := "is_alert = TRUE AND tenant_id = ?"
+	switch c.DefaultQuery("env", "all") {
+	case "prod":
+		where += " AND alert_signature LIKE '[PROD]%'"
+	case "non_prod":
+		where += " AND alert_signature NOT LIKE '[PROD]%'"
+	}
+	where += buildComponentFilterCondition(c.Query("component"))
+	where += buildVisibilityFilterCondition(c.Query("visibility"))
+	where += buildRegionFilterCondition(c.Query("region"), c.Query("provider"))
+	where += buildDedupFilterCondition(c.Query("dedup"))
+	where += buildClusterFilterCondition() + buildMuteRuleCondition() + buildStabilityGovernanceFilterCondition()
+	args := []interface{}{tenantID}
+
+	g, gctx := errgroup.WithContext(c.Request.Context())
+	g.SetLimit(dashboardQueryConcurrency)
+	gdb := dbc.WithContext(gctx)
+
+	fetchCounts := func(start, end string, result *dashboardCounts) func() error {
+		return func() error {
+			return gdb.Raw(`
+				SELECT
+					COUNT(*) as total,
+					SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END) as prod,
+					SUM(CASE WHEN alert_signature NOT LIKE '[PROD]%' THEN 1 ELSE 0 END) as non_prod,
+					SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical,
+					SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END) as fake,
+					SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END) as handled
+				FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+			`, append(args, start, end)...).Scan(result).Error
+		}
+	}
+	var curr, prev dashboardCounts
+	g.Go(fetchCounts(p.start, p.end, &curr))
+	g.Go(fetchCounts(p.prevStart, p.prevEnd, &prev))
+
+	dateSelect := "SUBSTR(REPLACE(created, ' UTC', ''), 1, 10) as date"
+	switch c.DefaultQuery("step", "day") {
+	case "week":
+		dateSelect = db.WeekKey("REPLACE(created, ' UTC', '')") + " as date"
+	case "month":
+		dateSelect = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 7) as date"
+	}
+	trend := []DailyTrend{}
+	g.Go(func() error {
+		return gdb.Raw(`
+			SELECT
+				`+dateSelect+`,
+				COUNT(*) as total_alerts,
+				SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical_count,
+				SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END) as major_count,
+				SUM(CASE WHEN priority = 'Warning' THEN 1 ELSE 0 END) as warning_count
+			FROM issues
+			WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+			GROUP BY date
+			ORDER BY date ASC
+		`, append(args, p.start[:10]+" 00:00:00", p.end[:10]+" 23:59:59")...).Scan(&trend).Error
+	})
+
+	var topClusters, topSignatures []periodCount
+	g.Go(func() error {
+		topClusters = topWithPrevious(gdb, "cluster_id", where, args, p.start, p.end, p.prevStart, p.prevEnd)
+		return gctx.Err()
+	})
+	g.Go(func() error {
+		topSignatures = topWithPrevious(gdb, "alert_signature", where, args, p.start, p.end, p.prevStart, p.prevEnd)
+		return gctx.Err()
+	})
+
+	recent := []models.Issue{}
+	g.Go(func() error {
+		return gdb.Where(where+" AND created_at_utc BETWEEN ? AND ?", append(args, p.start, p.end)...).
+			Order("created DESC").
+			Limit(tenantRecentIssues).
+			Find(&recent).Error
+	})
+
+	if err := g.Wait(); err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Resolve the tenant and every cluster name in one batch
+	nameIDs := append([]string{tenantID}, topValues(topClusters)...)
+	for _, issue := range recent {
+		nameIDs = append(nameIDs, issue.ClusterID)
+	}
+	names := services.GetNameResolver().ResolveBatch(c.Request.Context(), nameIDs)
+
+	resp := TenantStatsResponse{
+		TenantID:      tenantID,
+		TenantName:    tenant.Name,
+		Tier:          tenant.Tier,
+		Plan:          tenant.Plan,
+		Metrics:       dashboardMetrics(curr, prev),
+		DailyTrend:    trend,
+		TopClusters:   []ClusterCount{},
+		TopSignatures: []SignatureChange{},
+		RecentIssues:  []TenantIssue{},
+		DateRange:     p.dateRange(),
+	}
+	if resp.TenantName == "" {
+		resp.TenantName = names[tenantID].Name
+	}
+	for _, top := range topClusters {
+		change, trend := calculateChange(top.Count, top.PrevCount)
+		info := names[top.Value]
+		resp.TopClusters = append(resp.TopClusters, ClusterCount{
+			ClusterID:   top.Value,
+			ClusterName: info.Name,
+			TenantName:  info.TenantName,
+			Current:     top.Count,
+			Previous:    top.PrevCount,
+			Change:      change,
+			Trend:       trend,
+		})
+	}
+	for _, top := range topSignatures {
+		change, trend := calculateChange(top.Count, top.PrevCount)
+		resp.TopSignatures = append(resp.TopSignatures, SignatureChange{
+			Signature: top.Value,
+			Current:   top.Count,
+			Previous:  top.PrevCount,
+			Change:    change,
+			Trend:     trend,
+		})
+	}
+	for _, issue := range recent {
+		resp.RecentIssues = append(resp.RecentIssues, TenantIssue{Issue: issue, ClusterName: names[issue.ClusterID].Name})
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// tierUnknown labels alerts whose tenant has no tier metadata
+const tierUnknown = "unknown"
+
+// tierExpr resolves an issue's tenant tier
+const tierExpr = "COALESCE(NULLIF((SELECT tier FROM tenants WHERE tenants.id = issues.tenant_id), ''), '" + tierUnknown + "')"
+
+// buildTierFilterCondition filters by comma separated tenant tiers;
+// "unknown" matches alerts whose tenant has no tier metadata
+func buildTierFilterCondition(value string) string {
+	var known []string
+	includeUnknown := false
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == tierUnknown {
+			includeUnknown = true
+		} else if v != "" {
+			known = append(known, v)
+		}
+	}
+
+	var parts []string
+	if len(known) > 0 {
+		parts = append(parts, "tenant_id IN (SELECT id FROM tenants WHERE 1=1"+buildInFilterCondition("tier", strings.Join(known, ","))+")")
+	}
+	if includeUnknown {
+		parts = append(parts, "tenant_id NOT IN (SELECT id FROM tenants WHERE tier != '')")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " AND (" + strings.Join(parts, " OR ") + ")"
+}
+
+// TierBreakdown summarizes alerts for one tenant tier
+type TierBreakdown struct {
+	Tier string `json:"tier"`
+	BreakdownStats
+}
+
+// GetTierBreakdown returns counts, trend and handling rate per tenant tier
+func GetTierBreakdown(c *gin.Context) {
+	p, ok := bindPeriod(c)
+	if !ok {
+		return
+	}
+	groups, dateRange := breakdownBy(c, p, tierExpr, buildStabilityGovernanceFilterCondition())
+
+	items := make([]TierBreakdown, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, TierBreakdown{Tier: g.Value, BreakdownStats: g.Stats})
+	}
+
+	if requestTimedOut(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":      items,
+		"date_range": dateRange,
+	})
+}
+
+// GetTenants lists tenant metadata, optionally filtered by ?tier=
+func GetTenants(c *gin.Context) {
+	tenants, err := services.NewTenantService(db.DB).List(c.Request.Context(), c.Query("tier"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tenants)
+}
+
+// ImportTenants loads tenant metadata from CSV, either as the request body or a "file" upload
+func ImportTenants(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	count, err := services.NewTenantService(db.DB).ImportCSV(c.Request.Context(), body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "imported": count})
+}
+
+// SyncTenants pulls tenant metadata from the external tenant API
+func SyncTenants(c *gin.Context) {
+	count, err := services.NewTenantService(db.DB).SyncFromAPI(c.Request.Context())
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrTenantAPINotConfigured) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditTrigger, "sync", "tenants", nil, gin.H{"synced": count})
+	c.JSON(http.StatusOK, gin.H{"success": true, "synced": count})
+}
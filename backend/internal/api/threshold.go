@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// maxThresholdPoints keeps range queries below Prometheus' 11000 points per series limit
+const maxThresholdPoints = 10000
+
+// GetThresholdSuggestion suggests a threshold for an alert rule from the historical
+// distribution of its underlying query. ?percentile= (default 99) picks the upper tail
+// for ">" rules and the lower tail for "<" rules; ?days= (default 7) sets the lookback.
+func GetThresholdSuggestion(c *gin.Context) {
+	alert := c.Param("alert")
+
+	percentile, err := strconv.ParseFloat(c.DefaultQuery("percentile", "99"), 64)
+	if err != nil || percentile <= 0 || percentile >= 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "percentile must be between 0 and 100"})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 || days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 90"})
+		return
+	}
+	lookback := time.Duration(days) * 24 * time.Hour
+	// Default step: the smallest whole minute that stays under the points limit
+	step := lookback / maxThresholdPoints
+	if step%time.Minute != 0 {
+		step = step.Truncate(time.Minute) + time.Minute
+	}
+	if s := c.Query("step"); s != "" {
+		if step, err = time.ParseDuration(s); err != nil || step <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid step"})
+			return
+		}
+		if lookback/step > maxThresholdPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "step too small for the lookback window"})
+			return
+		}
+	}
+	if step < time.Minute {
+		step = time.Minute
+	}
+
+	// Find the rule (component narrows the search, but matching is by alert name)
+	rules, err := services.NewRulesService(config.Get()).GetRulesForComponent(c.Query("component"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rules"})
+		return
+	}
+	var rule *models.Rule
+	for i := range rules {
+		if rules[i].Alert == alert {
+			rule = &rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+		return
+	}
+	if strings.Contains(rule.FilePath, "/logging/") {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "threshold suggestions are only available for prometheus rules"})
+		return
+	}
+
+	parsed, err := services.ParseThresholdExpr(rule.Expr)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "expr": rule.Expr})
+		return
+	}
+
+	prom, err := services.NewPrometheusClient()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	end := time.Now().UTC()
+	values, seriesCount, err := prom.QueryRangeValues(c.Request.Context(), parsed.Query, end.Add(-lookback), end, step)
+	if err != nil {
+		if requestTimedOut(c) {
+			return
+		}
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrPrometheusNotConfigured) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if len(values) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "query returned no samples in the lookback window", "query": parsed.Query})
+		return
+	}
+
+	// Alerts on low values ("<") take the lower tail of the distribution
+	tail := percentile
+	if strings.HasPrefix(parsed.Operator, "<") {
+		tail = 100 - percentile
+	}
+
+	sorted := services.SortedCopy(values)
+	suggested := services.Percentile(sorted, tail)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alert":               rule.Alert,
+		"expr":                rule.Expr,
+		"query":               parsed.Query,
+		"operator":            parsed.Operator,
+		"current_threshold":   parsed.Threshold,
+		"suggested_threshold": suggested,
+		"percentile":          percentile,
+		"lookback_days":       days,
+		"step":                step.String(),
+		"series":              seriesCount,
+		"samples":             len(values),
+		"distribution": gin.H{
+			"min":  sorted[0],
+			"max":  sorted[len(sorted)-1],
+			"mean": sum / float64(len(sorted)),
+			"p50":  services.Percentile(sorted, 50),
+			"p90":  services.Percentile(sorted, 90),
+			"p95":  services.Percentile(sorted, 95),
+			"p99":  services.Percentile(sorted, 99),
+		},
+		// Share of historical samples that would have met the alert condition
+		"firing_ratio_current":   roundRatio(services.FiringRatio(values, parsed.Operator, parsed.Threshold)),
+		"firing_ratio_suggested": roundRatio(services.FiringRatio(values, parsed.Operator, suggested)),
+	})
+}
+
+func roundRatio(r float64) float64 {
+	return math.Round(r*10000) / 10000
+}
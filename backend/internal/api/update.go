@@ -1,12 +1,16 @@
 package api
 
 import (
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
 )
 
@@ -25,11 +29,39 @@ type UpdateRequest struct {
 
 // UpdateStatus represents update status
 type UpdateStatus struct {
-	Status        string     `json:"status"`
-	LastUpdate    *time.Time `json:"last_update"`
-	IsUpdating    bool       `json:"is_updating"`
-	JiraConnected bool       `json:"jira_connected"`
-	IssueCount    int64      `json:"issue_count"`
+	Status        string               `json:"status"`
+	LastUpdate    *time.Time           `json:"last_update"`
+	IsUpdating    bool                 `json:"is_updating"`
+	JiraConnected bool                 `json:"jira_connected"`
+	IssueCount    int64                `json:"issue_count"`
+	Sources       []IngestSourceStatus `json:"sources"`
+}
+
+// IngestSourceStatus is a registered ingestion source with its sync state;
+// Enabled is false when the source is not configured on this replica
+type IngestSourceStatus struct {
+	models.IngestSyncState
+	Enabled bool `json:"enabled"`
+}
+
+// syncLockName guards JIRA syncs so only one replica imports at a time
+const (
+	syncLockName = "data_updater"
+	syncLockTTL  = 5 * time.Minute
+)
+
+// runLocked runs a sync under the cross-replica lock, then rolls up the days it stored
+// alerts for; ran is false if another replica holds it
+func (c *UpdateController) runLocked(job func() (int, error)) (count int, ran bool, err error) {
+	ran, err = services.RunExclusive(c.db, syncLockName, syncLockTTL, func() error {
+		var syncErr error
+		count, syncErr = job()
+		return syncErr
+	})
+	if ran {
+		rollupStatsAfterSync(c.db, c.dataUpdater.TakeStoredDays())
+	}
+	return count, ran, err
 }
 
 // NewUpdateController creates a new update controller
@@ -43,8 +75,7 @@ func NewUpdateController(db *gorm.DB) *UpdateController {
 	dataUpdater, err := services.NewDataUpdater(sqlDB)
 	if err != nil {
 		// Log error but don't panic - JIRA cred might not be configured  yet
-		println("⚠️  Warning: Failed to initialize data updater:", err.Error())
-		println("   Data update features will be unavailable")
+		slog.Warn("Failed to initialize data updater, data update features will be unavailable", "err", err)
 	}
 
 	return &UpdateController{
@@ -60,7 +91,7 @@ func (c *UpdateController) TriggerUpdate(ctx *gin.Context) {
 	if c.dataUpdater == nil {
 		ctx.JSON(http.StatusServiceUnavailable, gin.H{
 			"success": false,
-			"error":   "Data updater not available - JIRA credentials not configured",
+			"error":   "Data updater not available - no ingestion source configured",
 		})
 		return
 	}
@@ -79,28 +110,36 @@ func (c *UpdateController) TriggerUpdate(ctx *gin.Context) {
 		req.Type = "incremental"
 	}
 
+	if req.Type == "" {
+		req.Type = "incremental"
+	}
+	audit(ctx, services.AuditTrigger, "sync", req.Type, nil, gin.H{"type": req.Type, "sources": c.dataUpdater.Sources()})
+
 	// Run update in background
+	logger := logFor(ctx)
 	go func() {
 		c.isUpdating = true
 		defer func() { c.isUpdating = false }()
 
-		var count int
-		var err error
-
-		if req.Type == "full" {
-			count, err = c.dataUpdater.FetchInitialData(30)
-		} else {
-			count, err = c.dataUpdater.IncrementalUpdate()
-		}
+		count, ran, err := c.runLocked(func() (int, error) {
+			if req.Type == "full" {
+				return c.dataUpdater.FetchInitialData(30)
+			}
+			return c.dataUpdater.IncrementalUpdate()
+		})
 
 		if err != nil {
-			println("❌ Update failed:", err.Error())
+			logger.Error("Update failed", "type", req.Type, "err", err)
+			return
+		}
+		if !ran {
+			logger.Warn("Skipping update: another replica holds the sync lock")
 			return
 		}
 
 		now := time.Now()
 		c.lastUpdate = &now
-		println("✅ Update completed successfully:", count, "issues processed")
+		logger.Info("Update completed", "type", req.Type, "issues", count)
 	}()
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -116,17 +155,33 @@ func (c *UpdateController) GetUpdateStatus(ctx *gin.Context) {
 	var count int64
 	c.db.Table("issues").Count(&count)
 
-	jiraConnected := false
+	var enabled []string
 	if c.dataUpdater != nil {
-		jiraConnected = true
+		enabled = c.dataUpdater.Sources()
+	}
+
+	var states []models.IngestSyncState
+	c.db.Order("source").Find(&states)
+	stateBySource := make(map[string]models.IngestSyncState, len(states))
+	for _, st := range states {
+		stateBySource[st.Source] = st
+	}
+	sources := make([]IngestSourceStatus, 0)
+	for _, name := range services.RegisteredIngesters() {
+		st, ok := stateBySource[name]
+		if !ok {
+			st = models.IngestSyncState{Source: name}
+		}
+		sources = append(sources, IngestSourceStatus{IngestSyncState: st, Enabled: containsString(enabled, name)})
 	}
 
 	status := UpdateStatus{
 		Status:        "online",
 		LastUpdate:    c.lastUpdate,
 		IsUpdating:    c.isUpdating,
-		JiraConnected: jiraConnected,
+		JiraConnected: containsString(enabled, services.IngestSourceJira),
 		IssueCount:    count,
+		Sources:       sources,
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -138,7 +193,7 @@ func (c *UpdateController) GetUpdateStatus(ctx *gin.Context) {
 // StartScheduler starts the automatic update scheduler
 func (c *UpdateController) StartScheduler(interval time.Duration) {
 	if c.dataUpdater == nil {
-		println("⚠️  Update scheduler not started: Data updater not available")
+		slog.Warn("Update scheduler not started: data updater not available")
 		return
 	}
 
@@ -146,49 +201,59 @@ func (c *UpdateController) StartScheduler(interval time.Duration) {
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
-		println("⏰ Automatic update scheduler started (Interval:", interval.String(), ")")
+		// Apply a changed interval on config reload without restarting the loop
+		var intervalMu sync.Mutex
+		config.OnReload(func(cfg *config.Config) {
+			intervalMu.Lock()
+			defer intervalMu.Unlock()
+			if cfg.SchedulerInterval != interval {
+				interval = cfg.SchedulerInterval
+				ticker.Reset(interval)
+				slog.Info("Update scheduler interval changed", "interval", interval)
+			}
+		})
+
+		slog.Info("Automatic update scheduler started", "interval", interval)
 
 		// Run immediately on startup (optional, maybe wait for first tick)
 		// Let's wait for first tick to avoid slowing down startup
 
 		for range ticker.C {
 			if c.isUpdating {
-				println("⚠️  Skipping scheduled update: Update already in progress")
+				slog.Warn("Skipping scheduled update: update already in progress")
 				continue
 			}
 
-			println("⏰ Starting scheduled incremental update...")
+			slog.Info("Starting scheduled incremental update")
 			c.isUpdating = true
 
-			count, err := c.dataUpdater.IncrementalUpdate()
+			count, ran, err := c.runLocked(c.dataUpdater.IncrementalUpdate)
 			c.isUpdating = false // Reset flag immediately after
 
 			if err != nil {
-				println("❌ Scheduled update failed:", err.Error())
+				slog.Error("Scheduled update failed", "err", err)
+			} else if !ran {
+				slog.Warn("Skipping scheduled update: another replica holds the sync lock")
 			} else {
 				now := time.Now()
 				c.lastUpdate = &now
-				if count > 0 {
-					println("✅ Scheduled update completed:", count, "new issues processed")
-				} else {
-					println("✅ Scheduled update check completed: No new issues")
-				}
+				slog.Info("Scheduled update completed", "issues", count)
 			}
 		}
 	}()
 }
 
-// RegisterUpdateRoutes registers update-related routes
-func RegisterUpdateRoutes(router *gin.Engine, db *gorm.DB) {
+// RegisterUpdateRoutes registers update-related routes on the /api group
+func RegisterUpdateRoutes(api *gin.RouterGroup, db *gorm.DB) {
 	controller := NewUpdateController(db)
 
 	// Check if database is empty and trigger initial update
 	var count int64
 	db.Table("issues").Count(&count)
 	if count == 0 {
-		println("🆕 Empty database detected (issue count: 0)")
+		slog.Info("Empty database detected")
 		if controller.dataUpdater != nil {
-			println("🚀 Triggering initial FULL data import (last 30 days)...")
+			slog.Info("Triggering initial full data import", "days", 30)
 			go func() {
 				// Wait a few seconds for server to start fully
 				time.Sleep(5 * time.Second)
@@ -197,27 +262,27 @@ func RegisterUpdateRoutes(router *gin.Engine, db *gorm.DB) {
 				defer func() { controller.isUpdating = false }()
 
 				// Fetch last 30 days of data
-				processed, err := controller.dataUpdater.FetchInitialData(30)
+				processed, ran, err := controller.runLocked(func() (int, error) {
+					return controller.dataUpdater.FetchInitialData(30)
+				})
 				if err != nil {
-					println("❌ Initial update failed:", err.Error())
+					slog.Error("Initial update failed", "err", err)
+				} else if !ran {
+					slog.Warn("Skipping initial update: another replica holds the sync lock")
 				} else {
 					now := time.Now()
 					controller.lastUpdate = &now
-					println("✅ Initial update completed:", processed, "issues imported")
+					slog.Info("Initial update completed", "issues", processed)
 				}
 			}()
 		} else {
-			println("⚠️  Skipping initial update: Data updater not configured (JIRA credentials missing)")
+			slog.Warn("Skipping initial update: data updater not configured")
 		}
 	}
 
-	// Start scheduler with 1 hour interval
-	// TODO: Make configurable via env var
-	controller.StartScheduler(1 * time.Hour)
+	// Start scheduler (interval from SCHEDULER_INTERVAL, default 1h)
+	controller.StartScheduler(config.Get().SchedulerInterval)
 
-	api := router.Group("/api")
-	{
-		api.POST("/update", controller.TriggerUpdate)
-		api.GET("/update/status", controller.GetUpdateStatus)
-	}
+	api.POST("/update", controller.TriggerUpdate)
+	api.GET("/update/status", controller.GetUpdateStatus)
 }
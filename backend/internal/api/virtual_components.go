@@ -0,0 +1,123 @@
+package api
+
+import (
+	"log/slog"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VirtualComponent is a synthetic component defined by an issue filter instead of
+// the components label, configured under virtual_components in component_categories.yaml
+type VirtualComponent struct {
+	Name     string `yaml:"name" json:"name"`
+	Category string `yaml:"category" json:"category"`
+	// Filter is a SQL condition on the issues table selecting the component's issues
+	Filter string `yaml:"filter" json:"filter"`
+	// Show controls sidebar visibility: "always" or "when_matching" (only if issues match Filter)
+	Show string `yaml:"show" json:"show"`
+	// Exclusive hides matching issues from regular components, except those in ExemptCategories
+	Exclusive        bool     `yaml:"exclusive" json:"exclusive"`
+	ExemptCategories []string `yaml:"exempt_categories" json:"exempt_categories,omitempty"`
+	// IncludeUngoverned keeps issues without a stability_governance label
+	IncludeUngoverned bool `yaml:"include_ungoverned" json:"include_ungoverned"`
+	// SkipNameResolution shows raw cluster/tenant IDs instead of calling the name API
+	// for every component in the virtual component's category
+	SkipNameResolution bool `yaml:"skip_name_resolution" json:"skip_name_resolution"`
+	// RulesComponent overrides the component used when matching alert rules
+	RulesComponent string `yaml:"rules_component" json:"rules_component,omitempty"`
+}
+
+// defaultVirtualComponents keeps older config files without a virtual_components section working
+var defaultVirtualComponents = []VirtualComponent{
+	{
+		Name:               "Serverless",
+		Category:           "Serverless",
+		Filter:             "(biz_type LIKE '%devtier%' OR biz_type LIKE '%TiDB Serverless%')",
+		Show:               "always",
+		SkipNameResolution: true,
+		RulesComponent:     "*",
+	},
+	{
+		Name:              "old-rules",
+		Category:          "Resilience",
+		Filter:            "(stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%')",
+		Show:              "when_matching",
+		Exclusive:         true,
+		ExemptCategories:  []string{"Resilience", "Serverless"},
+		IncludeUngoverned: true,
+	},
+}
+
+var virtualComponents = defaultVirtualComponents
+
+// parseVirtualComponents decodes the virtual_components section, skipping invalid entries.
+// A section that doesn't decode falls back to the defaults rather than dropping them all.
+func parseVirtualComponents(node *yaml.Node) []VirtualComponent {
+	var list []VirtualComponent
+	if err := node.Decode(&list); err != nil {
+		slog.Error("Failed to parse virtual_components, using the defaults", "err", err)
+		return defaultVirtualComponents
+	}
+
+	var valid []VirtualComponent
+	for _, vc := range list {
+		if vc.Name == "" || vc.Filter == "" {
+			continue
+		}
+		if vc.Category == "" {
+			vc.Category = vc.Name
+		}
+		if vc.Show == "" {
+			vc.Show = "always"
+		}
+		valid = append(valid, vc)
+	}
+	return valid
+}
+
+// getVirtualComponent returns the virtual component with the given name, if any
+func getVirtualComponent(name string) (VirtualComponent, bool) {
+	loadCategories()
+
+	categoryLock.RLock()
+	defer categoryLock.RUnlock()
+
+	for _, vc := range virtualComponents {
+		if vc.Name == name {
+			return vc, true
+		}
+	}
+	return VirtualComponent{}, false
+}
+
+// listVirtualComponents returns a snapshot of the configured virtual components
+func listVirtualComponents() []VirtualComponent {
+	loadCategories()
+
+	categoryLock.RLock()
+	defer categoryLock.RUnlock()
+
+	return append([]VirtualComponent(nil), virtualComponents...)
+}
+
+// exclusiveCondition returns the SQL condition excluding issues claimed by exclusive
+// virtual components from a regular component in the given category
+func exclusiveCondition(category string) string {
+	cond := ""
+	for _, vc := range listVirtualComponents() {
+		if !vc.Exclusive || containsString(vc.ExemptCategories, category) {
+			continue
+		}
+		cond += " AND NOT (" + vc.Filter + ")"
+	}
+	return cond
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
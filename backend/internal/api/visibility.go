@@ -0,0 +1,81 @@
+package api
+
+import (
+	"gorm.io/gorm"
+)
+
+// VisibilityCount breaks alerts down by visibility (internal/external), since
+// external-facing alerts carry different SLAs than internal ones
+type VisibilityCount struct {
+	Visibility   string  `json:"visibility"`
+	Current      int     `json:"current"`
+	Previous     int     `json:"previous"`
+	Change       float64 `json:"change"`
+	Trend        string  `json:"trend"`
+	Critical     int     `json:"critical"`
+	HandlingRate float64 `json:"handling_rate"`
+}
+
+// visibilityUnknown labels alerts without a visibility label
+const visibilityUnknown = "unknown"
+
+// buildVisibilityFilterCondition filters by comma separated visibility values;
+// "unknown" matches alerts without a visibility label
+func buildVisibilityFilterCondition(value string) string {
+	return buildOptionalInFilterCondition("visibility", value, visibilityUnknown)
+}
+
+// visibilityBreakdown counts alerts per visibility for the current and previous periods.
+// where is the issue condition without the date range, args its placeholders.
+func visibilityBreakdown(dbc *gorm.DB, where string, args []interface{}, start, end, prevStart, prevEnd string) []VisibilityCount {
+	label := "CASE WHEN visibility IS NULL OR visibility = '' THEN '" + visibilityUnknown + "' ELSE visibility END"
+
+	var current []struct {
+		Visibility string
+		Total      int
+		Critical   int
+		Handled    int
+	}
+	dbc.Raw(`
+		SELECT `+label+` as visibility,
+			COUNT(*) as total,
+			SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical,
+			SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END) as handled
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1
+		ORDER BY total DESC
+	`, append(append([]interface{}{}, args...), start, end)...).Scan(&current)
+
+	var previous []struct {
+		Visibility string
+		Total      int
+	}
+	dbc.Raw(`
+		SELECT `+label+` as visibility, COUNT(*) as total
+		FROM issues WHERE `+where+` AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1
+	`, append(append([]interface{}{}, args...), prevStart, prevEnd)...).Scan(&previous)
+	prevByVis := make(map[string]int)
+	for _, p := range previous {
+		prevByVis[p.Visibility] = p.Total
+	}
+
+	result := make([]VisibilityCount, 0, len(current))
+	for _, cur := range current {
+		change, trend := calculateChange(cur.Total, prevByVis[cur.Visibility])
+		handlingRate := 0.0
+		if cur.Total > 0 {
+			handlingRate = float64(cur.Handled) / float64(cur.Total) * 100
+		}
+		result = append(result, VisibilityCount{
+			Visibility:   cur.Visibility,
+			Current:      cur.Total,
+			Previous:     prevByVis[cur.Visibility],
+			Change:       change,
+			Trend:        trend,
+			Critical:     cur.Critical,
+			HandlingRate: handlingRate,
+		})
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/cache"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// webhookMaxSkew bounds how old (or far in the future) a signed request may be
+const webhookMaxSkew = 5 * time.Minute
+
+// WebhookAuth verifies inbound webhooks for an integration (e.g. "alertmanager", "grafana",
+// "jira", "deployments"). Configuration is per integration via env:
+//
+//	WEBHOOK_<NAME>_SECRET  HMAC-SHA256 shared secret; requests must send X-Webhook-Timestamp
+//	                       (unix seconds) and X-Webhook-Signature = sha256=hex(HMAC("<ts>.<body>"))
+//	WEBHOOK_<NAME>_TOKEN   static token sent as "Authorization: Bearer <token>" (for senders
+//	                       that can't sign, like Alertmanager)
+//
+// Signed requests are replay-protected: stale timestamps are rejected and each
+// signature is accepted only once within the skew window. Unconfigured integrations
+// are rejected so nothing is exposed by accident.
+func WebhookAuth(integration string) gin.HandlerFunc {
+	envPrefix := "WEBHOOK_" + strings.ToUpper(strings.ReplaceAll(integration, "-", "_"))
+
+	return func(c *gin.Context) {
+		secret := config.Lookup(envPrefix + "_SECRET")
+		token := config.Lookup(envPrefix + "_TOKEN")
+
+		switch {
+		case secret != "":
+			if !verifySignedWebhook(c, integration, secret) {
+				return
+			}
+		case token != "":
+			provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid webhook token"})
+				return
+			}
+		default:
+			logFor(c).Warn("Rejected webhook: no secret or token configured", "integration", integration, "env", envPrefix+"_SECRET")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "webhook not configured"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func verifySignedWebhook(c *gin.Context, integration, secret string) bool {
+	timestamp := c.GetHeader("X-Webhook-Timestamp")
+	signature := c.GetHeader("X-Webhook-Signature")
+	if timestamp == "" || signature == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing webhook signature headers"})
+		return false
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid webhook timestamp"})
+		return false
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > webhookMaxSkew || skew < -webhookMaxSkew {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "webhook timestamp outside allowed window"})
+		return false
+	}
+
+	// The body is read before the signature can be checked, so bound it first
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPushedBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("body exceeds %d bytes", maxPushedBytes)})
+			return false
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return false
+	}
+	// Restore the body for the downstream handler
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !services.VerifySignature(secret, timestamp, body, signature) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid webhook signature"})
+		return false
+	}
+
+	// Replay protection: remember accepted signatures for the skew window
+	replayKey := "webhook-replay:" + integration + ":" + signature
+	if _, seen := cache.Get().Get(replayKey); seen {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "webhook replay detected"})
+		return false
+	}
+	cache.Get().Set(replayKey, []byte(timestamp), 2*webhookMaxSkew)
+
+	return true
+}
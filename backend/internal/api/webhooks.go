@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// WebhookRequest is the create/update payload; enabled defaults to true. Events are
+// alert.critical, fake_rate.exceeded and task.review; fake_rate_threshold (percent) is
+// required with fake_rate.exceeded.
+type WebhookRequest struct {
+	Name              string   `json:"name"`
+	Enabled           *bool    `json:"enabled"`
+	URL               string   `json:"url"`
+	Secret            *string  `json:"secret"` // signing secret; left out keeps the stored one
+	Events            []string `json:"events"`
+	FakeRateThreshold float64  `json:"fake_rate_threshold"`
+}
+
+func (r WebhookRequest) apply(hook *models.Webhook) {
+	hook.Name = r.Name
+	hook.Enabled = r.Enabled == nil || *r.Enabled
+	hook.URL = r.URL
+	if r.Secret != nil {
+		hook.Secret = *r.Secret
+	}
+	hook.Events = r.Events
+	hook.FakeRateThreshold = r.FakeRateThreshold
+}
+
+// bindWebhook decodes and validates the payload into hook, writing a 400 on failure
+func bindWebhook(c *gin.Context, hook *models.Webhook) bool {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	req.apply(hook)
+
+	if problems := services.ValidateWebhook(hook); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook", "problems": problems})
+		return false
+	}
+
+	var count int64
+	dbFor(c).Model(&models.Webhook{}).Where("name = ? AND id != ?", hook.Name, hook.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "a webhook named '" + hook.Name + "' already exists"})
+		return false
+	}
+	return true
+}
+
+// GetWebhooks lists outbound webhooks
+func GetWebhooks(c *gin.Context) {
+	hooks := []models.Webhook{}
+	if err := dbFor(c).Order("id").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": hooks})
+}
+
+// GetWebhook returns one webhook
+func GetWebhook(c *gin.Context) {
+	var hook models.Webhook
+	if err := dbFor(c).First(&hook, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// CreateWebhook validates and stores a new webhook
+func CreateWebhook(c *gin.Context) {
+	var hook models.Webhook
+	if !bindWebhook(c, &hook) {
+		return
+	}
+	if err := dbFor(c).Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditCreate, "webhook", fmt.Sprint(hook.ID), nil, hook)
+	c.JSON(http.StatusCreated, hook)
+}
+
+// UpdateWebhook replaces an existing webhook
+func UpdateWebhook(c *gin.Context) {
+	var hook models.Webhook
+	if err := dbFor(c).First(&hook, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+	before := hook
+	if !bindWebhook(c, &hook) {
+		return
+	}
+	if err := dbFor(c).Save(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditUpdate, "webhook", fmt.Sprint(hook.ID), before, hook)
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook removes a webhook; its delivery log is kept
+func DeleteWebhook(c *gin.Context) {
+	dbc := dbFor(c)
+	var hook models.Webhook
+	if err := dbc.First(&hook, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+	if err := dbc.Delete(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	audit(c, services.AuditDelete, "webhook", fmt.Sprint(hook.ID), hook, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestWebhook sends a ping delivery to a webhook and waits for its outcome, retries included
+func TestWebhook(c *gin.Context) {
+	var hook models.Webhook
+	if err := dbFor(c).First(&hook, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+	sqlDB, err := dbFor(c).DB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+	data := gin.H{"webhook": hook.Name, "message": fmt.Sprintf("Test delivery to webhook %s. No action needed.", hook.Name)}
+	delivery, err := services.NewWebhookDispatcher(sqlDB).Deliver(ctx, hook, services.WebhookPing, data)
+	if delivery == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "delivery": delivery})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "delivery": delivery})
+}
+
+// GetWebhookDeliveries is the delivery log, newest first.
+// Optional: ?webhook_id=, ?event=, ?status=, ?limit=50
+func GetWebhookDeliveries(c *gin.Context) {
+	var limit int
+	fmt.Sscanf(c.DefaultQuery("limit", "50"), "%d", &limit)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := dbFor(c).Order("created_at DESC").Limit(limit)
+	if id := c.Param("id"); id != "" {
+		query = query.Where("webhook_id = ?", id)
+	} else if id := c.Query("webhook_id"); id != "" {
+		query = query.Where("webhook_id = ?", id)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := query.Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": deliveries})
+}
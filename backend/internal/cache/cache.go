@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Cache is a byte-oriented key/value cache with per-entry TTL.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+// PubSub fans messages out to every subscriber of a channel,
+// across replicas when backed by Redis.
+type PubSub interface {
+	Publish(channel string, message []byte) error
+	// Subscribe returns a message stream and a cancel func that closes it
+	Subscribe(channel string) (<-chan []byte, func())
+}
+
+// Backend bundles the cache and pub/sub of one storage choice
+type Backend interface {
+	Cache
+	PubSub
+}
+
+var (
+	backend     Backend
+	backendOnce sync.Once
+)
+
+// Get returns the shared backend: Redis when REDIS_URL is set, in-memory otherwise
+func Get() Backend {
+	backendOnce.Do(func() {
+		if url := config.Get().RedisURL; url != "" {
+			rb, err := NewRedis(url)
+			if err == nil {
+				slog.Info("Using Redis cache backend")
+				backend = rb
+				return
+			}
+			slog.Warn("Failed to connect to Redis, falling back to in-memory cache", "err", err)
+		}
+		backend = NewMemory(config.Get().CacheMaxEntries)
+	})
+	return backend
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // zero means no expiry
+}
+
+// Memory is the single-replica backend: a TTL map bounded to maxEntries, evicting the
+// least recently used entry, plus in-process pub/sub
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element // of *memoryEntry
+	recent     *list.List               // most recently used first
+	maxEntries int                      // 0 means unbounded
+
+	subMu       sync.RWMutex
+	subscribers map[string]map[chan []byte]struct{}
+}
+
+func NewMemory(maxEntries int) *Memory {
+	m := &Memory{
+		entries:     make(map[string]*list.Element),
+		recent:      list.New(),
+		maxEntries:  maxEntries,
+		subscribers: make(map[string]map[chan []byte]struct{}),
+	}
+	go m.janitor(time.Minute)
+	return m
+}
+
+func (m *Memory) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.remove(el)
+		return nil, false
+	}
+	m.recent.MoveToFront(el)
+	return entry.value, true
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		el.Value = entry
+		m.recent.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.recent.PushFront(entry)
+	for m.maxEntries > 0 && m.recent.Len() > m.maxEntries {
+		m.remove(m.recent.Back())
+	}
+}
+
+func (m *Memory) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+}
+
+// remove drops an entry; m.mu must be held
+func (m *Memory) remove(el *list.Element) {
+	m.recent.Remove(el)
+	delete(m.entries, el.Value.(*memoryEntry).key)
+}
+
+// janitor evicts expired entries so unread keys don't accumulate forever
+func (m *Memory) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		m.mu.Lock()
+		for _, el := range m.entries {
+			if e := el.Value.(*memoryEntry); !e.expiresAt.IsZero() && now.After(e.expiresAt) {
+				m.remove(el)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *Memory) Publish(channel string, message []byte) error {
+	m.subMu.RLock()
+	defer m.subMu.RUnlock()
+	for ch := range m.subscribers[channel] {
+		// Drop the message for slow subscribers rather than block the publisher
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(channel string) (<-chan []byte, func()) {
+	ch := make(chan []byte, 64)
+
+	m.subMu.Lock()
+	if m.subscribers[channel] == nil {
+		m.subscribers[channel] = make(map[chan []byte]struct{})
+	}
+	m.subscribers[channel][ch] = struct{}{}
+	m.subMu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			m.subMu.Lock()
+			delete(m.subscribers[channel], ch)
+			m.subMu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis shares cache entries and pub/sub messages across replicas
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis connects using a redis:// URL and verifies the connection
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis ping failed: %w", err)
+	}
+
+	return &Redis{client: client, prefix: "alerts:"}, nil
+}
+
+func (r *Redis) Get(key string) ([]byte, bool) {
+	val, err := r.client.Get(context.Background(), r.prefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Warn("Redis GET failed", "key", key, "err", err)
+		}
+		return nil, false
+	}
+	return val, true
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) {
+	if err := r.client.Set(context.Background(), r.prefix+key, value, ttl).Err(); err != nil {
+		slog.Warn("Redis SET failed", "key", key, "err", err)
+	}
+}
+
+func (r *Redis) Delete(key string) {
+	if err := r.client.Del(context.Background(), r.prefix+key).Err(); err != nil {
+		slog.Warn("Redis DEL failed", "key", key, "err", err)
+	}
+}
+
+func (r *Redis) Publish(channel string, message []byte) error {
+	return r.client.Publish(context.Background(), r.prefix+channel, message).Err()
+}
+
+func (r *Redis) Subscribe(channel string) (<-chan []byte, func()) {
+	sub := r.client.Subscribe(context.Background(), r.prefix+channel)
+	out := make(chan []byte, 64)
+
+	go func() {
+		defer close(out)
+		for msg := range sub.Channel() {
+			select {
+			case out <- []byte(msg.Payload):
+			default:
+			}
+		}
+	}()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { sub.Close() })
+	}
+	return out, cancel
+}
@@ -0,0 +1,706 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/nolouch/alerts-platform-v2/internal/logging"
+)
+
+// Config holds runtime settings that can be re-read without restarting the server.
+// Each setting comes from its environment variable, or failing that from the config file.
+type Config struct {
+	ConfigFile               string          `json:"config_file"` // empty when none was found
+	Addr                     string          `json:"addr"`        // HOST:PORT; read at startup only
+	DBDriver                 string          `json:"db_driver"`   // sqlite, postgres or mysql; read at startup only
+	DBDSN                    string          `json:"-"`
+	RedisURL                 string          `json:"-"`                 // shared cache; in-memory when empty, read at startup only
+	CacheMaxEntries          int             `json:"cache_max_entries"` // bound of the in-memory cache, read at startup only
+	TrustedProxies           []string        `json:"trusted_proxies"`   // IPs or CIDRs whose X-Forwarded-For is believed; read at startup only
+	Jira                     JiraConfig      `json:"jira"`
+	RulesSubDirs             []string        `json:"rules_subdirs"` // rule directories under RunbooksRepoPath
+	ComponentCategoriesPath  string          `json:"component_categories_path"`
+	ComponentAttributionPath string          `json:"component_attribution_path"`
+	ValueNormalizationPath   string          `json:"value_normalization_path"`
+	RulesCategoriesPath      string          `json:"rules_categories_path"`
+	RulesNotifyPath          string          `json:"rules_notify_path"`
+	RunbooksRepoPath         string          `json:"runbooks_repo_path"`
+	RuleTemplatesPath        string          `json:"rule_templates_path"`
+	SchedulerInterval        time.Duration   `json:"scheduler_interval"`
+	RequestTimeout           time.Duration   `json:"request_timeout"`
+	RuleAuditInterval        time.Duration   `json:"rule_audit_interval"`
+	SlowQueryThreshold       time.Duration   `json:"slow_query_threshold"`
+	LogLevel                 string          `json:"log_level"`
+	LogFormat                string          `json:"log_format"` // text or json; read at startup only
+	DedupWindow              time.Duration   `json:"dedup_window"`
+	ExportTarget             string          `json:"export_target"`
+	ExportInterval           time.Duration   `json:"export_interval"`
+	JobRetention             time.Duration   `json:"job_retention"`
+	Retention                RetentionPolicy `json:"retention"`
+	RetentionInterval        time.Duration   `json:"retention_interval"`
+	NameAPIURL               string          `json:"name_api_url"`
+	NameCacheTTL             time.Duration   `json:"name_cache_ttl"`
+	NameCacheSize            int             `json:"name_cache_size"`
+	NameFallbackPath         string          `json:"name_fallback_path"`
+	Anonymize                bool            `json:"anonymize"`
+	APIKeys                  []APIKey        `json:"-"`
+	APIAnonymousRead         bool            `json:"api_anonymous_read"`
+	OIDC                     OIDCConfig      `json:"oidc"`
+
+	values source // config file settings, for Lookup
+}
+
+// JiraConfig is the JIRA server alerts are imported from. Projects are searched in order;
+// each project's alerts are labelled O11Y:<Label>.
+type JiraConfig struct {
+	Server   string        `json:"server"`
+	User     string        `json:"user"`
+	Token    string        `json:"-"`
+	Projects []JiraProject `json:"projects"`
+}
+
+// JiraProject is one JIRA project key and the label its alerts are searched under
+type JiraProject struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// Enabled reports whether JIRA credentials are configured
+func (j JiraConfig) Enabled() bool {
+	return j.User != "" && j.Token != ""
+}
+
+// OIDCConfig enables browser login through an OpenID Connect provider (Okta, Google, ...)
+// when Issuer is set. Sessions are signed cookies, so every replica needs the same SessionSecret.
+type OIDCConfig struct {
+	Issuer         string        `json:"issuer"`
+	ClientID       string        `json:"client_id"`
+	ClientSecret   string        `json:"-"`
+	RedirectURL    string        `json:"redirect_url"`    // must point at /api/auth/callback
+	AllowedDomains []string      `json:"allowed_domains"` // email domains allowed to log in; empty allows all
+	SessionSecret  string        `json:"-"`
+	SessionTTL     time.Duration `json:"session_ttl"`
+	DefaultRole    string        `json:"default_role"` // for users without an assigned role
+	AdminEmails    []string      `json:"admin_emails"` // always admin, so roles can be assigned on a fresh install
+}
+
+// Enabled reports whether OIDC login is configured
+func (o OIDCConfig) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// RetentionPolicy says how many days issues are kept before the retention job archives
+// them. Rules override Days for a project, a priority or both; 0 days keeps forever.
+type RetentionPolicy struct {
+	Days  int             `json:"days"`
+	Rules []RetentionRule `json:"rules"`
+}
+
+// RetentionRule is one "PROJECT/PRIORITY=DAYS" entry of RETENTION_RULES; "*" matches any
+type RetentionRule struct {
+	Project  string `json:"project"`
+	Priority string `json:"priority"`
+	Days     int    `json:"days"`
+}
+
+// Enabled reports whether any issues can expire
+func (p RetentionPolicy) Enabled() bool {
+	if p.Days > 0 {
+		return true
+	}
+	for _, r := range p.Rules {
+		if r.Days > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DaysFor returns the retention for issues of project and priority: the most specific
+// matching rule (project and priority, then project, then priority), else Days
+func (p RetentionPolicy) DaysFor(project, priority string) int {
+	days, best := p.Days, 0
+	for _, r := range p.Rules {
+		rank := 0
+		if r.Project != "*" {
+			if !strings.EqualFold(r.Project, project) {
+				continue
+			}
+			rank += 2
+		}
+		if r.Priority != "*" {
+			if !strings.EqualFold(r.Priority, priority) {
+				continue
+			}
+			rank++
+		}
+		if rank > best || (rank == 0 && best == 0) {
+			days, best = r.Days, rank
+		}
+	}
+	return days
+}
+
+// APIKey is a static key from API_KEYS; keys can also be issued at runtime and kept in the DB
+type APIKey struct {
+	Name string
+	Role string
+	Key  string
+}
+
+// Roles, each allowed everything the one before it is: viewers read, editors also
+// triage issues (mute, notes, tasks), admins also change rules, config and syncs
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// ParseRole accepts a role, or the read/write scopes API keys had before roles
+// (read is viewer, write is admin)
+func ParseRole(s string) (string, bool) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "read":
+		return RoleViewer, true
+	case "write":
+		return RoleAdmin, true
+	}
+	_, ok := roleRank[s]
+	return s, ok
+}
+
+// RoleAllows reports whether role carries the rights of required
+func RoleAllows(role, required string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[required]
+}
+
+var (
+	current     *Config
+	currentMu   sync.RWMutex
+	subscribers []func(*Config)
+	subMu       sync.Mutex
+)
+
+// Load builds a fresh Config from the config file, the environment and the config directory
+func Load() (*Config, error) {
+	src, path, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	cfg := defaults(src)
+	cfg.ConfigFile = path
+	cfg.values = src
+
+	if err := loadServer(src, cfg); err != nil {
+		return nil, err
+	}
+
+	if v := src.get("SCHEDULER_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCHEDULER_INTERVAL %q: %w", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("SCHEDULER_INTERVAL must be positive, got %s", v)
+		}
+		cfg.SchedulerInterval = d
+	}
+
+	if v := src.get("REQUEST_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REQUEST_TIMEOUT %q: %w", v, err)
+		}
+		cfg.RequestTimeout = d
+	}
+
+	if v := src.get("RULES_AUDIT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RULES_AUDIT_INTERVAL %q: %w", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("RULES_AUDIT_INTERVAL must be positive, got %s", v)
+		}
+		cfg.RuleAuditInterval = d
+	}
+
+	if v := src.get("SLOW_QUERY_THRESHOLD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLOW_QUERY_THRESHOLD %q: %w", v, err)
+		}
+		cfg.SlowQueryThreshold = d
+	}
+
+	if v := src.get("LOG_LEVEL"); v != "" {
+		if _, err := logging.ParseLevel(v); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+		}
+		cfg.LogLevel = strings.ToLower(v)
+	}
+
+	if v := src.get("LOG_FORMAT"); v != "" {
+		v = strings.ToLower(v)
+		if v != "text" && v != "json" {
+			return nil, fmt.Errorf("invalid LOG_FORMAT %q, want text or json", v)
+		}
+		cfg.LogFormat = v
+	}
+
+	if v := src.get("DEDUP_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DEDUP_WINDOW %q: %w", v, err)
+		}
+		cfg.DedupWindow = d
+	}
+
+	if v := src.get("EXPORT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXPORT_INTERVAL %q: %w", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("EXPORT_INTERVAL must be positive, got %s", v)
+		}
+		cfg.ExportInterval = d
+	}
+
+	if v := src.get("JOB_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JOB_RETENTION %q: %w", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("JOB_RETENTION must be positive, got %s", v)
+		}
+		cfg.JobRetention = d
+	}
+
+	if err := loadRetention(src, cfg); err != nil {
+		return nil, err
+	}
+
+	if v := src.get("NAME_API_URL"); v != "" {
+		cfg.NameAPIURL = strings.TrimSuffix(v, "/")
+	}
+
+	if v := src.get("NAME_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NAME_CACHE_TTL %q: %w", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("NAME_CACHE_TTL must be positive, got %s", v)
+		}
+		cfg.NameCacheTTL = d
+	}
+
+	if v := src.get("NAME_CACHE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NAME_CACHE_SIZE %q: %w", v, err)
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("NAME_CACHE_SIZE must be positive, got %s", v)
+		}
+		cfg.NameCacheSize = n
+	}
+
+	if v := src.get("ANONYMIZE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ANONYMIZE %q: %w", v, err)
+		}
+		cfg.Anonymize = b
+	}
+
+	if v := src.get("API_KEYS"); v != "" {
+		keys, err := parseAPIKeys(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API_KEYS: %w", err)
+		}
+		cfg.APIKeys = keys
+	}
+
+	if v := src.get("API_ANONYMOUS_READ"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API_ANONYMOUS_READ %q: %w", v, err)
+		}
+		cfg.APIAnonymousRead = b
+	}
+
+	if err := loadOIDC(src, &cfg.OIDC); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadServer reads the listen address, database, cache, JIRA and rules repo settings
+func loadServer(src source, cfg *Config) error {
+	port := src.get("PORT")
+	if port == "" {
+		port = "8818"
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("invalid PORT %q: must be a number between 1 and 65535", port)
+	}
+	cfg.Addr = src.get("HOST") + ":" + port
+
+	if v := src.get("DB_DRIVER"); v != "" {
+		cfg.DBDriver = strings.ToLower(v)
+	}
+	switch cfg.DBDriver {
+	case "sqlite":
+	case "postgres", "mysql":
+		if src.get("DB_DSN") == "" {
+			return fmt.Errorf("DB_DSN is required for DB_DRIVER=%s", cfg.DBDriver)
+		}
+	default:
+		return fmt.Errorf("invalid DB_DRIVER %q: must be sqlite, postgres or mysql", cfg.DBDriver)
+	}
+	if v := src.get("DB_DSN"); v != "" {
+		cfg.DBDSN = v
+	}
+	cfg.RedisURL = src.get("REDIS_URL")
+	if v := src.get("CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid CACHE_MAX_ENTRIES %q: %w", v, err)
+		}
+		if n <= 0 {
+			return fmt.Errorf("CACHE_MAX_ENTRIES must be positive, got %s", v)
+		}
+		cfg.CacheMaxEntries = n
+	}
+	if v := src.get("TRUSTED_PROXIES"); v != "" {
+		cfg.TrustedProxies = nil
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			if net.ParseIP(p) == nil {
+				if _, _, err := net.ParseCIDR(p); err != nil {
+					return fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be an IP or CIDR", p)
+				}
+			}
+			cfg.TrustedProxies = append(cfg.TrustedProxies, p)
+		}
+	}
+
+	if v := src.get("JIRA_SERVER"); v != "" {
+		cfg.Jira.Server = strings.TrimSuffix(v, "/")
+	}
+	cfg.Jira.User = src.get("JIRA_USER")
+	cfg.Jira.Token = src.get("JIRA_TOKEN")
+	if v := src.get("JIRA_PROJECTS"); v != "" {
+		projects, err := parseJiraProjects(v)
+		if err != nil {
+			return fmt.Errorf("invalid JIRA_PROJECTS: %w", err)
+		}
+		cfg.Jira.Projects = projects
+	}
+
+	if v := src.get("RUNBOOKS_RULES_SUBDIRS"); v != "" {
+		cfg.RulesSubDirs = nil
+		for _, dir := range strings.Split(v, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				cfg.RulesSubDirs = append(cfg.RulesSubDirs, dir)
+			}
+		}
+	}
+	return nil
+}
+
+// parseJiraProjects reads comma-separated "KEY" or "KEY:Label" entries; the label
+// defaults to the key
+func parseJiraProjects(v string) ([]JiraProject, error) {
+	var projects []JiraProject
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, label, _ := strings.Cut(entry, ":")
+		if key == "" {
+			return nil, fmt.Errorf("entry %q has no project key", entry)
+		}
+		if label == "" {
+			label = key
+		}
+		projects = append(projects, JiraProject{Key: key, Label: label})
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("no projects listed")
+	}
+	return projects, nil
+}
+
+// loadRetention reads RETENTION_DAYS, RETENTION_RULES and RETENTION_INTERVAL
+func loadRetention(src source, cfg *Config) error {
+	if v := src.get("RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid RETENTION_DAYS %q: must be a number of days, 0 to keep forever", v)
+		}
+		cfg.Retention.Days = n
+	}
+
+	if v := src.get("RETENTION_RULES"); v != "" {
+		rules, err := parseRetentionRules(v)
+		if err != nil {
+			return fmt.Errorf("invalid RETENTION_RULES: %w", err)
+		}
+		cfg.Retention.Rules = rules
+	}
+
+	if v := src.get("RETENTION_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid RETENTION_INTERVAL %q: %w", v, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("RETENTION_INTERVAL must be positive, got %s", v)
+		}
+		cfg.RetentionInterval = d
+	}
+	return nil
+}
+
+// parseRetentionRules reads comma-separated "PROJECT/PRIORITY=DAYS" entries, e.g.
+// "O11YDEV/*=90,*/Minor=30"
+func parseRetentionRules(v string) ([]RetentionRule, error) {
+	var rules []RetentionRule
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		match, days, ok := strings.Cut(entry, "=")
+		project, priority, hasPriority := strings.Cut(match, "/")
+		if !ok || !hasPriority {
+			return nil, fmt.Errorf("entry %q: want PROJECT/PRIORITY=DAYS", entry)
+		}
+		project, priority = strings.TrimSpace(project), strings.TrimSpace(priority)
+		if project == "" || priority == "" {
+			return nil, fmt.Errorf("entry %q: use * to match any project or priority", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("entry %q: days must be a number, 0 to keep forever", entry)
+		}
+		rules = append(rules, RetentionRule{Project: project, Priority: priority, Days: n})
+	}
+	return rules, nil
+}
+
+// loadOIDC reads the OIDC_* settings; once OIDC_ISSUER is set the client and
+// session settings are required
+func loadOIDC(src source, o *OIDCConfig) error {
+	o.Issuer = strings.TrimSuffix(src.get("OIDC_ISSUER"), "/")
+	o.ClientID = src.get("OIDC_CLIENT_ID")
+	o.ClientSecret = src.get("OIDC_CLIENT_SECRET")
+	o.RedirectURL = src.get("OIDC_REDIRECT_URL")
+	o.SessionSecret = src.get("SESSION_SECRET")
+	for _, d := range strings.Split(src.get("OIDC_ALLOWED_DOMAINS"), ",") {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			o.AllowedDomains = append(o.AllowedDomains, d)
+		}
+	}
+	for _, e := range strings.Split(src.get("OIDC_ADMIN_EMAILS"), ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			o.AdminEmails = append(o.AdminEmails, e)
+		}
+	}
+
+	if v := src.get("OIDC_DEFAULT_ROLE"); v != "" {
+		role, ok := ParseRole(v)
+		if !ok {
+			return fmt.Errorf("invalid OIDC_DEFAULT_ROLE %q, want %s, %s or %s", v, RoleViewer, RoleEditor, RoleAdmin)
+		}
+		o.DefaultRole = role
+	}
+
+	if v := src.get("SESSION_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid SESSION_TTL %q: %w", v, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("SESSION_TTL must be positive, got %s", v)
+		}
+		o.SessionTTL = d
+	}
+
+	if !o.Enabled() {
+		return nil
+	}
+	required := []struct{ env, value string }{
+		{"OIDC_CLIENT_ID", o.ClientID},
+		{"OIDC_CLIENT_SECRET", o.ClientSecret},
+		{"OIDC_REDIRECT_URL", o.RedirectURL},
+		{"SESSION_SECRET", o.SessionSecret},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return fmt.Errorf("%s is required when OIDC_ISSUER is set", r.env)
+		}
+	}
+	return nil
+}
+
+// parseAPIKeys reads comma-separated "name:role:key" entries
+func parseAPIKeys(v string) ([]APIKey, error) {
+	var keys []APIKey
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("entry %q is not name:role:key", parts[0])
+		}
+		role, ok := ParseRole(parts[1])
+		if !ok {
+			return nil, fmt.Errorf("key %s has role %q, want %s, %s or %s", parts[0], parts[1], RoleViewer, RoleEditor, RoleAdmin)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("key name %s is used twice", parts[0])
+		}
+		seen[parts[0]] = true
+		keys = append(keys, APIKey{Name: parts[0], Role: role, Key: parts[2]})
+	}
+	return keys, nil
+}
+
+// Get returns the active config, loading it on first use
+func Get() *Config {
+	currentMu.RLock()
+	cfg := current
+	currentMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		loaded, err := Load()
+		if err != nil {
+			slog.Warn("Invalid configuration, using defaults", "err", err)
+			loaded = defaults(nil)
+		}
+		current = loaded
+	}
+	return current
+}
+
+func defaults(src source) *Config {
+	return &Config{
+		ComponentCategoriesPath:  resolvePath(src, "COMPONENT_CATEGORIES_PATH", "component_categories.yaml"),
+		ComponentAttributionPath: resolvePath(src, "COMPONENT_ATTRIBUTION_PATH", "component_attribution.yaml"),
+		ValueNormalizationPath:   resolvePath(src, "VALUE_NORMALIZATION_PATH", "value_normalization.yaml"),
+		RulesCategoriesPath:      resolvePath(src, "RULES_CATEGORIES_PATH", "rules_categories.yaml"),
+		RulesNotifyPath:          resolvePath(src, "RULES_NOTIFY_PATH", "rules_notify_manager.yaml"),
+		RunbooksRepoPath:         src.get("RUNBOOKS_REPO_PATH"),
+		Addr:                     ":8818",
+		DBDriver:                 "sqlite",
+		DBDSN:                    "./alerts_v2.db",
+		Jira: JiraConfig{
+			Server:   "https://tidb.atlassian.net",
+			Projects: []JiraProject{{"O11YDEV", "O11YDEV"}, {"O11YSTAG", "O11YSTAG"}, {"O11Y", "O11Y"}},
+		},
+		RulesSubDirs:       []string{"rules/cluster-next-gen", "rules/dedicated", "rules/logging"},
+		RuleTemplatesPath:  resolvePath(src, "RULE_TEMPLATES_PATH", "rule_templates"),
+		SchedulerInterval:  1 * time.Hour,
+		RequestTimeout:     30 * time.Second,
+		RuleAuditInterval:  24 * time.Hour,
+		SlowQueryThreshold: 500 * time.Millisecond,
+		LogLevel:           "info",
+		LogFormat:          "text",
+		DedupWindow:        10 * time.Minute,
+		ExportTarget:       src.get("EXPORT_TARGET"),
+		ExportInterval:     6 * time.Hour,
+		JobRetention:       24 * time.Hour,
+		RetentionInterval:  24 * time.Hour,
+		NameAPIURL:         "http://10.2.8.101:3535",
+		NameCacheTTL:       24 * time.Hour,
+		NameCacheSize:      10000,
+		CacheMaxEntries:    100000,
+		NameFallbackPath:   resolvePath(src, "NAME_FALLBACK_PATH", "name_fallback.yaml"),
+		OIDC:               OIDCConfig{SessionTTL: 12 * time.Hour, DefaultRole: RoleViewer},
+	}
+}
+
+// OnReload registers a callback invoked with the new config after every successful reload
+func OnReload(fn func(*Config)) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// Reload re-reads .env and the config files, swaps the active config and notifies subscribers.
+// On error the previous config stays active.
+func Reload() (*Config, error) {
+	// Overload so edited values in .env replace the ones loaded at startup
+	if err := godotenv.Overload(); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Unable to reload .env file", "err", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	currentMu.Lock()
+	current = cfg
+	currentMu.Unlock()
+
+	subMu.Lock()
+	fns := make([]func(*Config), len(subscribers))
+	copy(fns, subscribers)
+	subMu.Unlock()
+
+	for _, fn := range fns {
+		fn(cfg)
+	}
+
+	slog.Info("Configuration reloaded", "scheduler_interval", cfg.SchedulerInterval)
+	return cfg, nil
+}
+
+// resolvePath returns the configured path if set, otherwise the first existing
+// candidate under the usual config directories (backend may run from several cwds)
+func resolvePath(src source, envVar, name string) string {
+	if envVar != "" {
+		if v := src.get(envVar); v != "" {
+			return v
+		}
+	}
+
+	candidates := []string{
+		"../config/" + name,
+		"../../config/" + name,
+		"config/" + name,
+	}
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return "config/" + name
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source resolves settings by their environment variable name: the environment wins,
+// then the config file
+type source map[string]string
+
+func (s source) get(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return s[key]
+}
+
+// readConfigFile loads CONFIG_FILE (default config/config.yaml). Nested keys are joined
+// with "_" and upper-cased, so jira.server sets JIRA_SERVER, and lists become
+// comma-separated values. A missing default file is fine; a missing CONFIG_FILE is not.
+func readConfigFile() (source, string, error) {
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = resolvePath(nil, "", "config.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && os.IsNotExist(err) {
+			return source{}, "", nil
+		}
+		return nil, path, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, path, fmt.Errorf("config file %s: %w", path, err)
+	}
+	values := source{}
+	if err := flattenConfig("", root, values); err != nil {
+		return nil, path, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, path, nil
+}
+
+func flattenConfig(prefix string, node map[string]interface{}, out source) error {
+	for k, v := range node {
+		key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(k))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(key, val, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("%s: list entries must be plain values", strings.ToLower(key))
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the setting named key from the environment or the config file, for
+// settings without a Config field
+func Lookup(key string) string {
+	return Get().values.get(key)
+}
+
+// Init loads the config and makes it active, failing on any invalid setting.
+// The server calls it at startup so a bad config stops it with a clear error
+// instead of running on defaults.
+func Init() (*Config, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	currentMu.Lock()
+	current = cfg
+	currentMu.Unlock()
+	return cfg, nil
+}
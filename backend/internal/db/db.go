@@ -1,34 +1,64 @@
 package db
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 
-	"gorm.io/driver/sqlite"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
 func Init() error {
-	var err error
+	if err := Open(); err != nil {
+		return err
+	}
+
+	// Run migration to ensure schema is up to date
+	slog.Info("Running database migration")
+	if err := MigrateDatabase(DB); err != nil {
+		slog.Error("Migration failed", "err", err)
+		return err
+	}
+
+	return nil
+}
 
-	// Use local database in backend directory
-	dbPath := "./alerts_v2.db"
-	log.Printf("Connecting to database at: %s", dbPath)
+// Open connects to the database without running migrations. DB_DRIVER selects
+// sqlite (default), postgres or mysql; DB_DSN is the SQLite path (default
+// ./alerts_v2.db) or the server connection string.
+func Open() error {
+	cfg := config.Get()
+	name := cfg.DBDriver
+	open, ok := dialectors[name]
+	if !ok {
+		switch name {
+		case DriverPostgres, DriverMySQL:
+			return fmt.Errorf("DB_DRIVER=%s is not compiled in, build with -tags %s", name, name)
+		}
+		return fmt.Errorf("unsupported DB_DRIVER %q: must be sqlite, postgres or mysql", name)
+	}
+
+	dsn := cfg.DBDSN
+	if name == DriverSQLite {
+		slog.Info("Connecting to database", "dsn", dsn)
+	} else {
+		slog.Info("Connecting to database", "driver", name)
+	}
 
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	var err error
+	DB, err = gorm.Open(open(dsn), &gorm.Config{})
 	if err != nil {
 		return err
 	}
+	driver = name
 
-	log.Println("Database connection established")
+	slog.Info("Database connection established")
 
-	// Run migration to ensure schema is up to date
-	log.Println("Running database migration...")
-	if err := MigrateDatabase(DB); err != nil {
-		log.Printf("Migration error: %v", err)
+	// Time every statement for slow-query logging and GET /api/admin/query-stats
+	if err := DB.Use(queryStatsPlugin{}); err != nil {
 		return err
 	}
-
 	return nil
 }
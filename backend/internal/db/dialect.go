@@ -0,0 +1,146 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Supported DB_DRIVER values
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// dialectors maps DB_DRIVER to its gorm dialector. Postgres and MySQL register
+// themselves from files built with -tags postgres / -tags mysql, so the default
+// build only links SQLite.
+var dialectors = map[string]func(dsn string) gorm.Dialector{
+	DriverSQLite: sqlite.Open,
+}
+
+// driver is the dialect of DB; raw SQL that differs between databases asks for it
+var driver = DriverSQLite
+
+// Driver returns the dialect of the open database
+func Driver() string {
+	return driver
+}
+
+// WeekKey formats a "YYYY-MM-DD HH:MM:SS" expression as a "YYYY-WW" key with
+// Monday-first week numbers (SQLite's %W)
+func WeekKey(expr string) string {
+	switch driver {
+	case DriverPostgres:
+		ts := "CAST(" + expr + " AS TIMESTAMP)"
+		return "to_char(" + ts + ", 'YYYY') || '-' || lpad(CAST((EXTRACT(DOY FROM " + ts + ")::int + 6 - (EXTRACT(ISODOW FROM " + ts + ")::int - 1)) / 7 AS text), 2, '0')"
+	case DriverMySQL:
+		return "CONCAT(DATE_FORMAT(" + expr + ", '%Y'), '-', LPAD(WEEK(" + expr + ", 5), 2, '0'))"
+	}
+	return "strftime('%Y-%W', " + expr + ")"
+}
+
+// HoursSince is the number of hours between a UTC "YYYY-MM-DD HH:MM:SS" expression and now
+func HoursSince(expr string) string {
+	switch driver {
+	case DriverPostgres:
+		return "EXTRACT(EPOCH FROM ((now() AT TIME ZONE 'UTC') - CAST(" + expr + " AS TIMESTAMP))) / 3600"
+	case DriverMySQL:
+		return "TIMESTAMPDIFF(SECOND, " + expr + ", UTC_TIMESTAMP()) / 3600"
+	}
+	return "(julianday('now') - julianday(" + expr + ")) * 24"
+}
+
+// JSONEach is a FROM item expanding a JSON array of strings into rows of alias.value.
+// Values that are not a JSON array expand to no rows.
+func JSONEach(expr, alias string) string {
+	switch driver {
+	case DriverPostgres:
+		return "jsonb_array_elements_text(CASE WHEN " + expr + " LIKE '[%' THEN CAST(" + expr + " AS jsonb) ELSE '[]'::jsonb END) AS " + alias + "(value)"
+	case DriverMySQL:
+		return "JSON_TABLE(CASE WHEN JSON_VALID(" + expr + ") THEN " + expr + " ELSE '[]' END, '$[*]' COLUMNS (value VARCHAR(255) PATH '$')) AS " + alias
+	}
+	return "json_each(CASE WHEN json_valid(" + expr + ") THEN " + expr + " ELSE '[]' END) " + alias
+}
+
+// Concat joins string expressions (MySQL reads || as OR)
+func Concat(parts ...string) string {
+	if driver == DriverMySQL {
+		return "CONCAT(" + strings.Join(parts, ", ") + ")"
+	}
+	return strings.Join(parts, " || ")
+}
+
+// TimestampType is the column type for a date and time without zone
+func TimestampType() string {
+	if driver == DriverPostgres {
+		return "TIMESTAMP"
+	}
+	return "DATETIME"
+}
+
+// CastTimestamp converts a "YYYY-MM-DD HH:MM:SS" expression for a TimestampType column
+func CastTimestamp(expr string) string {
+	if driver == DriverSQLite {
+		return expr
+	}
+	return "CAST(" + expr + " AS " + TimestampType() + ")"
+}
+
+// OnConflictUpdate starts the upsert clause for a unique key; assignments follow,
+// using Excluded for the proposed values
+func OnConflictUpdate(key string) string {
+	if driver == DriverMySQL {
+		return "ON DUPLICATE KEY UPDATE"
+	}
+	return "ON CONFLICT(" + key + ") DO UPDATE SET"
+}
+
+// Excluded refers to the value proposed for column in an upsert
+func Excluded(column string) string {
+	if driver == DriverMySQL {
+		return "VALUES(" + column + ")"
+	}
+	return "excluded." + column
+}
+
+// UpsertInto builds an INSERT that updates the row with the same key in place, with a
+// placeholder per column. Columns left out keep their values, update triggers fire and
+// the SQLite rowid, which issues_fts is keyed on, is kept.
+func UpsertInto(table, key string, columns []string) string {
+	values := "?" + strings.Repeat(", ?", len(columns)-1)
+	sets := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if col != key {
+			sets = append(sets, col+" = "+Excluded(col))
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) %s %s", table, strings.Join(columns, ", "), values,
+		OnConflictUpdate(key), strings.Join(sets, ", "))
+}
+
+// Rebind rewrites ? placeholders for the driver. gorm does this itself; it is only
+// needed for queries run on the underlying *sql.DB.
+func Rebind(query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	inQuote := false
+	for _, ch := range query {
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+		case ch == '?' && !inQuote:
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+//go:build mysql
+
+package db
+
+import (
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Built with -tags mysql
+func init() {
+	dialectors[DriverMySQL] = func(dsn string) gorm.Dialector {
+		// Sized strings so indexed string columns don't become unindexable longtext
+		return mysql.New(mysql.Config{DSN: noBackslashEscapes(dsn), DefaultStringSize: 191})
+	}
+}
+
+// noBackslashEscapes adds NO_BACKSLASH_ESCAPES to the session's sql_mode. Filters quote
+// values by doubling single quotes, which only holds when a backslash can't escape the
+// quote after it as MySQL's default mode allows. A DSN that sets sql_mode itself is kept.
+func noBackslashEscapes(dsn string) string {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return dsn // the driver reports it on open
+	}
+	if _, ok := cfg.Params["sql_mode"]; ok {
+		return dsn
+	}
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	cfg.Params["sql_mode"] = "CONCAT(@@sql_mode, ',NO_BACKSLASH_ESCAPES')"
+	return cfg.FormatDSN()
+}
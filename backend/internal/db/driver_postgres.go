@@ -0,0 +1,10 @@
+//go:build postgres
+
+package db
+
+import "gorm.io/driver/postgres"
+
+// Built with -tags postgres
+func init() {
+	dialectors[DriverPostgres] = postgres.Open
+}
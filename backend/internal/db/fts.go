@@ -0,0 +1,95 @@
+package db
+
+import (
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// issueFTSTriggers keep issues_fts in step with issues. It is an external-content table
+// over the issues rowid, so changed and deleted rows must hand FTS5 their old text.
+var issueFTSTriggers = map[string]string{
+	"issues_fts_ai": `CREATE TRIGGER issues_fts_ai AFTER INSERT ON issues BEGIN
+		INSERT INTO issues_fts(rowid, title, description, labels) VALUES (new.rowid, new.title, new.description, new.labels);
+	END`,
+	"issues_fts_ad": `CREATE TRIGGER issues_fts_ad AFTER DELETE ON issues BEGIN
+		INSERT INTO issues_fts(issues_fts, rowid, title, description, labels) VALUES ('delete', old.rowid, old.title, old.description, old.labels);
+	END`,
+	"issues_fts_au": `CREATE TRIGGER issues_fts_au AFTER UPDATE OF title, description, labels ON issues BEGIN
+		INSERT INTO issues_fts(issues_fts, rowid, title, description, labels) VALUES ('delete', old.rowid, old.title, old.description, old.labels);
+		INSERT INTO issues_fts(rowid, title, description, labels) VALUES (new.rowid, new.title, new.description, new.labels);
+	END`,
+}
+
+var issueFTSEnabled atomic.Bool
+
+// IssueFTSEnabled reports whether issues_fts, the SQLite FTS5 index over issue titles,
+// descriptions and labels, is maintained and can be queried
+func IssueFTSEnabled() bool {
+	return issueFTSEnabled.Load()
+}
+
+// EnsureIssueFTS creates issues_fts and its triggers on SQLite builds with FTS5 (-tags
+// sqlite_fts5) and rebuilds the index whenever the triggers were missing. Without FTS5
+// the triggers are dropped, since writes to issues would fail on them, and search falls
+// back to LIKE; a later FTS5 build then rebuilds the stale index.
+func EnsureIssueFTS(db *gorm.DB) error {
+	issueFTSEnabled.Store(false)
+	if driver != DriverSQLite {
+		return nil
+	}
+
+	if !fts5Available(db) {
+		for name := range issueFTSTriggers {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+				return err
+			}
+		}
+		slog.Warn("SQLite was built without FTS5, issue search falls back to LIKE; build with -tags sqlite_fts5")
+		return nil
+	}
+
+	if err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS issues_fts USING fts5(
+		title, description, labels, content='issues', content_rowid='rowid')`).Error; err != nil {
+		return err
+	}
+	rebuild := false
+	for name, ddl := range issueFTSTriggers {
+		var n int64
+		if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?", name).Scan(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if err := db.Exec(ddl).Error; err != nil {
+			return err
+		}
+		rebuild = true
+	}
+	if rebuild {
+		slog.Info("Rebuilding the issue full-text index")
+		if err := db.Exec("INSERT INTO issues_fts(issues_fts) VALUES ('rebuild')").Error; err != nil {
+			return err
+		}
+	}
+
+	issueFTSEnabled.Store(true)
+	return nil
+}
+
+// fts5Available reports whether the linked SQLite has the FTS5 module
+func fts5Available(db *gorm.DB) bool {
+	var options []string
+	if err := db.Raw("PRAGMA compile_options").Scan(&options).Error; err != nil {
+		return false
+	}
+	for _, o := range options {
+		if strings.EqualFold(o, "ENABLE_FTS5") {
+			return true
+		}
+	}
+	return false
+}
@@ -2,53 +2,53 @@ package db
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"gorm.io/gorm"
 )
 
-// MigrateDatabase performs database schema migration
+// MigrateDatabase performs database schema migration. AutoMigrate only handles additive
+// model changes; anything needing backfill, dual-write or a way back goes in migrations.go.
 func MigrateDatabase(db *gorm.DB) error {
-	fmt.Println("🔄 Starting database migration...")
+	slog.Info("Starting database migration")
 
-	// Check if old schema exists
+	// Check if old schema exists. Pre-versioning databases are still upgraded by
+	// rename-and-recreate; later schema changes go through versioned migrations.
 	if db.Migrator().HasTable("issues") {
 		// Check if we have the new schema or old schema
 		hasDescription := db.Migrator().HasColumn(&models.Issue{}, "description")
 		hasProject := db.Migrator().HasColumn(&models.Issue{}, "project")
 
 		if !hasDescription || !hasProject {
-			fmt.Println("⚠️  Old schema detected. Migration required.")
-			fmt.Println("📋 This will:")
-			fmt.Println("   1. Backup existing data (if any)")
-			fmt.Println("   2. Drop old table")
-			fmt.Println("   3. Create new table with updated schema")
+			// Back up existing data (if any), drop the old table and create the new one
+			slog.Warn("Old schema detected, recreating the issues table")
 
 			// Backup table if it has data
 			var count int64
 			db.Table("issues").Count(&count)
 			if count > 0 {
 				backupTable := fmt.Sprintf("issues_backup_%d", int64(os.Getpid()))
-				fmt.Printf("💾 Backing up %d records to %s\n", count, backupTable)
+				slog.Info("Backing up issues", "records", count, "table", backupTable)
 
 				// Rename old table to backup
 				if err := db.Exec(fmt.Sprintf("ALTER TABLE issues RENAME TO %s", backupTable)).Error; err != nil {
 					return fmt.Errorf("failed to backup table: %w", err)
 				}
-				fmt.Printf("✅ Backup complete: %s\n", backupTable)
+				slog.Info("Backup complete", "table", backupTable)
 			} else {
 				// Drop empty old table
 				if err := db.Migrator().DropTable("issues"); err != nil {
 					return fmt.Errorf("failed to drop old table: %w", err)
 				}
-				fmt.Println("🗑️  Dropped empty old table")
+				slog.Info("Dropped empty old issues table")
 			}
 		}
 	}
 
 	// Create or update table with new schema
-	fmt.Println("🔨 Creating/updating table schema...")
+	slog.Info("Creating/updating table schema")
 	if err := db.AutoMigrate(&models.Issue{}); err != nil {
 		return fmt.Errorf("failed to migrate issues table: %w", err)
 	}
@@ -60,10 +60,49 @@ func MigrateDatabase(db *gorm.DB) error {
 		&models.AlertRule{},
 		&models.MutedIssue{},
 		&models.Task{},
+		&models.JobLock{},
+		&models.Tenant{},
+		&models.Cluster{},
+		&models.RuleAudit{},
+		&models.DashboardSnapshot{},
+		&models.NotificationRule{},
+		&models.ComponentTarget{},
+		&models.WarehouseExport{},
+		&models.FailedIssue{},
+		&models.TenantDigestRun{},
+		&models.IssueEvent{},
+		&models.ComponentOverride{},
+		&models.Job{},
+		&models.IngestSyncState{},
+		&models.APIKey{},
+		&models.MuteSuppression{},
+		&models.UserRole{},
+		&models.AuditLog{},
+		&models.ComponentHealthTransition{},
+		&models.Report{},
+		&models.MuteRule{},
+		&models.RuleRevision{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.NameCacheEntry{},
+		&models.ArchivedIssue{},
+		&models.RetentionRun{},
+		&models.IssueComponent{},
+		&models.SavedView{},
+		&models.IssueClassification{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate other tables: %w", err)
 	}
 
-	fmt.Println("✅ Database migration completed successfully")
+	// Versioned migrations run after AutoMigrate so they can build on the model tables
+	if err := RunMigrations(db); err != nil {
+		return err
+	}
+
+	if err := EnsureIssueFTS(db); err != nil {
+		return fmt.Errorf("failed to set up the issue full-text index: %w", err)
+	}
+
+	slog.Info("Database migration completed")
 	return nil
 }
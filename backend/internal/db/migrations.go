@@ -0,0 +1,318 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned, reversible schema change.
+//
+// Bigger changes roll out in three steps: Up adds the new structure alongside the old
+// one, writers dual-write both while DualWrite columns are enabled, and Backfill fills
+// the new structure for existing rows in batches. Readers switch over in a later
+// migration once BackfilledAt is set, and only then is the old structure dropped.
+type Migration struct {
+	Version int
+	Name    string
+	// Up and Down run inside a transaction
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error
+	// Backfill runs after Up outside the transaction; it must be idempotent since it
+	// is retried on every startup until it succeeds
+	Backfill func(db *gorm.DB) error
+	// DualWrite lists "table.column" targets writers should populate once Up is applied
+	DualWrite []string
+}
+
+// backfillBatchSize bounds each backfill UPDATE so large tables don't hold a long write lock
+const backfillBatchSize = 1000
+
+// migrations is the ordered list of versioned schema changes; append only
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "issues_created_ts",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("ALTER TABLE issues ADD COLUMN created_ts " + TimestampType()).Error; err != nil {
+				return err
+			}
+			if tx.Migrator().HasIndex("issues", "idx_issues_created_ts") {
+				return nil
+			}
+			return tx.Exec("CREATE INDEX idx_issues_created_ts ON issues(created_ts)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex("issues", "idx_issues_created_ts") {
+				if err := tx.Migrator().DropIndex("issues", "idx_issues_created_ts"); err != nil {
+					return err
+				}
+			}
+			return tx.Exec("ALTER TABLE issues DROP COLUMN created_ts").Error
+		},
+		Backfill: func(db *gorm.DB) error {
+			// The derived table lets MySQL limit a subquery on the table being updated
+			return backfillInBatches(db, `
+				UPDATE issues SET created_ts = `+CastTimestamp("REPLACE(created, ' UTC', '')")+`
+				WHERE id IN (SELECT id FROM (SELECT id FROM issues WHERE created_ts IS NULL AND created != '' LIMIT ?) pending)`)
+		},
+		DualWrite: []string{"issues.created_ts"},
+	},
+	{
+		Version: 2,
+		Name:    "api_keys_role",
+		// Keys issued before roles only have a read/write scope; read keys become
+		// viewers and write keys admins, which is what they could do before
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn("api_keys", "scope") {
+				return nil
+			}
+			return tx.Exec(`UPDATE api_keys SET role = CASE scope WHEN 'write' THEN 'admin' ELSE 'viewer' END
+				WHERE role IS NULL OR role = ''`).Error
+		},
+		// The scope column is left in place, so there is nothing to undo
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "issues_created_at_utc",
+		// Readers filter on the timestamp from here on, so the backfilled created_ts
+		// becomes created_at_utc and gets an index for the alert-only range scans
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex("issues", "idx_issues_created_ts") {
+				if err := tx.Migrator().DropIndex("issues", "idx_issues_created_ts"); err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec("ALTER TABLE issues RENAME COLUMN created_ts TO created_at_utc").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("CREATE INDEX idx_issues_created_at_utc ON issues(created_at_utc)").Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE INDEX idx_issues_alert_created_at_utc ON issues(is_alert, created_at_utc)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, index := range []string{"idx_issues_alert_created_at_utc", "idx_issues_created_at_utc"} {
+				if tx.Migrator().HasIndex("issues", index) {
+					if err := tx.Migrator().DropIndex("issues", index); err != nil {
+						return err
+					}
+				}
+			}
+			if err := tx.Exec("ALTER TABLE issues RENAME COLUMN created_at_utc TO created_ts").Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE INDEX idx_issues_created_ts ON issues(created_ts)").Error
+		},
+		// Catches rows stored while the migration was rolled back
+		Backfill: func(db *gorm.DB) error {
+			return backfillInBatches(db, `
+				UPDATE issues SET created_at_utc = `+CastTimestamp("REPLACE(created, ' UTC', '')")+`
+				WHERE id IN (SELECT id FROM (SELECT id FROM issues WHERE created_at_utc IS NULL AND created != '' LIMIT ?) pending)`)
+		},
+		DualWrite: []string{"issues.created_at_utc"},
+	},
+	{
+		Version: 4,
+		Name:    "issue_components",
+		// AutoMigrate creates the table; this fills it for issues stored before it existed
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		// Writers stop maintaining the table, so clear it for the backfill on re-apply
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM issue_components").Error
+		},
+		Backfill: func(db *gorm.DB) error {
+			// Batches are keyed on issue ID: issues without components add no rows, so the
+			// affected row count can't tell when backfillInBatches would be done
+			after, total := "", int64(0)
+			for {
+				var ids []string
+				if err := db.Table("issues").Where("id > ?", after).Order("id").Limit(backfillBatchSize).Pluck("id", &ids).Error; err != nil {
+					return err
+				}
+				if len(ids) == 0 {
+					break
+				}
+				res := db.Exec(`
+					INSERT INTO issue_components (issue_id, component)
+					SELECT DISTINCT issues.id, j.value FROM issues, `+JSONEach("issues.components", "j")+`
+					WHERE issues.id IN ? AND NOT EXISTS (SELECT 1 FROM issue_components ic WHERE ic.issue_id = issues.id)`, ids)
+				if res.Error != nil {
+					return res.Error
+				}
+				total += res.RowsAffected
+				after = ids[len(ids)-1]
+			}
+			slog.Info("Backfilled rows", "rows", total)
+			return nil
+		},
+		DualWrite: []string{"issue_components.component"},
+	},
+}
+
+var (
+	dualWriteMu      sync.RWMutex
+	dualWriteEnabled = map[string]bool{}
+)
+
+// DualWriteEnabled reports whether writers should populate a "table.column" target
+// added by an applied migration
+func DualWriteEnabled(target string) bool {
+	dualWriteMu.RLock()
+	defer dualWriteMu.RUnlock()
+	return dualWriteEnabled[target]
+}
+
+// RunMigrations applies pending migrations in version order and finishes any pending backfills
+func RunMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range sortedMigrations() {
+		record, ok := applied[m.Version]
+		if !ok {
+			slog.Info("Applying migration", "version", m.Version, "name", m.Name)
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&models.SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			record = models.SchemaMigration{Version: m.Version, Name: m.Name}
+		}
+
+		// Enable dual-write before the backfill so rows written meanwhile aren't missed
+		setDualWrite(m, true)
+
+		if m.Backfill != nil && record.BackfilledAt == nil {
+			slog.Info("Backfilling migration", "version", m.Version, "name", m.Name)
+			if err := m.Backfill(db); err != nil {
+				return fmt.Errorf("backfill %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			now := time.Now().UTC()
+			if err := db.Model(&models.SchemaMigration{}).Where("version = ?", m.Version).Update("backfilled_at", now).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RollbackMigrations reverts applied migrations newer than target, newest first
+func RollbackMigrations(db *gorm.DB, target int) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	ordered := sortedMigrations()
+	for i := len(ordered) - 1; i >= 0; i-- {
+		m := ordered[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return fmt.Errorf("migration %d_%s is not reversible", m.Version, m.Name)
+		}
+
+		slog.Info("Reverting migration", "version", m.Version, "name", m.Name)
+		// Stop dual-writing first so writers don't target a column that is going away
+		setDualWrite(m, false)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&models.SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			setDualWrite(m, true)
+			return fmt.Errorf("rollback of %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrationStatus lists every known migration with its applied/backfilled state
+func MigrationStatus(db *gorm.DB) ([]models.SchemaMigration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var status []models.SchemaMigration
+	for _, m := range sortedMigrations() {
+		if record, ok := applied[m.Version]; ok {
+			status = append(status, record)
+		} else {
+			status = append(status, models.SchemaMigration{Version: m.Version, Name: m.Name})
+		}
+	}
+	return status, nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]models.SchemaMigration, error) {
+	var records []models.SchemaMigration
+	if !db.Migrator().HasTable(&models.SchemaMigration{}) {
+		return map[int]models.SchemaMigration{}, nil
+	}
+	if err := db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	applied := make(map[int]models.SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+func sortedMigrations() []Migration {
+	ordered := append([]Migration(nil), migrations...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Version < ordered[j].Version })
+	return ordered
+}
+
+func setDualWrite(m Migration, enabled bool) {
+	dualWriteMu.Lock()
+	defer dualWriteMu.Unlock()
+	for _, target := range m.DualWrite {
+		dualWriteEnabled[target] = enabled
+	}
+}
+
+// backfillInBatches repeats an UPDATE taking a LIMIT placeholder until it touches no rows
+func backfillInBatches(db *gorm.DB, query string) error {
+	total := int64(0)
+	for {
+		res := db.Exec(query, backfillBatchSize)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			break
+		}
+		total += res.RowsAffected
+	}
+	slog.Info("Backfilled rows", "rows", total)
+	return nil
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/metrics"
+	"gorm.io/gorm"
+)
+
+// maxQueryPatterns caps the stats map; patterns beyond it are folded into one bucket
+const maxQueryPatterns = 500
+
+const otherQueryPattern = "(other)"
+
+// QueryStat aggregates timings for one normalized query pattern
+type QueryStat struct {
+	Pattern   string    `json:"pattern"`
+	Count     int64     `json:"count"`
+	Errors    int64     `json:"errors"`
+	SlowCount int64     `json:"slow_count"`
+	TotalMs   float64   `json:"total_ms"`
+	AvgMs     float64   `json:"avg_ms"`
+	MaxMs     float64   `json:"max_ms"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+var (
+	queryStatsMu    sync.Mutex
+	queryStats      = map[string]*QueryStat{}
+	queryStatsSince = time.Now().UTC()
+)
+
+var (
+	stringLiteralRe = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteralRe = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	inListRe        = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespaceRe    = regexp.MustCompile(`\s+`)
+)
+
+// normalizeQuery turns SQL into a pattern by replacing literals with placeholders,
+// so queries built by string concatenation group together
+func normalizeQuery(sql string) string {
+	pattern := stringLiteralRe.ReplaceAllString(sql, "?")
+	pattern = numberLiteralRe.ReplaceAllString(pattern, "?")
+	pattern = inListRe.ReplaceAllString(pattern, "IN (?)")
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(pattern, " "))
+}
+
+// queryStatsPlugin is a GORM plugin that times every statement, logs the ones over
+// SLOW_QUERY_THRESHOLD with their parameters and aggregates timings per pattern
+type queryStatsPlugin struct{}
+
+func (queryStatsPlugin) Name() string {
+	return "query_stats"
+}
+
+func (p queryStatsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("query_stats:before", startQueryTimer); err != nil {
+		return err
+	}
+	if err := cb.Create().After("*").Register("query_stats:after", recordQuery); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("query_stats:before", startQueryTimer); err != nil {
+		return err
+	}
+	if err := cb.Query().After("*").Register("query_stats:after", recordQuery); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("query_stats:before", startQueryTimer); err != nil {
+		return err
+	}
+	if err := cb.Update().After("*").Register("query_stats:after", recordQuery); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("query_stats:before", startQueryTimer); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("*").Register("query_stats:after", recordQuery); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register("query_stats:before", startQueryTimer); err != nil {
+		return err
+	}
+	if err := cb.Row().After("*").Register("query_stats:after", recordQuery); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("*").Register("query_stats:before", startQueryTimer); err != nil {
+		return err
+	}
+	return cb.Raw().After("*").Register("query_stats:after", recordQuery)
+}
+
+const queryStartKey = "query_stats:start"
+
+func startQueryTimer(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func recordQuery(db *gorm.DB) {
+	v, ok := db.InstanceGet(queryStartKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+
+	sql := db.Statement.SQL.String()
+	if sql == "" {
+		return
+	}
+
+	metrics.ObserveQuery(sql, elapsed)
+
+	threshold := config.Get().SlowQueryThreshold
+	slow := threshold > 0 && elapsed >= threshold
+	if slow {
+		slog.Warn("Slow query", "duration", elapsed.Round(time.Millisecond), "sql", db.Dialector.Explain(sql, db.Statement.Vars...))
+	}
+
+	pattern := normalizeQuery(sql)
+	ms := float64(elapsed) / float64(time.Millisecond)
+
+	queryStatsMu.Lock()
+	defer queryStatsMu.Unlock()
+
+	stat, ok := queryStats[pattern]
+	if !ok {
+		if len(queryStats) >= maxQueryPatterns {
+			pattern = otherQueryPattern
+			stat = queryStats[pattern]
+		}
+		if stat == nil {
+			stat = &QueryStat{Pattern: pattern}
+			queryStats[pattern] = stat
+		}
+	}
+	stat.Count++
+	stat.TotalMs += ms
+	if ms > stat.MaxMs {
+		stat.MaxMs = ms
+	}
+	if slow {
+		stat.SlowCount++
+	}
+	if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+		stat.Errors++
+	}
+	stat.LastSeen = time.Now().UTC()
+}
+
+// QueryStats returns a snapshot of per-pattern timings sorted by sort ("total", "avg",
+// "max" or "count"), plus when collection started
+func QueryStats(sortBy string, limit int) ([]QueryStat, time.Time) {
+	queryStatsMu.Lock()
+	stats := make([]QueryStat, 0, len(queryStats))
+	for _, s := range queryStats {
+		snapshot := *s
+		snapshot.AvgMs = snapshot.TotalMs / float64(snapshot.Count)
+		stats = append(stats, snapshot)
+	}
+	since := queryStatsSince
+	queryStatsMu.Unlock()
+
+	key := func(s QueryStat) float64 {
+		switch sortBy {
+		case "avg":
+			return s.AvgMs
+		case "max":
+			return s.MaxMs
+		case "count":
+			return float64(s.Count)
+		default:
+			return s.TotalMs
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if key(stats[i]) != key(stats[j]) {
+			return key(stats[i]) > key(stats[j])
+		}
+		return stats[i].Pattern < stats[j].Pattern
+	})
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats, since
+}
+
+// ResetQueryStats clears collected timings
+func ResetQueryStats() {
+	queryStatsMu.Lock()
+	defer queryStatsMu.Unlock()
+	queryStats = map[string]*QueryStat{}
+	queryStatsSince = time.Now().UTC()
+}
@@ -0,0 +1,82 @@
+// Package logging sets up the process-wide structured logger and carries
+// request-scoped loggers through contexts
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// level is shared by every handler Setup installs, so SetLevel applies to loggers
+// services already derived from the default one
+var level = new(slog.LevelVar)
+
+// Setup installs the default slog logger, writing text or JSON to stderr at lvl.
+// The standard log package is routed through it as well.
+func Setup(lvl, format string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: formatDuration}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q, want text or json", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel changes the minimum level of every logger from Setup
+func SetLevel(lvl string) error {
+	l, err := ParseLevel(lvl)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// ParseLevel accepts debug, info, warn or error; empty is info
+func ParseLevel(lvl string) (slog.Level, error) {
+	var l slog.Level
+	if lvl == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		return l, fmt.Errorf("unknown log level %q, want debug, info, warn or error", lvl)
+	}
+	return l, nil
+}
+
+// formatDuration writes durations as "1.5s" rather than nanoseconds, which JSON output
+// would otherwise do
+func formatDuration(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindDuration {
+		return slog.String(a.Key, a.Value.Duration().String())
+	}
+	return a
+}
+
+type ctxKey struct{}
+
+// WithLogger returns ctx carrying logger, for FromContext
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx (with request fields), or the default one
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,133 @@
+// Package metrics holds the server's own Prometheus metrics, served at /metrics
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "alert_dashboard"
+
+// Name resolver lookup results
+const (
+	NameCacheHit = "hit"      // the shared cache
+	NameStored   = "stored"   // the name_cache table
+	NameFetched  = "fetched"  // the name API
+	NameFallback = "fallback" // a stale, local or bare-ID name after the API failed
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database statement latency by operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation"})
+
+	syncRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_runs_total",
+		Help:      "Finished sync runs by source and status (ok, partial, failed).",
+	}, []string{"source", "status"})
+
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_run_duration_seconds",
+		Help:      "Sync run duration by source.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"source"})
+
+	nameLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "name_resolver_lookups_total",
+		Help:      "Tenant/cluster name lookups by where the name came from (hit, stored, fetched, fallback).",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbQueryDuration, syncRuns, syncDuration, nameLookups,
+	)
+}
+
+// Handler serves every registered metric in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ObserveHTTP records a served request. Route is the matched route pattern, never the
+// raw path, so IDs in URLs don't create a series each.
+func ObserveHTTP(method, route string, code int, elapsed time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records a database statement by its leading keyword
+func ObserveQuery(sql string, elapsed time.Duration) {
+	op := "other"
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		switch kw := strings.ToLower(fields[0]); kw {
+		case "select", "insert", "update", "delete", "with":
+			op = kw
+		}
+	}
+	dbQueryDuration.WithLabelValues(op).Observe(elapsed.Seconds())
+}
+
+// ObserveSync records a finished sync run
+func ObserveSync(source, status string, elapsed time.Duration) {
+	syncRuns.WithLabelValues(source, status).Inc()
+	syncDuration.WithLabelValues(source).Observe(elapsed.Seconds())
+}
+
+// ObserveNameLookup records where a resolved name came from
+func ObserveNameLookup(result string) {
+	nameLookups.WithLabelValues(result).Inc()
+}
+
+// RegisterQueueDepth exports the result of depth, called on every scrape, as a gauge
+// per status. Depths are read from the database so every replica reports the same.
+func RegisterQueueDepth(name, help string, depth func() map[string]float64) {
+	registry.MustRegister(&queueCollector{
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{"status"}, nil),
+		depth: depth,
+	})
+}
+
+type queueCollector struct {
+	desc  *prometheus.Desc
+	depth func() map[string]float64
+}
+
+func (q *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- q.desc
+}
+
+func (q *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	for status, n := range q.depth() {
+		ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, n, status)
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey is a key issued through the admin API. Only the SHA-256 of the key is
+// stored; Prefix identifies it in listings and logs.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"uniqueIndex" json:"name"`
+	Role       string     `json:"role"` // viewer, editor or admin
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `gorm:"uniqueIndex" json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
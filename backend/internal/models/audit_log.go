@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog records one mutation: who changed which resource, and its state before and
+// after as JSON (empty when there was none, e.g. before a create)
+type AuditLog struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+	Actor        string    `gorm:"index" json:"actor"` // login email or API key name, "anonymous" with auth off
+	RemoteAddr   string    `json:"remote_addr,omitempty"`
+	Action       string    `json:"action"`                // create, update, delete, mute, unmute, trigger
+	ResourceType string    `gorm:"index" json:"resource"` // rule, rules_notify, issue, mute_suppression, task, sync, ...
+	ResourceID   string    `gorm:"index" json:"resource_id"`
+	Component    string    `gorm:"index" json:"component,omitempty"` // set for component-scoped resources (rules, tasks)
+	Before       string    `gorm:"type:text" json:"before,omitempty"`
+	After        string    `gorm:"type:text" json:"after,omitempty"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
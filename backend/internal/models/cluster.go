@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Cluster holds deployment metadata for a cluster, imported via CSV
+type Cluster struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	TenantID  string    `json:"tenant_id"`
+	Region    string    `gorm:"index" json:"region"`   // e.g. us-east-1
+	Provider  string    `gorm:"index" json:"provider"` // e.g. aws, gcp, azure
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Cluster) TableName() string {
+	return "clusters"
+}
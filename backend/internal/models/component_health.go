@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ComponentHealthTransition records a component's sidebar health changing from one status
+// to another. The first status seen for a component is stored with an empty From.
+type ComponentHealthTransition struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Component string    `gorm:"index" json:"component"`
+	From      string    `gorm:"column:from_status" json:"from"`
+	To        string    `gorm:"column:to_status" json:"to"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (ComponentHealthTransition) TableName() string {
+	return "component_health_transitions"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ComponentOverride reassigns an issue to other components locally. It outlives
+// re-imports and re-enrichment; Previous keeps the attribution it replaced.
+type ComponentOverride struct {
+	IssueID              string    `gorm:"primaryKey" json:"issue_id"`
+	Components           []string  `gorm:"serializer:json" json:"components"`
+	PreviousComponents   []string  `gorm:"serializer:json" json:"previous_components"`
+	PreviousAttributedBy string    `json:"previous_attributed_by"`
+	Reason               string    `json:"reason,omitempty"`
+	Actor                string    `json:"actor,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+func (ComponentOverride) TableName() string {
+	return "component_overrides"
+}
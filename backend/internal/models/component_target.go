@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ComponentTarget holds a component's alerting SLA. Unset (nil) targets are not evaluated.
+type ComponentTarget struct {
+	Component         string    `gorm:"primaryKey" json:"component"`
+	MaxWeeklyAlerts   *int      `json:"max_weekly_alerts"`
+	MaxFakeRate       *float64  `json:"max_fake_rate"`       // percent
+	MinHandlingRate   *float64  `json:"min_handling_rate"`   // percent
+	QuietHourlyAlerts *float64  `json:"quiet_hourly_alerts"` // alerts/hour baseline for burn rates
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (ComponentTarget) TableName() string {
+	return "component_targets"
+}
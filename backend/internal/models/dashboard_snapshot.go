@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// DashboardSnapshot is a persisted dashboard response kept for point-in-time comparisons,
+// independent of issue data retention
+type DashboardSnapshot struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	Note      string    `gorm:"type:text" json:"note"`
+	Filters   string    `json:"-"`                  // Dashboard query string the snapshot was taken with
+	Data      string    `gorm:"type:text" json:"-"` // JSON of the DashboardDataResponse
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (DashboardSnapshot) TableName() string {
+	return "dashboard_snapshots"
+}
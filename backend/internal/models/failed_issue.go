@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// FailedIssue is a dead-lettered issue whose insert failed during sync. It is
+// retried at the start of each sync until it is stored or runs out of attempts.
+type FailedIssue struct {
+	IssueID       string    `gorm:"primaryKey" json:"issue_id"`
+	Source        string    `gorm:"default:jira" json:"source"` // ingestion source that fetched it
+	Payload       string    `gorm:"type:text" json:"-"`         // record as fetched from the source
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+}
+
+func (FailedIssue) TableName() string {
+	return "failed_issues"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// IngestSyncState tracks where each ingestion source left off. SyncedUntil is the end
+// of the last window stored without errors; the next incremental sync starts there.
+type IngestSyncState struct {
+	Source        string     `gorm:"primaryKey" json:"source"`
+	Status        string     `json:"status"` // running, ok, partial, failed
+	SyncedUntil   *time.Time `json:"synced_until,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFetched   int        `json:"last_fetched"`
+	LastStored    int        `json:"last_stored"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (IngestSyncState) TableName() string {
+	return "ingest_sync_states"
+}
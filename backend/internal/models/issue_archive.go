@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+)
+
+// ArchivedIssue is an issue the retention job moved out of the issues table. Columns match
+// Issue so rows copy across unchanged; the archive only indexes what lookups need.
+type ArchivedIssue struct {
+	ID                  string    `gorm:"primaryKey" json:"id"`
+	Title               string    `json:"title"`
+	Description         string    `gorm:"type:text" json:"description"`
+	Created             string    `gorm:"index:idx_issues_archive_created" json:"created"`
+	Priority            string    `json:"priority"`
+	Labels              string    `gorm:"type:text" json:"labels"`
+	IssueType           string    `json:"issuetype"`
+	ComponentsJSON      string    `gorm:"column:components;type:text" json:"-"`
+	Project             string    `json:"project"`
+	IsAlert             bool      `json:"is_alert"`
+	AlertSignature      string    `json:"alert_signature"`
+	ClusterID           string    `json:"cluster_id"`
+	TenantID            string    `json:"tenant_id"`
+	BizType             string    `json:"biz_type"`
+	Status              string    `json:"status"`
+	IsSubtask           bool      `json:"is_subtask"`
+	StabilityGovernance string    `json:"stability_governance"`
+	Visibility          string    `json:"visibility"`
+	ComponentName       string    `json:"component_name"`
+	SourceComponent     string    `json:"source_component"`
+	AlertGroup          string    `json:"alert_group"`
+	JiraComponents      string    `gorm:"type:text" json:"-"`
+	AttributedBy        string    `json:"attributed_by"`
+	RuleName            string    `json:"rule_name,omitempty"`
+	RuleRevision        string    `json:"rule_revision,omitempty"`
+	AcknowledgedAt      string    `json:"acknowledged_at,omitempty"`
+	ResolvedAt          string    `json:"resolved_at,omitempty"`
+	DuplicateOf         string    `json:"duplicate_of,omitempty"`
+	OccurrenceCount     int       `json:"occurrence_count"`
+	RawPayload          string    `gorm:"type:text" json:"-"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	ArchivedAt time.Time `gorm:"index" json:"archived_at"`
+}
+
+func (ArchivedIssue) TableName() string {
+	return "issues_archive"
+}
+
+// RetentionRun records one pass of the retention job
+type RetentionRun struct {
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	Trigger    string           `json:"trigger"` // scheduled, manual
+	Actor      string           `json:"actor,omitempty"`
+	Status     string           `json:"status"` // success, failed
+	Archived   int              `json:"archived"`
+	Groups     []RetentionGroup `gorm:"serializer:json;type:text" json:"groups"`
+	Error      string           `json:"error,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+}
+
+// RetentionGroup is the outcome for one project and priority
+type RetentionGroup struct {
+	Project  string `json:"project"`
+	Priority string `json:"priority"`
+	Days     int    `json:"days"`
+	Cutoff   string `json:"cutoff"`   // issues created before this were expired
+	Archived int    `json:"archived"` // or, in a plan, eligible to be
+}
+
+func (RetentionRun) TableName() string {
+	return "retention_runs"
+}
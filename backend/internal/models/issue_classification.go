@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// IssueClassification is a user's verdict on whether an alert was a fake alarm. It is
+// kept apart from the JIRA status, which re-imports overwrite, and wins over it in
+// fake-rate reports.
+type IssueClassification struct {
+	IssueID   string    `gorm:"primaryKey" json:"issue_id"`
+	Verdict   string    `gorm:"index" json:"verdict"` // fake or real
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (IssueClassification) TableName() string {
+	return "issue_classifications"
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// IssueEvent is a local action or delivery recorded against an issue, shown on its timeline
+type IssueEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	IssueID   string    `gorm:"index" json:"issue_id"`
+	Type      string    `json:"type"` // mute, ack, triage, note, notification, escalation
+	Actor     string    `json:"actor,omitempty"`
+	Detail    string    `gorm:"type:text" json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (IssueEvent) TableName() string {
+	return "issue_events"
+}
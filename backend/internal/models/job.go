@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Job is one background run (export, backfill, audit) started through the jobs API.
+// The finished artifact is kept on the row so any replica can serve the download.
+type Job struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"index" json:"type"`
+	Status      string     `gorm:"index" json:"status"` // queued, running, completed, failed, cancelled
+	Params      string     `gorm:"type:text" json:"params,omitempty"`
+	Done        int        `json:"done"`
+	Total       int        `json:"total"`
+	Message     string     `json:"message,omitempty"`
+	Result      string     `gorm:"type:text" json:"-"` // JSON summary of the run
+	Error       string     `json:"error,omitempty"`
+	Owner       string     `json:"owner"` // replica running the job
+	Artifact    []byte     `json:"-"`
+	FileName    string     `json:"file_name,omitempty"`
+	ContentType string     `json:"content_type,omitempty"`
+	Size        int        `json:"size"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"` // heartbeat while running
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
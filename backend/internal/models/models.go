@@ -6,25 +6,25 @@ import (
 
 // Issue maps to the 'issues' table with full JIRA data
 type Issue struct {
-	ID             string `gorm:"primaryKey" json:"id"`
+	ID             string `gorm:"primaryKey;index:idx_issues_created_id,priority:2" json:"id"`
 	Title          string `json:"title"`
-	Description    string `gorm:"type:text" json:"description"` // For category filtering
-	Created        string `json:"created"`                      // Stored as text (e.g., "2025-01-15 10:30:45 UTC")
-	Priority       string `json:"priority"`
+	Description    string `gorm:"type:text" json:"description"`                          // For category filtering
+	Created        string `gorm:"index:idx_issues_created_id,priority:1" json:"created"` // Stored as text (e.g., "2025-01-15 10:30:45 UTC"); range filters use created_at_utc
+	Priority       string `gorm:"index" json:"priority"`
 	Labels         string `gorm:"type:text" json:"labels"`              // JSON array of labels
 	IssueType      string `json:"issuetype"`                            // Issue type name
-	ComponentsJSON string `gorm:"column:components;type:text" json:"-"` // Raw JSON string
+	ComponentsJSON string `gorm:"column:components;type:text" json:"-"` // Raw JSON string, as attributed at ingest
 	Project        string `json:"project"`                              // JIRA project key (e.g., "O11Y")
 
 	// Alert specific fields
 	IsAlert        bool   `json:"is_alert"`
-	AlertSignature string `json:"alert_signature"`
+	AlertSignature string `gorm:"index" json:"alert_signature"`
 
 	// Metadata for filtering
-	ClusterID string `json:"cluster_id"`
-	TenantID  string `json:"tenant_id"`
+	ClusterID string `gorm:"index" json:"cluster_id"`
+	TenantID  string `gorm:"index" json:"tenant_id"`
 	BizType   string `json:"biz_type"` // "prod" or other
-	Status    string `json:"status"`
+	Status    string `gorm:"index" json:"status"`
 	IsSubtask bool   `json:"is_subtask"` // Whether this is a subtask
 
 	// New fields extracted from raw data
@@ -34,6 +34,31 @@ type Issue struct {
 	SourceComponent     string `json:"source_component"`
 	AlertGroup          string `json:"alert_group"`
 
+	// Component attribution: the JIRA components as fetched and the strategy that chose
+	// components (empty for issues imported before attribution)
+	JiraComponents string `gorm:"type:text" json:"-"`
+	AttributedBy   string `json:"attributed_by"`
+
+	// Alert rule that raised the issue and the rules repo commit live when it fired
+	RuleName     string `gorm:"index" json:"rule_name,omitempty"`
+	RuleRevision string `json:"rule_revision,omitempty"`
+
+	// When the issue first changed status and when it was resolved, from its JIRA status
+	// transitions; stored as text like Created, empty until it happens
+	AcknowledgedAt string `json:"acknowledged_at,omitempty"`
+	ResolvedAt     string `json:"resolved_at,omitempty"`
+
+	// Repeats of the same signature on the same cluster within the dedup window link to
+	// the first occurrence, which carries the total occurrence count
+	DuplicateOf     string `gorm:"index" json:"duplicate_of,omitempty"`
+	OccurrenceCount int    `gorm:"default:1" json:"occurrence_count"`
+
+	// RawPayload is the record as fetched, kept so extraction can be re-run without re-importing
+	RawPayload string `gorm:"type:text" json:"-"`
+	// Source is the ingestion source that extracts RawPayload; empty for issues imported
+	// before sources were tracked, which all came from JIRA
+	Source string `json:"source,omitempty"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
@@ -41,23 +66,49 @@ func (Issue) TableName() string {
 	return "issues"
 }
 
-// ComponentStat maps to the 'component_stats' table
+// IssueComponent maps to the 'issue_components' table, one row per component an issue is
+// attributed to (the entries of issues.components), so component filters are exact
+// matches on an index instead of LIKE scans over the JSON
+type IssueComponent struct {
+	IssueID   string `gorm:"primaryKey" json:"issue_id"`
+	Component string `gorm:"primaryKey;index" json:"component"`
+}
+
+func (IssueComponent) TableName() string {
+	return "issue_components"
+}
+
+// ComponentStat maps to the 'component_stats' table, a day's alerts per component rolled
+// up from issues. AlertCount counts every alert listing the component; the Primary counts
+// only those listing it first ("No Component" for none), as the dashboard ranks them.
 type ComponentStat struct {
-	Component  string `gorm:"primaryKey" json:"component"`
-	Date       string `gorm:"primaryKey" json:"date"`
-	AlertCount int    `json:"alert_count"`
+	Component        string    `gorm:"primaryKey" json:"component"`
+	Date             string    `gorm:"primaryKey" json:"date"`
+	AlertCount       int       `json:"alert_count"`
+	PrimaryCount     int       `json:"primary_count"`
+	PrimaryProdCount int       `json:"primary_prod_count"`
+	FilterHash       string    `gorm:"index" json:"filter_hash"` // the condition the counts were taken under
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 func (ComponentStat) TableName() string {
 	return "component_stats"
 }
 
-// DailyStat maps to 'daily_stats'
+// DailyStat maps to 'daily_stats', a day's alerts by priority rolled up from issues; the
+// Prod counts are the [PROD] alerts among them. Days without alerts have a zero row.
 type DailyStat struct {
-	Date          string `gorm:"primaryKey" json:"date"`
-	TotalAlerts   int    `json:"total_alerts"`
-	CriticalCount int    `json:"critical_count"`
-	MajorCount    int    `json:"major_count"`
+	Date          string    `gorm:"primaryKey" json:"date"`
+	TotalAlerts   int       `json:"total_alerts"`
+	CriticalCount int       `json:"critical_count"`
+	MajorCount    int       `json:"major_count"`
+	WarningCount  int       `json:"warning_count"`
+	ProdAlerts    int       `json:"prod_alerts"`
+	ProdCritical  int       `json:"prod_critical"`
+	ProdMajor     int       `json:"prod_major"`
+	ProdWarning   int       `json:"prod_warning"`
+	FilterHash    string    `gorm:"index" json:"filter_hash"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 func (DailyStat) TableName() string {
@@ -80,11 +131,36 @@ func (AlertRule) TableName() string {
 
 // MutedIssue maps to 'muted_issues'
 type MutedIssue struct {
-	IssueID string    `gorm:"primaryKey" json:"issue_id"`
-	MutedAt time.Time `gorm:"autoCreateTime" json:"muted_at"`
-	Reason  string    `json:"reason"`
+	IssueID   string     `gorm:"primaryKey" json:"issue_id"`
+	MutedAt   time.Time  `gorm:"autoCreateTime" json:"muted_at"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"` // nil mutes forever
+	SilenceID string     `json:"silence_id,omitempty"`              // Alertmanager silence created with the mute
 }
 
 func (MutedIssue) TableName() string {
 	return "muted_issues"
 }
+
+// JobLock maps to 'job_locks', a lease-based lock so only one replica runs a job
+type JobLock struct {
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (JobLock) TableName() string {
+	return "job_locks"
+}
+
+// SchemaMigration records an applied versioned migration in 'schema_migrations'
+type SchemaMigration struct {
+	Version      int        `gorm:"primaryKey" json:"version"`
+	Name         string     `json:"name"`
+	AppliedAt    time.Time  `json:"applied_at"`
+	BackfilledAt *time.Time `json:"backfilled_at"` // nil until the migration's backfill has finished
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
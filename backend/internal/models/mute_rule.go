@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// MuteRule hides every alert matching all of its set matchers from the dashboard and
+// component views. Unlike muted_issues it matches by pattern, so it also covers alerts
+// ingested after it was created.
+type MuteRule struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Reason         string     `json:"reason"`
+	AlertSignature string     `json:"alert_signature,omitempty"` // SQL LIKE pattern, e.g. [PROD] TiKV%
+	ClusterID      string     `json:"cluster_id,omitempty"`
+	TenantID       string     `json:"tenant_id,omitempty"`
+	Component      string     `json:"component,omitempty"`
+	Actor          string     `json:"actor,omitempty"`
+	ExpiresAt      *time.Time `gorm:"index" json:"expires_at,omitempty"` // nil mutes until deleted
+	SilenceID      string     `json:"silence_id,omitempty"`              // Alertmanager silence created with the rule
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (MuteRule) TableName() string {
+	return "mute_rules"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// MuteSuppression mutes future occurrences of a muted alert: issues ingested with the
+// same signature and cluster, created after the mute, are muted until ExpiresAt
+type MuteSuppression struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	AlertSignature string    `gorm:"index" json:"alert_signature"`
+	ClusterID      string    `json:"cluster_id"`
+	SourceIssueID  string    `json:"source_issue_id"` // the issue whose mute created it
+	Actor          string    `json:"actor,omitempty"`
+	Matched        int       `json:"matched"` // occurrences muted so far
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `gorm:"index" json:"expires_at"`
+}
+
+func (MuteSuppression) TableName() string {
+	return "mute_suppressions"
+}
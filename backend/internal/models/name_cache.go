@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// NameCacheEntry is a tenant/cluster name resolved through the name API. Expired entries
+// are refreshed on next use and still answer while the API is down.
+type NameCacheEntry struct {
+	ID         string    `gorm:"primaryKey" json:"id"`
+	Type       string    `json:"type"`
+	Name       string    `json:"name"`
+	TenantID   string    `json:"tenant_id"`
+	TenantName string    `json:"tenant_name"`
+	FetchedAt  time.Time `gorm:"index" json:"fetched_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (NameCacheEntry) TableName() string {
+	return "name_cache"
+}
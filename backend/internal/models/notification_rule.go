@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationRule routes alerts matching its filters to a channel once Threshold
+// alerts arrive within WindowMinutes. Events picks what it notifies about besides
+// alerts: finished sync runs and tasks waiting for review.
+type NotificationRule struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"uniqueIndex" json:"name"`
+	Enabled       bool      `json:"enabled"`
+	Channel       string    `json:"channel"`                           // slack, lark, pagerduty, webhook
+	Target        string    `json:"-"`                                 // webhook URL, or the PagerDuty routing key
+	Secret        string    `json:"-"`                                 // signs requests to a Lark bot that verifies them
+	Severities    []string  `gorm:"serializer:json" json:"severities"` // empty matches every priority
+	Components    []string  `gorm:"serializer:json" json:"components"` // empty matches every component
+	Events        []string  `gorm:"serializer:json" json:"events"`     // alert, sync, task_review, report; empty is alert
+	Template      string    `gorm:"type:text" json:"template"`         // text/template for the message body
+	Threshold     int       `json:"threshold"`
+	WindowMinutes int       `json:"window_minutes"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (NotificationRule) TableName() string {
+	return "notification_rules"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Report is a generated alert summary for one week or month. Each period is generated
+// once by the scheduler; a manual run replaces it.
+type Report struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Period      string    `gorm:"uniqueIndex:idx_reports_period" json:"period"`       // weekly, monthly
+	PeriodStart string    `gorm:"uniqueIndex:idx_reports_period" json:"period_start"` // YYYY-MM-DD (UTC)
+	PeriodEnd   string    `json:"period_end"`                                         // YYYY-MM-DD, exclusive
+	Alerts      int64     `json:"alerts"`
+	Summary     string    `gorm:"type:text" json:"-"` // JSON of the report figures
+	Markdown    string    `gorm:"type:text" json:"-"`
+	HTML        string    `gorm:"type:text" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Report) TableName() string {
+	return "reports"
+}
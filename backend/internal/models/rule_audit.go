@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// RuleAudit is one dated result of the scheduled rule audit (lint, coverage, drift, noise)
+type RuleAudit struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Date           string    `gorm:"uniqueIndex" json:"date"` // YYYY-MM-DD, re-runs on the same day replace the report
+	RuleCount      int       `json:"rule_count"`
+	LintCount      int       `json:"lint_count"`
+	UncoveredCount int       `json:"uncovered_count"`
+	DriftCount     int       `json:"drift_count"`
+	NoisyCount     int       `json:"noisy_count"`
+	Regressed      bool      `json:"regressed"`
+	Regressions    string    `gorm:"type:text" json:"-"` // JSON array of regression messages
+	Report         string    `gorm:"type:text" json:"-"` // JSON of the full audit details
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (RuleAudit) TableName() string {
+	return "rule_audits"
+}
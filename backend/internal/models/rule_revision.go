@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// RuleRevision is one change to an alert rule made through the API, with the rule's YAML
+// before and after (empty for a create or a delete). Alert is the name after the change;
+// PreviousAlert differs when an update renamed the rule.
+type RuleRevision struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+	Component      string    `gorm:"index" json:"component"`
+	FilePath       string    `gorm:"index" json:"file_path"`
+	Action         string    `json:"action"` // create, update or delete
+	Alert          string    `gorm:"index" json:"alert"`
+	PreviousAlert  string    `gorm:"index" json:"previous_alert"`
+	Actor          string    `json:"actor"`
+	TaskID         *uint     `gorm:"index" json:"task_id,omitempty"`
+	PullRequestURL string    `json:"pull_request_url,omitempty"` // set when the edit was proposed rather than written
+	OldYAML        string    `gorm:"type:text" json:"old_yaml"`
+	NewYAML        string    `gorm:"type:text" json:"new_yaml"`
+}
+
+func (RuleRevision) TableName() string {
+	return "rule_revisions"
+}
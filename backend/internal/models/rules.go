@@ -1,19 +1,21 @@
 package models
 
 type RuleGroup struct {
-	Name  string `yaml:"name" json:"name"`
-	Rules []Rule `yaml:"rules" json:"rules"`
+	Name     string `yaml:"name" json:"name"`
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Rules    []Rule `yaml:"rules" json:"rules"`
 }
 
 type Rule struct {
-	Alert       string            `yaml:"alert" json:"alert,omitempty"`
+	Alert       string            `yaml:"alert,omitempty" json:"alert,omitempty"`
+	Record      string            `yaml:"record,omitempty" json:"record,omitempty"`
 	Expr        string            `yaml:"expr" json:"expr"`
-	For         string            `yaml:"for" json:"for,omitempty"`
-	Labels      map[string]string `yaml:"labels" json:"labels,omitempty"`
-	Annotations map[string]string `yaml:"annotations" json:"annotations,omitempty"`
-	// Augmented fields
+	For         string            `yaml:"for,omitempty" json:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
 	// Augmented fields
 	FilePath string `json:"file_path,omitempty" yaml:"-"`
+	Group    string `json:"group,omitempty" yaml:"-"`
 	Category string `json:"category,omitempty" yaml:"-"`
 }
 
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedView is a named combination of dashboard and issue list filters, so a view can
+// be shared by ID instead of reconstructing its query string
+type SavedView struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	Name        string            `gorm:"uniqueIndex" json:"name"`
+	Description string            `json:"description,omitempty"`
+	Filters     map[string]string `gorm:"serializer:json" json:"filters"`
+	Actor       string            `json:"actor,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+func (SavedView) TableName() string {
+	return "saved_views"
+}
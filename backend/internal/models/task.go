@@ -14,7 +14,7 @@ type Task struct {
 
 	RuleName    string `json:"rule_name"`
 	RuleContent string `gorm:"type:text" json:"rule_content"` // JSON string of AlertRule
-	Type        string `json:"type"`                          // ADD, EDIT, DELETE
+	Type        string `json:"type"`                          // ADD, EDIT, DELETE, IMPORT
 	Status      string `json:"status"`                        // submitted, processing, waiting_for_review, merged, rejected
 	PRLink      string `json:"pr_link"`
 	Component   string `json:"component"`
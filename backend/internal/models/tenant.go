@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Tenant holds tier/plan metadata for a tenant, imported from the tenant API or CSV
+type Tenant struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	Tier      string    `gorm:"index" json:"tier"` // e.g. premium, enterprise, standard, free
+	Plan      string    `json:"plan"`
+	Source    string    `json:"source"` // api or csv
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// TenantDigestRun is one week's noisy-tenant digest; each week is generated and sent once
+type TenantDigestRun struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WeekStart   string    `gorm:"uniqueIndex" json:"week_start"` // YYYY-MM-DD, the Monday (UTC) of the digested week
+	TenantCount int       `json:"tenant_count"`
+	Channel     string    `json:"channel"`
+	Status      string    `json:"status"` // sent, partial, skipped (no channel configured)
+	Error       string    `json:"error,omitempty"`
+	SentTenants string    `gorm:"type:text" json:"-"` // JSON array of tenant IDs already delivered
+	Report      string    `gorm:"type:text" json:"-"` // JSON array of the per-tenant digests
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (TenantDigestRun) TableName() string {
+	return "tenant_digest_runs"
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// UserRole is the role assigned to an OIDC user, keyed by lowercased email. Users
+// without one get OIDC_DEFAULT_ROLE.
+type UserRole struct {
+	Email      string    `gorm:"primaryKey" json:"email"`
+	Role       string    `json:"role"` // viewer, editor or admin
+	AssignedBy string    `json:"assigned_by,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// WarehouseExport records the export of one day's issues and aggregates to the warehouse target
+type WarehouseExport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Date      string    `gorm:"uniqueIndex" json:"date"` // YYYY-MM-DD (UTC) of the exported issues
+	Target    string    `json:"target"`
+	Files     int       `json:"files"`
+	Rows      int       `json:"rows"`
+	Status    string    `json:"status"` // success, failed
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (WarehouseExport) TableName() string {
+	return "warehouse_exports"
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Webhook posts a signed JSON payload to URL for each of its event types.
+// FakeRateThreshold is the percentage above which the fake alarm rate event fires.
+type Webhook struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Name              string    `gorm:"uniqueIndex" json:"name"`
+	Enabled           bool      `json:"enabled"`
+	URL               string    `json:"url"`
+	Secret            string    `json:"-"`                             // HMAC-SHA256 key for X-Webhook-Signature; unsigned when empty
+	Events            []string  `gorm:"serializer:json" json:"events"` // alert.critical, fake_rate.exceeded, task.review
+	FakeRateThreshold float64   `json:"fake_rate_threshold"`           // percent
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery is the log of one event sent to a webhook, updated on every attempt
+type WebhookDelivery struct {
+	ID             string     `gorm:"primaryKey" json:"id"` // also sent as X-Webhook-Delivery
+	WebhookID      uint       `gorm:"index" json:"webhook_id"`
+	Event          string     `gorm:"index" json:"event"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	Status         string     `gorm:"index" json:"status"` // pending, succeeded, failed
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
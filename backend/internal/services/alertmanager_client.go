@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// ErrAlertmanagerNotConfigured is returned when ALERTMANAGER_URL is unset
+var ErrAlertmanagerNotConfigured = errors.New("ALERTMANAGER_URL is not configured")
+
+// defaultSilenceDuration bounds silences for mutes without an expiry; Alertmanager
+// silences always end
+const defaultSilenceDuration = 24 * time.Hour
+
+// AlertmanagerClient creates and expires silences through the Alertmanager v2 API
+type AlertmanagerClient struct {
+	BaseURL  string
+	Token    string
+	Username string
+	Password string
+	client   *http.Client
+}
+
+func NewAlertmanagerClient() (*AlertmanagerClient, error) {
+	base := config.Lookup("ALERTMANAGER_URL")
+	if base == "" {
+		return nil, ErrAlertmanagerNotConfigured
+	}
+	return &AlertmanagerClient{
+		BaseURL:  strings.TrimRight(base, "/"),
+		Token:    config.Lookup("ALERTMANAGER_TOKEN"),
+		Username: config.Lookup("ALERTMANAGER_USERNAME"),
+		Password: config.Lookup("ALERTMANAGER_PASSWORD"),
+		client:   NewOutboundClient(10 * time.Second),
+	}, nil
+}
+
+// SilenceMatcher is an Alertmanager label matcher
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// Silence is a silence to create
+type Silence struct {
+	Matchers  []SilenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+}
+
+// CreateSilence creates a silence and returns its ID
+func (a *AlertmanagerClient) CreateSilence(ctx context.Context, s Silence) (string, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := a.do(ctx, http.MethodPost, "/api/v2/silences", body, &out); err != nil {
+		return "", err
+	}
+	return out.SilenceID, nil
+}
+
+// ExpireSilence ends a silence now; one that is already gone counts as expired
+func (a *AlertmanagerClient) ExpireSilence(ctx context.Context, id string) error {
+	err := a.do(ctx, http.MethodDelete, "/api/v2/silence/"+url.PathEscape(id), nil, nil)
+	var statusErr *alertmanagerStatusError
+	if errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+type alertmanagerStatusError struct {
+	status int
+	body   string
+}
+
+func (e *alertmanagerStatusError) Error() string {
+	return fmt.Sprintf("alertmanager returned status %d: %s", e.status, e.body)
+}
+
+func (a *AlertmanagerClient) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, a.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case a.Token != "":
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	case a.Username != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &alertmanagerStatusError{status: resp.StatusCode, body: strings.TrimSpace(string(respBody))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// SilenceEnd is when the silence for a mute ends: its expiry, or
+// ALERTMANAGER_SILENCE_DURATION (default 24h) from now for a mute without one
+func SilenceEnd(expiresAt *time.Time, now time.Time) time.Time {
+	if expiresAt != nil {
+		return *expiresAt
+	}
+	if d, err := time.ParseDuration(config.Lookup("ALERTMANAGER_SILENCE_DURATION")); err == nil && d > 0 {
+		return now.Add(d)
+	}
+	return now.Add(defaultSilenceDuration)
+}
+
+// silenceLabel is the Alertmanager label carrying an issue field, overridable with
+// ALERTMANAGER_<FIELD>_LABEL
+func silenceLabel(field, fallback string) string {
+	if v := config.Lookup("ALERTMANAGER_" + field + "_LABEL"); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func equalMatcher(name, value string) SilenceMatcher {
+	return SilenceMatcher{Name: name, Value: value, IsEqual: true}
+}
+
+// IssueSilenceMatchers matches the alert behind an issue: its rule on its cluster
+func IssueSilenceMatchers(issue models.Issue) ([]SilenceMatcher, error) {
+	if issue.RuleName == "" {
+		return nil, fmt.Errorf("issue %s has no known alert rule to silence", issue.ID)
+	}
+	matchers := []SilenceMatcher{equalMatcher("alertname", issue.RuleName)}
+	if issue.ClusterID != "" {
+		matchers = append(matchers, equalMatcher(silenceLabel("CLUSTER", "cluster_id"), issue.ClusterID))
+	}
+	return matchers, nil
+}
+
+// MuteRuleSilenceMatchers matches what a mute rule hides. Cluster, tenant and component
+// map to labels; a signature pattern matches the alert rules of the issues it has
+// matched so far, as there is no label for it.
+func MuteRuleSilenceMatchers(db *gorm.DB, rule models.MuteRule) ([]SilenceMatcher, error) {
+	var matchers []SilenceMatcher
+	if rule.AlertSignature != "" {
+		var names []string
+		if err := db.Model(&models.Issue{}).Distinct("rule_name").
+			Where("alert_signature LIKE ? AND COALESCE(rule_name, '') != ''", rule.AlertSignature).
+			Order("rule_name").Pluck("rule_name", &names).Error; err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no alert rule is known for signatures like %q", rule.AlertSignature)
+		}
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = regexp.QuoteMeta(name)
+		}
+		matchers = append(matchers, SilenceMatcher{Name: "alertname", Value: strings.Join(quoted, "|"), IsRegex: true, IsEqual: true})
+	}
+	if rule.ClusterID != "" {
+		matchers = append(matchers, equalMatcher(silenceLabel("CLUSTER", "cluster_id"), rule.ClusterID))
+	}
+	if rule.TenantID != "" {
+		matchers = append(matchers, equalMatcher(silenceLabel("TENANT", "tenant_id"), rule.TenantID))
+	}
+	if rule.Component != "" {
+		matchers = append(matchers, equalMatcher(silenceLabel("COMPONENT", "component"), rule.Component))
+	}
+	return matchers, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Pseudonym kinds
+const (
+	AnonTenant  = "tenant"
+	AnonCluster = "cluster"
+)
+
+// anonymizedFields maps response fields (JSON keys, CSV columns) to what they identify
+var anonymizedFields = map[string]string{
+	"tenant_id":    AnonTenant,
+	"tenant_name":  AnonTenant,
+	"tenantId":     AnonTenant,
+	"tenantName":   AnonTenant,
+	"tenant":       AnonTenant,
+	"cluster_id":   AnonCluster,
+	"cluster_name": AnonCluster,
+	"clusterId":    AnonCluster,
+	"clusterName":  AnonCluster,
+	"cluster":      AnonCluster,
+}
+
+// Identities shorter than this are not scrubbed from free text, where they would
+// match inside unrelated words
+const minScrubLength = 4
+
+var (
+	anonKey     []byte
+	anonKeyOnce sync.Once
+	// pseudonyms maps each issued pseudonym back to its identity for filters
+	pseudonyms sync.Map
+)
+
+// anonymizeKey keys the pseudonyms with ANONYMIZE_SECRET (or SHARE_LINK_SECRET) so they
+// stay stable across restarts and replicas; without either they only last per process
+func anonymizeKey() []byte {
+	anonKeyOnce.Do(func() {
+		secret := config.Lookup("ANONYMIZE_SECRET")
+		if secret == "" {
+			secret = config.Lookup("SHARE_LINK_SECRET")
+		}
+		if secret != "" {
+			anonKey = []byte(secret)
+			return
+		}
+		anonKey = make([]byte, 32)
+		rand.Read(anonKey)
+		slog.Warn("ANONYMIZE_SECRET not set, pseudonyms change on restart")
+	})
+	return anonKey
+}
+
+// Pseudonym returns the stable stand-in for a tenant or cluster identity, e.g. tenant-3fa91c2b
+func Pseudonym(kind, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, anonymizeKey())
+	mac.Write([]byte(kind + ":" + value))
+	p := kind + "-" + hex.EncodeToString(mac.Sum(nil))[:8]
+	pseudonyms.Store(p, value)
+	return p
+}
+
+// ResolvePseudonym returns the identity behind a pseudonym issued by this replica
+func ResolvePseudonym(p string) (string, bool) {
+	v, ok := pseudonyms.Load(p)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// anonymizer collects the identities replaced in a response so they can also be
+// scrubbed from free text (titles, signatures, descriptions)
+type anonymizer struct {
+	seen map[string]string // identity -> pseudonym
+}
+
+func (a *anonymizer) pseudonym(kind, value string) string {
+	if p, ok := a.seen[value]; ok {
+		return p
+	}
+	if _, ok := ResolvePseudonym(value); ok {
+		return value
+	}
+	p := Pseudonym(kind, value)
+	a.seen[value] = p
+	return p
+}
+
+func (a *anonymizer) fields(v interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, child := range x {
+			if kind, ok := anonymizedFields[k]; ok {
+				if s, ok := child.(string); ok {
+					x[k] = a.pseudonym(kind, s)
+					continue
+				}
+			}
+			a.fields(child)
+		}
+	case []interface{}:
+		for _, child := range x {
+			a.fields(child)
+		}
+	}
+}
+
+func (a *anonymizer) replacer() *strings.Replacer {
+	var ids []string
+	for id := range a.seen {
+		if len(id) >= minScrubLength {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	// Longest first so an identity containing another is replaced whole
+	sort.Slice(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+	pairs := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		pairs = append(pairs, id, a.seen[id])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func scrub(v interface{}, r *strings.Replacer) interface{} {
+	switch x := v.(type) {
+	case string:
+		return r.Replace(x)
+	case map[string]interface{}:
+		for k, child := range x {
+			x[k] = scrub(child, r)
+		}
+	case []interface{}:
+		for i, child := range x {
+			x[i] = scrub(child, r)
+		}
+	}
+	return v
+}
+
+// AnonymizeJSON replaces tenant and cluster identities in a JSON document with pseudonyms
+func AnonymizeJSON(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	a := &anonymizer{seen: map[string]string{}}
+	a.fields(doc)
+	if r := a.replacer(); r != nil {
+		doc = scrub(doc, r)
+	}
+	return json.Marshal(doc)
+}
+
+// AnonymizeCSV replaces tenant and cluster identities in a CSV with a header row
+func AnonymizeCSV(body []byte) ([]byte, error) {
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return body, err
+	}
+
+	a := &anonymizer{seen: map[string]string{}}
+	kinds := make([]string, len(rows[0]))
+	for i, col := range rows[0] {
+		kinds[i] = anonymizedFields[col]
+	}
+	for _, row := range rows[1:] {
+		for i := range row {
+			if i < len(kinds) && kinds[i] != "" {
+				row[i] = a.pseudonym(kinds[i], row[i])
+			}
+		}
+	}
+	if r := a.replacer(); r != nil {
+		for _, row := range rows[1:] {
+			for i := range row {
+				if i >= len(kinds) || kinds[i] == "" {
+					row[i] = r.Replace(row[i])
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
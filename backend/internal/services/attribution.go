@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Attribution strategies, tried in the configured order
+const (
+	AttributeJiraComponents  = "jira_components"  // the JIRA components field
+	AttributeRawLabel        = "raw_label"        // component label in the raw alert data
+	AttributeSourceComponent = "source_component" // source_component label in the raw alert data
+	AttributeAlertGroup      = "alert_group"      // alert_groups mapping
+	AttributeFallback        = "fallback"         // the fallback component
+	// AttributeOverride marks components reassigned by hand; it is not a pipeline strategy
+	AttributeOverride = "override"
+)
+
+var attributionStrategies = []string{
+	AttributeJiraComponents, AttributeRawLabel, AttributeSourceComponent, AttributeAlertGroup, AttributeFallback,
+}
+
+// AttributionConfig is component_attribution.yaml
+type AttributionConfig struct {
+	Strategies  []string          `yaml:"strategies" json:"strategies"`
+	AlertGroups map[string]string `yaml:"alert_groups" json:"alert_groups"`
+	Fallback    string            `yaml:"fallback" json:"fallback"`
+}
+
+// defaultAttribution keeps JIRA components authoritative and fills gaps from raw data
+var defaultAttribution = AttributionConfig{
+	Strategies: []string{AttributeJiraComponents, AttributeRawLabel, AttributeAlertGroup, AttributeFallback},
+}
+
+var (
+	attribution     *AttributionConfig
+	attributionMu   sync.Mutex
+	attributionOnce sync.Once
+)
+
+// LoadAttributionConfig reads and validates the attribution pipeline; a missing file yields the defaults
+func LoadAttributionConfig(path string) (*AttributionConfig, error) {
+	cfg := defaultAttribution
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &cfg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if len(cfg.Strategies) == 0 {
+		cfg.Strategies = defaultAttribution.Strategies
+	}
+	for _, s := range cfg.Strategies {
+		if !containsString(attributionStrategies, s) {
+			return nil, fmt.Errorf("unknown attribution strategy %q (expected one of %s)", s, strings.Join(attributionStrategies, ", "))
+		}
+	}
+	return &cfg, nil
+}
+
+// GetAttributionConfig returns the active pipeline, re-read on config reload
+func GetAttributionConfig() *AttributionConfig {
+	attributionOnce.Do(func() {
+		config.OnReload(func(cfg *config.Config) {
+			attributionMu.Lock()
+			attribution = nil
+			attributionMu.Unlock()
+		})
+	})
+
+	attributionMu.Lock()
+	defer attributionMu.Unlock()
+	if attribution == nil {
+		cfg, err := LoadAttributionConfig(config.Get().ComponentAttributionPath)
+		if err != nil {
+			slog.Warn("Invalid component attribution config, using defaults", "err", err)
+			def := defaultAttribution
+			cfg = &def
+		}
+		attribution = cfg
+	}
+	return attribution
+}
+
+// AttributionInput is what the strategies look at for one alert
+type AttributionInput struct {
+	JiraComponents  []string
+	ComponentName   string
+	SourceComponent string
+	AlertGroup      string
+}
+
+// Candidates returns the components each strategy would pick, omitting strategies with no answer
+func (a *AttributionConfig) Candidates(in AttributionInput) map[string][]string {
+	out := make(map[string][]string)
+	if len(in.JiraComponents) > 0 {
+		out[AttributeJiraComponents] = in.JiraComponents
+	}
+	if in.ComponentName != "" {
+		out[AttributeRawLabel] = []string{in.ComponentName}
+	}
+	if in.SourceComponent != "" {
+		out[AttributeSourceComponent] = []string{in.SourceComponent}
+	}
+	if comp := a.AlertGroups[in.AlertGroup]; in.AlertGroup != "" && comp != "" {
+		out[AttributeAlertGroup] = []string{comp}
+	}
+	if a.Fallback != "" {
+		out[AttributeFallback] = []string{a.Fallback}
+	}
+	return out
+}
+
+// Attribute runs the strategies in order and returns the first answer and the strategy
+// that gave it; (nil, "") if none did
+func (a *AttributionConfig) Attribute(in AttributionInput) ([]string, string) {
+	candidates := a.Candidates(in)
+	for _, s := range a.Strategies {
+		if comps, ok := candidates[s]; ok {
+			return comps, s
+		}
+	}
+	return nil, ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
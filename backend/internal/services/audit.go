@@ -0,0 +1,66 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Audit actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditMute    = "mute"
+	AuditUnmute  = "unmute"
+	AuditTrigger = "trigger"
+)
+
+// AuditEntry describes a mutation; Before and After are stored as JSON, nil as empty
+type AuditEntry struct {
+	Actor        string
+	RemoteAddr   string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Component    string
+	Before       interface{}
+	After        interface{}
+}
+
+// RecordAudit appends an entry to the audit log. The change has already happened by
+// the time it is recorded, so a failure is logged rather than returned.
+func RecordAudit(db *gorm.DB, e AuditEntry) {
+	if e.Actor == "" {
+		e.Actor = "anonymous"
+	}
+	row := models.AuditLog{
+		CreatedAt:    time.Now().UTC(),
+		Actor:        e.Actor,
+		RemoteAddr:   e.RemoteAddr,
+		Action:       e.Action,
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		Component:    e.Component,
+		Before:       auditJSON(e.Before),
+		After:        auditJSON(e.After),
+	}
+	if err := db.Create(&row).Error; err != nil {
+		slog.Warn("Failed to record audit", "action", e.Action, "resource_type", e.ResourceType, "resource_id", e.ResourceID, "actor", e.Actor, "err", err)
+	}
+}
+
+func auditJSON(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClusterService maintains cluster region/provider metadata used for region-scoped stats
+type ClusterService struct {
+	DB *gorm.DB
+}
+
+func NewClusterService(db *gorm.DB) *ClusterService {
+	return &ClusterService{DB: db}
+}
+
+// ImportCSV loads clusters from CSV with a header row. Required columns: cluster_id (or id),
+// region and provider; name and tenant_id are optional.
+func (s *ClusterService) ImportCSV(ctx context.Context, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	idCol, ok := cols["cluster_id"]
+	if !ok {
+		if idCol, ok = cols["id"]; !ok {
+			return 0, errors.New("csv is missing a cluster_id column")
+		}
+	}
+	for _, required := range []string{"region", "provider"} {
+		if _, ok := cols[required]; !ok {
+			return 0, fmt.Errorf("csv is missing a %s column", required)
+		}
+	}
+
+	get := func(row []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var clusters []models.Cluster
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		if idCol >= len(row) || strings.TrimSpace(row[idCol]) == "" {
+			continue
+		}
+		clusters = append(clusters, models.Cluster{
+			ID:       strings.TrimSpace(row[idCol]),
+			Name:     get(row, "name"),
+			TenantID: get(row, "tenant_id"),
+			Region:   strings.ToLower(get(row, "region")),
+			Provider: strings.ToLower(get(row, "provider")),
+		})
+	}
+
+	if len(clusters) == 0 {
+		return 0, nil
+	}
+	err = s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "tenant_id", "region", "provider", "updated_at"}),
+	}).CreateInBatches(clusters, 500).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(clusters), nil
+}
+
+// List returns clusters, optionally filtered by region and provider
+func (s *ClusterService) List(ctx context.Context, region, provider string) ([]models.Cluster, error) {
+	var clusters []models.Cluster
+	query := s.DB.WithContext(ctx).Order("id")
+	if region != "" {
+		query = query.Where("region = ?", strings.ToLower(region))
+	}
+	if provider != "" {
+		query = query.Where("provider = ?", strings.ToLower(provider))
+	}
+	if err := query.Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// componentOverride returns the JSON components an issue was reassigned to, or ""
+func (u *DataUpdater) componentOverride(id string) string {
+	var components string
+	err := u.db.QueryRow(db.Rebind("SELECT components FROM component_overrides WHERE issue_id = ?"), id).Scan(&components)
+	if err != nil && err != sql.ErrNoRows {
+		u.logger.Warn("Failed to read component override", "issue_id", id, "err", err)
+	}
+	return components
+}
+
+// SetComponents replaces the issue's JIRA components
+func (c *JiraClient) SetComponents(ctx context.Context, key string, components []string) error {
+	names := make([]map[string]string, 0, len(components))
+	for _, name := range components {
+		names = append(names, map[string]string{"name": name})
+	}
+	resp, err := c.client.Issue.UpdateIssueWithContext(ctx, key, map[string]interface{}{
+		"fields": map[string]interface{}{"components": names},
+	})
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("JIRA rejected the component update of %s: %s", key, resp.Status)
+		}
+		return fmt.Errorf("failed to update JIRA components of %s: %w", key, err)
+	}
+	return nil
+}
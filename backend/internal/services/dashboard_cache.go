@@ -0,0 +1,27 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/cache"
+)
+
+// dashboardGenerationKey holds the generation cached dashboard responses are keyed on.
+// It lives in the shared cache, so with Redis a write on one replica retires the
+// responses every replica cached.
+const dashboardGenerationKey = "dashboard:generation"
+
+// DashboardGeneration returns the current generation of cached dashboard responses
+func DashboardGeneration() string {
+	if raw, ok := cache.Get().Get(dashboardGenerationKey); ok {
+		return string(raw)
+	}
+	return "0"
+}
+
+// InvalidateDashboards starts a new generation, so dashboards are recomputed instead of
+// served from responses cached before a mute, classification, reassignment or sync
+func InvalidateDashboards() {
+	cache.Get().Set(dashboardGenerationKey, []byte(strconv.FormatInt(time.Now().UnixNano(), 10)), 0)
+}
@@ -1,20 +1,38 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
 )
 
-// DataUpdater handles data updates from JIRA
+// DataUpdater syncs issues from the registered ingestion sources into the database
 type DataUpdater struct {
 	db         *sql.DB
-	jiraClient *JiraClient
-	logger     *log.Logger
+	sources    map[string]Ingester // every registered source, for re-extracting stored records
+	enabled    []string            // sources configured to fetch, sorted
+	logger     *slog.Logger
+	indexQueue []string           // stored issues not yet mirrored to the search index
+	storedDays map[string]bool    // created dates of alerts stored since TakeStoredDays
+	notifier   *Notifier          // sends new prod alerts and finished syncs
+	webhooks   *WebhookDispatcher // fires outbound webhooks on critical alerts and fake alarm rate rises
+	ctx        context.Context    // bounds the name lookups of the run in progress; nil for syncs
+}
+
+// runContext returns the context of the run in progress, e.g. the request that pushed
+// the records
+func (u *DataUpdater) runContext() context.Context {
+	if u.ctx == nil {
+		return context.Background()
+	}
+	return u.ctx
 }
 
 // IssueData represents processed issue data ready for database insertion
@@ -42,172 +60,78 @@ type IssueData struct {
 	ComponentName       string
 	SourceComponent     string
 	AlertGroup          string
+
+	JiraComponents string // JSON array as fetched; Components holds the attributed ones
+	AttributedBy   string
+
+	RuleName     string // alert rule that raised it, matched from the signature
+	RuleRevision string // rules repo commit live when it fired
+
+	AcknowledgedAt string // first status change, same format as Created; empty until then
+	ResolvedAt     string // when it entered its current resolved status; empty while open
+
+	RawPayload string // record as fetched from its source
+	Source     string // name of the source it was fetched from
 }
 
-// NewDataUpdater creates a new data updater
+// NewDataUpdater creates a data updater over every registered source that can be
+// configured; it fails only when none can
 func NewDataUpdater(db *sql.DB) (*DataUpdater, error) {
-	jiraClient, err := NewJiraClient()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create JIRA client: %w", err)
+	u := &DataUpdater{
+		db:       db,
+		logger:   slog.Default().With("service", "sync"),
+		notifier: NewNotifier(db),
+		webhooks: NewWebhookDispatcher(db),
 	}
-
-	return &DataUpdater{
-		db:         db,
-		jiraClient: jiraClient,
-		logger:     log.Default(),
-	}, nil
+	if problems := u.loadIngesters(true); len(u.enabled) == 0 {
+		return nil, fmt.Errorf("no ingestion source available: %s", strings.Join(problems, "; "))
+	}
+	return u, nil
 }
 
-// FetchInitialData fetches initial data for the last N days
+// FetchInitialData fetches the last N days from every enabled source
 func (u *DataUpdater) FetchInitialData(daysBack int) (int, error) {
-	u.logger.Printf("[INFO] Starting initial data fetch for last %d days\n", daysBack)
-
-	// Test connection first
-	if err := u.jiraClient.TestConnection(); err != nil {
-		return 0, fmt.Errorf("JIRA connection test failed: %w", err)
-	}
-	u.logger.Println("[SUCCESS] JIRA connection successful")
+	u.logger.Info("Starting initial data fetch", "days", daysBack)
 
-	endDate := time.Now().UTC()
-	startDate := endDate.AddDate(0, 0, -daysBack)
+	u.RetryFailedIssues()
 
-	u.logger.Printf("[INFO] Fetching data from %s to %s\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-
-	// Fetch all alerts from O11Y projects
-	allIssues, err := u.fetchAllO11YAlerts(startDate, endDate)
+	startDate := time.Now().UTC().AddDate(0, 0, -daysBack)
+	successCount, err := u.syncSources(func(string) (time.Time, error) {
+		return startDate, nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch alerts: %w", err)
-	}
-
-	u.logger.Printf("[INFO] Total fetched: %d issues\n", len(allIssues))
-
-	// Process and store issues
-	successCount := 0
-	for i, issue := range allIssues {
-		if u.processIssue(&issue) {
-			successCount++
-		}
-
-		// Show progress every 50 issues
-		if (i+1)%50 == 0 || (i+1) == len(allIssues) {
-			progress := float64(i+1) / float64(len(allIssues)) * 100
-			u.logger.Printf("[PROGRESS] Processed %d/%d issues (%.1f%%) - %d successful\n", i+1, len(allIssues), progress, successCount)
-		}
+		return successCount, err
 	}
 
-	u.logger.Printf("[SUCCESS] Initial data fetch completed: %d/%d successful\n", successCount, len(allIssues))
+	u.logger.Info("Initial data fetch completed", "stored", successCount)
 	return successCount, nil
 }
 
-// IncrementalUpdate performs incremental update - fetch only new data since last update
+// IncrementalUpdate fetches what each enabled source added since its last sync
 func (u *DataUpdater) IncrementalUpdate() (int, error) {
-	u.logger.Println("[INFO] Starting incremental update")
+	u.logger.Info("Starting incremental update")
 
-	// Test connection first
-	if err := u.jiraClient.TestConnection(); err != nil {
-		return 0, fmt.Errorf("JIRA connection test failed: %w", err)
-	}
+	u.RetryFailedIssues()
 
-	// Get latest issue date from database
-	var latestDate sql.NullString
-	err := u.db.QueryRow("SELECT MAX(created) FROM issues").Scan(&latestDate)
-	if err != nil && err != sql.ErrNoRows {
-		return 0, fmt.Errorf("failed to get latest issue date: %w", err)
-	}
-
-	var startDate time.Time
-	if latestDate.Valid {
-		// Parse the date and add 1 second to avoid duplicates
-		t, err := time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(latestDate.String, " UTC"))
+	successCount, err := u.syncSources(func(name string) (time.Time, error) {
+		since, err := u.syncedUntil(name)
 		if err != nil {
-			return 0, fmt.Errorf("failed to parse latest date: %w", err)
+			return time.Time{}, err
 		}
-		startDate = t.Add(1 * time.Second)
-	} else {
-		// If no data exists, fetch last 30 days
-		startDate = time.Now().UTC().AddDate(0, 0, -30)
-	}
-
-	endDate := time.Now().UTC()
-
-	u.logger.Printf("[INFO] Fetching new data from %s to %s\n", startDate.Format("2006-01-02 15:04:05"), endDate.Format("2006-01-02 15:04:05"))
-
-	// Fetch all new alerts
-	allIssues, err := u.fetchAllO11YAlerts(startDate, endDate)
-	if err != nil {
-		return 0, fmt.Errorf("failed to fetch alerts: %w", err)
-	}
-
-	u.logger.Printf("[INFO] Total fetched: %d new issues\n", len(allIssues))
-
-	// Process and store issues
-	successCount := 0
-	for i, issue := range allIssues {
-		if u.processIssue(&issue) {
-			successCount++
-		}
-
-		// Show progress every 50 issues
-		if (i+1)%50 == 0 || (i+1) == len(allIssues) {
-			progress := float64(i+1) / float64(len(allIssues)) * 100
-			u.logger.Printf("[PROGRESS] Processed %d/%d issues (%.1f%%) - %d successful\n", i+1, len(allIssues), progress, successCount)
+		if since.IsZero() {
+			// Never synced: fetch the default backfill window
+			since = time.Now().UTC().Add(-ingestDefaultBackfill)
 		}
+		return since, nil
+	})
+	if err != nil {
+		return successCount, err
 	}
 
-	u.logger.Printf("[SUCCESS] Incremental update completed: %d/%d successful\n", successCount, len(allIssues))
+	u.logger.Info("Incremental update completed", "stored", successCount)
 	return successCount, nil
 }
 
-// fetchAllO11YAlerts fetches all alerts from O11Y-related projects
-func (u *DataUpdater) fetchAllO11YAlerts(startDate, endDate time.Time) ([]JiraIssue, error) {
-	projects := []struct {
-		Key   string
-		Label string
-	}{
-		{"O11YDEV", "O11YDEV"},
-		{"O11YSTAG", "O11YSTAG"},
-		{"O11Y", "O11Y"},
-	}
-
-	var allIssues []JiraIssue
-
-	for _, proj := range projects {
-		// Build JQL query with assignee and subtask filters to reduce data volume
-		jql := fmt.Sprintf(
-			"project = %s AND created >= '%s' AND created < '%s' AND assignee != EMPTY AND issuetype != Sub-task",
-			proj.Key,
-			startDate.Format("2006-01-02 15:04"),
-			endDate.Format("2006-01-02 15:04"),
-		)
-
-		label := fmt.Sprintf("O11Y:%s", proj.Label)
-		u.logger.Printf("\n[SEARCH] Searching %s for alerts...\n", proj.Key)
-		u.logger.Printf("[JQL] %s\n", jql)
-
-		issues, err := u.jiraClient.SearchAllIssues(jql, 100, label)
-		if err != nil {
-			u.logger.Printf(" [ERROR] Search failed for %s: %v\n", proj.Key, err)
-			return nil, fmt.Errorf("failed to search %s: %w", proj.Key, err)
-		}
-
-		u.logger.Printf(" [RESULT] Fetched %d issues from %s\n", len(issues), proj.Key)
-		allIssues = append(allIssues, issues...)
-		u.logger.Printf("[CUMULATIVE] Total collected so far: %d issues\n", len(allIssues))
-	}
-
-	u.logger.Printf("\n[SUMMARY] Total issues fetched from all projects: %d\n", len(allIssues))
-	return allIssues, nil
-}
-
-// processIssue processes and stores a single JIRA issue
-func (u *DataUpdater) processIssue(issue *JiraIssue) bool {
-	// Extract data
-	issueData := u.extractIssueData(issue)
-
-	// Insert or update in database
-	return u.insertOrUpdateIssue(issueData)
-}
-
 // extractIssueData extracts and processes issue data
 func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 	data := &IssueData{
@@ -219,6 +143,7 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 		Project:     issue.Fields.Project.Key,
 		IsAlert:     false,
 		IsSubtask:   false,
+		RawPayload:  u.toJSON(issue),
 	}
 
 	// Priority
@@ -234,8 +159,9 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 
 	// Status
 	if issue.Fields.Status != nil {
-		data.Status = issue.Fields.Status.Name
+		data.Status = u.convertStatus(issue.Fields.Status.Name)
 	}
+	data.AcknowledgedAt, data.ResolvedAt = u.lifecycleTimes(issue.Changelog, data.Status)
 
 	// Labels
 	if len(issue.Fields.Labels) > 0 {
@@ -283,9 +209,28 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 		}
 	}
 
+	// Attribute alerts to components through the configured strategy pipeline
+	data.JiraComponents = data.Components
+	if data.IsAlert {
+		attributed, strategy := GetAttributionConfig().Attribute(AttributionInput{
+			JiraComponents:  components,
+			ComponentName:   data.ComponentName,
+			SourceComponent: data.SourceComponent,
+			AlertGroup:      data.AlertGroup,
+		})
+		if attributed != nil {
+			data.Components = u.toJSON(attributed)
+		}
+		data.AttributedBy = strategy
+	}
+	if override := u.componentOverride(data.ID); override != "" {
+		data.Components = override
+		data.AttributedBy = AttributeOverride
+	}
+
 	// NEW: Try to resolve tenant_id from cluster_id if still missing
 	if data.TenantID == "" && data.ClusterID != "" {
-		if info, err := GetNameResolver().Resolve(data.ClusterID); err == nil && info.TenantID != "" {
+		if info, err := GetNameResolver().ResolveContext(u.runContext(), data.ClusterID); err == nil && info.TenantID != "" {
 			data.TenantID = info.TenantID
 		}
 	}
@@ -293,22 +238,53 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 	return data
 }
 
-// convertPriority converts priority names (including Chinese)
-func (u *DataUpdater) convertPriority(priority string) string {
-	mapping := map[string]string{
-		"严重":       "Critical",
-		"重要":       "Major",
-		"低":        "Low",
-		"Medium":   "Medium",
-		"High":     "Major",
-		"Critical": "Critical",
-		"Major":    "Major",
-	}
+// resolvedStatuses are the canonical statuses that end an alert's handling
+var resolvedStatuses = []string{"Resolved", "Closed", "Won't Fix", "FAKE ALARM"}
 
-	if mapped, ok := mapping[priority]; ok {
-		return mapped
+// IsResolvedStatus reports whether a canonical status ends an alert's handling
+func IsResolvedStatus(status string) bool {
+	return containsString(resolvedStatuses, status)
+}
+
+// NormalizeStatus maps a workflow status in any language to its canonical form
+func NormalizeStatus(status string) string {
+	normalized, _ := GetNormalizationConfig().Status.Normalize(status)
+	return normalized
+}
+
+// lifecycleTimes derives when an issue was acknowledged (its first status change) and,
+// if its current status is a resolved one, when it was resolved (the last move from an
+// open status into a resolved one, so Resolved -> Closed keeps the original time)
+func (u *DataUpdater) lifecycleTimes(changes []JiraStatusChange, status string) (acknowledged, resolved string) {
+	const layout = "2006-01-02 15:04:05 UTC"
+	var resolvedAt time.Time
+	for i, ch := range changes {
+		if i == 0 {
+			acknowledged = ch.At.UTC().Format(layout)
+		}
+		from, to := u.convertStatus(ch.From), u.convertStatus(ch.To)
+		switch {
+		case !containsString(resolvedStatuses, to):
+			resolvedAt = time.Time{} // reopened
+		case !containsString(resolvedStatuses, from):
+			resolvedAt = ch.At
+		}
+	}
+	if containsString(resolvedStatuses, status) && !resolvedAt.IsZero() {
+		resolved = resolvedAt.UTC().Format(layout)
 	}
-	return priority
+	return acknowledged, resolved
+}
+
+// convertPriority maps priority names (including Chinese) to their canonical form
+func (u *DataUpdater) convertPriority(priority string) string {
+	normalized, _ := GetNormalizationConfig().Priority.Normalize(priority)
+	return normalized
+}
+
+// convertStatus maps workflow statuses in any language to their canonical form
+func (u *DataUpdater) convertStatus(status string) string {
+	return NormalizeStatus(status)
 }
 
 // convertToUTC converts JIRA timestamp to UTC format
@@ -319,7 +295,7 @@ func (u *DataUpdater) convertToUTC(jiraTime string) string {
 		// Try alternative format
 		t, err = time.Parse(time.RFC3339, jiraTime)
 		if err != nil {
-			u.logger.Printf("[WARN] Failed to parse time %s: %v\n", jiraTime, err)
+			u.logger.Warn("Failed to parse time", "time", jiraTime, "err", err)
 			return jiraTime
 		}
 	}
@@ -370,7 +346,7 @@ func (u *DataUpdater) extractFromRawAlertData(rawData interface{}, existingLabel
 
 	if err := json.Unmarshal(jsonData, &data); err != nil {
 		// Log error for debugging
-		// u.logger.Printf("[WARN] Failed to unmarshal raw alert data: %v (data: %s)\n", err, string(jsonData))
+		// u.logger.Debug("Failed to unmarshal raw alert data", "err", err, "data", string(jsonData))
 		return "", "", "", u.toJSON(existingLabels), "", "", "", "", ""
 	}
 
@@ -467,18 +443,14 @@ func (u *DataUpdater) extractBizTypeFromDescription(description string) string {
 }
 
 // insertOrUpdateIssue inserts or updates an issue in the database
-func (u *DataUpdater) insertOrUpdateIssue(data *IssueData) bool {
-	query := `
-		INSERT OR REPLACE INTO issues (
-			id, title, description, created, priority, labels, issue_type,
-			components, project, is_alert, alert_signature, cluster_id,
-			tenant_id, biz_type, status, is_subtask,
-			stability_governance, visibility, component_name, source_component, alert_group
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
-	_, err := u.db.Exec(
-		query,
+func (u *DataUpdater) insertOrUpdateIssue(data *IssueData) error {
+	columns := []string{"id", "title", "description", "created", "priority", "labels", "issue_type",
+		"components", "project", "is_alert", "alert_signature", "cluster_id",
+		"tenant_id", "biz_type", "status", "is_subtask",
+		"stability_governance", "visibility", "component_name", "source_component", "alert_group",
+		"jira_components", "attributed_by", "rule_name", "rule_revision", "acknowledged_at", "resolved_at",
+		"raw_payload", "source"}
+	args := []interface{}{
 		data.ID,
 		data.Title,
 		data.Description,
@@ -500,12 +472,51 @@ func (u *DataUpdater) insertOrUpdateIssue(data *IssueData) bool {
 		data.ComponentName,
 		data.SourceComponent,
 		data.AlertGroup,
-	)
+		data.JiraComponents,
+		data.AttributedBy,
+		data.RuleName,
+		data.RuleRevision,
+		data.AcknowledgedAt,
+		data.ResolvedAt,
+		data.RawPayload,
+		data.Source,
+	}
 
-	if err != nil {
-		u.logger.Printf("[ERROR] Failed to insert issue %s: %v\n", data.ID, err)
-		return false
+	// Range filters read the parsed timestamp. Migration 3 renames created_ts, whose
+	// dual-write stays enabled, so the new column takes precedence.
+	if db.DualWriteEnabled("issues.created_at_utc") {
+		columns = append(columns, "created_at_utc")
+		args = append(args, strings.TrimSuffix(data.Created, " UTC"))
+	} else if db.DualWriteEnabled("issues.created_ts") {
+		columns = append(columns, "created_ts")
+		args = append(args, strings.TrimSuffix(data.Created, " UTC"))
 	}
 
-	return true
+	if _, err := u.db.Exec(db.Rebind(db.UpsertInto("issues", "id", columns)), args...); err != nil {
+		return err
+	}
+	if err := u.storeIssueComponents(data.ID, data.Components); err != nil {
+		return fmt.Errorf("failed to store components of %s: %w", data.ID, err)
+	}
+
+	if data.IsAlert && len(data.Created) >= 10 {
+		if u.storedDays == nil {
+			u.storedDays = map[string]bool{}
+		}
+		u.storedDays[data.Created[:10]] = true
+	}
+
+	u.applyDedupWindow(data)
+	return nil
+}
+
+// TakeStoredDays returns the created dates (YYYY-MM-DD) of the alerts stored since the
+// last call, for the stats rollup
+func (u *DataUpdater) TakeStoredDays() []string {
+	days := make([]string, 0, len(u.storedDays))
+	for day := range u.storedDays {
+		days = append(days, day)
+	}
+	u.storedDays = nil
+	return days
 }
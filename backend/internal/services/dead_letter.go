@@ -0,0 +1,102 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// FailedIssueMaxAttempts is how many failed inserts a dead-lettered issue gets before
+// automatic retries stop; it stays listed until requeued or discarded
+const FailedIssueMaxAttempts = 5
+
+// deadLetterIssue records the raw payload of an issue whose insert failed, counting attempts
+func (u *DataUpdater) deadLetterIssue(source string, data *IssueData, cause error) {
+	now := time.Now().UTC()
+	_, err := u.db.Exec(db.Rebind(`
+		INSERT INTO failed_issues (issue_id, source, payload, error, attempts, first_failed_at, last_failed_at)
+		VALUES (?, ?, ?, ?, 1, ?, ?)
+		`+db.OnConflictUpdate("issue_id")+`
+			source = `+db.Excluded("source")+`,
+			payload = `+db.Excluded("payload")+`,
+			error = `+db.Excluded("error")+`,
+			attempts = failed_issues.attempts + 1,
+			last_failed_at = `+db.Excluded("last_failed_at")),
+		data.ID, source, data.RawPayload, cause.Error(), now, now)
+	if err != nil {
+		// The database is likely down as a whole; the issue is only in the logs now
+		u.logger.Error("Failed to dead-letter issue", "issue_id", data.ID, "err", err)
+	}
+}
+
+// clearDeadLetter drops an issue from the dead-letter table once it has been stored
+func (u *DataUpdater) clearDeadLetter(id string) {
+	if _, err := u.db.Exec(db.Rebind("DELETE FROM failed_issues WHERE issue_id = ?"), id); err != nil {
+		u.logger.Warn("Failed to clear dead-lettered issue", "issue_id", id, "err", err)
+	}
+}
+
+// RetryFailedIssues re-processes dead-lettered issues that still have attempts left.
+// Issues stored successfully leave the table; others count another attempt.
+func (u *DataUpdater) RetryFailedIssues() (retried, recovered int) {
+	rows, err := u.db.Query(db.Rebind("SELECT issue_id FROM failed_issues WHERE attempts < ? ORDER BY first_failed_at"), FailedIssueMaxAttempts)
+	if err != nil {
+		u.logger.Error("Failed to load dead-lettered issues", "err", err)
+		return 0, 0
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return 0, 0
+	}
+	u.logger.Info("Retrying dead-lettered issues", "issues", len(ids))
+
+	for _, id := range ids {
+		retried++
+		if err := u.RetryFailedIssue(id); err == nil {
+			recovered++
+		}
+	}
+	u.logger.Info("Dead-letter retry finished", "recovered", recovered, "retried", retried)
+	return retried, recovered
+}
+
+// RetryFailedIssue re-processes one dead-lettered issue from its stored payload
+func (u *DataUpdater) RetryFailedIssue(id string) error {
+	var source, payload string
+	err := u.db.QueryRow(db.Rebind("SELECT COALESCE(source, ''), payload FROM failed_issues WHERE issue_id = ?"), id).Scan(&source, &payload)
+	if err != nil {
+		return fmt.Errorf("dead-lettered issue %s not found: %w", id, err)
+	}
+	if source == "" {
+		source = IngestSourceJira // dead-lettered before sources were tracked
+	}
+
+	src, ok := u.sources[source]
+	if !ok {
+		return fmt.Errorf("ingestion source %s of %s is not registered", source, id)
+	}
+	if _, err := src.Extract(json.RawMessage(payload)); err != nil {
+		// Can't be fixed by retrying, so use up the attempts right away
+		u.db.Exec(db.Rebind("UPDATE failed_issues SET error = ?, attempts = ?, last_failed_at = ? WHERE issue_id = ?"),
+			"unreadable payload: "+err.Error(), FailedIssueMaxAttempts, time.Now().UTC(), id)
+		return fmt.Errorf("unreadable payload for %s: %w", id, err)
+	}
+
+	if !u.processRecord(src, json.RawMessage(payload)) {
+		var lastErr string
+		u.db.QueryRow(db.Rebind("SELECT error FROM failed_issues WHERE issue_id = ?"), id).Scan(&lastErr)
+		return fmt.Errorf("insert of %s failed again: %s", id, lastErr)
+	}
+	u.flushSearchIndex()
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// dedupSignatureCondition matches root alerts (not linked to another occurrence)
+// sharing an alert signature and cluster
+const dedupSignatureCondition = `is_alert = TRUE AND alert_signature = ? AND cluster_id = ?
+	AND (duplicate_of = '' OR duplicate_of IS NULL) AND id != ?`
+
+// applyDedupWindow links a stored alert to the first occurrence of its signature on the
+// same cluster within the dedup window, or, if it is the first occurrence, adopts repeats
+// that were imported before it. Rows are kept either way so drill-downs still see every ticket.
+func (u *DataUpdater) applyDedupWindow(data *IssueData) {
+	window := config.Get().DedupWindow
+	if window <= 0 || !data.IsAlert || data.AlertSignature == "" || data.ClusterID == "" {
+		return
+	}
+
+	created, err := time.Parse("2006-01-02 15:04:05 UTC", data.Created)
+	if err != nil {
+		return
+	}
+	windowStart := created.Add(-window).Format("2006-01-02 15:04:05") + " UTC"
+	windowEnd := created.Add(window).Format("2006-01-02 15:04:05") + " UTC"
+
+	var rootID string
+	err = u.db.QueryRow(db.Rebind(`SELECT id FROM issues WHERE `+dedupSignatureCondition+`
+		AND created >= ? AND created <= ?
+		ORDER BY created, id LIMIT 1`),
+		data.AlertSignature, data.ClusterID, data.ID, windowStart, data.Created).Scan(&rootID)
+
+	switch {
+	case err == sql.ErrNoRows:
+		// First occurrence: repeats imported earlier (and their own repeats) move under this issue
+		rootID = data.ID
+		// The derived tables let MySQL update the table the subqueries read
+		_, err = u.db.Exec(db.Rebind(`UPDATE issues SET duplicate_of = ?, occurrence_count = 1
+			WHERE duplicate_of IN (SELECT id FROM (SELECT id FROM issues WHERE `+dedupSignatureCondition+` AND created > ? AND created <= ?) r1)
+				OR id IN (SELECT id FROM (SELECT id FROM issues WHERE `+dedupSignatureCondition+` AND created > ? AND created <= ?) r2)`),
+			rootID,
+			data.AlertSignature, data.ClusterID, data.ID, data.Created, windowEnd,
+			data.AlertSignature, data.ClusterID, data.ID, data.Created, windowEnd)
+	case err == nil:
+		// Repeat: link it, along with anything that had been linked to it
+		_, err = u.db.Exec(db.Rebind(`UPDATE issues SET duplicate_of = ?, occurrence_count = 1 WHERE id = ? OR duplicate_of = ?`),
+			rootID, data.ID, data.ID)
+	}
+	if err != nil {
+		u.logger.Error("Failed to apply dedup window", "issue_id", data.ID, "err", err)
+		return
+	}
+
+	var repeats int
+	if err := u.db.QueryRow(db.Rebind(`SELECT COUNT(*) FROM issues WHERE duplicate_of = ?`), rootID).Scan(&repeats); err != nil {
+		u.logger.Error("Failed to count repeats", "issue_id", rootID, "err", err)
+		return
+	}
+	if _, err := u.db.Exec(db.Rebind(`UPDATE issues SET occurrence_count = ? WHERE id = ?`), 1+repeats, rootID); err != nil {
+		u.logger.Error("Failed to update occurrence count", "issue_id", rootID, "err", err)
+	}
+}
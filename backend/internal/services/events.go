@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/cache"
+)
+
+// eventsChannel carries live dashboard events over the cache pub/sub, so with Redis every
+// replica's subscribers see events published by any replica
+const eventsChannel = "events"
+
+// Event types pushed to live dashboards
+const (
+	EventIssueIngested = "issue.ingested"
+	EventSyncFinished  = "sync.finished"
+	EventTaskStatus    = "task.status"
+)
+
+// Event is one live update; Data is the event type's payload as JSON
+type Event struct {
+	Type string          `json:"type"`
+	At   time.Time       `json:"at"`
+	Data json.RawMessage `json:"data"`
+}
+
+// IssueIngestedEvent is published for every issue stored by a sync
+type IssueIngestedEvent struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Priority   string   `json:"priority"`
+	Components []string `json:"components"`
+	ClusterID  string   `json:"cluster_id,omitempty"`
+	TenantID   string   `json:"tenant_id,omitempty"`
+	BizType    string   `json:"biz_type,omitempty"`
+	Created    string   `json:"created"`
+	IsAlert    bool     `json:"is_alert"`
+}
+
+// SyncFinishedEvent is published when a source finishes a sync run, successfully or not
+type SyncFinishedEvent struct {
+	Source  string `json:"source"`
+	Status  string `json:"status"` // ok, partial or failed
+	Fetched int    `json:"fetched"`
+	Stored  int    `json:"stored"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TaskStatusEvent is published when a task is created or moves to another status
+type TaskStatusEvent struct {
+	ID        uint   `json:"id"`
+	Component string `json:"component,omitempty"`
+	RuleName  string `json:"rule_name,omitempty"`
+	Status    string `json:"status"`
+	PRLink    string `json:"pr_link,omitempty"`
+}
+
+// PublishEvent sends an event to live subscribers. Delivery is best effort: events are
+// dropped for slow subscribers and lost while nobody listens.
+func PublishEvent(eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.Warn("Failed to encode event", "event", eventType, "err", err)
+		return
+	}
+	message, err := json.Marshal(Event{Type: eventType, At: time.Now().UTC(), Data: payload})
+	if err != nil {
+		slog.Warn("Failed to encode event", "event", eventType, "err", err)
+		return
+	}
+	if err := cache.Get().Publish(eventsChannel, message); err != nil {
+		slog.Warn("Failed to publish event", "event", eventType, "err", err)
+	}
+}
+
+// SubscribeEvents streams published events until cancel is called. Like the pub/sub
+// underneath, it drops events rather than block when the reader falls behind.
+func SubscribeEvents() (<-chan Event, func()) {
+	messages, cancel := cache.Get().Subscribe(eventsChannel)
+	events := make(chan Event, 64)
+	go func() {
+		defer close(events)
+		for message := range messages {
+			var event Event
+			if err := json.Unmarshal(message, &event); err != nil {
+				continue
+			}
+			select {
+			case events <- event:
+			default:
+			}
+		}
+	}()
+	return events, cancel
+}
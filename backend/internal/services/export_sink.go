@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportSink stores exported files under a key like "issues/date=2025-01-15/issues.csv"
+type ExportSink interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// NewExportSink builds the sink for an EXPORT_TARGET: file:///dir, s3://bucket/prefix or
+// gs://bucket/prefix. Object store credentials come from EXPORT_ACCESS_KEY_ID /
+// EXPORT_SECRET_ACCESS_KEY, with EXPORT_REGION and EXPORT_ENDPOINT as overrides.
+func NewExportSink(target string) (ExportSink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export target %q: %w", target, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("export target %q has no directory", target)
+		}
+		return &dirSink{root: u.Path}, nil
+
+	case "s3", "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("export target %q has no bucket", target)
+		}
+		sink := &objectSink{
+			client:    NewOutboundClient(2 * time.Minute),
+			bucket:    u.Host,
+			prefix:    strings.Trim(u.Path, "/"),
+			region:    config.Lookup("EXPORT_REGION"),
+			endpoint:  strings.TrimSuffix(config.Lookup("EXPORT_ENDPOINT"), "/"),
+			accessKey: config.Lookup("EXPORT_ACCESS_KEY_ID"),
+			secretKey: config.Lookup("EXPORT_SECRET_ACCESS_KEY"),
+		}
+		if sink.accessKey == "" || sink.secretKey == "" {
+			return nil, fmt.Errorf("EXPORT_ACCESS_KEY_ID and EXPORT_SECRET_ACCESS_KEY are required for %s targets", u.Scheme)
+		}
+		if u.Scheme == "gs" {
+			// GCS interoperability: SigV4 with HMAC keys, region "auto"
+			if sink.region == "" {
+				sink.region = "auto"
+			}
+			if sink.endpoint == "" {
+				sink.endpoint = "https://storage.googleapis.com"
+			}
+		} else {
+			if sink.region == "" {
+				sink.region = "us-east-1"
+			}
+			if sink.endpoint == "" {
+				sink.endpoint = "https://s3." + sink.region + ".amazonaws.com"
+			}
+		}
+		return sink, nil
+	}
+	return nil, fmt.Errorf("unsupported export target %q (expected file://, s3:// or gs://)", target)
+}
+
+// dirSink writes files below a local directory, e.g. a mounted warehouse staging area
+type dirSink struct {
+	root string
+}
+
+func (s *dirSink) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write then rename so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// objectSink uploads with path-style S3 PUTs signed with AWS Signature V4
+type objectSink struct {
+	client    *http.Client
+	endpoint  string
+	bucket    string
+	prefix    string
+	region    string
+	accessKey string
+	secretKey string
+}
+
+func (s *objectSink) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	canonicalURI := "/" + s.bucket + "/" + awsURIEscape(key)
+	u, err := url.Parse(s.endpoint + canonicalURI)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	s.sign(req, canonicalURI, body, contentType, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload of %s failed: %s: %s", key, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign adds the SigV4 headers for a single-chunk PUT
+func (s *objectSink) sign(req *http.Request, canonicalURI string, body []byte, contentType string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		"", // no query string
+		"content-type:" + contentType,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// awsURIEscape percent-encodes everything but unreserved characters, keeping "/" separators
+func awsURIEscape(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		if ch == '/' || ch == '-' || ch == '_' || ch == '.' || ch == '~' ||
+			('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// NewOutboundTransport builds the transport shared by all outbound HTTP clients.
+//
+//	OUTBOUND_PROXY      explicit proxy URL; otherwise HTTPS_PROXY/HTTP_PROXY/NO_PROXY are honored
+//	OUTBOUND_CA_BUNDLE  PEM file of extra CAs trusted in addition to the system pool
+func NewOutboundTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if proxy := config.Lookup("OUTBOUND_PROXY"); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OUTBOUND_PROXY: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if bundle := config.Lookup("OUTBOUND_CA_BUNDLE"); bundle != "" {
+		pem, err := os.ReadFile(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OUTBOUND_CA_BUNDLE: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in OUTBOUND_CA_BUNDLE %s", bundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return transport, nil
+}
+
+// NewOutboundClient returns an http.Client using the outbound transport.
+// A misconfigured proxy/CA is logged and falls back to the default transport.
+func NewOutboundClient(timeout time.Duration) *http.Client {
+	transport, err := NewOutboundTransport()
+	if err != nil {
+		slog.Warn("Outbound HTTP config invalid, using defaults", "err", err)
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/metrics"
+)
+
+// ingestDefaultBackfill is how far back an incremental sync reaches for a source
+// that has never completed a sync
+const ingestDefaultBackfill = 30 * 24 * time.Hour
+
+// Ingester is one source of issues (JIRA, webhooks, Opsgenie, CSV backfill, ...).
+// Records are kept as raw JSON so failed ones can be dead-lettered and retried
+// without going back to the source.
+type Ingester interface {
+	// Name is the registry key, also stored with sync state and dead letters
+	Name() string
+	// FetchSince returns the records created in [since, until)
+	FetchSince(since, until time.Time) ([]json.RawMessage, error)
+	// Extract turns one fetched record into issue data; it must work offline
+	Extract(record json.RawMessage) (*IssueData, error)
+	// Upsert stores the extracted issue, replacing any earlier version
+	Upsert(data *IssueData) error
+}
+
+// ingestConfigurer is implemented by sources that need credentials or endpoints
+// before they can fetch. Sources that fail to configure are left out of syncs but
+// can still extract dead-lettered records.
+type ingestConfigurer interface {
+	Configure() error
+}
+
+// ingestUpdateFetcher is implemented by sources whose records change after creation
+// (e.g. JIRA status transitions). Syncs also fetch the records updated in their window
+// and refresh the status and lifecycle times of issues already stored.
+type ingestUpdateFetcher interface {
+	FetchUpdatedSince(since, until time.Time) ([]json.RawMessage, error)
+}
+
+// IngesterFactory builds a source bound to the updater that stores its records
+type IngesterFactory func(u *DataUpdater) Ingester
+
+var (
+	ingestersMu sync.RWMutex
+	ingesters   = map[string]IngesterFactory{}
+)
+
+// RegisterIngester makes a source available to every DataUpdater. Sources register
+// themselves from init, like the database drivers.
+func RegisterIngester(name string, factory IngesterFactory) {
+	ingestersMu.Lock()
+	defer ingestersMu.Unlock()
+	if _, dup := ingesters[name]; dup {
+		panic("ingestion source registered twice: " + name)
+	}
+	ingesters[name] = factory
+}
+
+// RegisteredIngesters returns the names of all registered sources, sorted
+func RegisteredIngesters() []string {
+	ingestersMu.RLock()
+	defer ingestersMu.RUnlock()
+	names := make([]string, 0, len(ingesters))
+	for name := range ingesters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadIngesters builds every registered source for u; with configure set, sources
+// that fail to configure are logged and not enabled for fetching
+func (u *DataUpdater) loadIngesters(configure bool) (problems []string) {
+	ingestersMu.RLock()
+	defer ingestersMu.RUnlock()
+
+	u.sources = make(map[string]Ingester, len(ingesters))
+	u.enabled = nil
+	for name, factory := range ingesters {
+		src := factory(u)
+		u.sources[name] = src
+		if !configure {
+			continue
+		}
+		if c, ok := src.(ingestConfigurer); ok {
+			if err := c.Configure(); err != nil {
+				u.logger.Warn("Ingestion source unavailable", "source", name, "err", err)
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+		}
+		u.enabled = append(u.enabled, name)
+	}
+	sort.Strings(u.enabled)
+	return problems
+}
+
+// Sources returns the names of the sources this updater syncs from
+func (u *DataUpdater) Sources() []string {
+	return append([]string(nil), u.enabled...)
+}
+
+// syncSources runs sync over every enabled source, continuing past failures so one
+// broken source does not hold back the others
+func (u *DataUpdater) syncSources(window func(name string) (time.Time, error)) (int, error) {
+	total := 0
+	var errs []error
+	for _, name := range u.enabled {
+		since, err := window(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		count, err := u.syncSource(u.sources[name], since, time.Now().UTC())
+		total += count
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// syncSource fetches one source's records for [since, until), stores them and records
+// the outcome in the source's sync state. The window advances once the fetch succeeds;
+// records that failed to store are dead-lettered and retried on their own.
+func (u *DataUpdater) syncSource(src Ingester, since, until time.Time) (int, error) {
+	name := src.Name()
+	started := time.Now()
+	u.logger.Info("Syncing", "source", name, "since", since, "until", until)
+	u.markSyncRunning(name)
+
+	records, err := src.FetchSince(since, until)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch: %w", err)
+		u.markSyncFailed(name, started, err)
+		return 0, err
+	}
+	u.logger.Info("Fetched records", "source", name, "records", len(records))
+
+	successCount := 0
+	for i, record := range records {
+		if u.processRecord(src, record) {
+			successCount++
+		}
+
+		// Show progress every 50 records
+		if (i+1)%50 == 0 || (i+1) == len(records) {
+			u.logger.Info("Sync progress", "source", name, "processed", i+1, "total", len(records), "stored", successCount)
+		}
+	}
+
+	if f, ok := src.(ingestUpdateFetcher); ok {
+		u.refreshUpdated(src, f, since, until)
+	}
+
+	u.flushSearchIndex()
+
+	u.markSyncDone(name, started, until, len(records), successCount)
+	return successCount, nil
+}
+
+// IngestPushed stores records a source pushed to its inbound webhook, the same way a sync
+// stores the ones it fetches, and returns how many were stored. ctx bounds the name
+// lookups of the records.
+func (u *DataUpdater) IngestPushed(ctx context.Context, source string, records []json.RawMessage) (int, error) {
+	src, ok := u.sources[source]
+	if !ok {
+		return 0, fmt.Errorf("ingestion source %s is not registered", source)
+	}
+	u.ctx = ctx
+	defer func() { u.ctx = nil }()
+	stored := 0
+	for _, record := range records {
+		if u.processRecord(src, record) {
+			stored++
+		}
+	}
+	u.flushSearchIndex()
+	u.logger.Info("Stored pushed records", "source", source, "records", len(records), "stored", stored)
+	return stored, nil
+}
+
+func publishIssueIngested(data *IssueData) IssueIngestedEvent {
+	var components []string
+	json.Unmarshal([]byte(data.Components), &components)
+	event := IssueIngestedEvent{
+		ID:         data.ID,
+		Title:      data.Title,
+		Priority:   data.Priority,
+		Components: components,
+		ClusterID:  data.ClusterID,
+		TenantID:   data.TenantID,
+		BizType:    data.BizType,
+		Created:    data.Created,
+		IsAlert:    data.IsAlert,
+	}
+	PublishEvent(EventIssueIngested, event)
+	return event
+}
+
+// processRecord extracts and stores a single record, dead-lettering it on failure
+func (u *DataUpdater) processRecord(src Ingester, record json.RawMessage) bool {
+	data, err := src.Extract(record)
+	if err != nil {
+		u.logger.Error("Failed to extract record", "source", src.Name(), "err", err)
+		return false
+	}
+	data.RawPayload = string(record)
+	data.Source = src.Name()
+	u.stampRuleVersion(data)
+
+	// Sync windows overlap, so only alerts not stored before are new enough to notify or
+	// to inherit a mute; re-syncs must not undo an unmute
+	isNew := !u.issueStored(data.ID)
+	notify := u.notifier != nil && data.IsAlert && strings.HasPrefix(data.AlertSignature, "[PROD]") && isNew
+
+	// Failures go to the dead-letter table for the next run
+	if err := src.Upsert(data); err != nil {
+		u.logger.Error("Failed to insert issue", "issue_id", data.ID, "err", err)
+		u.deadLetterIssue(src.Name(), data, err)
+		return false
+	}
+	u.clearDeadLetter(data.ID)
+	if isNew {
+		u.applyMuteSuppression(data)
+	}
+	u.queueSearchIndex(data.ID)
+	event := publishIssueIngested(data)
+	if notify && !u.issueMuted(data.ID) {
+		if reason := GetRulesNotifyManager().Suppression(data.BizType, data.TenantID, data.ClusterID); reason != "" {
+			u.logger.Info("Not notifying suppressed alert", "issue_id", data.ID, "reason", reason)
+		} else {
+			u.notifier.NotifyAlert(event)
+			if data.Priority == "Critical" && u.webhooks != nil {
+				u.webhooks.Fire(WebhookCriticalAlert, event)
+			}
+		}
+	}
+	return true
+}
+
+// issueStored reports whether an issue is already in the database
+func (u *DataUpdater) issueStored(id string) bool {
+	var n int
+	err := u.db.QueryRow(db.Rebind("SELECT COUNT(*) FROM issues WHERE id = ?"), id).Scan(&n)
+	return err != nil || n > 0
+}
+
+// issueMuted reports whether an issue has an active mute, such as one inherited from a
+// mute suppression
+func (u *DataUpdater) issueMuted(id string) bool {
+	var n int
+	err := u.db.QueryRow(db.Rebind("SELECT COUNT(*) FROM muted_issues WHERE issue_id = ? AND "+MutedIssueActive),
+		id, time.Now().UTC()).Scan(&n)
+	return err != nil || n > 0
+}
+
+// refreshUpdated updates the status, acknowledgment and resolution times of stored issues
+// that changed in [since, until). It is best effort: failures are logged and the issue
+// keeps its stored state until it changes again.
+func (u *DataUpdater) refreshUpdated(src Ingester, f ingestUpdateFetcher, since, until time.Time) {
+	records, err := f.FetchUpdatedSince(since, until)
+	if err != nil {
+		u.logger.Warn("Failed to refresh updated records", "source", src.Name(), "err", err)
+		return
+	}
+
+	refreshed := 0
+	for _, record := range records {
+		data, err := src.Extract(record)
+		if err != nil {
+			u.logger.Warn("Failed to extract updated record", "source", src.Name(), "err", err)
+			continue
+		}
+		res, err := u.db.Exec(db.Rebind(`
+			UPDATE issues SET status = ?, acknowledged_at = ?, resolved_at = ?, raw_payload = ?
+			WHERE id = ?`),
+			data.Status, data.AcknowledgedAt, data.ResolvedAt, string(record), data.ID)
+		if err != nil {
+			u.logger.Warn("Failed to refresh issue", "issue_id", data.ID, "err", err)
+			continue
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			refreshed++
+			u.queueSearchIndex(data.ID)
+		}
+	}
+	u.logger.Info("Refreshed updated records", "source", src.Name(), "refreshed", refreshed, "records", len(records))
+}
+
+// syncedUntil returns the end of the source's last completed sync, or zero if it has none
+func (u *DataUpdater) syncedUntil(name string) (time.Time, error) {
+	var until sql.NullTime
+	err := u.db.QueryRow(db.Rebind("SELECT synced_until FROM ingest_sync_states WHERE source = ?"), name).Scan(&until)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, fmt.Errorf("failed to load sync state: %w", err)
+	}
+	if !until.Valid {
+		return time.Time{}, nil
+	}
+	return until.Time.UTC(), nil
+}
+
+// syncFinished publishes a finished sync run and notifies the rules subscribed to it.
+// Runs that stored or refreshed issues may have moved the fake alarm rate past a
+// webhook's threshold.
+func (u *DataUpdater) syncFinished(run SyncFinishedEvent) {
+	if run.Status != "failed" || run.Stored > 0 {
+		InvalidateDashboards()
+	}
+	PublishEvent(EventSyncFinished, run)
+	if u.notifier != nil {
+		u.notifier.NotifySync(run)
+	}
+	if u.webhooks != nil && run.Status != "failed" {
+		u.webhooks.CheckFakeRate()
+	}
+}
+
+// markSyncRunning creates the source's sync state on its first run
+func (u *DataUpdater) markSyncRunning(name string) {
+	now := time.Now().UTC()
+	_, err := u.db.Exec(db.Rebind(`
+		INSERT INTO ingest_sync_states (source, status, last_run_at, last_fetched, last_stored, last_error, updated_at)
+		VALUES (?, 'running', ?, 0, 0, '', ?)
+		`+db.OnConflictUpdate("source")+`
+			status = `+db.Excluded("status")+`,
+			last_run_at = `+db.Excluded("last_run_at")+`,
+			updated_at = `+db.Excluded("updated_at")),
+		name, now, now)
+	if err != nil {
+		u.logger.Warn("Failed to record sync start", "source", name, "err", err)
+	}
+}
+
+// markSyncDone advances the source's window; a run where some records failed is
+// marked partial
+func (u *DataUpdater) markSyncDone(name string, started, until time.Time, fetched, stored int) {
+	status, lastErr := "ok", ""
+	if stored < fetched {
+		status = "partial"
+		lastErr = fmt.Sprintf("%d of %d records failed", fetched-stored, fetched)
+	}
+	metrics.ObserveSync(name, status, time.Since(started))
+	u.syncFinished(SyncFinishedEvent{Source: name, Status: status, Fetched: fetched, Stored: stored, Error: lastErr})
+	now := time.Now().UTC()
+	_, err := u.db.Exec(db.Rebind(`
+		UPDATE ingest_sync_states SET status = ?, synced_until = ?, last_success_at = ?,
+			last_fetched = ?, last_stored = ?, last_error = ?, updated_at = ?
+		WHERE source = ?`), status, until, now, fetched, stored, lastErr, now, name)
+	if err != nil {
+		u.logger.Warn("Failed to record sync state", "source", name, "err", err)
+	}
+}
+
+// markSyncFailed keeps the window where it was so the next run fetches it again
+func (u *DataUpdater) markSyncFailed(name string, started time.Time, cause error) {
+	metrics.ObserveSync(name, "failed", time.Since(started))
+	u.syncFinished(SyncFinishedEvent{Source: name, Status: "failed", Error: cause.Error()})
+	_, err := u.db.Exec(db.Rebind(`
+		UPDATE ingest_sync_states SET status = 'failed', last_error = ?, updated_at = ?
+		WHERE source = ?`), cause.Error(), time.Now().UTC(), name)
+	if err != nil {
+		u.logger.Warn("Failed to record sync state", "source", name, "err", err)
+	}
+}
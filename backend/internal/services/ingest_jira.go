@@ -0,0 +1,128 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"time"
+)
+
+// IngestSourceJira is the registry name of the JIRA source
+const IngestSourceJira = "jira"
+
+func init() {
+	RegisterIngester(IngestSourceJira, func(u *DataUpdater) Ingester {
+		return &jiraIngester{u: u}
+	})
+}
+
+// jiraIngester imports alerts from the O11Y JIRA projects. Records are JiraIssue JSON,
+// which is also what raw_payload and re-enrichment expect.
+type jiraIngester struct {
+	u        *DataUpdater
+	client   *JiraClient
+	projects []config.JiraProject
+}
+
+func (j *jiraIngester) Name() string { return IngestSourceJira }
+
+// Configure creates the JIRA client from the config
+func (j *jiraIngester) Configure() error {
+	cfg := config.Get().Jira
+	client, err := NewJiraClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create JIRA client: %w", err)
+	}
+	j.client = client
+	j.projects = cfg.Projects
+	return nil
+}
+
+func (j *jiraIngester) FetchSince(since, until time.Time) ([]json.RawMessage, error) {
+	if j.client == nil {
+		return nil, fmt.Errorf("JIRA client not configured")
+	}
+	if err := j.client.TestConnection(); err != nil {
+		return nil, fmt.Errorf("JIRA connection test failed: %w", err)
+	}
+	j.u.logger.Info("JIRA connection successful")
+
+	issues, err := j.fetchAllO11YAlerts(fmt.Sprintf("created >= '%s' AND created < '%s'",
+		since.Format(jiraTimeLayout), until.Format(jiraTimeLayout)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch alerts: %w", err)
+	}
+	return encodeJiraIssues(issues)
+}
+
+// FetchUpdatedSince returns the alerts created before since that changed in
+// [since, until), so their status transitions reach the stored issues
+func (j *jiraIngester) FetchUpdatedSince(since, until time.Time) ([]json.RawMessage, error) {
+	if j.client == nil {
+		return nil, fmt.Errorf("JIRA client not configured")
+	}
+	issues, err := j.fetchAllO11YAlerts(fmt.Sprintf("updated >= '%s' AND updated < '%s' AND created < '%s'",
+		since.Format(jiraTimeLayout), until.Format(jiraTimeLayout), since.Format(jiraTimeLayout)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch updated alerts: %w", err)
+	}
+	return encodeJiraIssues(issues)
+}
+
+// jiraTimeLayout is the JQL date format
+const jiraTimeLayout = "2006-01-02 15:04"
+
+func encodeJiraIssues(issues []JiraIssue) ([]json.RawMessage, error) {
+	records := make([]json.RawMessage, 0, len(issues))
+	for i := range issues {
+		record, err := json.Marshal(&issues[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", issues[i].Key, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (j *jiraIngester) Extract(record json.RawMessage) (*IssueData, error) {
+	var issue JiraIssue
+	if err := json.Unmarshal(record, &issue); err != nil {
+		return nil, fmt.Errorf("unreadable JIRA issue: %w", err)
+	}
+	return j.u.extractIssueData(&issue), nil
+}
+
+func (j *jiraIngester) Upsert(data *IssueData) error {
+	return j.u.insertOrUpdateIssue(data)
+}
+
+// fetchAllO11YAlerts fetches the alerts matching a JQL time condition from the configured projects
+func (j *jiraIngester) fetchAllO11YAlerts(window string) ([]JiraIssue, error) {
+	u := j.u
+	var allIssues []JiraIssue
+
+	for _, proj := range j.projects {
+		// Build JQL query with assignee and subtask filters to reduce data volume
+		jql := fmt.Sprintf(
+			"project = %s AND %s AND assignee != EMPTY AND issuetype != Sub-task",
+			proj.Key,
+			window,
+		)
+
+		label := fmt.Sprintf("O11Y:%s", proj.Label)
+		u.logger.Info("Searching JIRA project for alerts", "project", proj.Key)
+		u.logger.Debug("JIRA search", "project", proj.Key, "jql", jql)
+
+		issues, err := j.client.SearchAllIssues(jql, 100, label)
+		if err != nil {
+			u.logger.Error("JIRA search failed", "project", proj.Key, "err", err)
+			return nil, fmt.Errorf("failed to search %s: %w", proj.Key, err)
+		}
+
+		allIssues = append(allIssues, issues...)
+		u.logger.Info("Fetched JIRA issues", "project", proj.Key, "issues", len(issues), "total", len(allIssues))
+	}
+
+	u.logger.Info("Fetched issues from all JIRA projects", "issues", len(allIssues))
+	return allIssues, nil
+}
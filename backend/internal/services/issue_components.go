@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// issueComponentsTarget is the dual-write target of the issue_components migration
+const issueComponentsTarget = "issue_components.component"
+
+// storeIssueComponents mirrors an issue's components JSON into issue_components
+func (u *DataUpdater) storeIssueComponents(id, componentsJSON string) error {
+	if !db.DualWriteEnabled(issueComponentsTarget) {
+		return nil
+	}
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(db.Rebind("DELETE FROM issue_components WHERE issue_id = ?"), id); err != nil {
+		return err
+	}
+	for _, component := range distinctComponents(decodeComponentList(componentsJSON)) {
+		if _, err := tx.Exec(db.Rebind("INSERT INTO issue_components (issue_id, component) VALUES (?, ?)"), id, component); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetIssueComponents replaces the issue_components rows of an issue whose components
+// column is changed outside the pipeline, e.g. by a component override
+func SetIssueComponents(tx *gorm.DB, issueID string, components []string) error {
+	if !db.DualWriteEnabled(issueComponentsTarget) {
+		return nil
+	}
+	if err := tx.Where("issue_id = ?", issueID).Delete(&models.IssueComponent{}).Error; err != nil {
+		return err
+	}
+	rows := []models.IssueComponent{}
+	for _, component := range distinctComponents(components) {
+		rows = append(rows, models.IssueComponent{IssueID: issueID, Component: component})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// decodeComponentList parses a components JSON array; anything else lists no components
+func decodeComponentList(componentsJSON string) []string {
+	var components []string
+	json.Unmarshal([]byte(componentsJSON), &components)
+	return components
+}
+
+func distinctComponents(components []string) []string {
+	seen := make(map[string]bool, len(components))
+	var distinct []string
+	for _, c := range components {
+		if !seen[c] {
+			seen[c] = true
+			distinct = append(distinct, c)
+		}
+	}
+	return distinct
+}
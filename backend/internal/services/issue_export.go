@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IssueExportColumns are the issue fields written by ExportIssuesCSV, the warehouse
+// issue columns plus the title
+var IssueExportColumns = []string{
+	"id", "created", "title", "project", "issue_type", "priority", "status", "alert_signature",
+	"cluster_id", "tenant_id", "biz_type", "components", "stability_governance", "visibility",
+	"component_name", "source_component", "alert_group", "duplicate_of", "occurrence_count", "attributed_by",
+}
+
+// IssueExportSummary describes a finished issue export
+type IssueExportSummary struct {
+	Rows int `json:"rows"`
+}
+
+// ExportIssuesCSV writes every issue matched by query (a filtered issues query) as CSV
+func ExportIssuesCSV(ctx context.Context, query *gorm.DB, progress JobProgress) (*JobArtifact, interface{}, error) {
+	query = query.WithContext(ctx)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+	progress(0, int(total), "exporting issues")
+
+	var buf bytes.Buffer
+	rows, err := WriteIssuesCSV(query, &buf, func(rows int) {
+		if rows%1000 == 0 {
+			progress(rows, int(total), "exporting issues")
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	progress(rows, rows, fmt.Sprintf("exported %d issues", rows))
+
+	return &JobArtifact{
+		FileName:    IssueExportFileName(),
+		ContentType: "text/csv",
+		Data:        buf.Bytes(),
+	}, IssueExportSummary{Rows: rows}, nil
+}
+
+// IssueExportFileName names an issue export after the current time
+func IssueExportFileName() string {
+	return "issues-" + time.Now().UTC().Format("20060102-150405") + ".csv"
+}
+
+// WriteIssuesCSV streams the issues matched by query to out as CSV, header first, and
+// returns the number of rows written. onRow, if set, is called after each row.
+func WriteIssuesCSV(query *gorm.DB, out io.Writer, onRow func(rows int)) (int, error) {
+	columns := make([]string, len(IssueExportColumns))
+	for i, col := range IssueExportColumns {
+		columns[i] = "issues." + col
+	}
+	rs, err := query.Session(&gorm.Session{}).Select(strings.Join(columns, ", ")).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rs.Close()
+
+	w := csv.NewWriter(out)
+	if err := w.Write(IssueExportColumns); err != nil {
+		return 0, err
+	}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	row := make([]string, len(columns))
+	rows := 0
+	for rs.Next() {
+		if err := rs.Scan(ptrs...); err != nil {
+			return rows, err
+		}
+		for i, v := range values {
+			row[i] = csvValue(v)
+		}
+		if err := w.Write(row); err != nil {
+			return rows, err
+		}
+		rows++
+		if onRow != nil {
+			onRow(rows)
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return rows, err
+	}
+	w.Flush()
+	return rows, w.Error()
+}
@@ -3,22 +3,28 @@ package services
 import (
 	"context"
 	"fmt"
-	"os"
+	"log/slog"
+	"sort"
 	"time"
 
 	jira "github.com/andygrunwald/go-jira"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 )
 
 // JiraClient wraps the JIRA client
 type JiraClient struct {
 	client *jira.Client
 	ctx    context.Context
+	logger *slog.Logger
 }
 
 // JiraIssue represents a simplified JIRA issue structure
 type JiraIssue struct {
 	Key    string
 	Fields JiraIssueFields
+
+	// Changelog holds the issue's status changes, oldest first
+	Changelog []JiraStatusChange `json:",omitempty"`
 }
 
 // JiraIssueFields contains issue field data
@@ -69,24 +75,24 @@ type JiraSearchResult struct {
 	Total      int
 }
 
-// NewJiraClient creates a new JIRA client using credentials from environment
-func NewJiraClient() (*JiraClient, error) {
-	server := os.Getenv("JIRA_SERVER")
-	username := os.Getenv("JIRA_USER")
-	token := os.Getenv("JIRA_TOKEN")
-
-	if server == "" {
-		server = "https://tidb.atlassian.net"
+// NewJiraClient creates a new JIRA client for the configured server and credentials
+func NewJiraClient(cfg config.JiraConfig) (*JiraClient, error) {
+	if !cfg.Enabled() {
+		return nil, fmt.Errorf("JIRA credentials not configured (JIRA_USER, JIRA_TOKEN)")
 	}
+	server, username, token := cfg.Server, cfg.User, cfg.Token
 
-	if username == "" || token == "" {
-		return nil, fmt.Errorf("JIRA credentials not found in environment variables")
+	// Honor corporate proxy / custom CA settings
+	outbound, err := NewOutboundTransport()
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbound HTTP config: %w", err)
 	}
 
 	// Create transport with basic auth
 	tp := jira.BasicAuthTransport{
-		Username: username,
-		Password: token, // Use Password field for API token in v1
+		Username:  username,
+		Password:  token, // Use Password field for API token in v1
+		Transport: outbound,
 	}
 
 	// Create JIRA client
@@ -98,6 +104,7 @@ func NewJiraClient() (*JiraClient, error) {
 	return &JiraClient{
 		client: client,
 		ctx:    context.Background(),
+		logger: slog.Default().With("service", "jira"),
 	}, nil
 }
 
@@ -116,6 +123,7 @@ func (c *JiraClient) SearchIssues(jql string, startAt int, maxResults int) (*Jir
 	// Note: This is different from Search() which uses deprecated /rest/api/2/search
 	opts := &jira.SearchOptionsV2{
 		Fields:     []string{"summary", "description", "created", "priority", "labels", "issuetype", "components", "status", "project", "customfield_10160", "parent"},
+		Expand:     "changelog",
 		MaxResults: maxResults,
 	}
 
@@ -183,6 +191,9 @@ func (c *JiraClient) SearchIssues(jql string, startAt int, maxResults int) (*Jir
 			}
 		}
 
+		// Status transitions, for acknowledgment and resolution times
+		converted.Changelog = statusChanges(issue.Changelog)
+
 		result.Issues = append(result.Issues, converted)
 	}
 
@@ -195,7 +206,7 @@ func (c *JiraClient) SearchAllIssues(jql string, pageSize int, label string) ([]
 		pageSize = 100
 	}
 
-	fmt.Printf("\n🔍 [%s] Starting search with JQL: %s\n", label, jql)
+	c.logger.Debug("Starting JIRA search", "label", label, "jql", jql)
 
 	var allIssues []JiraIssue
 	nextPageToken := ""
@@ -207,22 +218,23 @@ func (c *JiraClient) SearchAllIssues(jql string, pageSize int, label string) ([]
 
 		// Safety check
 		if pageNum > maxPages {
-			fmt.Printf("⚠️  [WARNING] Reached max page limit (%d). Stopping pagination.\n", maxPages)
+			c.logger.Warn("Reached max page limit, stopping pagination", "label", label, "max_pages", maxPages)
 			break
 		}
 		// Use SearchV2JQL with NextPageToken for pagination
 		opts := &jira.SearchOptionsV2{
 			Fields:        []string{"summary", "description", "created", "priority", "labels", "issuetype", "components", "status", "project", "customfield_10160", "parent"},
+			Expand:        "changelog",
 			MaxResults:    pageSize,
 			NextPageToken: nextPageToken,
 		}
 
-		fmt.Printf("[DEBUG] [%s] Fetching page %d (pageSize=%d, token=%s)\n", label, pageNum, pageSize, nextPageToken)
+		c.logger.Debug("Fetching JIRA page", "label", label, "page", pageNum, "page_size", pageSize, "token", nextPageToken)
 		issues, resp, err := c.client.Issue.SearchV2JQL(jql, opts)
 		if err != nil {
 			return nil, fmt.Errorf("JIRA search error on page %d: %w", pageNum, err)
 		}
-		fmt.Printf("[DEBUG] [%s] Page %d: got %d issues, nextToken=%s\n", label, pageNum, len(issues), resp.NextPageToken)
+		c.logger.Debug("Fetched JIRA page", "label", label, "page", pageNum, "issues", len(issues), "next_token", resp.NextPageToken)
 
 		// Convert issues to our format
 		for _, issue := range issues {
@@ -276,24 +288,150 @@ func (c *JiraClient) SearchAllIssues(jql string, pageSize int, label string) ([]
 				}
 			}
 
+			// Status transitions, for acknowledgment and resolution times
+			converted.Changelog = statusChanges(issue.Changelog)
+
 			allIssues = append(allIssues, converted)
 		}
 
 		// Check if there's a next page using NextPageToken from response
 		if resp.NextPageToken == "" {
-			fmt.Printf("[DEBUG] [%s] No more pages (empty nextToken). Total pages fetched: %d\n", label, pageNum)
+			c.logger.Debug("No more JIRA pages", "label", label, "pages", pageNum)
 			break
 		}
 
 		// IMPORTANT: Check if nextPageToken is the same (infinite loop detection)
 		if resp.NextPageToken == nextPageToken {
-			fmt.Printf("⚠️  [WARNING] [%s] NextPageToken repeated (infinite loop detected). Stopping at page %d\n", label, pageNum)
+			c.logger.Warn("JIRA page token repeated, stopping pagination", "label", label, "page", pageNum)
 			break
 		}
 
 		nextPageToken = resp.NextPageToken
 	}
 
-	fmt.Printf("✅ [PAGINATION COMPLETE] [%s] Total issues collected: %d across %d pages\n", label, len(allIssues), pageNum)
+	c.logger.Info("JIRA search complete", "label", label, "issues", len(allIssues), "pages", pageNum)
 	return allIssues, nil
 }
+
+// JiraStatusChange is one status transition from an issue's changelog
+type JiraStatusChange struct {
+	At     time.Time
+	Author string
+	From   string
+	To     string
+}
+
+// StatusTransitions returns the issue's status changes, oldest first
+func (c *JiraClient) StatusTransitions(ctx context.Context, key string) ([]JiraStatusChange, error) {
+	issue, _, err := c.client.Issue.GetWithContext(ctx, key, &jira.GetQueryOptions{Expand: "changelog", Fields: "status"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch changelog of %s: %w", key, err)
+	}
+	return statusChanges(issue.Changelog), nil
+}
+
+// statusChanges extracts the status transitions from a changelog, oldest first
+func statusChanges(changelog *jira.Changelog) []JiraStatusChange {
+	if changelog == nil {
+		return nil
+	}
+
+	var changes []JiraStatusChange
+	for _, h := range changelog.Histories {
+		at, err := time.Parse("2006-01-02T15:04:05.000-0700", h.Created)
+		if err != nil {
+			continue
+		}
+		for _, item := range h.Items {
+			if item.Field != "status" {
+				continue
+			}
+			changes = append(changes, JiraStatusChange{At: at.UTC(), Author: h.Author.DisplayName, From: item.FromString, To: item.ToString})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].At.Before(changes[j].At) })
+	return changes
+}
+
+// JiraTransition is a workflow transition currently available on an issue
+type JiraTransition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   string `json:"to"` // status the issue moves to
+}
+
+// Transitions lists the workflow transitions the issue can take from its current status
+func (c *JiraClient) Transitions(ctx context.Context, key string) ([]JiraTransition, error) {
+	transitions, resp, err := c.client.Issue.GetTransitionsWithContext(ctx, key)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("JIRA rejected the transitions lookup of %s: %s", key, resp.Status)
+		}
+		return nil, fmt.Errorf("failed to fetch transitions of %s: %w", key, err)
+	}
+	result := make([]JiraTransition, 0, len(transitions))
+	for _, t := range transitions {
+		result = append(result, JiraTransition{ID: t.ID, Name: t.Name, To: t.To.Name})
+	}
+	return result, nil
+}
+
+// TransitionIssue moves the issue through the workflow transition with the given ID
+func (c *JiraClient) TransitionIssue(ctx context.Context, key, transitionID string) error {
+	resp, err := c.client.Issue.DoTransitionWithContext(ctx, key, transitionID)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("JIRA rejected the transition of %s: %s", key, resp.Status)
+		}
+		return fmt.Errorf("failed to transition %s: %w", key, err)
+	}
+	return nil
+}
+
+// JiraComment is a comment posted on an issue
+type JiraComment struct {
+	ID      string `json:"id"`
+	Author  string `json:"author"`
+	Body    string `json:"body"`
+	Created string `json:"created"`
+}
+
+// AddComment posts a comment on the issue as the configured JIRA user
+func (c *JiraClient) AddComment(ctx context.Context, key, body string) (*JiraComment, error) {
+	// Send only the body; go-jira's Comment serializes empty author and visibility objects
+	req, err := c.client.NewRequestWithContext(ctx, "POST", fmt.Sprintf("rest/api/2/issue/%s/comment", key), map[string]string{"body": body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build comment on %s: %w", key, err)
+	}
+	comment := new(jira.Comment)
+	resp, err := c.client.Do(req, comment)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("JIRA rejected the comment on %s: %s", key, resp.Status)
+		}
+		return nil, fmt.Errorf("failed to comment on %s: %w", key, err)
+	}
+	return &JiraComment{ID: comment.ID, Author: comment.Author.DisplayName, Body: comment.Body, Created: comment.Created}, nil
+}
+
+// AssignIssue assigns the issue to the user with the given account ID, or unassigns it
+// when accountID is empty
+func (c *JiraClient) AssignIssue(ctx context.Context, key, accountID string) error {
+	// go-jira's User omits an empty accountId, while JIRA unassigns only on an explicit null
+	var assignee interface{}
+	if accountID != "" {
+		assignee = accountID
+	}
+	req, err := c.client.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("rest/api/2/issue/%s/assignee", key), map[string]interface{}{"accountId": assignee})
+	if err != nil {
+		return fmt.Errorf("failed to build assignee update of %s: %w", key, err)
+	}
+	resp, err := c.client.Do(req, nil)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("JIRA rejected the assignee update of %s: %s", key, resp.Status)
+		}
+		return fmt.Errorf("failed to update assignee of %s: %w", key, err)
+	}
+	return nil
+}
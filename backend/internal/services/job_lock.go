@@ -0,0 +1,116 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"gorm.io/gorm"
+)
+
+var (
+	instanceID     string
+	instanceIDOnce sync.Once
+)
+
+// InstanceID identifies this replica as a lock owner (hostname-pid-random)
+func InstanceID() string {
+	instanceIDOnce.Do(func() {
+		host, _ := os.Hostname()
+		buf := make([]byte, 4)
+		rand.Read(buf)
+		instanceID = fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf))
+	})
+	return instanceID
+}
+
+// JobLock is a DB-backed lease lock. A lease that is not renewed before
+// it expires can be taken over by another replica, so a crashed holder
+// never blocks the job forever.
+type JobLock struct {
+	db    *gorm.DB
+	name  string
+	owner string
+	ttl   time.Duration
+}
+
+func NewJobLock(db *gorm.DB, name string, ttl time.Duration) *JobLock {
+	return &JobLock{
+		db:    db,
+		name:  name,
+		owner: InstanceID(),
+		ttl:   ttl,
+	}
+}
+
+// TryAcquire takes (or renews) the lease; returns false if another live owner holds it
+func (l *JobLock) TryAcquire() (bool, error) {
+	now := time.Now().UTC()
+	var res *gorm.DB
+	if db.Driver() == db.DriverMySQL {
+		// No conditional upsert in MySQL: keep the row unchanged (0 rows affected) unless
+		// we own it or it expired. owner is assigned first, so expires_at sees the new owner.
+		res = l.db.Exec(`
+			INSERT INTO job_locks (name, owner, expires_at) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE
+				owner = IF(owner = ? OR expires_at < ?, VALUES(owner), owner),
+				expires_at = IF(owner = ?, VALUES(expires_at), expires_at)
+		`, l.name, l.owner, now.Add(l.ttl), l.owner, now, l.owner)
+	} else {
+		res = l.db.Exec(`
+			INSERT INTO job_locks (name, owner, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+			WHERE job_locks.owner = ? OR job_locks.expires_at < ?
+		`, l.name, l.owner, now.Add(l.ttl), l.owner, now)
+	}
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %w", l.name, res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Release drops the lease if we still own it
+func (l *JobLock) Release() error {
+	return l.db.Exec("DELETE FROM job_locks WHERE name = ? AND owner = ?", l.name, l.owner).Error
+}
+
+// RunExclusive runs fn only if the lease can be acquired, renewing it while fn runs.
+// ran is false when another replica currently holds the lock. Pass the plain DB rather
+// than a request-bound one, so the lease is still released after a request deadline.
+func RunExclusive(db *gorm.DB, name string, ttl time.Duration, fn func() error) (ran bool, err error) {
+	lock := NewJobLock(db, name, ttl)
+	ok, err := lock.TryAcquire()
+	if err != nil || !ok {
+		return false, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if ok, err := lock.TryAcquire(); err != nil || !ok {
+					slog.Warn("Failed to renew lock", "lock", name, "held", ok, "err", err)
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		close(done)
+		if err := lock.Release(); err != nil {
+			slog.Warn("Failed to release lock", "lock", name, "err", err)
+		}
+	}()
+
+	return true, fn()
+}
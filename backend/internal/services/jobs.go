@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+const (
+	// MaxConcurrentJobs bounds the jobs running on one replica; the rest wait queued
+	MaxConcurrentJobs = 2
+	// jobHeartbeat is how often a running job touches its row; a job silent for
+	// jobStaleAfter is assumed lost with its replica
+	jobHeartbeat  = time.Minute
+	jobStaleAfter = 10 * time.Minute
+)
+
+// ErrJobNotRunning is returned when cancelling a job this replica is not running
+var ErrJobNotRunning = errors.New("job is not running on this replica")
+
+// JobArtifact is the downloadable output of a finished job
+type JobArtifact struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// JobProgress reports done of total units of work (total 0 when unknown)
+type JobProgress func(done, total int, message string)
+
+// JobFunc does the work of a job and returns an optional artifact and a JSON-able summary
+type JobFunc func(ctx context.Context, progress JobProgress) (*JobArtifact, interface{}, error)
+
+var (
+	jobSlots   = make(chan struct{}, MaxConcurrentJobs)
+	jobCancels = map[string]context.CancelFunc{}
+	jobMu      sync.Mutex
+)
+
+// StartJob records a queued job and runs fn in the background on this replica
+func StartJob(db *gorm.DB, kind string, params interface{}, fn JobFunc) (*models.Job, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	job := models.Job{
+		ID:     hex.EncodeToString(buf),
+		Type:   kind,
+		Status: JobQueued,
+		Owner:  InstanceID(),
+	}
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		job.Params = string(encoded)
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobMu.Lock()
+	jobCancels[job.ID] = cancel
+	jobMu.Unlock()
+
+	go runJob(ctx, cancel, db, job.ID, fn)
+	return &job, nil
+}
+
+func runJob(ctx context.Context, cancel context.CancelFunc, db *gorm.DB, id string, fn JobFunc) {
+	defer func() {
+		jobMu.Lock()
+		delete(jobCancels, id)
+		jobMu.Unlock()
+		cancel()
+	}()
+
+	// Keep the row fresh while waiting for a slot so it is not taken for lost
+	heartbeat := time.NewTicker(jobHeartbeat)
+	defer heartbeat.Stop()
+	for acquired := false; !acquired; {
+		select {
+		case jobSlots <- struct{}{}:
+			acquired = true
+		case <-heartbeat.C:
+			db.Model(&models.Job{}).Where("id = ?", id).Update("updated_at", time.Now())
+		case <-ctx.Done():
+			finishJob(db, id, nil, nil, ctx.Err())
+			return
+		}
+	}
+	defer func() { <-jobSlots }()
+
+	started := time.Now().UTC()
+	db.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     JobRunning,
+		"started_at": started,
+		"updated_at": time.Now(),
+	})
+
+	var progressMu sync.Mutex
+	lastWrite := time.Time{}
+	progress := func(done, total int, message string) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		// Progress can be reported per row; the row is written at most once a second
+		if time.Since(lastWrite) < time.Second && done < total {
+			return
+		}
+		lastWrite = time.Now()
+		db.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"done":       done,
+			"total":      total,
+			"message":    message,
+			"updated_at": time.Now(),
+		})
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				db.Model(&models.Job{}).Where("id = ?", id).Update("updated_at", time.Now())
+			}
+		}
+	}()
+
+	var artifact *JobArtifact
+	var summary interface{}
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		artifact, summary, err = fn(ctx, progress)
+	}()
+	finishJob(db, id, artifact, summary, err)
+
+	if err != nil {
+		slog.Error("Job failed", "job_id", id, "err", err)
+	} else {
+		slog.Info("Job completed", "job_id", id, "duration", time.Since(started).Round(time.Second))
+	}
+}
+
+func finishJob(db *gorm.DB, id string, artifact *JobArtifact, summary interface{}, err error) {
+	now := time.Now().UTC()
+	updates := map[string]interface{}{
+		"status":      JobCompleted,
+		"finished_at": now,
+		"updated_at":  now,
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		updates["status"] = JobCancelled
+		updates["error"] = "cancelled"
+	case err != nil:
+		updates["status"] = JobFailed
+		updates["error"] = err.Error()
+	}
+	if summary != nil {
+		if encoded, encErr := json.Marshal(summary); encErr == nil {
+			updates["result"] = string(encoded)
+		}
+	}
+	if artifact != nil {
+		updates["artifact"] = artifact.Data
+		updates["file_name"] = artifact.FileName
+		updates["content_type"] = artifact.ContentType
+		updates["size"] = len(artifact.Data)
+	}
+	// Written without the job context so a cancelled job still records its end
+	db.WithContext(context.Background()).Model(&models.Job{}).Where("id = ?", id).Updates(updates)
+}
+
+// CancelJob stops a queued or running job owned by this replica
+func CancelJob(id string) error {
+	jobMu.Lock()
+	cancel, ok := jobCancels[id]
+	jobMu.Unlock()
+	if !ok {
+		return ErrJobNotRunning
+	}
+	cancel()
+	return nil
+}
+
+// SweepJobs fails jobs whose replica stopped heart-beating and deletes finished
+// jobs (with their artifacts) older than retention
+func SweepJobs(db *gorm.DB, retention time.Duration) (lost, pruned int64, err error) {
+	now := time.Now()
+	res := db.Model(&models.Job{}).
+		Where("status IN ? AND updated_at < ?", []string{JobQueued, JobRunning}, now.Add(-jobStaleAfter)).
+		Updates(map[string]interface{}{
+			"status":      JobFailed,
+			"error":       "lost: the replica running the job stopped responding",
+			"finished_at": now.UTC(),
+			"updated_at":  now,
+		})
+	if res.Error != nil {
+		return 0, 0, res.Error
+	}
+	lost = res.RowsAffected
+
+	res = db.Where("status NOT IN ? AND finished_at < ?", []string{JobQueued, JobRunning}, now.Add(-retention).UTC()).
+		Delete(&models.Job{})
+	return lost, res.RowsAffected, res.Error
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// MutedIssueActive matches the muted_issues rows still in force; its placeholder is the
+// current time. Mutes without an expiry never lapse.
+const MutedIssueActive = "(muted_issues.expires_at IS NULL OR muted_issues.expires_at > ?)"
+
+// applyMuteSuppression mutes a newly stored issue when an active suppression covers its
+// signature and cluster. Only occurrences created after the original mute are muted, and
+// only on first import, so re-syncs neither mute older issues nor re-mute unmuted ones.
+func (u *DataUpdater) applyMuteSuppression(data *IssueData) {
+	if !data.IsAlert || data.AlertSignature == "" {
+		return
+	}
+	created, err := time.Parse("2006-01-02 15:04:05 UTC", data.Created)
+	if err != nil {
+		return
+	}
+
+	var id uint
+	var source string
+	err = u.db.QueryRow(db.Rebind(`
+		SELECT id, source_issue_id FROM mute_suppressions
+		WHERE alert_signature = ? AND cluster_id = ? AND created_at <= ? AND expires_at > ? AND source_issue_id != ?
+		ORDER BY expires_at DESC LIMIT 1`),
+		data.AlertSignature, data.ClusterID, created, time.Now().UTC(), data.ID).Scan(&id, &source)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		u.logger.Warn("Failed to check mute suppressions", "issue_id", data.ID, "err", err)
+		return
+	}
+
+	res, err := u.db.Exec(db.Rebind(`
+		INSERT INTO muted_issues (issue_id, muted_at, reason)
+		SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM muted_issues WHERE issue_id = ?)`),
+		data.ID, time.Now().UTC(), fmt.Sprintf("Inherited from mute of %s", source), data.ID)
+	if err != nil {
+		u.logger.Warn("Failed to mute from suppression", "issue_id", data.ID, "suppression_id", id, "err", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		u.db.Exec(db.Rebind("UPDATE mute_suppressions SET matched = matched + 1 WHERE id = ?"), id)
+		u.logger.Info("Muted repeat of muted issue", "issue_id", data.ID, "muted_issue_id", source)
+	}
+}
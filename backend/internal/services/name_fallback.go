@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nameFallbackEntry is one name in name_fallback.yaml, either just the name or the name
+// with its tenant
+type nameFallbackEntry struct {
+	Name       string `yaml:"name"`
+	TenantID   string `yaml:"tenant_id"`
+	TenantName string `yaml:"tenant_name"`
+}
+
+func (e *nameFallbackEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Name = node.Value
+		return nil
+	}
+	type plain nameFallbackEntry
+	return node.Decode((*plain)(e))
+}
+
+// LoadNameFallback reads the local id→name mapping used while the name API is down. Files
+// ending in .csv hold id,name[,tenant_id,tenant_name] rows (a leading "id" header is
+// skipped); anything else is YAML with a names map. A missing file maps nothing.
+func LoadNameFallback(path string) (map[string]NameInfo, error) {
+	names := map[string]NameInfo{}
+	if path == "" {
+		return names, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		for line := 1; ; line++ {
+			row, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			if line == 1 && strings.EqualFold(strings.TrimSpace(row[0]), "id") {
+				continue
+			}
+			if len(row) < 2 || strings.TrimSpace(row[0]) == "" {
+				return nil, fmt.Errorf("%s line %d: want id,name[,tenant_id,tenant_name]", path, line)
+			}
+			info := NameInfo{ID: strings.TrimSpace(row[0]), Name: strings.TrimSpace(row[1])}
+			if len(row) > 2 {
+				info.TenantID = strings.TrimSpace(row[2])
+			}
+			if len(row) > 3 {
+				info.TenantName = strings.TrimSpace(row[3])
+			}
+			names[info.ID] = info
+		}
+		return names, nil
+	}
+
+	var file struct {
+		Names map[string]nameFallbackEntry `yaml:"names"`
+	}
+	if err := yaml.NewDecoder(f).Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for id, e := range file.Names {
+		names[id] = NameInfo{ID: id, Name: e.Name, TenantID: e.TenantID, TenantName: e.TenantName}
+	}
+	return names, nil
+}
@@ -1,12 +1,25 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/cache"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/metrics"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NameInfo struct {
@@ -22,10 +35,37 @@ type nameApiResponse struct {
 	Data    NameInfo `json:"data"`
 }
 
+// nameStaleTTL keeps last-known names around as a fallback while the API is down
+const nameStaleTTL = 30 * 24 * time.Hour
+
+// nameMissTTL is how long an ID the name API doesn't know is answered from the cache
+// before it is asked again
+const nameMissTTL = 5 * time.Minute
+
+// nameBatchParallelism bounds the concurrent lookups of one ResolveBatch
+const nameBatchParallelism = 8
+
+// namePruneEvery is how many persisted resolutions go by between trims of name_cache
+// down to the configured size
+const namePruneEvery = 100
+
+var errNameNotFound = errors.New("api returned success=false")
+
 type NameResolver struct {
-	cache      map[string]NameInfo
-	cacheMutex sync.RWMutex
-	client     *http.Client
+	cache   cache.Cache
+	client  *http.Client
+	breaker *CircuitBreaker
+	group   singleflight.Group
+
+	mu        sync.RWMutex
+	baseURL   string
+	cacheTTL  time.Duration
+	cacheSize int
+	db        *gorm.DB // name_cache table; resolutions are only kept in the shared cache without it
+
+	fallbackPath  string
+	fallbackNames map[string]NameInfo // local names for IDs the API never resolved
+	writes        atomic.Int64
 }
 
 var (
@@ -36,15 +76,51 @@ var (
 func GetNameResolver() *NameResolver {
 	resolverOnce.Do(func() {
 		resolverInstance = &NameResolver{
-			cache: make(map[string]NameInfo),
-			client: &http.Client{
-				Timeout: 2 * time.Second,
-			},
+			// Shared cache so replicas don't each hit the name API
+			cache:  cache.Get(),
+			client: NewOutboundClient(2 * time.Second),
+			// Open after 3 consecutive failures, probe again after 30s
+			breaker: NewCircuitBreaker(3, 30*time.Second),
 		}
+		resolverInstance.configure(config.Get())
+		config.OnReload(resolverInstance.configure)
 	})
 	return resolverInstance
 }
 
+func (nr *NameResolver) configure(cfg *config.Config) {
+	fallbackNames, err := LoadNameFallback(cfg.NameFallbackPath)
+	if err != nil {
+		slog.Warn("Invalid name fallback file, keeping the previous one", "path", cfg.NameFallbackPath, "err", err)
+	}
+
+	nr.mu.Lock()
+	nr.baseURL, nr.cacheTTL, nr.cacheSize = cfg.NameAPIURL, cfg.NameCacheTTL, cfg.NameCacheSize
+	if err == nil {
+		nr.fallbackPath, nr.fallbackNames = cfg.NameFallbackPath, fallbackNames
+	}
+	db := nr.db
+	nr.mu.Unlock()
+	if db != nil {
+		go nr.prune(db)
+	}
+}
+
+// SetDB persists resolutions to the name_cache table of db, so they survive restarts
+// and outlast the shared cache as a fallback
+func (nr *NameResolver) SetDB(db *gorm.DB) {
+	nr.mu.Lock()
+	nr.db = db
+	nr.mu.Unlock()
+	go nr.prune(db)
+}
+
+func (nr *NameResolver) settings() (baseURL string, ttl time.Duration, size int, db *gorm.DB) {
+	nr.mu.RLock()
+	defer nr.mu.RUnlock()
+	return nr.baseURL, nr.cacheTTL, nr.cacheSize, nr.db
+}
+
 func isNumeric(s string) bool {
 	for _, c := range s {
 		if c < '0' || c > '9' {
@@ -55,6 +131,11 @@ func isNumeric(s string) bool {
 }
 
 func (nr *NameResolver) Resolve(id string) (NameInfo, error) {
+	return nr.ResolveContext(context.Background(), id)
+}
+
+// ResolveContext resolves an ID to names, aborting the API call when ctx is done
+func (nr *NameResolver) ResolveContext(ctx context.Context, id string) (NameInfo, error) {
 	if id == "" {
 		return NameInfo{}, fmt.Errorf("empty id")
 	}
@@ -64,44 +145,314 @@ func (nr *NameResolver) Resolve(id string) (NameInfo, error) {
 	}
 
 	// Check cache
-	nr.cacheMutex.RLock()
-	if info, ok := nr.cache[id]; ok {
-		nr.cacheMutex.RUnlock()
+	cacheKey := "name:" + id
+	if raw, ok := nr.cache.Get(cacheKey); ok {
+		var info NameInfo
+		if err := json.Unmarshal(raw, &info); err == nil {
+			metrics.ObserveNameLookup(metrics.NameCacheHit)
+			return info, nil
+		}
+	}
+
+	// IDs the API recently didn't know get their fallback name without asking again
+	if raw, ok := nr.cache.Get("name-miss:" + id); ok {
+		var info NameInfo
+		if err := json.Unmarshal(raw, &info); err == nil {
+			metrics.ObserveNameLookup(metrics.NameCacheHit)
+			return info, errNameNotFound
+		}
+	}
+
+	// Concurrent lookups of one ID share a single database read and API call. The call
+	// outlives callers that give up, so their deadline doesn't fail the others.
+	ch := nr.group.DoChan(id, func() (interface{}, error) {
+		return nr.lookup(context.WithoutCancel(ctx), id)
+	})
+	select {
+	case res := <-ch:
+		return res.Val.(NameInfo), res.Err
+	case <-ctx.Done():
+		return NameInfo{ID: id, Name: id}, ctx.Err()
+	}
+}
+
+// lookup resolves an ID missing from the shared cache, from the persisted resolutions
+// or the name API
+func (nr *NameResolver) lookup(ctx context.Context, id string) (NameInfo, error) {
+	cacheKey := "name:" + id
+	_, ttl, _, db := nr.settings()
+	if entry, ok := nr.stored(ctx, db, id); ok && time.Now().UTC().Before(entry.ExpiresAt) {
+		info := nameInfoFromEntry(entry)
+		if raw, err := json.Marshal(info); err == nil {
+			nr.cache.Set(cacheKey, raw, time.Until(entry.ExpiresAt))
+		}
+		metrics.ObserveNameLookup(metrics.NameStored)
 		return info, nil
 	}
-	nr.cacheMutex.RUnlock()
 
-	// Fetch from API
-	// API: http://10.2.8.101:3535/api/name?id={id}
-	url := fmt.Sprintf("http://10.2.8.101:3535/api/name?id=%s", id)
-	resp, err := nr.client.Get(url)
+	// Fail fast while the name API is known to be down
+	if !nr.breaker.Allow() {
+		return nr.fallback(ctx, db, id), ErrCircuitOpen
+	}
+
+	info, err := nr.fetch(ctx, id)
+	if errors.Is(err, errNameNotFound) {
+		// The API answered, so this is no outage
+		nr.breaker.Success()
+		info = nr.fallback(ctx, db, id)
+		if raw, err := json.Marshal(info); err == nil {
+			nr.cache.Set("name-miss:"+id, raw, nameMissTTL)
+		}
+		return info, err
+	}
 	if err != nil {
-		return NameInfo{ID: id, Name: id}, err // Return ID as name on error fallback? Or just error.
+		// Only an API that can't be reached or fails itself is down; a lookup given up on,
+		// a 4xx or an odd response says nothing about the API
+		var down *nameAPIDownError
+		if ctx.Err() == nil && errors.As(err, &down) {
+			nr.breaker.Failure()
+		}
+		return nr.fallback(ctx, db, id), err
+	}
+	nr.breaker.Success()
+	metrics.ObserveNameLookup(metrics.NameFetched)
+
+	// Update cache (plus a long-lived stale copy used as fallback during outages)
+	if raw, err := json.Marshal(info); err == nil {
+		nr.cache.Set(cacheKey, raw, ttl)
+		nr.cache.Set("name-stale:"+id, raw, nameStaleTTL)
+	}
+	nr.persist(db, id, info, ttl)
+
+	return info, nil
+}
+
+// ResolveBatch resolves several IDs at once, at most nameBatchParallelism at a time, and
+// returns their names by ID. IDs that fail to resolve get their fallback name, as with
+// ResolveContext; empty IDs are left out.
+func (nr *NameResolver) ResolveBatch(ctx context.Context, ids []string) map[string]NameInfo {
+	names := make(map[string]NameInfo, len(ids))
+	var unique []string
+	for _, id := range ids {
+		if _, seen := names[id]; !seen && id != "" {
+			names[id] = NameInfo{ID: id, Name: id}
+			unique = append(unique, id)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, nameBatchParallelism)
+	for _, id := range unique {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return
+			}
+			info, _ := nr.ResolveContext(ctx, id)
+			mu.Lock()
+			names[id] = info
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return names
+}
+
+// fallback returns the last known name for id, then its name in the fallback file, or
+// the ID itself
+func (nr *NameResolver) fallback(ctx context.Context, db *gorm.DB, id string) NameInfo {
+	metrics.ObserveNameLookup(metrics.NameFallback)
+	if raw, ok := nr.cache.Get("name-stale:" + id); ok {
+		var info NameInfo
+		if err := json.Unmarshal(raw, &info); err == nil {
+			return info
+		}
+	}
+	if entry, ok := nr.stored(ctx, db, id); ok {
+		return nameInfoFromEntry(entry)
+	}
+	nr.mu.RLock()
+	info, ok := nr.fallbackNames[id]
+	nr.mu.RUnlock()
+	if ok {
+		return info
+	}
+	return NameInfo{ID: id, Name: id}
+}
+
+func nameInfoFromEntry(entry models.NameCacheEntry) NameInfo {
+	return NameInfo{Type: entry.Type, ID: entry.ID, Name: entry.Name, TenantID: entry.TenantID, TenantName: entry.TenantName}
+}
+
+// stored returns the persisted resolution of id, expired or not
+func (nr *NameResolver) stored(ctx context.Context, db *gorm.DB, id string) (models.NameCacheEntry, bool) {
+	var entry models.NameCacheEntry
+	if db == nil {
+		return entry, false
+	}
+	err := db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&entry).Error
+	return entry, err == nil && entry.ID != ""
+}
+
+// persist upserts a resolution, trimming the table every namePruneEvery writes
+func (nr *NameResolver) persist(db *gorm.DB, id string, info NameInfo, ttl time.Duration) {
+	if db == nil {
+		return
+	}
+	now := time.Now().UTC()
+	entry := models.NameCacheEntry{
+		ID:         id,
+		Type:       info.Type,
+		Name:       info.Name,
+		TenantID:   info.TenantID,
+		TenantName: info.TenantName,
+		FetchedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "name", "tenant_id", "tenant_name", "fetched_at", "expires_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		slog.Warn("Failed to persist name", "id", id, "err", err)
+		return
+	}
+	if nr.writes.Add(1)%namePruneEvery == 0 {
+		go nr.prune(db)
+	}
+}
+
+// prune keeps the cacheSize most recently fetched resolutions
+func (nr *NameResolver) prune(db *gorm.DB) {
+	_, _, size, _ := nr.settings()
+	if size <= 0 {
+		return
+	}
+	var cutoff []time.Time
+	if err := db.Model(&models.NameCacheEntry{}).Order("fetched_at DESC").Offset(size).Limit(1).Pluck("fetched_at", &cutoff).Error; err != nil {
+		slog.Warn("Failed to trim the name cache", "err", err)
+		return
+	}
+	if len(cutoff) == 0 {
+		return
+	}
+	res := db.Where("fetched_at <= ?", cutoff[0]).Delete(&models.NameCacheEntry{})
+	if res.Error != nil {
+		slog.Warn("Failed to trim the name cache", "err", res.Error)
+		return
+	}
+	slog.Info("Trimmed the name cache", "removed", res.RowsAffected, "limit", size)
+}
+
+// Forget drops the cached and persisted names of ids, or of every persisted ID when ids is
+// empty, so they are fetched again on next use. It returns how many IDs were dropped.
+func (nr *NameResolver) Forget(ctx context.Context, ids ...string) (int, error) {
+	_, _, _, db := nr.settings()
+	if len(ids) == 0 && db != nil {
+		if err := db.WithContext(ctx).Model(&models.NameCacheEntry{}).Pluck("id", &ids).Error; err != nil {
+			return 0, err
+		}
+	}
+	for _, id := range ids {
+		nr.cache.Delete("name:" + id)
+		nr.cache.Delete("name-stale:" + id)
+		nr.cache.Delete("name-miss:" + id)
+	}
+	if db != nil && len(ids) > 0 {
+		for start := 0; start < len(ids); start += 500 {
+			end := start + 500
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if err := db.WithContext(ctx).Where("id IN ?", ids[start:end]).Delete(&models.NameCacheEntry{}).Error; err != nil {
+				return start, err
+			}
+		}
+	}
+	return len(ids), nil
+}
+
+// NameCacheStats describes the resolver's settings and persisted names
+type NameCacheStats struct {
+	APIURL        string        `json:"api_url"`
+	TTL           string        `json:"ttl"`
+	MaxSize       int           `json:"max_size"`
+	Entries       int64         `json:"entries"`
+	Expired       int64         `json:"expired"`
+	FallbackPath  string        `json:"fallback_path"`
+	FallbackNames int           `json:"fallback_names"`
+	Breaker       BreakerStatus `json:"breaker"`
+}
+
+// CacheStats reports the resolver's settings and how many names are persisted
+func (nr *NameResolver) CacheStats(ctx context.Context) (NameCacheStats, error) {
+	baseURL, ttl, size, db := nr.settings()
+	stats := NameCacheStats{APIURL: baseURL, TTL: ttl.String(), MaxSize: size, Breaker: nr.breaker.Status()}
+	nr.mu.RLock()
+	stats.FallbackPath, stats.FallbackNames = nr.fallbackPath, len(nr.fallbackNames)
+	nr.mu.RUnlock()
+	if db == nil {
+		return stats, nil
+	}
+	if err := db.WithContext(ctx).Model(&models.NameCacheEntry{}).Count(&stats.Entries).Error; err != nil {
+		return stats, err
+	}
+	err := db.WithContext(ctx).Model(&models.NameCacheEntry{}).Where("expires_at <= ?", time.Now().UTC()).Count(&stats.Expired).Error
+	return stats, err
+}
+
+// nameAPIDownError is a fetch failure that means the name API is down: a transport
+// error or a 5xx response
+type nameAPIDownError struct{ err error }
+
+func (e *nameAPIDownError) Error() string { return e.err.Error() }
+func (e *nameAPIDownError) Unwrap() error { return e.err }
+
+// fetch calls the name API. Unknown IDs (success=false) return errNameNotFound, which
+// lookup doesn't count as an outage; only a *nameAPIDownError trips the breaker.
+func (nr *NameResolver) fetch(ctx context.Context, id string) (NameInfo, error) {
+	// API: {NAME_API_URL}/api/name?id={id}
+	baseURL, _, _, _ := nr.settings()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/name?id="+url.QueryEscape(id), nil)
+	if err != nil {
+		return NameInfo{}, err
+	}
+	resp, err := nr.client.Do(req)
+	if err != nil {
+		return NameInfo{}, &nameAPIDownError{err}
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return NameInfo{}, &nameAPIDownError{fmt.Errorf("api returned status: %d", resp.StatusCode)}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return NameInfo{ID: id, Name: id}, fmt.Errorf("api returned status: %d", resp.StatusCode)
+		return NameInfo{}, fmt.Errorf("api returned status: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return NameInfo{ID: id, Name: id}, err
+		return NameInfo{}, err
 	}
 
 	var apiResp nameApiResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return NameInfo{ID: id, Name: id}, err
+		return NameInfo{}, err
 	}
 
 	if !apiResp.Success {
-		return NameInfo{ID: id, Name: id}, fmt.Errorf("api returned success=false")
+		return NameInfo{ID: id, Name: id}, errNameNotFound
 	}
 
-	// Update cache
-	nr.cacheMutex.Lock()
-	nr.cache[id] = apiResp.Data
-	nr.cacheMutex.Unlock()
-
 	return apiResp.Data, nil
 }
+
+// Health reports the circuit breaker state of the name API
+func (nr *NameResolver) Health() BreakerStatus {
+	return nr.breaker.Status()
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// ValueMapping maps raw JIRA values (any language or workflow) onto canonical ones
+type ValueMapping struct {
+	Canonical []string          `yaml:"canonical" json:"canonical"`
+	Aliases   map[string]string `yaml:"aliases" json:"aliases"`
+}
+
+// NormalizationConfig is value_normalization.yaml
+type NormalizationConfig struct {
+	Priority ValueMapping `yaml:"priority" json:"priority"`
+	Status   ValueMapping `yaml:"status" json:"status"`
+}
+
+// defaultNormalization is used when value_normalization.yaml is missing
+var defaultNormalization = NormalizationConfig{
+	Priority: ValueMapping{
+		Canonical: []string{"Critical", "Major", "Medium", "Low"},
+		Aliases: map[string]string{
+			"严重":   "Critical",
+			"重要":   "Major",
+			"低":    "Low",
+			"High": "Major",
+		},
+	},
+	Status: ValueMapping{
+		Canonical: []string{"Created", "In Progress", "Resolved", "Closed", "Won't Fix", "FAKE ALARM"},
+	},
+}
+
+var (
+	normalization     *NormalizationConfig
+	normalizationMu   sync.Mutex
+	normalizationOnce sync.Once
+)
+
+// LoadNormalizationConfig reads and validates the mapping table; a missing file yields the defaults
+func LoadNormalizationConfig(path string) (*NormalizationConfig, error) {
+	cfg := defaultNormalization
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &cfg, nil
+		}
+		return nil, err
+	}
+	cfg = NormalizationConfig{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for field, m := range map[string]ValueMapping{"priority": cfg.Priority, "status": cfg.Status} {
+		for alias, target := range m.Aliases {
+			if !containsString(m.Canonical, target) {
+				return nil, fmt.Errorf("%s alias %q maps to %q, which is not a canonical %s", field, alias, target, field)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// GetNormalizationConfig returns the active mapping table, re-read on config reload
+func GetNormalizationConfig() *NormalizationConfig {
+	normalizationOnce.Do(func() {
+		config.OnReload(func(cfg *config.Config) {
+			normalizationMu.Lock()
+			normalization = nil
+			normalizationMu.Unlock()
+		})
+	})
+
+	normalizationMu.Lock()
+	defer normalizationMu.Unlock()
+	if normalization == nil {
+		cfg, err := LoadNormalizationConfig(config.Get().ValueNormalizationPath)
+		if err != nil {
+			slog.Warn("Invalid value normalization config, using defaults", "err", err)
+			def := defaultNormalization
+			cfg = &def
+		}
+		normalization = cfg
+	}
+	return normalization
+}
+
+// Normalize returns the canonical form of value and whether it is known. Aliases match
+// exactly first, then ignoring case and surrounding whitespace; unknown values are kept.
+func (m *ValueMapping) Normalize(value string) (string, bool) {
+	if target, ok := m.Aliases[value]; ok {
+		return target, true
+	}
+	if containsString(m.Canonical, value) {
+		return value, true
+	}
+
+	key := strings.TrimSpace(value)
+	for alias, target := range m.Aliases {
+		if strings.EqualFold(alias, key) {
+			return target, true
+		}
+	}
+	for _, c := range m.Canonical {
+		if strings.EqualFold(c, key) {
+			return c, true
+		}
+	}
+	return value, false
+}
+
+// normalizedColumns are the issue columns the mapping table applies to
+var normalizedColumns = []string{"priority", "status"}
+
+func (n *NormalizationConfig) mapping(column string) *ValueMapping {
+	if column == "priority" {
+		return &n.Priority
+	}
+	return &n.Status
+}
+
+// UnmappedValue is a stored value the mapping table does not know
+type UnmappedValue struct {
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Issues int64  `json:"issues"`
+	Alerts int64  `json:"alerts"`
+	Sample string `json:"sample"` // an issue carrying the value
+}
+
+// UnmappedValues lists stored priorities and statuses with no canonical form, most used first
+func (n *NormalizationConfig) UnmappedValues(db *gorm.DB) ([]UnmappedValue, error) {
+	out := []UnmappedValue{}
+	for _, column := range normalizedColumns {
+		var rows []UnmappedValue
+		err := db.Raw(`SELECT ? as field, `+column+` as value, COUNT(*) as issues,
+				SUM(CASE WHEN is_alert = TRUE THEN 1 ELSE 0 END) as alerts, MAX(id) as sample
+			FROM issues WHERE `+column+` != '' GROUP BY `+column, column).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		m := n.mapping(column)
+		for _, r := range rows {
+			if _, ok := m.Normalize(r.Value); !ok {
+				out = append(out, r)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Issues > out[j].Issues })
+	return out, nil
+}
+
+// ApplyToStored rewrites stored priorities and statuses to their canonical form, so
+// aliases added after import take effect retroactively. Returns rows updated per field.
+func (n *NormalizationConfig) ApplyToStored(db *gorm.DB) (map[string]int64, error) {
+	updated := make(map[string]int64, len(normalizedColumns))
+	for _, column := range normalizedColumns {
+		var values []string
+		if err := db.Raw(`SELECT DISTINCT ` + column + ` FROM issues WHERE ` + column + ` != ''`).Scan(&values).Error; err != nil {
+			return updated, err
+		}
+
+		m := n.mapping(column)
+		updated[column] = 0
+		for _, v := range values {
+			target, ok := m.Normalize(v)
+			if !ok || target == v {
+				continue
+			}
+			res := db.Exec(`UPDATE issues SET `+column+` = ? WHERE `+column+` = ?`, target, v)
+			if res.Error != nil {
+				return updated, res.Error
+			}
+			updated[column] += res.RowsAffected
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// Notification channels
+const (
+	ChannelSlack     = "slack"
+	ChannelLark      = "lark"
+	ChannelPagerDuty = "pagerduty"
+	ChannelWebhook   = "webhook"
+)
+
+var notificationChannels = []string{ChannelSlack, ChannelLark, ChannelPagerDuty, ChannelWebhook}
+
+// Events a notification rule can notify about
+const (
+	NotifyAlert      = "alert"       // a new prod alert
+	NotifySync       = "sync"        // a finished sync run
+	NotifyTaskReview = "task_review" // a task waiting for review
+	NotifyReport     = "report"      // a generated weekly or monthly report
+)
+
+var notificationEvents = []string{NotifyAlert, NotifySync, NotifyTaskReview, NotifyReport}
+
+// notificationSeverities are the normalized issue priorities (see convertPriority)
+var notificationSeverities = []string{"Critical", "Major", "Medium", "Warning", "Low"}
+
+// ValidateNotificationRule checks a rule before it is stored and normalizes its lists
+func ValidateNotificationRule(rule *models.NotificationRule) []string {
+	var problems []string
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		problems = append(problems, "name is required")
+	}
+
+	rule.Channel = strings.ToLower(strings.TrimSpace(rule.Channel))
+	rule.Target = strings.TrimSpace(rule.Target)
+	switch rule.Channel {
+	case ChannelSlack, ChannelLark, ChannelWebhook:
+		u, err := url.Parse(rule.Target)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("target must be an http(s) URL for %s", rule.Channel))
+		}
+	case ChannelPagerDuty:
+		if rule.Target == "" {
+			problems = append(problems, "target must be the PagerDuty routing key")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("channel must be one of %s", strings.Join(notificationChannels, ", ")))
+	}
+	rule.Secret = strings.TrimSpace(rule.Secret)
+	if rule.Secret != "" && rule.Channel != ChannelLark {
+		problems = append(problems, "secret is only used by lark bots")
+	}
+
+	severities := []string{}
+	for _, s := range rule.Severities {
+		matched := ""
+		for _, known := range notificationSeverities {
+			if strings.EqualFold(strings.TrimSpace(s), known) {
+				matched = known
+			}
+		}
+		if matched == "" {
+			problems = append(problems, fmt.Sprintf("unknown severity %q (expected %s)", s, strings.Join(notificationSeverities, ", ")))
+			continue
+		}
+		severities = append(severities, matched)
+	}
+	rule.Severities = severities
+
+	components := []string{}
+	for _, c := range rule.Components {
+		if c = strings.TrimSpace(c); c != "" {
+			components = append(components, c)
+		}
+	}
+	rule.Components = components
+
+	events := []string{}
+	for _, e := range rule.Events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		switch {
+		case !containsString(notificationEvents, e):
+			problems = append(problems, fmt.Sprintf("unknown event %q (expected %s)", e, strings.Join(notificationEvents, ", ")))
+		case !containsString(events, e):
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		events = append(events, NotifyAlert)
+	}
+	rule.Events = events
+
+	rule.Template = strings.TrimSpace(rule.Template)
+	if rule.Template != "" {
+		for _, event := range rule.Events {
+			if _, err := renderNotification(*rule, event, sampleNotificationData(event)); err != nil {
+				problems = append(problems, fmt.Sprintf("template: %v", err))
+				break
+			}
+		}
+	}
+
+	if rule.Threshold < 1 {
+		problems = append(problems, "threshold must be at least 1")
+	}
+	if rule.WindowMinutes < 1 {
+		problems = append(problems, "window_minutes must be at least 1")
+	}
+	return problems
+}
+
+// RuleNotifies reports whether rule is subscribed to event; rules without events only
+// notify about alerts
+func RuleNotifies(rule models.NotificationRule, event string) bool {
+	if len(rule.Events) == 0 {
+		return event == NotifyAlert
+	}
+	return containsString(rule.Events, event)
+}
+
+// notificationRuleMatches reports whether an alert with the given components and priority is routed by rule
+func notificationRuleMatches(rule models.NotificationRule, components []string, severity string) bool {
+	if !RuleNotifies(rule, NotifyAlert) {
+		return false
+	}
+	if len(rule.Severities) > 0 {
+		found := false
+		for _, s := range rule.Severities {
+			if strings.EqualFold(s, severity) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return ruleMatchesComponents(rule, components)
+}
+
+// ruleMatchesComponents reports whether any of components is one the rule routes
+func ruleMatchesComponents(rule models.NotificationRule, components []string) bool {
+	if len(rule.Components) == 0 {
+		return true
+	}
+	for _, want := range rule.Components {
+		for _, c := range components {
+			if strings.EqualFold(want, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchNotificationRules returns the enabled rules routing an alert with the given
+// components and priority
+func MatchNotificationRules(db *gorm.DB, components []string, severity string) ([]models.NotificationRule, error) {
+	var rules []models.NotificationRule
+	if err := db.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	var matched []models.NotificationRule
+	for _, rule := range rules {
+		if notificationRuleMatches(rule, components, severity) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched, nil
+}
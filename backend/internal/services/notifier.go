@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Notifier sends new prod alerts, finished sync runs, tasks waiting for review and reports
+// to the channels of the notification rules subscribed to them. Sending is best effort and off
+// the caller's path: failures are logged.
+type Notifier struct {
+	db     *sql.DB
+	client *http.Client
+	logger *slog.Logger
+}
+
+func NewNotifier(db *sql.DB) *Notifier {
+	return &Notifier{db: db, client: NewOutboundClient(10 * time.Second), logger: slog.Default().With("service", "notifier")}
+}
+
+// notificationWindows holds, per rule, the alerts that matched it within its window
+// without firing it yet
+var notificationWindows struct {
+	sync.Mutex
+	times map[uint][]time.Time
+}
+
+// NotifyAlert routes a newly ingested alert by its components and priority; a rule fires
+// once Threshold of them arrive within WindowMinutes
+func (n *Notifier) NotifyAlert(alert IssueIngestedEvent) {
+	go n.dispatch(NotifyAlert, alert.Components, alert.Priority, alert)
+}
+
+// NotifySync sends a finished sync run, successful or not
+func (n *Notifier) NotifySync(run SyncFinishedEvent) {
+	go n.dispatch(NotifySync, nil, "", run)
+}
+
+// NotifyReport sends the summary of a generated report
+func (n *Notifier) NotifyReport(report ReportSummary) {
+	go n.dispatch(NotifyReport, nil, "", report)
+}
+
+// NotifyTaskReview sends a task that is waiting for review, routed by its component
+func (n *Notifier) NotifyTaskReview(task TaskStatusEvent) {
+	var components []string
+	if task.Component != "" {
+		components = []string{task.Component}
+	}
+	go n.dispatch(NotifyTaskReview, components, "", task)
+}
+
+func (n *Notifier) dispatch(event string, components []string, severity string, data interface{}) {
+	rules, err := n.enabledRules()
+	if err != nil {
+		n.logger.Warn("Failed to load notification rules", "event", event, "err", err)
+		return
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		if !RuleNotifies(rule, event) {
+			continue
+		}
+		switch event {
+		case NotifyAlert:
+			if !notificationRuleMatches(rule, components, severity) || !fireNotificationWindow(rule, now) {
+				continue
+			}
+		case NotifyTaskReview:
+			if !ruleMatchesComponents(rule, components) {
+				continue
+			}
+		}
+		if err := n.send(context.Background(), rule, event, data); err != nil {
+			n.logger.Warn("Failed to send notification", "event", event, "rule", rule.Name, "err", err)
+		}
+	}
+}
+
+// TestSend renders sample data for event through rule's template and sends it, so a
+// rule's channel and template can be checked without waiting for the real thing
+func (n *Notifier) TestSend(ctx context.Context, rule models.NotificationRule, event string) (Message, error) {
+	msg, err := renderNotification(rule, event, sampleNotificationData(event))
+	if err != nil {
+		return msg, err
+	}
+	msg.Title = "[TEST] " + msg.Title
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return msg, SendSignedMessage(ctx, n.client, rule.Channel, rule.Target, rule.Secret, msg)
+}
+
+func (n *Notifier) send(ctx context.Context, rule models.NotificationRule, event string, data interface{}) error {
+	msg, err := renderNotification(rule, event, data)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return SendSignedMessage(ctx, n.client, rule.Channel, rule.Target, rule.Secret, msg)
+}
+
+// enabledRules loads the enabled notification rules
+func (n *Notifier) enabledRules() ([]models.NotificationRule, error) {
+	rows, err := n.db.Query(db.Rebind(`
+		SELECT id, name, channel, target, secret, severities, components, events, template, threshold, window_minutes
+		FROM notification_rules WHERE enabled = ? ORDER BY id`), true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.NotificationRule
+	for rows.Next() {
+		var rule models.NotificationRule
+		var secret, severities, components, events, tmpl sql.NullString
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Channel, &rule.Target, &secret, &severities, &components, &events,
+			&tmpl, &rule.Threshold, &rule.WindowMinutes); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(severities.String), &rule.Severities)
+		json.Unmarshal([]byte(components.String), &rule.Components)
+		json.Unmarshal([]byte(events.String), &rule.Events)
+		rule.Secret, rule.Template, rule.Enabled = secret.String, tmpl.String, true
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// fireNotificationWindow records an alert matching rule at `at` and reports whether it
+// brings the rule to its threshold
+func fireNotificationWindow(rule models.NotificationRule, at time.Time) bool {
+	notificationWindows.Lock()
+	defer notificationWindows.Unlock()
+	if notificationWindows.times == nil {
+		notificationWindows.times = map[uint][]time.Time{}
+	}
+	var fired bool
+	notificationWindows.times[rule.ID], fired = advanceNotificationWindow(notificationWindows.times[rule.ID], at, rule)
+	return fired
+}
+
+// advanceNotificationWindow adds an alert at `at` to the rule's window, dropping alerts
+// older than WindowMinutes. Reaching Threshold fires the rule and empties the window.
+func advanceNotificationWindow(window []time.Time, at time.Time, rule models.NotificationRule) ([]time.Time, bool) {
+	cutoff := at.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+	kept := window[:0]
+	for _, t := range window {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, at)
+	if len(kept) >= rule.Threshold {
+		return kept[:0], true
+	}
+	return kept, false
+}
+
+// defaultNotificationTemplates are the message bodies of rules without a template. A
+// template sees the event's payload: IssueIngestedEvent, SyncFinishedEvent,
+// TaskStatusEvent or ReportSummary.
+var defaultNotificationTemplates = map[string]string{
+	NotifyAlert: `{{.Title}}
+Priority: {{.Priority}}{{if .Components}}, components: {{join .Components ", "}}{{end}}
+{{- if .ClusterID}}
+Cluster: {{.ClusterID}}{{end}}{{if .TenantID}}, tenant: {{.TenantID}}{{end}}
+Created: {{.Created}}`,
+	NotifySync: `Sync of {{.Source}} finished {{.Status}}: stored {{.Stored}} of {{.Fetched}} fetched records
+{{- if .Error}}
+Error: {{.Error}}{{end}}`,
+	NotifyReport: `{{.Alerts}} alerts ({{.Critical}} critical), {{printf "%+.1f" .Change}}% vs the period before ({{.Previous}})
+Fake alarm rate: {{printf "%.1f" .FakeRate}}%
+{{- if .TopRules}}
+Top rules:{{range .TopRules}}
+  {{.Count}}  {{.Name}}{{end}}{{end}}
+{{- if .Regressions}}
+Regressions:{{range .Regressions}}
+  {{.Rule}}: {{.Previous}} -> {{.Alerts}}{{end}}{{end}}`,
+	NotifyTaskReview: `Task {{.ID}}{{if .RuleName}} for {{.RuleName}}{{end}}{{if .Component}} ({{.Component}}){{end}} is waiting for review
+{{- if .PRLink}}
+Pull request: {{.PRLink}}{{end}}`,
+}
+
+var notificationFuncs = template.FuncMap{"join": strings.Join}
+
+// priorityLevels and syncLevels color alerts and sync runs; anything else is info
+var (
+	priorityLevels = map[string]string{"Critical": LevelCritical, "Major": LevelWarning}
+	syncLevels     = map[string]string{"ok": LevelOK, "partial": LevelWarning, "failed": LevelCritical}
+)
+
+// renderNotification builds the message for an event, the body from rule's template or
+// the event's default
+func renderNotification(rule models.NotificationRule, event string, data interface{}) (Message, error) {
+	msg := Message{Data: map[string]interface{}{"event": event, "rule": rule.Name, "payload": data}}
+	switch d := data.(type) {
+	case IssueIngestedEvent:
+		msg.Title = fmt.Sprintf("%s alert %s", d.Priority, d.ID)
+		msg.Level = priorityLevels[d.Priority]
+	case SyncFinishedEvent:
+		msg.Title = fmt.Sprintf("Sync of %s: %s", d.Source, d.Status)
+		msg.Level = syncLevels[d.Status]
+	case TaskStatusEvent:
+		msg.Title = fmt.Sprintf("Task %d waiting for review", d.ID)
+	case ReportSummary:
+		msg.Title = fmt.Sprintf("%s alert report for %s", reportTitle(d.Period), d.PeriodStart)
+	default:
+		return msg, fmt.Errorf("unknown notification event %q", event)
+	}
+
+	text := rule.Template
+	if text == "" {
+		text = defaultNotificationTemplates[event]
+	}
+	tmpl, err := template.New(rule.Name).Funcs(notificationFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return msg, err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return msg, err
+	}
+	msg.Text = b.String()
+	return msg, nil
+}
+
+// sampleNotificationData is a made-up event payload for test sends and template checks
+func sampleNotificationData(event string) interface{} {
+	switch event {
+	case NotifySync:
+		return SyncFinishedEvent{Source: "jira", Status: "ok", Fetched: 42, Stored: 42}
+	case NotifyReport:
+		return ReportSummary{Period: ReportWeekly, PeriodStart: "2026-01-05", PeriodEnd: "2026-01-12", Alerts: 120, Previous: 100, Change: 20,
+			Critical: 8, FakeAlarms: 6, FakeRate: 5, TopRules: []DigestCount{{Name: "TiKVStoreDown", Count: 30}},
+			Regressions: []ReportRegression{{Rule: "TiKVStoreDown", Alerts: 30, Previous: 10, Increase: 20, Change: 200}}}
+	case NotifyTaskReview:
+		return TaskStatusEvent{ID: 1, Component: "tikv", RuleName: "TiKVStoreDown", Status: "waiting_for_review", PRLink: "https://github.com/example/runbooks/pull/1"}
+	}
+	return IssueIngestedEvent{
+		ID:         "O11Y-0000",
+		Title:      "[PROD] TiKV store down",
+		Priority:   "Critical",
+		Components: []string{"tikv"},
+		ClusterID:  "1234567890",
+		TenantID:   "1000",
+		Created:    time.Now().UTC().Format("2006-01-02 15:04:05") + " UTC",
+		IsAlert:    true,
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelEmail sends through the SMTP relay in SMTP_ADDR; its target is a comma separated
+// recipient list
+const ChannelEmail = "email"
+
+// Message levels, the header color of Lark cards
+const (
+	LevelCritical = "critical"
+	LevelWarning  = "warning"
+	LevelInfo     = "info"
+	LevelOK       = "ok"
+)
+
+// Message is a channel-agnostic notification. Data is attached as-is for webhooks.
+type Message struct {
+	Title string
+	Text  string
+	Level string // one of the Level constants, info when empty
+	Data  interface{}
+}
+
+// SendMessage delivers msg to a slack, lark or generic webhook URL, or by email
+func SendMessage(ctx context.Context, client *http.Client, channel, target string, msg Message) error {
+	return SendSignedMessage(ctx, client, channel, target, "", msg)
+}
+
+// SendSignedMessage is SendMessage for a Lark bot with signature verification on, whose
+// secret signs the request; other channels ignore the secret
+func SendSignedMessage(ctx context.Context, client *http.Client, channel, target, secret string, msg Message) error {
+	var body interface{}
+	switch channel {
+	case ChannelSlack:
+		body = map[string]interface{}{"text": "*" + msg.Title + "*\n" + msg.Text}
+	case ChannelLark:
+		card := larkCard(msg)
+		if secret != "" {
+			timestamp := time.Now().Unix()
+			card["timestamp"] = strconv.FormatInt(timestamp, 10)
+			card["sign"] = larkSign(secret, timestamp)
+		}
+		body = card
+	case ChannelWebhook:
+		body = map[string]interface{}{"title": msg.Title, "text": msg.Text, "data": msg.Data}
+	case ChannelEmail:
+		return sendEmail(target, msg)
+	default:
+		return fmt.Errorf("unsupported channel %q", channel)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", channel, resp.Status)
+	}
+	if channel == ChannelLark {
+		// Lark answers 200 and reports failures such as a bad signature in the body
+		var result struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if json.Unmarshal(data, &result) == nil && result.Code != 0 {
+			return fmt.Errorf("lark returned code %d: %s", result.Code, result.Msg)
+		}
+	}
+	return nil
+}
+
+// larkCardColors maps message levels to Lark card header templates
+var larkCardColors = map[string]string{
+	LevelCritical: "red",
+	LevelWarning:  "orange",
+	LevelInfo:     "blue",
+	LevelOK:       "green",
+}
+
+// larkCard renders msg as a Lark interactive card: a header colored by level over the
+// text as Lark markdown
+func larkCard(msg Message) map[string]interface{} {
+	color, ok := larkCardColors[msg.Level]
+	if !ok {
+		color = larkCardColors[LevelInfo]
+	}
+	return map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]interface{}{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"template": color,
+				"title":    map[string]string{"tag": "plain_text", "content": msg.Title},
+			},
+			"elements": []interface{}{
+				map[string]interface{}{
+					"tag":  "div",
+					"text": map[string]string{"tag": "lark_md", "content": msg.Text},
+				},
+			},
+		},
+	}
+}
+
+// larkSign is the signature of a Lark bot request: HMAC-SHA256 keyed by the timestamp
+// and secret, over nothing
+func larkSign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sendEmail sends a plain-text mail via SMTP_ADDR (host:port), authenticating with
+// SMTP_USERNAME/SMTP_PASSWORD when set. SMTP_FROM is the sender.
+func sendEmail(recipients string, msg Message) error {
+	addr := config.Lookup("SMTP_ADDR")
+	from := config.Lookup("SMTP_FROM")
+	if addr == "" || from == "" {
+		return fmt.Errorf("SMTP_ADDR and SMTP_FROM are required for email notifications")
+	}
+
+	var to []string
+	for _, r := range strings.Split(recipients, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			to = append(to, r)
+		}
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no email recipients")
+	}
+
+	var auth smtp.Auth
+	if user := config.Lookup("SMTP_USERNAME"); user != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", user, config.Lookup("SMTP_PASSWORD"), host)
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + strings.ReplaceAll(msg.Title, "\n", " ") + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+
+	return smtp.SendMail(addr, auth, from, to, []byte(b.String()))
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPrometheusNotConfigured is returned when PROMETHEUS_URL is unset
+var ErrPrometheusNotConfigured = errors.New("PROMETHEUS_URL is not configured")
+
+// PrometheusClient queries the Prometheus HTTP API of the metrics datasource
+type PrometheusClient struct {
+	BaseURL string
+	Token   string
+	client  *http.Client
+}
+
+func NewPrometheusClient() (*PrometheusClient, error) {
+	base := config.Lookup("PROMETHEUS_URL")
+	if base == "" {
+		return nil, ErrPrometheusNotConfigured
+	}
+	return &PrometheusClient{
+		BaseURL: strings.TrimRight(base, "/"),
+		Token:   config.Lookup("PROMETHEUS_TOKEN"),
+		client:  NewOutboundClient(60 * time.Second),
+	}, nil
+}
+
+type promRangeResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRangeValues runs a range query and returns every sample value across all series
+func (p *PrometheusClient) QueryRangeValues(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]float64, int, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/api/v1/query_range", strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var body promRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode prometheus response (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Status != "success" {
+		return nil, 0, fmt.Errorf("prometheus query failed: %s: %s", body.ErrorType, body.Error)
+	}
+
+	var values []float64
+	for _, series := range body.Data.Result {
+		for _, pair := range series.Values {
+			s, ok := pair[1].(string)
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) { // skip NaN and ±Inf
+				continue
+			}
+			values = append(values, v)
+		}
+	}
+	return values, len(body.Data.Result), nil
+}
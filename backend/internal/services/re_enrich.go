@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+const reEnrichBatchSize = 200
+
+// ReEnrichProgress reports a running or finished re-enrichment
+type ReEnrichProgress struct {
+	Status     string     `json:"status"`     // running, completed, failed
+	StartDate  string     `json:"start_date"` // set by the caller, the end date is inclusive
+	EndDate    string     `json:"end_date"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Updated    int        `json:"updated"`
+	Skipped    int        `json:"skipped"` // imported before raw payloads were stored
+	Failed     int        `json:"failed"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// NewOfflineDataUpdater returns a DataUpdater that can only re-process stored issues.
+// It needs no JIRA credentials.
+func NewOfflineDataUpdater(db *sql.DB) *DataUpdater {
+	u := &DataUpdater{
+		db:       db,
+		logger:   slog.Default().With("service", "re_enrich"),
+		notifier: NewNotifier(db),
+		webhooks: NewWebhookDispatcher(db),
+	}
+	u.loadIngesters(false)
+	return u
+}
+
+// ReEnrich re-runs the extraction of each issue's source over the stored raw payloads of
+// issues created in [startDate, endDate) and updates the derived columns in place. onProgress is called
+// after every batch.
+func (u *DataUpdater) ReEnrich(ctx context.Context, startDate, endDate time.Time, onProgress func(ReEnrichProgress)) (ReEnrichProgress, error) {
+	progress := ReEnrichProgress{
+		Status:    "running",
+		StartedAt: time.Now().UTC(),
+	}
+	u.ctx = ctx
+	defer func() { u.ctx = nil }()
+	start := startDate.Format("2006-01-02 15:04:05")
+	end := endDate.Format("2006-01-02 15:04:05")
+
+	err := u.db.QueryRowContext(ctx,
+		db.Rebind("SELECT COUNT(*) FROM issues WHERE created_at_utc >= ? AND created_at_utc < ?"),
+		start, end).Scan(&progress.Total)
+	if err != nil {
+		return progress, fmt.Errorf("failed to count issues: %w", err)
+	}
+	u.logger.Info("Re-enriching issues", "total", progress.Total, "start", start, "end", end)
+	onProgress(progress)
+
+	// Page by id so large ranges never hold every payload in memory
+	lastID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		rows, err := u.db.QueryContext(ctx, db.Rebind(`
+			SELECT id, COALESCE(raw_payload, ''), COALESCE(source, '') FROM issues
+			WHERE created_at_utc >= ? AND created_at_utc < ? AND id > ?
+			ORDER BY id LIMIT ?`), start, end, lastID, reEnrichBatchSize)
+		if err != nil {
+			return progress, fmt.Errorf("failed to load issues: %w", err)
+		}
+
+		type storedIssue struct{ id, payload, source string }
+		var batch []storedIssue
+		for rows.Next() {
+			var si storedIssue
+			if err := rows.Scan(&si.id, &si.payload, &si.source); err != nil {
+				rows.Close()
+				return progress, fmt.Errorf("failed to read issue: %w", err)
+			}
+			batch = append(batch, si)
+		}
+		rows.Close()
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, si := range batch {
+			lastID = si.id
+			progress.Processed++
+			if si.payload == "" {
+				progress.Skipped++
+				continue
+			}
+
+			if si.source == "" {
+				si.source = IngestSourceJira // imported before sources were tracked
+			}
+			src, ok := u.sources[si.source]
+			if !ok {
+				u.logger.Warn("Ingestion source of stored payload is not registered", "issue_id", si.id, "source", si.source)
+				progress.Failed++
+				continue
+			}
+			data, err := src.Extract(json.RawMessage(si.payload))
+			if err != nil {
+				u.logger.Warn("Failed to decode stored payload", "issue_id", si.id, "err", err)
+				progress.Failed++
+				continue
+			}
+			u.stampRuleVersion(data)
+			if u.updateEnrichedFields(data) {
+				progress.Updated++
+				u.queueSearchIndex(si.id)
+			} else {
+				progress.Failed++
+			}
+		}
+
+		u.flushSearchIndex()
+
+		u.logger.Info("Re-enrichment progress", "processed", progress.Processed, "total", progress.Total,
+			"updated", progress.Updated, "skipped", progress.Skipped, "failed", progress.Failed)
+		onProgress(progress)
+	}
+
+	if progress.Updated > 0 {
+		InvalidateDashboards()
+	}
+	return progress, nil
+}
+
+// updateEnrichedFields rewrites the extracted columns of an existing issue, leaving
+// created/created_at and anything not derived from the payload untouched
+func (u *DataUpdater) updateEnrichedFields(data *IssueData) bool {
+	_, err := u.db.Exec(db.Rebind(`
+		UPDATE issues SET
+			title = ?, description = ?, priority = ?, labels = ?, issue_type = ?,
+			components = ?, project = ?, is_alert = ?, alert_signature = ?, cluster_id = ?,
+			tenant_id = ?, biz_type = ?, status = ?, is_subtask = ?,
+			stability_governance = ?, visibility = ?, component_name = ?, source_component = ?, alert_group = ?,
+			jira_components = ?, attributed_by = ?, rule_name = ?, rule_revision = ?,
+			acknowledged_at = ?, resolved_at = ?
+		WHERE id = ?`),
+		data.Title,
+		data.Description,
+		data.Priority,
+		data.Labels,
+		data.IssueType,
+		data.Components,
+		data.Project,
+		data.IsAlert,
+		data.AlertSignature,
+		data.ClusterID,
+		data.TenantID,
+		data.BizType,
+		data.Status,
+		data.IsSubtask,
+		data.StabilityGovernance,
+		data.Visibility,
+		data.ComponentName,
+		data.SourceComponent,
+		data.AlertGroup,
+		data.JiraComponents,
+		data.AttributedBy,
+		data.RuleName,
+		data.RuleRevision,
+		data.AcknowledgedAt,
+		data.ResolvedAt,
+		data.ID,
+	)
+	if err == nil {
+		err = u.storeIssueComponents(data.ID, data.Components)
+	}
+	if err != nil {
+		u.logger.Error("Failed to re-enrich issue", "issue_id", data.ID, "err", err)
+		return false
+	}
+	return true
+}
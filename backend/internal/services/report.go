@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Report periods
+const (
+	ReportWeekly  = "weekly"
+	ReportMonthly = "monthly"
+)
+
+// ReportPeriods lists the supported periods
+var ReportPeriods = []string{ReportWeekly, ReportMonthly}
+
+const (
+	ReportLockName = "reports"
+	ReportLockTTL  = 5 * time.Minute
+	reportTopN     = 10
+)
+
+// ReportRegression is a rule that fired more than in the period before
+type ReportRegression struct {
+	Rule     string  `json:"rule"`
+	Alerts   int64   `json:"alerts"`
+	Previous int64   `json:"previous"`
+	Increase int64   `json:"increase"`
+	Change   float64 `json:"change"` // percent
+}
+
+// ReportSummary holds the figures of one period's alert report
+type ReportSummary struct {
+	Period      string             `json:"period"`
+	PeriodStart string             `json:"period_start"`
+	PeriodEnd   string             `json:"period_end"` // exclusive
+	Alerts      int64              `json:"alerts"`
+	Previous    int64              `json:"previous"` // alerts in the period before
+	Change      float64            `json:"change"`   // percent vs the period before
+	Critical    int64              `json:"critical"`
+	FakeAlarms  int64              `json:"fake_alarms"`
+	FakeRate    float64            `json:"fake_rate"` // percent of alerts
+	TopRules    []DigestCount      `json:"top_rules"`
+	TopClusters []DigestCount      `json:"top_clusters"`
+	Regressions []ReportRegression `json:"regressions"`
+}
+
+// ReportService builds, renders and stores the weekly and monthly alert reports
+type ReportService struct {
+	DB *gorm.DB
+}
+
+func NewReportService(db *gorm.DB) *ReportService {
+	return &ReportService{DB: db}
+}
+
+// ReportPeriodStart returns the start (UTC midnight) of the period containing day:
+// its Monday for weekly reports, the 1st for monthly ones
+func ReportPeriodStart(period string, day time.Time) (time.Time, error) {
+	day = day.UTC().Truncate(24 * time.Hour)
+	switch period {
+	case ReportWeekly:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	case ReportMonthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown report period %q, want one of %s", period, strings.Join(ReportPeriods, ", "))
+}
+
+// reportPeriodEnd returns the start of the period after the one starting at start
+func reportPeriodEnd(period string, start time.Time) time.Time {
+	if period == ReportMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// LastCompletePeriod returns the start of the most recent finished week or month
+func LastCompletePeriod(period string, now time.Time) (time.Time, error) {
+	current, err := ReportPeriodStart(period, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if period == ReportMonthly {
+		return current.AddDate(0, -1, 0), nil
+	}
+	return current.AddDate(0, 0, -7), nil
+}
+
+// Build computes the report figures for the period starting at start
+func (s *ReportService) Build(ctx context.Context, period string, start time.Time) (*ReportSummary, error) {
+	dbc := s.DB.WithContext(ctx)
+	end := reportPeriodEnd(period, start)
+	prevStart := start.AddDate(0, 0, -7)
+	if period == ReportMonthly {
+		prevStart = start.AddDate(0, -1, 0)
+	}
+	const layout = "2006-01-02 15:04:05"
+	from, to := start.Format(layout), end.Add(-time.Second).Format(layout)
+	prevFrom, prevTo := prevStart.Format(layout), start.Add(-time.Second).Format(layout)
+
+	summary := &ReportSummary{
+		Period:      period,
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   end.Format("2006-01-02"),
+	}
+
+	var totals struct {
+		Alerts   int64
+		Critical int64
+		Fake     int64
+	}
+	err := dbc.Raw(`
+		SELECT COUNT(*) as alerts,
+			COALESCE(SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END), 0) as critical,
+			COALESCE(SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END), 0) as fake
+		FROM issues
+		WHERE is_alert = TRUE AND created_at_utc BETWEEN ? AND ?`, from, to).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	summary.Alerts, summary.Critical, summary.FakeAlarms = totals.Alerts, totals.Critical, totals.Fake
+	if summary.Alerts > 0 {
+		summary.FakeRate = float64(summary.FakeAlarms) / float64(summary.Alerts) * 100
+	}
+
+	if err := dbc.Raw(`SELECT COUNT(*) FROM issues WHERE is_alert = TRUE
+		AND created_at_utc BETWEEN ? AND ?`, prevFrom, prevTo).Scan(&summary.Previous).Error; err != nil {
+		return nil, err
+	}
+	summary.Change = percentChange(summary.Alerts, summary.Previous)
+
+	// Rules are the stamped rule name, or the alert signature for alerts not matched to a rule
+	const ruleColumn = "COALESCE(NULLIF(rule_name, ''), alert_signature)"
+	summary.TopRules = reportTopCounts(dbc, ruleColumn, from, to)
+	summary.TopClusters = reportTopCounts(dbc, "cluster_id", from, to)
+	for i := range summary.TopClusters {
+		if info, err := GetNameResolver().ResolveContext(ctx, summary.TopClusters[i].Name); err == nil && info.Name != "" && info.Name != summary.TopClusters[i].Name {
+			summary.TopClusters[i].Name = info.Name + " (" + summary.TopClusters[i].Name + ")"
+		}
+	}
+
+	summary.Regressions = []ReportRegression{}
+	err = dbc.Raw(`
+		SELECT rule, alerts, previous, alerts - previous as increase FROM (
+			SELECT `+ruleColumn+` as rule,
+				SUM(CASE WHEN created_at_utc BETWEEN ? AND ? THEN 1 ELSE 0 END) as alerts,
+				SUM(CASE WHEN created_at_utc BETWEEN ? AND ? THEN 1 ELSE 0 END) as previous
+			FROM issues
+			WHERE is_alert = TRUE AND created_at_utc BETWEEN ? AND ?
+			GROUP BY 1
+		) t
+		WHERE alerts > previous AND rule != ''
+		ORDER BY increase DESC, rule
+		LIMIT ?`, from, to, prevFrom, prevTo, prevFrom, to, reportTopN).Scan(&summary.Regressions).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range summary.Regressions {
+		summary.Regressions[i].Change = percentChange(summary.Regressions[i].Alerts, summary.Regressions[i].Previous)
+	}
+	return summary, ctx.Err()
+}
+
+func reportTopCounts(dbc *gorm.DB, column, from, to string) []DigestCount {
+	top := []DigestCount{}
+	dbc.Raw(`SELECT `+column+` as name, COUNT(*) as count FROM issues
+		WHERE is_alert = TRUE AND `+column+` != '' AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1 ORDER BY count DESC, name LIMIT ?`, from, to, reportTopN).Scan(&top)
+	return top
+}
+
+// percentChange is the change from previous to current in percent, 100 when there was
+// nothing before
+func percentChange(current, previous int64) float64 {
+	if previous == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 100
+	}
+	return float64(current-previous) / float64(previous) * 100
+}
+
+// Generate builds, renders and stores the report for the period starting at start,
+// replacing an existing one
+func (s *ReportService) Generate(ctx context.Context, period string, start time.Time) (*models.Report, error) {
+	summary, err := s.Build(ctx, period, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s report: %w", period, err)
+	}
+	markdown, err := RenderReportMarkdown(summary)
+	if err != nil {
+		return nil, err
+	}
+	html, err := RenderReportHTML(summary)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(summary)
+
+	var report models.Report
+	s.DB.Where("period = ? AND period_start = ?", period, summary.PeriodStart).Limit(1).Find(&report)
+	report.Period = period
+	report.PeriodStart = summary.PeriodStart
+	report.PeriodEnd = summary.PeriodEnd
+	report.Alerts = summary.Alerts
+	report.Summary = string(data)
+	report.Markdown = markdown
+	report.HTML = html
+	if err := s.DB.Save(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GenerateDue stores the report for the last finished period of each of periods that
+// has none yet, returning those generated
+func (s *ReportService) GenerateDue(ctx context.Context, periods []string, now time.Time) ([]models.Report, error) {
+	var generated []models.Report
+	for _, period := range periods {
+		start, err := LastCompletePeriod(period, now)
+		if err != nil {
+			return generated, err
+		}
+		var count int64
+		s.DB.Model(&models.Report{}).Where("period = ? AND period_start = ?", period, start.Format("2006-01-02")).Count(&count)
+		if count > 0 {
+			continue
+		}
+		report, err := s.Generate(ctx, period, start)
+		if err != nil {
+			return generated, err
+		}
+		generated = append(generated, *report)
+	}
+	return generated, nil
+}
+
+var reportFuncs = map[string]interface{}{
+	"pct":    func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+	"signed": func(v float64) string { return fmt.Sprintf("%+.1f%%", v) },
+	"title":  reportTitle,
+}
+
+// reportTitle is the period as it starts a report's title
+func reportTitle(period string) string {
+	if period == ReportMonthly {
+		return "Monthly"
+	}
+	return "Weekly"
+}
+
+const reportMarkdownTemplate = `# {{title .Period}} alert report: {{.PeriodStart}} to {{.PeriodEnd}}
+
+| Alerts | Previous | Change | Critical | Fake alarms | Fake-alarm rate |
+|---|---|---|---|---|---|
+| {{.Alerts}} | {{.Previous}} | {{signed .Change}} | {{.Critical}} | {{.FakeAlarms}} | {{pct .FakeRate}} |
+
+## Top rules
+{{range .TopRules}}
+- {{.Name}}: {{.Count}}{{else}}
+No alerts.{{end}}
+
+## Top clusters
+{{range .TopClusters}}
+- {{.Name}}: {{.Count}}{{else}}
+No alerts.{{end}}
+
+## Biggest regressions
+{{range .Regressions}}
+- {{.Rule}}: {{.Previous}} → {{.Alerts}} (+{{.Increase}}, {{signed .Change}}){{else}}
+No rule fired more than in the period before.{{end}}
+`
+
+const reportHTMLTemplate = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{title .Period}} alert report {{.PeriodStart}}</title></head>
+<body>
+<h1>{{title .Period}} alert report: {{.PeriodStart}} to {{.PeriodEnd}}</h1>
+<table border="1" cellpadding="4">
+<tr><th>Alerts</th><th>Previous</th><th>Change</th><th>Critical</th><th>Fake alarms</th><th>Fake-alarm rate</th></tr>
+<tr><td>{{.Alerts}}</td><td>{{.Previous}}</td><td>{{signed .Change}}</td><td>{{.Critical}}</td><td>{{.FakeAlarms}}</td><td>{{pct .FakeRate}}</td></tr>
+</table>
+<h2>Top rules</h2>
+{{if .TopRules}}<ol>{{range .TopRules}}<li>{{.Name}}: {{.Count}}</li>{{end}}</ol>{{else}}<p>No alerts.</p>{{end}}
+<h2>Top clusters</h2>
+{{if .TopClusters}}<ol>{{range .TopClusters}}<li>{{.Name}}: {{.Count}}</li>{{end}}</ol>{{else}}<p>No alerts.</p>{{end}}
+<h2>Biggest regressions</h2>
+{{if .Regressions}}<table border="1" cellpadding="4">
+<tr><th>Rule</th><th>Previous</th><th>Alerts</th><th>Increase</th><th>Change</th></tr>
+{{range .Regressions}}<tr><td>{{.Rule}}</td><td>{{.Previous}}</td><td>{{.Alerts}}</td><td>+{{.Increase}}</td><td>{{signed .Change}}</td></tr>
+{{end}}</table>{{else}}<p>No rule fired more than in the period before.</p>{{end}}
+</body></html>
+`
+
+var (
+	reportMarkdown = template.Must(template.New("report.md").Funcs(reportFuncs).Parse(reportMarkdownTemplate))
+	reportHTML     = htmltemplate.Must(htmltemplate.New("report.html").Funcs(reportFuncs).Parse(reportHTMLTemplate))
+)
+
+// RenderReportMarkdown renders a report as Markdown
+func RenderReportMarkdown(summary *ReportSummary) (string, error) {
+	var buf bytes.Buffer
+	if err := reportMarkdown.Execute(&buf, summary); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderReportHTML renders a report as a standalone HTML page
+func RenderReportHTML(summary *ReportSummary) (string, error) {
+	var buf bytes.Buffer
+	if err := reportHTML.Execute(&buf, summary); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	RetentionLockName = "retention"
+	RetentionLockTTL  = 30 * time.Minute
+	// retentionBatchSize bounds each archive transaction so large backlogs don't hold a
+	// long write lock on issues
+	retentionBatchSize = 500
+)
+
+// RetentionService moves issues past the retention policy into issues_archive. Issues
+// are expired per project and priority, by their created time.
+type RetentionService struct {
+	db     *gorm.DB
+	policy config.RetentionPolicy
+	logger *slog.Logger
+}
+
+func NewRetentionService(db *gorm.DB, policy config.RetentionPolicy) *RetentionService {
+	return &RetentionService{db: db, policy: policy, logger: slog.Default().With("service", "retention")}
+}
+
+// Plan counts the issues each project and priority has past its retention at now.
+// Groups kept forever are left out.
+func (s *RetentionService) Plan(ctx context.Context, now time.Time) ([]models.RetentionGroup, error) {
+	dbc := s.db.WithContext(ctx)
+	var pairs []struct {
+		Project  string
+		Priority string
+	}
+	if err := dbc.Model(&models.Issue{}).Distinct("project", "priority").Scan(&pairs).Error; err != nil {
+		return nil, err
+	}
+
+	var groups []models.RetentionGroup
+	for _, p := range pairs {
+		days := s.policy.DaysFor(p.Project, p.Priority)
+		if days <= 0 {
+			continue
+		}
+		g := models.RetentionGroup{
+			Project:  p.Project,
+			Priority: p.Priority,
+			Days:     days,
+			Cutoff:   now.UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05"),
+		}
+		var n int64
+		if err := s.expired(dbc, g).Count(&n).Error; err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			g.Archived = int(n)
+			groups = append(groups, g)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Project != groups[j].Project {
+			return groups[i].Project < groups[j].Project
+		}
+		return groups[i].Priority < groups[j].Priority
+	})
+	return groups, nil
+}
+
+// expired selects the issues of g created before its cutoff
+func (s *RetentionService) expired(dbc *gorm.DB, g models.RetentionGroup) *gorm.DB {
+	return dbc.Model(&models.Issue{}).
+		Where("project = ? AND priority = ? AND created_at_utc < ?", g.Project, g.Priority, g.Cutoff)
+}
+
+// Run archives every expired issue and records the run. Issues are copied to
+// issues_archive and deleted in batches, each in its own transaction, so a failed or
+// cancelled run keeps what it archived and the next run picks up the rest.
+func (s *RetentionService) Run(ctx context.Context, trigger, actor string, progress JobProgress) (*models.RetentionRun, error) {
+	run := &models.RetentionRun{Trigger: trigger, Actor: actor, Status: "success", StartedAt: time.Now().UTC()}
+
+	runErr := s.archiveAll(ctx, run, progress)
+	if runErr != nil {
+		run.Status = "failed"
+		run.Error = runErr.Error()
+	}
+	run.FinishedAt = time.Now().UTC()
+	if run.Groups == nil {
+		run.Groups = []models.RetentionGroup{}
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record retention run: %w", err)
+	}
+
+	if runErr != nil {
+		return run, fmt.Errorf("retention run failed after archiving %d issues: %w", run.Archived, runErr)
+	}
+	return run, nil
+}
+
+func (s *RetentionService) archiveAll(ctx context.Context, run *models.RetentionRun, progress JobProgress) error {
+	plan, err := s.Plan(ctx, run.StartedAt)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, g := range plan {
+		total += g.Archived
+	}
+	if progress != nil {
+		progress(0, total, fmt.Sprintf("%d issues to archive", total))
+	}
+
+	columns, err := archiveColumns(s.db)
+	if err != nil {
+		return err
+	}
+	for _, g := range plan {
+		g.Archived = 0
+		for {
+			if err := ctx.Err(); err != nil {
+				run.Groups = append(run.Groups, g)
+				return err
+			}
+			n, err := s.archiveBatch(ctx, g, columns, run.StartedAt)
+			g.Archived += n
+			run.Archived += n
+			if err != nil {
+				run.Groups = append(run.Groups, g)
+				return fmt.Errorf("archiving %s/%s: %w", g.Project, g.Priority, err)
+			}
+			if progress != nil && n > 0 {
+				progress(run.Archived, total, fmt.Sprintf("archived %d issues", run.Archived))
+			}
+			if n < retentionBatchSize {
+				break
+			}
+		}
+		s.logger.Info("Archived expired issues", "project", g.Project, "priority", g.Priority,
+			"days", g.Days, "issues", g.Archived)
+		run.Groups = append(run.Groups, g)
+	}
+	return nil
+}
+
+// archiveBatch moves up to retentionBatchSize of g's expired issues and returns how many
+func (s *RetentionService) archiveBatch(ctx context.Context, g models.RetentionGroup, columns string, archivedAt time.Time) (int, error) {
+	dbc := s.db.WithContext(ctx)
+	var ids []string
+	if err := s.expired(dbc, g).Order("created, id").Limit(retentionBatchSize).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := dbc.Transaction(func(tx *gorm.DB) error {
+		// An issue re-imported after it was archived replaces its archived copy
+		if err := tx.Where("id IN ?", ids).Delete(&models.ArchivedIssue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO issues_archive ("+columns+", archived_at) SELECT "+columns+", ? FROM issues WHERE id IN ?",
+			archivedAt, ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("issue_id IN ?", ids).Delete(&models.IssueComponent{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.Issue{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// SQL is the source of truth; a stale document only shows up until the next reindex
+	if idx := GetSearchIndex(); idx != nil {
+		for _, id := range ids {
+			if err := idx.DeleteDocument(ctx, id); err != nil {
+				s.logger.Warn("Failed to remove archived issues from the search index", "err", err)
+				break
+			}
+		}
+	}
+	return len(ids), nil
+}
+
+// archiveColumns lists the issue columns copied to issues_archive
+func archiveColumns(db *gorm.DB) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&models.ArchivedIssue{}); err != nil {
+		return "", err
+	}
+	var columns []string
+	for _, name := range stmt.Schema.DBNames {
+		if name != "archived_at" {
+			columns = append(columns, name)
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Rule audit defaults; the noise threshold is overridable via RULES_AUDIT_NOISE_THRESHOLD
+const (
+	RuleAuditLockName     = "rule_audit"
+	RuleAuditLockTTL      = 5 * time.Minute
+	ruleAuditWindowDays   = 7
+	ruleAuditNoiseDefault = 20
+)
+
+// NoisyRule is a rule that fired more than the noise threshold within the audit window
+type NoisyRule struct {
+	Alert string `json:"alert"`
+	Owner string `json:"owner,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// RuleAuditReport holds the details behind the counts of a RuleAudit
+type RuleAuditReport struct {
+	Lint      []RuleLintIssue `json:"lint"`
+	Uncovered []string        `json:"uncovered_components"`
+	Drift     []RuleDrift     `json:"drift"`
+	Noisy     []NoisyRule     `json:"noisy"`
+}
+
+// RuleAuditService runs the rule analyses and persists one report per day
+type RuleAuditService struct {
+	DB     *gorm.DB
+	client *http.Client
+}
+
+func NewRuleAuditService(db *gorm.DB) *RuleAuditService {
+	return &RuleAuditService{
+		DB:     db,
+		client: NewOutboundClient(10 * time.Second),
+	}
+}
+
+func ruleAuditNoiseThreshold() int64 {
+	if v, err := strconv.ParseInt(config.Lookup("RULES_AUDIT_NOISE_THRESHOLD"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return ruleAuditNoiseDefault
+}
+
+// Run performs the audit, stores it under today's date and notifies when it regressed
+// against the most recent earlier audit
+func (s *RuleAuditService) Run(ctx context.Context) (*models.RuleAudit, []string, error) {
+	rules := NewRulesService(config.Get())
+	allRules := rules.AllRules()
+	since := time.Now().UTC().AddDate(0, 0, -ruleAuditWindowDays)
+
+	report := RuleAuditReport{
+		Lint:  rules.LintRules(),
+		Drift: rules.DetectDrift(nil, "", nil),
+	}
+	if report.Drift == nil {
+		report.Drift = []RuleDrift{}
+	}
+
+	uncovered, err := s.uncoveredComponents(allRules, since)
+	if err != nil {
+		return nil, nil, fmt.Errorf("coverage analysis failed: %w", err)
+	}
+	report.Uncovered = uncovered
+
+	noisy, err := s.noisyRules(allRules, since)
+	if err != nil {
+		return nil, nil, fmt.Errorf("noise analysis failed: %w", err)
+	}
+	report.Noisy = noisy
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	audit := &models.RuleAudit{
+		Date:           time.Now().UTC().Format("2006-01-02"),
+		RuleCount:      len(allRules),
+		LintCount:      len(report.Lint),
+		UncoveredCount: len(report.Uncovered),
+		DriftCount:     len(report.Drift),
+		NoisyCount:     len(report.Noisy),
+		Report:         string(reportJSON),
+	}
+
+	var previous models.RuleAudit
+	var regressions []string
+	if err := s.DB.Where("date < ?", audit.Date).Order("date DESC").First(&previous).Error; err == nil {
+		regressions = compareAudits(&previous, audit)
+	}
+	if regressions == nil {
+		regressions = []string{}
+	}
+	audit.Regressed = len(regressions) > 0
+	regressionsJSON, _ := json.Marshal(regressions)
+	audit.Regressions = string(regressionsJSON)
+
+	// A same-day re-run only notifies if it found different regressions
+	var existing models.RuleAudit
+	alreadyNotified := s.DB.Where("date = ?", audit.Date).First(&existing).Error == nil &&
+		existing.Regressed && existing.Regressions == audit.Regressions
+
+	err = s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rule_count", "lint_count", "uncovered_count", "drift_count", "noisy_count", "regressed", "regressions", "report", "updated_at"}),
+	}).Create(audit).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save audit: %w", err)
+	}
+	// The upsert leaves the ID unset when an existing row was updated
+	s.DB.Where("date = ?", audit.Date).First(audit)
+
+	if audit.Regressed && !alreadyNotified {
+		if err := s.notify(ctx, audit, regressions); err != nil {
+			slog.Warn("Failed to send rule audit notification", "err", err)
+		}
+	}
+	return audit, regressions, nil
+}
+
+// List returns the most recent audits, newest first
+func (s *RuleAuditService) List(limit int) ([]models.RuleAudit, error) {
+	var audits []models.RuleAudit
+	err := s.DB.Order("date DESC").Limit(limit).Find(&audits).Error
+	return audits, err
+}
+
+// compareAudits describes every count that got worse since the previous audit
+func compareAudits(previous, current *models.RuleAudit) []string {
+	var regressions []string
+	check := func(name string, before, after int) {
+		if after > before {
+			regressions = append(regressions, fmt.Sprintf("%s increased from %d to %d", name, before, after))
+		}
+	}
+	check("lint violations", previous.LintCount, current.LintCount)
+	check("uncovered components", previous.UncoveredCount, current.UncoveredCount)
+	check("drifting rules", previous.DriftCount, current.DriftCount)
+	check("noisy rules", previous.NoisyCount, current.NoisyCount)
+	return regressions
+}
+
+// uncoveredComponents lists components that alerted in the window but match no rule's
+// component or source_component label
+func (s *RuleAuditService) uncoveredComponents(rules []models.Rule, since time.Time) ([]string, error) {
+	var components []string
+	err := s.DB.Model(&models.Issue{}).
+		Where("is_alert = ? AND component_name != '' AND created_at_utc >= ?", true, since.Format("2006-01-02 15:04:05")).
+		Distinct().
+		Pluck("component_name", &components).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []string
+	for _, rule := range rules {
+		for _, key := range []string{"component", "source_component"} {
+			if v := rule.Labels[key]; v != "" {
+				labels = append(labels, strings.ToLower(v))
+			}
+		}
+	}
+
+	uncovered := []string{}
+	for _, component := range components {
+		covered := false
+		for _, label := range labels {
+			if strings.Contains(label, strings.ToLower(component)) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			uncovered = append(uncovered, component)
+		}
+	}
+	sort.Strings(uncovered)
+	return uncovered, nil
+}
+
+// noisyRules returns rules whose alert count in the window reaches the noise threshold
+func (s *RuleAuditService) noisyRules(rules []models.Rule, since time.Time) ([]NoisyRule, error) {
+	owners := make(map[string]string)
+	seen := make(map[string]bool)
+	alertNames := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if !seen[rule.Alert] {
+			seen[rule.Alert] = true
+			alertNames = append(alertNames, rule.Alert)
+		}
+		if owners[rule.Alert] == "" {
+			owners[rule.Alert] = RuleOwner(rule)
+		}
+	}
+
+	noise, err := RuleNoise(s.DB, alertNames, since)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := ruleAuditNoiseThreshold()
+	noisy := []NoisyRule{}
+	for alert, count := range noise {
+		if count >= threshold {
+			noisy = append(noisy, NoisyRule{Alert: alert, Owner: owners[alert], Count: count})
+		}
+	}
+	sort.Slice(noisy, func(i, j int) bool {
+		if noisy[i].Count != noisy[j].Count {
+			return noisy[i].Count > noisy[j].Count
+		}
+		return noisy[i].Alert < noisy[j].Alert
+	})
+	return noisy, nil
+}
+
+// notify posts a regression summary to RULES_AUDIT_WEBHOOK_URL, if configured
+func (s *RuleAuditService) notify(ctx context.Context, audit *models.RuleAudit, regressions []string) error {
+	url := config.Lookup("RULES_AUDIT_WEBHOOK_URL")
+	if url == "" {
+		slog.Warn("Rule audit regressed", "date", audit.Date, "regressions", regressions)
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"text":        fmt.Sprintf("Rule audit %s regressed: %s", audit.Date, strings.Join(regressions, "; ")),
+		"date":        audit.Date,
+		"regressions": regressions,
+		"audit":       audit,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
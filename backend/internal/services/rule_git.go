@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// RulesGitConfig turns rule edits into pull requests against the runbooks repo instead of
+// writes to its working tree. It is the `git:` section of rules_categories.yaml; the
+// RULES_GIT_* env vars override it. Pushing uses the remote's own git credentials, the
+// token is only for the provider API.
+type RulesGitConfig struct {
+	Provider   string   `yaml:"provider"`    // github or gitlab
+	Repo       string   `yaml:"repo"`        // owner/name, or the GitLab project path
+	Remote     string   `yaml:"remote"`      // default origin
+	BaseBranch string   `yaml:"base_branch"` // default main
+	APIURL     string   `yaml:"api_url"`     // default the public github.com / gitlab.com API
+	Token      string   `yaml:"token"`
+	Reviewers  []string `yaml:"reviewers"` // GitHub logins, or GitLab user IDs
+	AuthorName string   `yaml:"author_name"`
+	AuthorMail string   `yaml:"author_email"`
+}
+
+// Enabled reports whether rule edits should go through pull requests
+func (g RulesGitConfig) Enabled() bool {
+	return g.Provider != "" && g.Repo != ""
+}
+
+// withEnv applies the RULES_GIT_* overrides and defaults
+func (g RulesGitConfig) withEnv() RulesGitConfig {
+	for env, field := range map[string]*string{
+		"RULES_GIT_PROVIDER":     &g.Provider,
+		"RULES_GIT_REPO":         &g.Repo,
+		"RULES_GIT_REMOTE":       &g.Remote,
+		"RULES_GIT_BASE_BRANCH":  &g.BaseBranch,
+		"RULES_GIT_API_URL":      &g.APIURL,
+		"RULES_GIT_TOKEN":        &g.Token,
+		"RULES_GIT_AUTHOR_NAME":  &g.AuthorName,
+		"RULES_GIT_AUTHOR_EMAIL": &g.AuthorMail,
+	} {
+		if v := config.Lookup(env); v != "" {
+			*field = v
+		}
+	}
+	if v := config.Lookup("RULES_GIT_REVIEWERS"); v != "" {
+		g.Reviewers = nil
+		for _, r := range strings.Split(v, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				g.Reviewers = append(g.Reviewers, r)
+			}
+		}
+	}
+	g.Provider = strings.ToLower(strings.TrimSpace(g.Provider))
+	if g.Remote == "" {
+		g.Remote = "origin"
+	}
+	if g.BaseBranch == "" {
+		g.BaseBranch = "main"
+	}
+	if g.AuthorName == "" {
+		g.AuthorName = "alerts-platform"
+	}
+	if g.AuthorMail == "" {
+		g.AuthorMail = "alerts-platform@localhost"
+	}
+	if g.APIURL == "" {
+		switch g.Provider {
+		case "github":
+			g.APIURL = "https://api.github.com"
+		case "gitlab":
+			g.APIURL = "https://gitlab.com/api/v4"
+		}
+	}
+	g.APIURL = strings.TrimRight(g.APIURL, "/")
+	return g
+}
+
+// RulePullRequest is the pull (or merge) request opened for a rule edit
+type RulePullRequest struct {
+	URL    string `json:"url"`
+	Number int    `json:"number"`
+	Branch string `json:"branch"`
+}
+
+// ruleGitMu serializes rule edits through git; they share the repo's worktree list and
+// branch namespace
+var ruleGitMu sync.Mutex
+
+// proposeRuleChange applies edit to a rule file on a fresh branch off the remote base
+// branch, commits and pushes it and opens a pull request titled title. summary says what
+// the change does to the file; allowCreate is as for modifyRuleFile. The repo's own
+// working tree is not touched: the edit happens in a temporary worktree.
+func (s *RulesService) proposeRuleChange(filePath, alert, title, summary, actor string, allowCreate bool, edit func(rf *models.RuleFile) error) (*RulePullRequest, error) {
+	g := s.Git
+	if g.Provider != "github" && g.Provider != "gitlab" {
+		return nil, fmt.Errorf("unsupported rules git provider %q", g.Provider)
+	}
+	rel, err := s.repoRelPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleGitMu.Lock()
+	defer ruleGitMu.Unlock()
+
+	if _, err := git(s.RepoPath, "fetch", g.Remote, g.BaseBranch); err != nil {
+		return nil, err
+	}
+	worktree, err := os.MkdirTemp("", "rules-edit-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(worktree)
+	branch := fmt.Sprintf("alerts-platform/%s-%d", ruleBranchName(alert), time.Now().Unix())
+	if _, err := git(s.RepoPath, "worktree", "add", "-b", branch, worktree, g.Remote+"/"+g.BaseBranch); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, err := git(s.RepoPath, "worktree", "remove", "--force", worktree); err != nil {
+			s.logger.Warn("Failed to remove rule edit worktree", "worktree", worktree, "err", err)
+		}
+		// The branch lives on in the remote; drop the local copy
+		git(s.RepoPath, "branch", "-D", branch)
+	}()
+
+	if err := modifyRuleFile(filepath.Join(worktree, rel), filePath, allowCreate, edit); err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("%s in `%s`.", summary, rel)
+	if actor != "" {
+		body += fmt.Sprintf("\n\nRequested by %s through the alerts dashboard.", actor)
+	}
+	if _, err := git(worktree, "add", rel); err != nil {
+		return nil, err
+	}
+	if _, err := git(worktree, "-c", "user.name="+g.AuthorName, "-c", "user.email="+g.AuthorMail,
+		"commit", "-m", title, "-m", body); err != nil {
+		return nil, err
+	}
+	if _, err := git(worktree, "push", g.Remote, branch); err != nil {
+		return nil, err
+	}
+
+	pr, err := g.openPullRequest(branch, title, body)
+	if err != nil {
+		return nil, fmt.Errorf("pushed %s but failed to open a pull request: %w", branch, err)
+	}
+	pr.Branch = branch
+	return pr, nil
+}
+
+// ruleBranchName reduces an alert name to something safe in a branch name
+func ruleBranchName(alert string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, alert)
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	if name == "" {
+		name = "rule"
+	}
+	return name
+}
+
+// openPullRequest opens the pull request on GitHub or the merge request on GitLab and
+// asks the configured reviewers
+func (g *RulesGitConfig) openPullRequest(branch, title, body string) (*RulePullRequest, error) {
+	client := NewOutboundClient(30 * time.Second)
+	if g.Provider == "gitlab" {
+		payload := map[string]interface{}{
+			"source_branch": branch,
+			"target_branch": g.BaseBranch,
+			"title":         title,
+			"description":   body,
+		}
+		var reviewerIDs []int
+		for _, r := range g.Reviewers {
+			if id, err := strconv.Atoi(r); err == nil {
+				reviewerIDs = append(reviewerIDs, id)
+			} else {
+				slog.Warn("GitLab reviewers must be user IDs, skipping", "reviewer", r)
+			}
+		}
+		if len(reviewerIDs) > 0 {
+			payload["reviewer_ids"] = reviewerIDs
+		}
+		var mr struct {
+			IID    int    `json:"iid"`
+			WebURL string `json:"web_url"`
+		}
+		endpoint := fmt.Sprintf("%s/projects/%s/merge_requests", g.APIURL, url.PathEscape(g.Repo))
+		if err := g.apiPost(client, endpoint, payload, &mr); err != nil {
+			return nil, err
+		}
+		return &RulePullRequest{URL: mr.WebURL, Number: mr.IID}, nil
+	}
+
+	var pr struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/pulls", g.APIURL, g.Repo)
+	payload := map[string]interface{}{"title": title, "head": branch, "base": g.BaseBranch, "body": body}
+	if err := g.apiPost(client, endpoint, payload, &pr); err != nil {
+		return nil, err
+	}
+	if len(g.Reviewers) > 0 {
+		// The pull request is open either way; a reviewer request failing only gets logged
+		endpoint := fmt.Sprintf("%s/repos/%s/pulls/%d/requested_reviewers", g.APIURL, g.Repo, pr.Number)
+		if err := g.apiPost(client, endpoint, map[string]interface{}{"reviewers": g.Reviewers}, nil); err != nil {
+			slog.Warn("Failed to request reviewers", "pr", pr.HTMLURL, "err", err)
+		}
+	}
+	return &RulePullRequest{URL: pr.HTMLURL, Number: pr.Number}, nil
+}
+
+func (g *RulesGitConfig) apiPost(client *http.Client, endpoint string, payload, out interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.Token != "" {
+		if g.Provider == "gitlab" {
+			req.Header.Set("PRIVATE-TOKEN", g.Token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+g.Token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d: %s", g.Provider, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// RuleValidationError lists the problems that block saving a rule
+type RuleValidationError struct {
+	Alert    string
+	Problems []string
+}
+
+func (e *RuleValidationError) Error() string {
+	return fmt.Sprintf("rule '%s' is invalid: %s", e.Alert, strings.Join(e.Problems, "; "))
+}
+
+// RuleLintIssue is a rule in the repo that fails validation
+type RuleLintIssue struct {
+	Alert    string   `json:"alert"`
+	FilePath string   `json:"file_path"`
+	Category string   `json:"category"`
+	Owner    string   `json:"owner,omitempty"`
+	Problems []string `json:"problems"`
+}
+
+// ownerLabels are the labels that identify a rule's owning team, in order of preference
+func ownerLabels() []string {
+	if v := config.Lookup("RULES_OWNER_LABELS"); v != "" {
+		var labels []string
+		for _, l := range strings.Split(v, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				labels = append(labels, l)
+			}
+		}
+		return labels
+	}
+	return []string{"owner", "team"}
+}
+
+// RuleOwner returns the owning team of a rule, or "" if it has none
+func RuleOwner(rule models.Rule) string {
+	for _, label := range ownerLabels() {
+		if v := strings.TrimSpace(rule.Labels[label]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ValidateRule checks a single alert rule: expression syntax, required labels and ownership
+func ValidateRule(rule models.Rule) []string {
+	var problems []string
+	if err := validateExpr(rule.Expr); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, label := range requiredRuleLabels() {
+		if rule.Labels[label] == "" {
+			problems = append(problems, fmt.Sprintf("missing required label %q", label))
+		}
+	}
+	if RuleOwner(rule) == "" {
+		problems = append(problems, fmt.Sprintf("missing owner label (one of %s)", strings.Join(ownerLabels(), ", ")))
+	}
+	return problems
+}
+
+// AllRules returns every alert rule across the category paths, each file once
+func (s *RulesService) AllRules() []models.Rule {
+	categories := make([]string, 0, len(s.CategoryPathsMap))
+	for category := range s.CategoryPathsMap {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	seen := make(map[string]bool)
+	var rules []models.Rule
+	for _, category := range categories {
+		for _, rule := range s.GetRulesForCategory(category) {
+			key := rule.FilePath + "\x00" + rule.Alert
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// LintRules validates every rule in the repo
+func (s *RulesService) LintRules() []RuleLintIssue {
+	issues := []RuleLintIssue{}
+	for _, rule := range s.AllRules() {
+		if problems := ValidateRule(rule); len(problems) > 0 {
+			issues = append(issues, RuleLintIssue{
+				Alert:    rule.Alert,
+				FilePath: rule.FilePath,
+				Category: rule.Category,
+				Owner:    RuleOwner(rule),
+				Problems: problems,
+			})
+		}
+	}
+	return issues
+}
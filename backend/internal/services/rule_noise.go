@@ -0,0 +1,63 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// RuleNoise counts alerts created since the given time per alert rule name.
+// Issues are attributed to rules by matching the alert name in their signature.
+func RuleNoise(db *gorm.DB, alertNames []string, since time.Time) (map[string]int64, error) {
+	var signatures []struct {
+		AlertSignature string
+		Count          int64
+	}
+	err := db.Model(&models.Issue{}).
+		Select("alert_signature, COUNT(*) as count").
+		Where("is_alert = ? AND created_at_utc >= ?", true, since.UTC().Format("2006-01-02 15:04:05")).
+		Group("alert_signature").
+		Scan(&signatures).Error
+	if err != nil {
+		return nil, err
+	}
+
+	noise := make(map[string]int64)
+	for _, s := range signatures {
+		if alert := MatchAlertName(s.AlertSignature, alertNames); alert != "" {
+			noise[alert] += s.Count
+		}
+	}
+	return noise, nil
+}
+
+// MatchAlertName returns the alert name referenced by an issue's alert signature.
+// Signatures are issue titles, so the longest alert name found as a whole word wins.
+func MatchAlertName(signature string, alertNames []string) string {
+	best := ""
+	for _, name := range alertNames {
+		if len(name) <= len(best) {
+			continue
+		}
+		idx := strings.Index(signature, name)
+		for idx >= 0 {
+			end := idx + len(name)
+			if (idx == 0 || !isAlertNameChar(signature[idx-1])) && (end == len(signature) || !isAlertNameChar(signature[end])) {
+				best = name
+				break
+			}
+			next := strings.Index(signature[idx+1:], name)
+			if next < 0 {
+				break
+			}
+			idx += 1 + next
+		}
+	}
+	return best
+}
+
+func isAlertNameChar(b byte) bool {
+	return b == '_' || b == ':' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// RuleRevisionEntry is a rule change to record. Action is AuditCreate, AuditUpdate or
+// AuditDelete; Before is nil for a create (or an update of a rule that wasn't found),
+// After is nil for a delete.
+type RuleRevisionEntry struct {
+	Action         string
+	Component      string
+	FilePath       string
+	PreviousAlert  string
+	Actor          string
+	TaskID         *uint
+	PullRequestURL string
+	Before         *models.Rule
+	After          *models.Rule
+}
+
+// RecordRuleRevision appends an edit to the rule history. Like RecordAudit it runs after
+// the change, so a failure is logged rather than returned.
+func RecordRuleRevision(db *gorm.DB, e RuleRevisionEntry) {
+	if e.Actor == "" {
+		e.Actor = "anonymous"
+	}
+	row := models.RuleRevision{
+		CreatedAt:      time.Now().UTC(),
+		Component:      e.Component,
+		FilePath:       e.FilePath,
+		Action:         e.Action,
+		Alert:          e.PreviousAlert,
+		PreviousAlert:  e.PreviousAlert,
+		Actor:          e.Actor,
+		TaskID:         e.TaskID,
+		PullRequestURL: e.PullRequestURL,
+	}
+	if e.Before != nil {
+		row.OldYAML = RuleYAML(*e.Before)
+	}
+	if e.After != nil {
+		row.Alert = e.After.Alert
+		row.NewYAML = RuleYAML(*e.After)
+	}
+	if err := db.Create(&row).Error; err != nil {
+		slog.Warn("Failed to record rule revision", "rule", e.PreviousAlert, "file", e.FilePath, "actor", e.Actor, "err", err)
+	}
+}
+
+// RuleYAML is a single rule as it appears in a rule file
+func RuleYAML(rule models.Rule) string {
+	data, err := yaml.Marshal(rule)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
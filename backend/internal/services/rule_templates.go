@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateParam is a placeholder of a rule template
+type TemplateParam struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	Default     string `yaml:"default" json:"default,omitempty"`
+	Required    bool   `yaml:"required" json:"required"`
+}
+
+// RuleTemplate is a parameterized rule file. Path and Rules are Go templates
+// referencing parameters as {{.component}}, {{.threshold}}, {{.severity}}, ...
+type RuleTemplate struct {
+	Name        string          `yaml:"name" json:"name"`
+	Description string          `yaml:"description" json:"description"`
+	Path        string          `yaml:"path" json:"path"`
+	Parameters  []TemplateParam `yaml:"parameters" json:"parameters"`
+	Rules       string          `yaml:"rules" json:"rules"`
+}
+
+// LoadRuleTemplates reads every *.yaml template in the configured templates directory
+func LoadRuleTemplates() ([]RuleTemplate, error) {
+	dir := config.Get().RuleTemplatesPath
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule templates from %s: %w", dir, err)
+	}
+
+	var templates []RuleTemplate
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml")) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var t RuleTemplate
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			slog.Warn("Skipping invalid rule template", "template", name, "err", err)
+			continue
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		templates = append(templates, t)
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// GetRuleTemplate returns the template with the given name
+func GetRuleTemplate(name string) (*RuleTemplate, error) {
+	templates, err := LoadRuleTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Render fills in the template with params (falling back to defaults) and validates the
+// resulting rule file. Returns the target path relative to the rules repo and the content.
+func (t *RuleTemplate) Render(params map[string]string) (string, string, error) {
+	values := make(map[string]string)
+	var missing []string
+	for _, p := range t.Parameters {
+		v := strings.TrimSpace(params[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			missing = append(missing, p.Name)
+		}
+		values[p.Name] = v
+	}
+	if len(missing) > 0 {
+		return "", "", fmt.Errorf("missing required parameters: %s", strings.Join(missing, ", "))
+	}
+
+	render := func(name, text string) (string, error) {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid template %s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		return buf.String(), nil
+	}
+
+	path, err := render("path", t.Path)
+	if err != nil {
+		return "", "", err
+	}
+	path = filepath.ToSlash(filepath.Clean(strings.TrimSpace(path)))
+	if path == "." || !isSafeBundlePath(path) {
+		return "", "", fmt.Errorf("template path %q must be relative to the rules repo", path)
+	}
+
+	content, err := render("rules", t.Rules)
+	if err != nil {
+		return "", "", err
+	}
+	if _, errs := validateRuleFile([]byte(content)); len(errs) > 0 {
+		return "", "", fmt.Errorf("rendered rules are invalid: %s", strings.Join(errs, "; "))
+	}
+	return path, content, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// RuleFileProblem is one validation failure in a rule file; Group and Rule are empty when
+// the problem is not tied to one
+type RuleFileProblem struct {
+	Group   string `json:"group,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p RuleFileProblem) String() string {
+	switch {
+	case p.Rule != "":
+		return fmt.Sprintf("group %q, rule %q: %s", p.Group, p.Rule, p.Message)
+	case p.Group != "":
+		return fmt.Sprintf("group %q: %s", p.Group, p.Message)
+	}
+	return p.Message
+}
+
+// RuleFileValidationError is a rule file that Prometheus would refuse to load. Validator
+// is "promtool" or "builtin", whichever checked it.
+type RuleFileValidationError struct {
+	FilePath  string
+	Validator string
+	Problems  []RuleFileProblem
+}
+
+func (e *RuleFileValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.String()
+	}
+	return fmt.Sprintf("%s fails %s validation: %s", e.FilePath, e.Validator, strings.Join(msgs, "; "))
+}
+
+// promtoolPath is the promtool binary from RULES_PROMTOOL or PATH, "" if there is none
+func promtoolPath() string {
+	if v := config.Lookup("RULES_PROMTOOL"); v != "" {
+		return v
+	}
+	path, _ := exec.LookPath("promtool")
+	return path
+}
+
+// ValidateRuleFile checks a rule file the way Prometheus loads it: with `promtool check
+// rules` when promtool is available, otherwise with the built-in checks. displayPath
+// names the file in the error, for when path is a temporary copy.
+func ValidateRuleFile(path, displayPath string) error {
+	var problems []RuleFileProblem
+	validator := "builtin"
+	if promtool := promtoolPath(); promtool != "" {
+		validator = "promtool"
+		var err error
+		if problems, err = promtoolCheck(promtool, path); err != nil {
+			return err
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		problems = checkRuleFile(data)
+	}
+	if len(problems) > 0 {
+		return &RuleFileValidationError{FilePath: displayPath, Validator: validator, Problems: problems}
+	}
+	return nil
+}
+
+// promtoolRuleProblem matches the rule-level errors promtool prints
+var promtoolRuleProblem = regexp.MustCompile(`^(?:\d+:\d+: )?group "([^"]*)", rule \d+, "([^"]*)": (.*)$`)
+
+// promtoolCheck runs `promtool check rules` on path. It fails only when promtool itself
+// can't run; rejected rules come back as problems.
+func promtoolCheck(promtool, path string) ([]RuleFileProblem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, promtool, "check", "rules", path)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	if err == nil {
+		return nil, nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok || ctx.Err() != nil {
+		return nil, fmt.Errorf("promtool check rules: %w", err)
+	}
+
+	var problems []RuleFileProblem
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "FAILED:" || strings.HasPrefix(line, "Checking ") {
+			continue
+		}
+		line = strings.TrimPrefix(line, path+": ")
+		if m := promtoolRuleProblem.FindStringSubmatch(line); m != nil {
+			problems = append(problems, RuleFileProblem{Group: m[1], Rule: m[2], Message: m[3]})
+		} else {
+			problems = append(problems, RuleFileProblem{Message: line})
+		}
+	}
+	if len(problems) == 0 {
+		problems = append(problems, RuleFileProblem{Message: "promtool check rules failed: " + err.Error()})
+	}
+	return problems, nil
+}
+
+var (
+	promDurationPattern = regexp.MustCompile(`^(\d+y)?(\d+w)?(\d+d)?(\d+h)?(\d+m)?(\d+s)?(\d+ms)?$`)
+	labelNamePattern    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	metricNamePattern   = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+// checkRuleFile is the built-in subset of promtool's checks: structure, names, durations
+// and expression syntax as far as validateExpr can tell
+func checkRuleFile(data []byte) []RuleFileProblem {
+	var rf models.RuleFile
+	if err := yaml.Unmarshal(data, &rf); err != nil {
+		return []RuleFileProblem{{Message: "invalid yaml: " + err.Error()}}
+	}
+
+	var problems []RuleFileProblem
+	seen := map[string]bool{}
+	for _, group := range rf.Groups {
+		add := func(rule, format string, args ...interface{}) {
+			problems = append(problems, RuleFileProblem{Group: group.Name, Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+		if group.Name == "" {
+			add("", "group name is empty")
+		} else if seen[group.Name] {
+			add("", "duplicate group name")
+		}
+		seen[group.Name] = true
+		if group.Interval != "" && !validPromDuration(group.Interval) {
+			add("", "invalid interval %q", group.Interval)
+		}
+
+		for _, rule := range group.Rules {
+			name := rule.Alert
+			if name == "" {
+				name = rule.Record
+			}
+			switch {
+			case rule.Alert != "" && rule.Record != "":
+				add(name, "only one of alert and record may be set")
+			case rule.Alert == "" && rule.Record == "":
+				add("", "one of alert or record must be set")
+			case rule.Record != "":
+				if !metricNamePattern.MatchString(rule.Record) {
+					add(name, "invalid recording rule name %q", rule.Record)
+				}
+				if rule.For != "" {
+					add(name, "recording rules can't have a for duration")
+				}
+				if len(rule.Annotations) > 0 {
+					add(name, "recording rules can't have annotations")
+				}
+			}
+			if err := validateExpr(rule.Expr); err != nil {
+				add(name, "%v", err)
+			}
+			if rule.For != "" && !validPromDuration(rule.For) {
+				add(name, "invalid for duration %q", rule.For)
+			}
+			for label := range rule.Labels {
+				if !labelNamePattern.MatchString(label) {
+					add(name, "invalid label name %q", label)
+				}
+			}
+			for annotation := range rule.Annotations {
+				if !labelNamePattern.MatchString(annotation) {
+					add(name, "invalid annotation name %q", annotation)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// validPromDuration reports whether s is a Prometheus duration such as 5m or 1h30m
+func validPromDuration(s string) bool {
+	return s != "" && promDurationPattern.MatchString(s)
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// ruleVersionCheckInterval bounds how often ingest asks git whether the rules repo moved
+const ruleVersionCheckInterval = time.Minute
+
+// RuleChange is a commit that touched a file defining a rule. Versions are tracked per
+// file, so a commit editing another rule in the same file counts as a change too.
+type RuleChange struct {
+	Revision  string    `json:"revision"`
+	ChangedAt time.Time `json:"changed_at"`
+	Author    string    `json:"author"`
+	Subject   string    `json:"subject"`
+	File      string    `json:"file"`
+}
+
+// ruleVersionIndex maps alert names to the rule files defining them and each file's
+// history, as of one HEAD of the rules repo
+type ruleVersionIndex struct {
+	head    string
+	names   []string
+	files   map[string][]string     // alert name -> repo-relative files
+	history map[string][]RuleChange // file -> commits, newest first
+}
+
+var ruleVersions struct {
+	sync.Mutex
+	index   *ruleVersionIndex
+	checked time.Time
+	warned  bool // the repo is unusable and that was logged
+}
+
+// currentRuleVersions returns the index for the rules repo's HEAD, rebuilding it when
+// HEAD moved. It is nil when the repo is not a git checkout.
+func currentRuleVersions() *ruleVersionIndex {
+	ruleVersions.Lock()
+	defer ruleVersions.Unlock()
+	if ruleVersions.index != nil && time.Since(ruleVersions.checked) < ruleVersionCheckInterval {
+		return ruleVersions.index
+	}
+	ruleVersions.checked = time.Now()
+
+	s := NewRulesService(config.Get())
+	head, err := git(s.RepoPath, "rev-parse", "HEAD")
+	if err != nil {
+		if !ruleVersions.warned {
+			slog.Warn("Rule versions unavailable, rules repo is not a readable git checkout", "repo", s.RepoPath, "err", err)
+			ruleVersions.warned = true
+		}
+		ruleVersions.index = nil
+		return nil
+	}
+	ruleVersions.warned = false
+	head = strings.TrimSpace(head)
+	if ruleVersions.index != nil && ruleVersions.index.head == head {
+		return ruleVersions.index
+	}
+
+	// HEAD moved, so files changed; don't wait for the rules index to notice
+	invalidateRulesIndex()
+	index, err := buildRuleVersionIndex(s, head)
+	if err != nil {
+		slog.Warn("Failed to index rule versions", "head", head, "err", err)
+		return ruleVersions.index
+	}
+	ruleVersions.index = index
+	return index
+}
+
+// buildRuleVersionIndex reads every rule file under the configured subdirectories and the
+// git history of those subdirectories in one pass
+func buildRuleVersionIndex(s *RulesService, head string) (*ruleVersionIndex, error) {
+	index := &ruleVersionIndex{head: head, files: map[string][]string{}, history: map[string][]RuleChange{}}
+	var dirs []string
+	for _, subDir := range s.SubDirs {
+		subDir = strings.TrimSpace(subDir)
+		dirs = append(dirs, subDir)
+		s.rulesUnder(subDir, func(path string, rules []models.Rule) {
+			rel, err := filepath.Rel(s.RepoPath, path)
+			if err != nil {
+				return
+			}
+			rel = filepath.ToSlash(rel)
+			for _, rule := range rules {
+				if !containsString(index.files[rule.Alert], rel) {
+					index.files[rule.Alert] = append(index.files[rule.Alert], rel)
+				}
+			}
+		})
+	}
+	for name := range index.files {
+		index.names = append(index.names, name)
+	}
+	sort.Strings(index.names)
+
+	// Records are separated by \x1e and fields by \x1f; file names follow each header
+	args := append([]string{"log", "--no-renames", "--name-only", "--format=%x1e%H%x1f%ct%x1f%an%x1f%s", "--"}, dirs...)
+	out, err := git(s.RepoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(fields[1], 10, 64)
+		for _, file := range lines[1:] {
+			if file = strings.TrimSpace(file); file != "" {
+				index.history[file] = append(index.history[file], RuleChange{
+					Revision:  fields[0],
+					ChangedAt: time.Unix(ts, 0).UTC(),
+					Author:    fields[2],
+					Subject:   fields[3],
+					File:      file,
+				})
+			}
+		}
+	}
+	return index, nil
+}
+
+// changes returns the commits to any file defining alert, newest first
+func (idx *ruleVersionIndex) changes(alert string) []RuleChange {
+	var all []RuleChange
+	for _, file := range idx.files[alert] {
+		all = append(all, idx.history[file]...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].ChangedAt.After(all[j].ChangedAt) })
+	return all
+}
+
+// revisionAt returns the newest change to alert's files made at or before t
+func (idx *ruleVersionIndex) revisionAt(alert string, t time.Time) string {
+	for _, change := range idx.changes(alert) {
+		if !change.ChangedAt.After(t) {
+			return change.Revision
+		}
+	}
+	return ""
+}
+
+// stampRuleVersion records which rule raised an alert and the rule revision that was live
+// when it fired. Derived from the alert's creation time, so re-ingesting gives the same stamp.
+func (u *DataUpdater) stampRuleVersion(data *IssueData) {
+	if !data.IsAlert || data.AlertSignature == "" {
+		return
+	}
+	idx := currentRuleVersions()
+	if idx == nil {
+		return
+	}
+	data.RuleName = MatchAlertName(data.AlertSignature, idx.names)
+	if data.RuleName == "" {
+		return
+	}
+	if created, err := time.Parse("2006-01-02 15:04:05 UTC", data.Created); err == nil {
+		data.RuleRevision = idx.revisionAt(data.RuleName, created)
+	}
+}
+
+// RuleChanges returns the changes to the files defining alert, newest first
+func RuleChanges(alert string) ([]RuleChange, error) {
+	idx := currentRuleVersions()
+	if idx == nil {
+		return nil, fmt.Errorf("rule history unavailable: %s is not a git checkout", NewRulesService(config.Get()).RepoPath)
+	}
+	if len(idx.files[alert]) == 0 {
+		return nil, fmt.Errorf("no rule file defines %s", alert)
+	}
+	return idx.changes(alert), nil
+}
+
+// git runs a git command in the rules repo and returns its stdout
+func git(repo string, args ...string) (string, error) {
+	if _, err := os.Stat(repo); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
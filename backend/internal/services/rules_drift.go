@@ -0,0 +1,151 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// RuleDivergence is one field that differs between categories for the same alert
+type RuleDivergence struct {
+	Field  string            `json:"field"`  // expr, for, labels.<name>
+	Values map[string]string `json:"values"` // category -> value ("" if unset)
+}
+
+// RuleDrift reports an alert defined in several categories whose definitions diverge
+type RuleDrift struct {
+	Alert       string            `json:"alert"`
+	Component   string            `json:"component,omitempty"`
+	Categories  []string          `json:"categories"`
+	MissingIn   []string          `json:"missing_in,omitempty"`
+	Files       map[string]string `json:"files"` // category -> file path
+	Divergences []RuleDivergence  `json:"divergences"`
+}
+
+// GetRulesForCategory returns every alert rule under the category's paths
+func (s *RulesService) GetRulesForCategory(category string) []models.Rule {
+	var rules []models.Rule
+	for _, subDir := range s.CategoryPathsMap[category] {
+		s.rulesUnder(subDir, func(path string, fileRules []models.Rule) {
+			for _, rule := range fileRules {
+				rule.Category = category
+				rule.FilePath = path
+				rules = append(rules, rule)
+			}
+		})
+	}
+	return rules
+}
+
+// DetectDrift compares alerts with the same name across categories and reports
+// differences in expr, for and labels. ignoreLabels are left out of the comparison.
+func (s *RulesService) DetectDrift(categories []string, component string, ignoreLabels []string) []RuleDrift {
+	if len(categories) == 0 {
+		for category := range s.CategoryPathsMap {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+
+	ignored := make(map[string]bool)
+	for _, l := range ignoreLabels {
+		ignored[l] = true
+	}
+
+	// alert -> category -> first definition found
+	byAlert := make(map[string]map[string]models.Rule)
+	for _, category := range categories {
+		for _, rule := range s.GetRulesForCategory(category) {
+			if component != "" && !strings.EqualFold(rule.Labels["component"], component) && !strings.EqualFold(rule.Labels["source_component"], component) {
+				continue
+			}
+			if byAlert[rule.Alert] == nil {
+				byAlert[rule.Alert] = make(map[string]models.Rule)
+			}
+			if _, exists := byAlert[rule.Alert][category]; !exists {
+				byAlert[rule.Alert][category] = rule
+			}
+		}
+	}
+
+	var drifts []RuleDrift
+	for alert, defs := range byAlert {
+		if len(defs) < 2 {
+			continue
+		}
+
+		present := make([]string, 0, len(defs))
+		for _, category := range categories {
+			if _, ok := defs[category]; ok {
+				present = append(present, category)
+			}
+		}
+
+		var divergences []RuleDivergence
+		compare := func(field string, value func(models.Rule) string) {
+			values := make(map[string]string)
+			distinct := make(map[string]bool)
+			for _, category := range present {
+				v := value(defs[category])
+				values[category] = v
+				distinct[v] = true
+			}
+			if len(distinct) > 1 {
+				divergences = append(divergences, RuleDivergence{Field: field, Values: values})
+			}
+		}
+
+		compare("expr", func(r models.Rule) string { return strings.Join(strings.Fields(r.Expr), " ") })
+		compare("for", func(r models.Rule) string { return r.For })
+
+		labelNames := make(map[string]bool)
+		for _, category := range present {
+			for name := range defs[category].Labels {
+				if !ignored[name] {
+					labelNames[name] = true
+				}
+			}
+		}
+		names := make([]string, 0, len(labelNames))
+		for name := range labelNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			name := name
+			compare("labels."+name, func(r models.Rule) string { return r.Labels[name] })
+		}
+
+		if len(divergences) == 0 {
+			continue
+		}
+
+		drift := RuleDrift{
+			Alert:       alert,
+			Categories:  present,
+			Files:       make(map[string]string),
+			Divergences: divergences,
+		}
+		for _, category := range categories {
+			def, ok := defs[category]
+			if !ok {
+				drift.MissingIn = append(drift.MissingIn, category)
+				continue
+			}
+			drift.Files[category] = def.FilePath
+			if drift.Component == "" {
+				drift.Component = def.Labels["component"]
+			}
+		}
+		drifts = append(drifts, drift)
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if len(drifts[i].Divergences) != len(drifts[j].Divergences) {
+			return len(drifts[i].Divergences) > len(drifts[j].Divergences)
+		}
+		return drifts[i].Alert < drifts[j].Alert
+	})
+	return drifts
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportRules writes the rule files matching component/category to w as a tar.gz.
+// With normalize, each file only keeps the matching rules and is re-encoded with
+// consistent formatting; otherwise files are copied verbatim. Returns the file count.
+func (s *RulesService) ExportRules(w io.Writer, component, category string, normalize bool) (int, error) {
+	if component == "" {
+		component = "*"
+	}
+
+	var rules []models.Rule
+	var err error
+	if category != "" {
+		rules, err = s.GetRulesForComponentAndCategory(component, category)
+	} else {
+		if component == "*" {
+			// GetRulesForComponent matches by substring, so "" selects every labeled rule
+			component = ""
+		}
+		rules, err = s.GetRulesForComponent(component)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Matched alert names per file
+	matched := make(map[string]map[string]bool)
+	for _, rule := range rules {
+		if matched[rule.FilePath] == nil {
+			matched[rule.FilePath] = make(map[string]bool)
+		}
+		matched[rule.FilePath][rule.Alert] = true
+	}
+	paths := make([]string, 0, len(matched))
+	for p := range matched {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	for _, path := range paths {
+		var content []byte
+		if normalize {
+			content, err = s.normalizedRuleFile(path, matched[path])
+		} else {
+			content, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to export %s: %w", path, err)
+		}
+
+		name, err := filepath.Rel(s.RepoPath, path)
+		if err != nil || strings.HasPrefix(name, "..") {
+			name = filepath.Base(path)
+		}
+
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(name),
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return 0, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	return len(paths), nil
+}
+
+// normalizedRuleFile re-encodes a rule file keeping only the named alerts
+func (s *RulesService) normalizedRuleFile(path string, alerts map[string]bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rf models.RuleFile
+	if err := yaml.Unmarshal(data, &rf); err != nil {
+		return nil, err
+	}
+
+	// Local types so empty optional fields are omitted from the normalized output
+	type exportRule struct {
+		Alert       string            `yaml:"alert"`
+		Expr        string            `yaml:"expr"`
+		For         string            `yaml:"for,omitempty"`
+		Labels      map[string]string `yaml:"labels,omitempty"`
+		Annotations map[string]string `yaml:"annotations,omitempty"`
+	}
+	type exportGroup struct {
+		Name  string       `yaml:"name"`
+		Rules []exportRule `yaml:"rules"`
+	}
+	var out struct {
+		Groups []exportGroup `yaml:"groups"`
+	}
+	for _, group := range rf.Groups {
+		var kept []exportRule
+		for _, rule := range group.Rules {
+			if alerts[rule.Alert] {
+				kept = append(kept, exportRule{rule.Alert, rule.Expr, rule.For, rule.Labels, rule.Annotations})
+			}
+		}
+		if len(kept) > 0 {
+			out.Groups = append(out.Groups, exportGroup{Name: group.Name, Rules: kept})
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&out); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
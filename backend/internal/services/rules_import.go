@@ -0,0 +1,231 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// maxBundleFileSize bounds a single rule file inside an imported bundle
+const maxBundleFileSize = 5 << 20
+
+// RuleFileChange describes one file of an imported bundle against the current repo
+type RuleFileChange struct {
+	Path   string   `json:"path"`
+	Status string   `json:"status"` // added, modified, unchanged, invalid
+	Rules  int      `json:"rules"`
+	Diff   string   `json:"diff,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+
+	content []byte
+}
+
+// RuleImportPlan is the dry-run result of importing a bundle
+type RuleImportPlan struct {
+	Files   []RuleFileChange `json:"files"`
+	Valid   bool             `json:"valid"`
+	Changed int              `json:"changed"`
+}
+
+// Diff returns the combined diff of all changed files
+func (p *RuleImportPlan) Diff() string {
+	var sb strings.Builder
+	for _, f := range p.Files {
+		sb.WriteString(f.Diff)
+	}
+	return sb.String()
+}
+
+// Contents returns path -> new content for all changed files
+func (p *RuleImportPlan) Contents() map[string]string {
+	contents := make(map[string]string)
+	for _, f := range p.Files {
+		if f.Status == "added" || f.Status == "modified" {
+			contents[f.Path] = string(f.content)
+		}
+	}
+	return contents
+}
+
+// requiredRuleLabels returns the labels every imported rule must carry
+func requiredRuleLabels() []string {
+	if v := config.Lookup("RULES_REQUIRED_LABELS"); v != "" {
+		var labels []string
+		for _, l := range strings.Split(v, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				labels = append(labels, l)
+			}
+		}
+		return labels
+	}
+	return []string{"severity", "component"}
+}
+
+// PlanImport reads a tar.gz bundle (as produced by ExportRules), validates every rule
+// and diffs each file against the repo without writing anything
+func (s *RulesService) PlanImport(r io.Reader) (*RuleImportPlan, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle is not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	plan := &RuleImportPlan{Valid: true}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		change := RuleFileChange{Path: path.Clean(hdr.Name)}
+		if !isSafeBundlePath(change.Path) {
+			change.Status = "invalid"
+			change.Errors = append(change.Errors, "path must be relative to the rules repo")
+		} else if ext := path.Ext(change.Path); ext != ".yaml" && ext != ".yml" {
+			continue
+		} else if hdr.Size > maxBundleFileSize {
+			change.Status = "invalid"
+			change.Errors = append(change.Errors, fmt.Sprintf("file exceeds %d bytes", maxBundleFileSize))
+		} else {
+			content, err := io.ReadAll(io.LimitReader(tr, maxBundleFileSize))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+			}
+			change.content = content
+			change.Rules, change.Errors = validateRuleFile(content)
+			if len(change.Errors) > 0 {
+				change.Status = "invalid"
+			} else {
+				s.diffAgainstRepo(&change)
+			}
+		}
+
+		if change.Status == "invalid" {
+			plan.Valid = false
+		} else if change.Status != "unchanged" {
+			plan.Changed++
+		}
+		plan.Files = append(plan.Files, change)
+	}
+
+	if len(plan.Files) == 0 {
+		return nil, fmt.Errorf("bundle contains no rule files")
+	}
+	return plan, nil
+}
+
+func (s *RulesService) diffAgainstRepo(change *RuleFileChange) {
+	current, err := os.ReadFile(filepath.Join(s.RepoPath, filepath.FromSlash(change.Path)))
+	if err != nil {
+		change.Status = "added"
+		change.Diff = UnifiedDiff("/dev/null", "b/"+change.Path, "", string(change.content))
+		return
+	}
+	change.Diff = UnifiedDiff("a/"+change.Path, "b/"+change.Path, string(current), string(change.content))
+	if change.Diff == "" {
+		change.Status = "unchanged"
+	} else {
+		change.Status = "modified"
+	}
+}
+
+func isSafeBundlePath(p string) bool {
+	return p != "." && !path.IsAbs(p) && p != ".." && !strings.HasPrefix(p, "../")
+}
+
+// validateRuleFile parses a rule file and checks every rule's expression and labels
+func validateRuleFile(content []byte) (int, []string) {
+	var rf models.RuleFile
+	if err := yaml.Unmarshal(content, &rf); err != nil {
+		return 0, []string{fmt.Sprintf("invalid yaml: %v", err)}
+	}
+	if len(rf.Groups) == 0 {
+		return 0, []string{"no rule groups found"}
+	}
+
+	var errs []string
+	count := 0
+	for _, group := range rf.Groups {
+		if group.Name == "" {
+			errs = append(errs, "rule group without a name")
+		}
+		for _, rule := range group.Rules {
+			if rule.Alert == "" {
+				// Recording rules aren't managed here
+				continue
+			}
+			count++
+			for _, problem := range ValidateRule(rule) {
+				errs = append(errs, fmt.Sprintf("%s: %s", rule.Alert, problem))
+			}
+		}
+	}
+	return count, errs
+}
+
+// validateExpr performs basic PromQL syntax checks: non-empty, balanced
+// brackets and quotes, and no dangling binary operator
+func validateExpr(expr string) error {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return fmt.Errorf("empty expr")
+	}
+
+	var stack []rune
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var quote rune
+	escaped := false
+	for _, ch := range expr {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\' && quote != '`':
+				escaped = true
+			case ch == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'', '`':
+			quote = ch
+		case '(', '[', '{':
+			stack = append(stack, ch)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[ch] {
+				return fmt.Errorf("unbalanced %q in expr", ch)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("unterminated string in expr")
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q in expr", stack[len(stack)-1])
+	}
+
+	for _, op := range []string{"+", "-", "*", "/", "%", "^", "==", "!=", ">", "<", ">=", "<=", " and", " or", " unless"} {
+		if strings.HasSuffix(expr, op) {
+			return fmt.Errorf("expr ends with operator %q", strings.TrimSpace(op))
+		}
+	}
+	return nil
+}
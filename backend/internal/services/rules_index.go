@@ -0,0 +1,127 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// defaultRulesIndexTTL is how long a directory listing of the rules repo is trusted
+const defaultRulesIndexTTL = 30 * time.Second
+
+// ruleFileEntry is a parsed rule file and the stat it was parsed at; a file that failed
+// to parse keeps its error so it isn't re-read on every call
+type ruleFileEntry struct {
+	modTime time.Time
+	size    int64
+	rules   []models.Rule
+	err     error
+}
+
+// ruleDirListing is the rule files found under a directory, in walk order
+type ruleDirListing struct {
+	files  []string
+	listed time.Time
+}
+
+// rulesIndex caches parsed rule files so the rules endpoints don't walk and re-parse the
+// repo on every request. Directories are re-walked once their listing is older than the
+// TTL; only files whose size or mtime changed are parsed again. Edits made through
+// UpdateRule invalidate their file right away.
+var rulesIndex struct {
+	sync.Mutex
+	dirs  map[string]*ruleDirListing
+	files map[string]*ruleFileEntry
+}
+
+// rulesIndexTTL is RULES_INDEX_TTL, a Go duration; 0 disables the cache
+func rulesIndexTTL() time.Duration {
+	if v := config.Lookup("RULES_INDEX_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultRulesIndexTTL
+}
+
+// invalidateRuleFile drops a file from the index so the next read parses it again
+func invalidateRuleFile(path string) {
+	rulesIndex.Lock()
+	delete(rulesIndex.files, filepath.Clean(path))
+	rulesIndex.Unlock()
+}
+
+// invalidateRulesIndex makes the next read re-walk every directory
+func invalidateRulesIndex() {
+	rulesIndex.Lock()
+	rulesIndex.dirs = nil
+	rulesIndex.Unlock()
+}
+
+// rulesUnder calls fn with the alert rules of every rule file under subDir of the repo.
+// fn gets its own copy of each file's rules.
+func (s *RulesService) rulesUnder(subDir string, fn func(path string, rules []models.Rule)) {
+	basePath := filepath.Clean(filepath.Join(s.RepoPath, strings.TrimSpace(subDir)))
+
+	type parsedFile struct {
+		path  string
+		rules []models.Rule
+	}
+	var parsed []parsedFile
+
+	rulesIndex.Lock()
+	if rulesIndex.dirs == nil {
+		rulesIndex.dirs = map[string]*ruleDirListing{}
+	}
+	if rulesIndex.files == nil {
+		rulesIndex.files = map[string]*ruleFileEntry{}
+	}
+	listing := rulesIndex.dirs[basePath]
+	if listing == nil || time.Since(listing.listed) >= rulesIndexTTL() {
+		listing = &ruleDirListing{listed: time.Now()}
+		err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || (!strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml")) {
+				return nil // Skip errors accessing files
+			}
+			listing.files = append(listing.files, path)
+			if e := rulesIndex.files[path]; e != nil && (!e.modTime.Equal(info.ModTime()) || e.size != info.Size()) {
+				delete(rulesIndex.files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.Warn("Failed to walk rules directory", "path", basePath, "err", err)
+		}
+		rulesIndex.dirs[basePath] = listing
+	}
+	for _, path := range listing.files {
+		e := rulesIndex.files[path]
+		if e == nil {
+			e = &ruleFileEntry{}
+			if info, err := os.Stat(path); err == nil {
+				e.modTime, e.size = info.ModTime(), info.Size()
+			}
+			if e.rules, e.err = s.parseFile(path); e.err != nil {
+				// log error but continue
+				s.logger.Warn("Failed to parse rule file", "path", path, "err", e.err)
+			}
+			rulesIndex.files[path] = e
+		}
+		if e.err == nil {
+			parsed = append(parsed, parsedFile{path, append([]models.Rule(nil), e.rules...)})
+		}
+	}
+	if rulesIndexTTL() == 0 {
+		rulesIndex.dirs, rulesIndex.files = nil, nil
+	}
+	rulesIndex.Unlock()
+
+	for _, f := range parsed {
+		fn(f.path, f.rules)
+	}
+}
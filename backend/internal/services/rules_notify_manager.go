@@ -2,10 +2,13 @@ package services
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,6 +22,45 @@ type RulesNotifyConfig struct {
 	DedicatedWhitelist []RulesNotifyEntry `json:"dedicated_whitelist" yaml:"dedicated_whitelist"`
 }
 
+// Suppression reasons of the rules notify config
+const (
+	SuppressedByNextgenBlacklist   = "nextgen_blacklist"
+	SuppressedByDedicatedNotListed = "dedicated_not_whitelisted"
+)
+
+// Suppression returns why the config suppresses notifications for an alert of the given
+// biz type, tenant and cluster, or "" when it is notified. Nextgen alerts from
+// blacklisted tenants/clusters are suppressed and, when the whitelist is not empty,
+// dedicated alerts are only notified for whitelisted ones. Serverless alerts are never
+// suppressed. A nil config allows everything.
+func (c *RulesNotifyConfig) Suppression(bizType, tenantID, clusterID string) string {
+	if c == nil {
+		return ""
+	}
+	listed := func(entries []RulesNotifyEntry) bool {
+		for _, e := range entries {
+			if (e.Type == "tenant" && e.ID == tenantID && tenantID != "") ||
+				(e.Type == "cluster" && e.ID == clusterID && clusterID != "") {
+				return true
+			}
+		}
+		return false
+	}
+	biz := strings.ToLower(bizType)
+	switch {
+	case strings.Contains(biz, "nextgen"):
+		if listed(c.NextgenBlacklist) {
+			return SuppressedByNextgenBlacklist
+		}
+	case strings.Contains(biz, "devtier") || strings.Contains(biz, "tidb serverless"):
+	default:
+		if len(c.DedicatedWhitelist) > 0 && !listed(c.DedicatedWhitelist) {
+			return SuppressedByDedicatedNotListed
+		}
+	}
+	return ""
+}
+
 type RulesNotifyManagerService struct {
 	ConfigPath string
 	mu         sync.RWMutex
@@ -31,25 +73,24 @@ var (
 
 func GetRulesNotifyManager() *RulesNotifyManagerService {
 	rulesNotifyManagerServiceOnce.Do(func() {
-		// Determine config path relative to binary execution or fixed for dev
-		// Similar to existing services logic
-		configPath := "config/rules_notify_manager.yaml"
-		// Try to find it if we are deeper
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			if _, err := os.Stat("../" + configPath); err == nil {
-				configPath = "../" + configPath
-			} else if _, err := os.Stat("../../" + configPath); err == nil {
-				configPath = "../../" + configPath
-			}
-		}
-
 		rulesNotifyManagerService = &RulesNotifyManagerService{
-			ConfigPath: configPath,
+			ConfigPath: config.Get().RulesNotifyPath,
 		}
+		// Pick up a relocated config file on hot reload
+		config.OnReload(func(cfg *config.Config) {
+			rulesNotifyManagerService.SetConfigPath(cfg.RulesNotifyPath)
+		})
 	})
 	return rulesNotifyManagerService
 }
 
+// SetConfigPath switches the backing file; in-flight reads finish against the old path
+func (s *RulesNotifyManagerService) SetConfigPath(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ConfigPath = path
+}
+
 func (s *RulesNotifyManagerService) GetRules() (*RulesNotifyConfig, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -103,3 +144,14 @@ func (s *RulesNotifyManagerService) UpdateRules(config RulesNotifyConfig) error
 
 	return nil
 }
+
+// Suppression evaluates the saved config for an alert, see RulesNotifyConfig.Suppression.
+// A config that cannot be read suppresses nothing, so a broken file never drops alerts.
+func (s *RulesNotifyManagerService) Suppression(bizType, tenantID, clusterID string) string {
+	cfg, err := s.GetRules()
+	if err != nil {
+		slog.Warn("Failed to load rules notify config, notifying anyway", "err", err)
+		return ""
+	}
+	return cfg.Suppression(bizType, tenantID, clusterID)
+}
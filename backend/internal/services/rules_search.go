@@ -0,0 +1,88 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// RuleSearchQuery narrows a rule search. Text matches case-insensitively anywhere in the
+// alert name, expr, label names and values and annotation values. Labels are "name" (the
+// label is set) or "name=value" (exact value), all required.
+type RuleSearchQuery struct {
+	Text     string
+	Severity string
+	Labels   []string
+}
+
+// RuleSearchMatch is a rule that matched, with the fields the text was found in
+type RuleSearchMatch struct {
+	models.Rule
+	MatchedIn []string `json:"matched_in,omitempty"` // alert, expr, labels, annotations
+}
+
+// SearchRules searches every rule in the repo, ordered by alert name then file
+func (s *RulesService) SearchRules(q RuleSearchQuery) []RuleSearchMatch {
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+	matches := []RuleSearchMatch{}
+	for _, rule := range s.AllRules() {
+		if q.Severity != "" && !strings.EqualFold(rule.Labels["severity"], q.Severity) {
+			continue
+		}
+		if !ruleHasLabels(rule, q.Labels) {
+			continue
+		}
+		match := RuleSearchMatch{Rule: rule}
+		if text != "" {
+			match.MatchedIn = ruleTextMatches(rule, text)
+			if len(match.MatchedIn) == 0 {
+				continue
+			}
+		}
+		matches = append(matches, match)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Alert != matches[j].Alert {
+			return matches[i].Alert < matches[j].Alert
+		}
+		return matches[i].FilePath < matches[j].FilePath
+	})
+	return matches
+}
+
+func ruleHasLabels(rule models.Rule, labels []string) bool {
+	for _, l := range labels {
+		name, value, exact := strings.Cut(l, "=")
+		v, ok := rule.Labels[strings.TrimSpace(name)]
+		if !ok || (exact && v != strings.TrimSpace(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleTextMatches lists the fields of rule containing text, which is lower case
+func ruleTextMatches(rule models.Rule, text string) []string {
+	contains := func(v string) bool { return strings.Contains(strings.ToLower(v), text) }
+	var fields []string
+	if contains(rule.Alert) {
+		fields = append(fields, "alert")
+	}
+	if contains(rule.Expr) {
+		fields = append(fields, "expr")
+	}
+	for name, value := range rule.Labels {
+		if contains(name) || contains(value) {
+			fields = append(fields, "labels")
+			break
+		}
+	}
+	for _, value := range rule.Annotations {
+		if contains(value) {
+			fields = append(fields, "annotations")
+			break
+		}
+	}
+	return fields
+}
@@ -1,11 +1,14 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/nolouch/alerts-platform-v2/internal/config"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"gopkg.in/yaml.v3"
 )
@@ -15,11 +18,14 @@ type RulesService struct {
 	SubDirs          []string
 	CategoryPathsMap map[string][]string // Maps category (premium/dedicated/essential) to paths
 	ComponentGroups  map[string][]string // Maps component group name to list of components
+	Git              *RulesGitConfig     // nil unless rule edits go through pull requests
+	logger           *slog.Logger
 }
 
 type RulesConfig struct {
 	Categories map[string]CategoryPaths `yaml:"categories"`
 	RepoPath   string                   `yaml:"repo_path"`
+	Git        RulesGitConfig           `yaml:"git"`
 }
 
 type ComponentCategoriesConfig struct {
@@ -31,52 +37,41 @@ type CategoryPaths struct {
 	Logging    []string `yaml:"logging"`
 }
 
-func NewRulesService() *RulesService {
+// NewRulesService reads the rules repo layout and category mappings configured in cfg
+func NewRulesService(cfg *config.Config) *RulesService {
+	logger := slog.Default().With("service", "rules")
+
 	// Defaults matching the legacy python env vars
-	repoPath := os.Getenv("RUNBOOKS_REPO_PATH")
+	repoPath := cfg.RunbooksRepoPath
 	if repoPath == "" {
 		repoPath = "/Users/nolouch/program/docs/runbooks"
 	}
 
-	subDirsEnv := os.Getenv("RUNBOOKS_RULES_SUBDIRS")
-	var subDirs []string
-	if subDirsEnv != "" {
-		subDirs = strings.Split(subDirsEnv, ",")
-	} else {
-		subDirs = []string{"rules/cluster-next-gen", "rules/dedicated", "rules/logging"}
-	}
+	subDirs := cfg.RulesSubDirs
 
 	// Load category mapping from config file
 	categoryPathsMap := make(map[string][]string)
-	configPaths := []string{
-		"../config/rules_categories.yaml",
-		"../../config/rules_categories.yaml",
-		"config/rules_categories.yaml",
-	}
-
-	for _, configPath := range configPaths {
-		data, err := os.ReadFile(configPath)
-		if err == nil {
-			var config RulesConfig
-			if err := yaml.Unmarshal(data, &config); err == nil {
-				// Flatten CategoryPaths (prometheus + logging) into a single list per category
-				for category, paths := range config.Categories {
-					var allPaths []string
-					allPaths = append(allPaths, paths.Prometheus...)
-					allPaths = append(allPaths, paths.Logging...)
-					categoryPathsMap[category] = allPaths
-				}
-				if config.RepoPath != "" && repoPath == "/Users/nolouch/program/docs/runbooks" {
-					repoPath = config.RepoPath
-				}
-				fmt.Printf("✅ Loaded rules categories config from %s\n", configPath)
-				break
+	var gitConfig RulesGitConfig
+	if data, err := os.ReadFile(cfg.RulesCategoriesPath); err == nil {
+		var rulesConfig RulesConfig
+		if err := yaml.Unmarshal(data, &rulesConfig); err == nil {
+			// Flatten CategoryPaths (prometheus + logging) into a single list per category
+			for category, paths := range rulesConfig.Categories {
+				var allPaths []string
+				allPaths = append(allPaths, paths.Prometheus...)
+				allPaths = append(allPaths, paths.Logging...)
+				categoryPathsMap[category] = allPaths
 			}
+			if rulesConfig.RepoPath != "" && repoPath == "/Users/nolouch/program/docs/runbooks" {
+				repoPath = rulesConfig.RepoPath
+			}
+			gitConfig = rulesConfig.Git
+			logger.Info("Loaded rules categories config", "path", cfg.RulesCategoriesPath)
 		}
 	}
 
 	if len(categoryPathsMap) == 0 {
-		fmt.Println("⚠️  Warning: Could not load rules_categories.yaml, using default paths for all categories")
+		logger.Warn("Could not load rules_categories.yaml, using default paths for all categories")
 		// Fallback: use all paths for all categories
 		categoryPathsMap = map[string][]string{
 			"premium":   subDirs,
@@ -87,30 +82,25 @@ func NewRulesService() *RulesService {
 
 	// Load component categories map
 	componentGroups := make(map[string][]string)
-	compConfigPaths := []string{
-		"../config/component_categories.yaml",
-		"../../config/component_categories.yaml",
-		"config/component_categories.yaml",
-	}
-
-	for _, configPath := range compConfigPaths {
-		data, err := os.ReadFile(configPath)
-		if err == nil {
-			var config ComponentCategoriesConfig
-			if err := yaml.Unmarshal(data, &config); err == nil {
-				componentGroups = config.Categories
-				fmt.Printf("✅ Loaded component categories config from %s\n", configPath)
-				break
-			}
+	if data, err := os.ReadFile(cfg.ComponentCategoriesPath); err == nil {
+		var compConfig ComponentCategoriesConfig
+		if err := yaml.Unmarshal(data, &compConfig); err == nil {
+			componentGroups = compConfig.Categories
+			logger.Info("Loaded component categories config", "path", cfg.ComponentCategoriesPath)
 		}
 	}
 
-	return &RulesService{
+	svc := &RulesService{
 		RepoPath:         repoPath,
 		SubDirs:          subDirs,
 		CategoryPathsMap: categoryPathsMap,
 		ComponentGroups:  componentGroups,
+		logger:           logger,
+	}
+	if gitConfig = gitConfig.withEnv(); gitConfig.Enabled() {
+		svc.Git = &gitConfig
 	}
+	return svc
 }
 
 // GetRulesForComponent scans all configured directories and filters rules by 'component' label
@@ -118,27 +108,7 @@ func (s *RulesService) GetRulesForComponent(componentName string) ([]models.Rule
 	var matchedRules []models.Rule
 
 	for _, subDir := range s.SubDirs {
-		basePath := filepath.Join(s.RepoPath, strings.TrimSpace(subDir))
-
-		err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip errors accessing files
-			}
-			if info.IsDir() {
-				return nil
-			}
-			if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
-				return nil
-			}
-
-			// Parse file
-			fileRules, err := s.parseFile(path)
-			if err != nil {
-				// log error but continue
-				fmt.Printf("Error parsing %s: %v\n", path, err)
-				return nil
-			}
-
+		s.rulesUnder(subDir, func(path string, fileRules []models.Rule) {
 			// Filter rules
 			for _, rule := range fileRules {
 				// Check if component label matches
@@ -163,11 +133,7 @@ func (s *RulesService) GetRulesForComponent(componentName string) ([]models.Rule
 					matchedRules = append(matchedRules, rule)
 				}
 			}
-			return nil
 		})
-		if err != nil {
-			fmt.Printf("Error walking %s: %v\n", basePath, err)
-		}
 	}
 
 	return matchedRules, nil
@@ -181,7 +147,7 @@ func (s *RulesService) GetRulesForComponentAndCategory(componentName, category s
 	categoryPaths, ok := s.CategoryPathsMap[category]
 	if !ok || len(categoryPaths) == 0 {
 		// Fallback to all paths if category not found
-		fmt.Printf("⚠️  Category '%s' not found in config, using all paths\n", category)
+		s.logger.Warn("Category not found in config, using all paths", "category", category)
 		categoryPaths = s.SubDirs
 	}
 
@@ -204,27 +170,7 @@ func (s *RulesService) GetRulesForComponentAndCategory(componentName, category s
 	// Also handle wildcard case if ever needed, but we rely on targetComponents now.
 
 	for _, subDir := range categoryPaths {
-		basePath := filepath.Join(s.RepoPath, strings.TrimSpace(subDir))
-
-		err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip errors accessing files
-			}
-			if info.IsDir() {
-				return nil
-			}
-			if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
-				return nil
-			}
-
-			// Parse file
-			fileRules, err := s.parseFile(path)
-			if err != nil {
-				// log error but continue
-				fmt.Printf("Error parsing %s: %v\n", path, err)
-				return nil
-			}
-
+		s.rulesUnder(subDir, func(path string, fileRules []models.Rule) {
 			// Filter rules
 			for _, rule := range fileRules {
 				matched := false
@@ -255,11 +201,7 @@ func (s *RulesService) GetRulesForComponentAndCategory(componentName, category s
 					matchedRules = append(matchedRules, rule)
 				}
 			}
-			return nil
 		})
-		if err != nil {
-			fmt.Printf("Error walking %s: %v\n", basePath, err)
-		}
 	}
 
 	return matchedRules, nil
@@ -280,6 +222,7 @@ func (s *RulesService) parseFile(path string) ([]models.Rule, error) {
 	for _, group := range rf.Groups {
 		for _, rule := range group.Rules {
 			if rule.Alert != "" {
+				rule.Group = group.Name
 				rules = append(rules, rule)
 			}
 		}
@@ -287,52 +230,207 @@ func (s *RulesService) parseFile(path string) ([]models.Rule, error) {
 	return rules, nil
 }
 
-// UpdateRule updates a specific rule in a specific file
-func (s *RulesService) UpdateRule(filePath string, oldAlertName string, updatedRule models.Rule) error {
-	// 1. Read the file
-	data, err := os.ReadFile(filePath)
+// FindRule returns the rule with the given alert name from a rule file
+func (s *RulesService) FindRule(filePath, alert string) (*models.Rule, error) {
+	rules, err := s.parseFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Alert == alert {
+			return &rules[i], nil
+		}
 	}
+	return nil, fmt.Errorf("rule '%s' not found in %s", alert, filePath)
+}
 
-	var rf models.RuleFile
-	if err := yaml.Unmarshal(data, &rf); err != nil {
-		return fmt.Errorf("failed to parse yaml: %w", err)
-	}
-
-	// 2. Find and update the rule
-	found := false
-	for i, group := range rf.Groups {
-		for j, rule := range group.Rules {
-			if rule.Alert == oldAlertName {
-				// Update fields
-				rf.Groups[i].Rules[j] = updatedRule
-				// Preserve fields that are not in updatedRule if needed?
-				// Assuming updatedRule contains all necessary fields.
-				// However, updatedRule might have extra fields like FilePath/Category that shouldn't be in YAML.
-				// We should strip them by using the correct struct tags which we have.
-				found = true
-				break
+// Errors for rule edits that don't fit the file as it is
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+	ErrRuleExists   = errors.New("rule already exists")
+)
+
+// UpdateRule updates a specific rule in a specific file. With rules git configured the
+// edit is proposed as a pull request, which is returned; otherwise the file is written
+// in place and the pull request is nil. actor is credited in the pull request.
+func (s *RulesService) UpdateRule(filePath string, oldAlertName string, updatedRule models.Rule, actor string) (*RulePullRequest, error) {
+	// Blocking validation: rules must be well-formed and owned before they are saved
+	if problems := ValidateRule(updatedRule); len(problems) > 0 {
+		return nil, &RuleValidationError{Alert: updatedRule.Alert, Problems: problems}
+	}
+
+	edit := func(rf *models.RuleFile) error {
+		for i, group := range rf.Groups {
+			for j, rule := range group.Rules {
+				if rule.Alert == oldAlertName {
+					// FilePath/Category and the other augmented fields are yaml:"-"
+					rf.Groups[i].Rules[j] = updatedRule
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%w: '%s' in %s", ErrRuleNotFound, oldAlertName, filePath)
+	}
+
+	if s.Git != nil {
+		title := fmt.Sprintf("Update alert rule %s", updatedRule.Alert)
+		if oldAlertName != updatedRule.Alert {
+			title = fmt.Sprintf("Update alert rule %s (was %s)", updatedRule.Alert, oldAlertName)
+		}
+		return s.proposeRuleChange(filePath, updatedRule.Alert, title, fmt.Sprintf("Edits `%s`", oldAlertName), actor, false, edit)
+	}
+	return nil, modifyRuleFile(filePath, filePath, false, edit)
+}
+
+// CreateRule adds a rule to a group of a rule file. An empty group means the file's
+// first group; a group or file that doesn't exist yet is created, new files only inside
+// the rules repo. Validation and pull requests work as for UpdateRule.
+func (s *RulesService) CreateRule(filePath, groupName string, newRule models.Rule, actor string) (*RulePullRequest, error) {
+	if problems := ValidateRule(newRule); len(problems) > 0 {
+		return nil, &RuleValidationError{Alert: newRule.Alert, Problems: problems}
+	}
+	if _, err := s.repoRelPath(filePath); err != nil {
+		return nil, &RuleValidationError{Alert: newRule.Alert, Problems: []string{err.Error()}}
+	}
+	if !strings.HasSuffix(filePath, ".yaml") && !strings.HasSuffix(filePath, ".yml") {
+		return nil, &RuleValidationError{Alert: newRule.Alert, Problems: []string{"rule files must end in .yaml or .yml"}}
+	}
+
+	edit := func(rf *models.RuleFile) error {
+		for _, group := range rf.Groups {
+			for _, rule := range group.Rules {
+				if rule.Alert == newRule.Alert {
+					return fmt.Errorf("%w: '%s' in %s", ErrRuleExists, newRule.Alert, filePath)
+				}
 			}
 		}
-		if found {
-			break
+		if groupName == "" {
+			if len(rf.Groups) == 0 {
+				return &RuleValidationError{Alert: newRule.Alert, Problems: []string{"group is required for a file without groups"}}
+			}
+			groupName = rf.Groups[0].Name
+		}
+		for i := range rf.Groups {
+			if rf.Groups[i].Name == groupName {
+				rf.Groups[i].Rules = append(rf.Groups[i].Rules, newRule)
+				return nil
+			}
 		}
+		rf.Groups = append(rf.Groups, models.RuleGroup{Name: groupName, Rules: []models.Rule{newRule}})
+		return nil
 	}
 
-	if !found {
-		return fmt.Errorf("rule '%s' not found in %s", oldAlertName, filePath)
+	if s.Git != nil {
+		title := fmt.Sprintf("Add alert rule %s", newRule.Alert)
+		return s.proposeRuleChange(filePath, newRule.Alert, title, fmt.Sprintf("Adds `%s`", newRule.Alert), actor, true, edit)
 	}
+	return nil, modifyRuleFile(filePath, filePath, true, edit)
+}
+
+// DeleteRule removes a rule from a rule file, and its group if that leaves it empty.
+// Pull requests work as for UpdateRule.
+func (s *RulesService) DeleteRule(filePath, alert, actor string) (*RulePullRequest, error) {
+	edit := func(rf *models.RuleFile) error {
+		for i, group := range rf.Groups {
+			for j, rule := range group.Rules {
+				if rule.Alert != alert {
+					continue
+				}
+				rf.Groups[i].Rules = append(group.Rules[:j:j], group.Rules[j+1:]...)
+				if len(rf.Groups[i].Rules) == 0 {
+					rf.Groups = append(rf.Groups[:i:i], rf.Groups[i+1:]...)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: '%s' in %s", ErrRuleNotFound, alert, filePath)
+	}
+
+	if s.Git != nil {
+		title := fmt.Sprintf("Delete alert rule %s", alert)
+		return s.proposeRuleChange(filePath, alert, title, fmt.Sprintf("Deletes `%s`", alert), actor, false, edit)
+	}
+	return nil, modifyRuleFile(filePath, filePath, false, edit)
+}
+
+// repoRelPath is filePath relative to the rules repo, or an error if it is outside
+func (s *RulesService) repoRelPath(filePath string) (string, error) {
+	rel, err := filepath.Rel(s.RepoPath, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the rules repo", filePath)
+	}
+	return rel, nil
+}
 
-	// 3. Write back to file
-	newData, err := yaml.Marshal(&rf)
+// modifyRuleFile applies edit to the rule file at filePath and validates the result
+// before writing it. With allowCreate a missing file starts out empty. displayPath names
+// the file in validation errors.
+func modifyRuleFile(filePath, displayPath string, allowCreate bool, edit func(rf *models.RuleFile) error) error {
+	// 1. Read the file
+	var rf, original models.RuleFile
+	data, err := os.ReadFile(filePath)
+	create := allowCreate && os.IsNotExist(err)
+	switch {
+	case create:
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to read file: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, &rf); err != nil {
+			return fmt.Errorf("failed to parse yaml: %w", err)
+		}
+		yaml.Unmarshal(data, &original)
+	}
+
+	// 2. Apply the change
+	if err := edit(&rf); err != nil {
+		return err
+	}
+
+	// 3. Write to a temporary file next to the original, validate it, and only then
+	// move it into place so a rejected edit leaves the file untouched. Existing files
+	// are edited in place where possible so comments and layout survive.
+	newData, spliced := []byte(nil), false
+	if !create {
+		if newData, spliced = spliceRuleFile(data, &original, &rf); !spliced {
+			slog.Warn("Could not edit rule file in place, rewriting the whole file", "path", displayPath)
+		}
+	}
+	if !spliced {
+		if newData, err = yaml.Marshal(&rf); err != nil {
+			return fmt.Errorf("failed to marshal yaml: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+"-*")
 	if err != nil {
-		return fmt.Errorf("failed to marshal yaml: %w", err)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(newData); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, newData, 0644); err != nil {
+	if err := ValidateRuleFile(tmp.Name(), displayPath); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
+	invalidateRuleFile(filePath)
+	if create {
+		invalidateRulesIndex()
+	}
 
 	return nil
 }
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// spliceRuleFile renders the change from before to after, both decoded from src, as an
+// edit of src that leaves everything outside the changed rule or group byte-identical:
+// comments, key order, anchors and indentation elsewhere survive. A replaced rule keeps
+// its own key order, comments and scalar styles too. ok is false when the change isn't
+// one rule or group replaced, added or removed, or src is laid out in a way the splice
+// can't follow; the caller then marshals the whole file.
+func spliceRuleFile(src []byte, before, after *models.RuleFile) ([]byte, bool) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil || len(doc.Content) == 0 {
+		return nil, false
+	}
+	groups := mappingValue(doc.Content[0], "groups")
+	if groups == nil || groups.Kind != yaml.SequenceNode || groups.Style&yaml.FlowStyle != 0 || len(groups.Content) != len(before.Groups) {
+		return nil, false
+	}
+	e := &ruleFileSplicer{lines: splitLinesKeepEnds(src), indent: detectIndent(&doc)}
+
+	ok := false
+	switch {
+	case len(after.Groups) == len(before.Groups):
+		changed := -1
+		for i := range before.Groups {
+			if !yamlEqual(before.Groups[i], after.Groups[i]) {
+				if changed >= 0 {
+					return nil, false
+				}
+				changed = i
+			}
+		}
+		if changed < 0 {
+			return src, true
+		}
+		ok = e.spliceRules(groups.Content[changed], before.Groups[changed], after.Groups[changed])
+	case len(after.Groups) == len(before.Groups)+1:
+		if !yamlEqual(before.Groups, after.Groups[:len(before.Groups)]) {
+			return nil, false
+		}
+		ok = e.appendItem(groups.Content, after.Groups[len(after.Groups)-1])
+	case len(after.Groups) == len(before.Groups)-1 && len(after.Groups) > 0:
+		i := removedIndex(len(before.Groups), func(i, j int) bool { return yamlEqual(before.Groups[i], after.Groups[j]) })
+		ok = i >= 0 && e.remove(groups.Content[i])
+	}
+	if !ok {
+		return nil, false
+	}
+
+	// The splice must say exactly what the struct edit says
+	out := []byte(strings.Join(e.lines, ""))
+	var check models.RuleFile
+	if err := yaml.Unmarshal(out, &check); err != nil || !yamlEqual(check, *after) {
+		return nil, false
+	}
+	return out, true
+}
+
+// spliceRules splices a change to the rules of one group, whose name and interval
+// must be unchanged
+func (e *ruleFileSplicer) spliceRules(groupNode *yaml.Node, before, after models.RuleGroup) bool {
+	if before.Name != after.Name || before.Interval != after.Interval {
+		return false
+	}
+	rules := mappingValue(groupNode, "rules")
+	if rules == nil || rules.Kind != yaml.SequenceNode || rules.Style&yaml.FlowStyle != 0 || len(rules.Content) != len(before.Rules) {
+		return false
+	}
+
+	switch {
+	case len(after.Rules) == len(before.Rules):
+		for i := range before.Rules {
+			if !yamlEqual(before.Rules[i], after.Rules[i]) {
+				return e.replace(rules.Content[i], after.Rules[i])
+			}
+		}
+	case len(after.Rules) == len(before.Rules)+1:
+		return e.appendItem(rules.Content, after.Rules[len(after.Rules)-1])
+	case len(after.Rules) == len(before.Rules)-1:
+		i := removedIndex(len(before.Rules), func(i, j int) bool { return yamlEqual(before.Rules[i], after.Rules[j]) })
+		return i >= 0 && e.remove(rules.Content[i])
+	}
+	return false
+}
+
+// ruleFileSplicer edits the lines of a rule file by sequence item. A splice makes one
+// edit, so the node line numbers it goes by are those of the original text.
+type ruleFileSplicer struct {
+	lines  []string // each with its line ending
+	indent int
+}
+
+// itemSpan is the lines [start, end) of a block sequence item whose content is node,
+// and the text before the node on its first line ("  - "). Trailing blank lines and
+// comments at the dash's indentation belong to what follows, so they are left out.
+func (e *ruleFileSplicer) itemSpan(node *yaml.Node) (start, end int, prefix string, ok bool) {
+	start = node.Line - 1
+	if start < 0 || start >= len(e.lines) || node.Column < 3 || len(e.lines[start]) < node.Column-1 {
+		return 0, 0, "", false
+	}
+	prefix = e.lines[start][:node.Column-1]
+	dash := strings.Index(prefix, "-")
+	if dash < 0 || strings.TrimSpace(prefix) != "-" || strings.TrimLeft(prefix[:dash], " ") != "" {
+		return 0, 0, "", false
+	}
+	end = start + 1
+	for ; end < len(e.lines); end++ {
+		line := e.lines[end]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " ")) <= dash {
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(e.lines[end-1]) == "" {
+		end--
+	}
+	return start, end, prefix, true
+}
+
+// replace rewrites the rule item node as rule, keeping the node's key order, comments
+// and scalar styles
+func (e *ruleFileSplicer) replace(node *yaml.Node, rule models.Rule) bool {
+	start, end, prefix, ok := e.itemSpan(node)
+	if !ok || node.Kind != yaml.MappingNode {
+		return false
+	}
+	if err := updateRuleNode(node, rule); err != nil {
+		return false
+	}
+	// Comments above the item are outside its span and stay where they are
+	node.HeadComment = ""
+	text, ok := e.render(node, prefix)
+	if !ok {
+		return false
+	}
+	e.lines = append(e.lines[:start], append(text, e.lines[end:]...)...)
+	return true
+}
+
+// appendItem adds v as a new item after the last of items, laid out like it
+func (e *ruleFileSplicer) appendItem(items []*yaml.Node, v interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+	_, end, prefix, ok := e.itemSpan(items[len(items)-1])
+	if !ok {
+		return false
+	}
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return false
+	}
+	text, ok := e.render(&node, prefix)
+	if !ok {
+		return false
+	}
+	if end > 0 && !strings.HasSuffix(e.lines[end-1], "\n") {
+		e.lines[end-1] += "\n"
+	}
+	e.lines = append(e.lines[:end], append(text, e.lines[end:]...)...)
+	return true
+}
+
+// remove deletes the item node, with the comment lines directly above it
+func (e *ruleFileSplicer) remove(node *yaml.Node) bool {
+	start, end, prefix, ok := e.itemSpan(node)
+	if !ok {
+		return false
+	}
+	dash := strings.Index(prefix, "-")
+	for start > 0 {
+		line := e.lines[start-1]
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "#") || len(line)-len(trimmed) != dash {
+			break
+		}
+		start--
+	}
+	e.lines = append(e.lines[:start], e.lines[end:]...)
+	return true
+}
+
+// render encodes node as a sequence item starting with prefix, the rest of its lines
+// indented to line up under the first
+func (e *ruleFileSplicer) render(node *yaml.Node, prefix string) ([]string, bool) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(e.indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, false
+	}
+	enc.Close()
+	pad := strings.Repeat(" ", len(prefix))
+	var out []string
+	for i, line := range splitLinesKeepEnds(buf.Bytes()) {
+		switch {
+		case i == 0:
+			out = append(out, prefix+line)
+		case strings.TrimSpace(line) == "":
+			out = append(out, line)
+		default:
+			out = append(out, pad+line)
+		}
+	}
+	return out, len(out) > 0
+}
+
+// updateRuleNode makes a rule mapping node say rule. Keys keep their order and
+// comments, changed scalars keep their style; keys the rule doesn't have are dropped
+// and new ones appended. Keys models.Rule doesn't know about are left alone.
+func updateRuleNode(node *yaml.Node, rule models.Rule) error {
+	scalars := map[string]string{"alert": rule.Alert, "record": rule.Record, "expr": rule.Expr, "for": rule.For}
+	maps := map[string]map[string]string{"labels": rule.Labels, "annotations": rule.Annotations}
+	order := []string{"alert", "record", "expr", "for", "labels", "annotations"}
+
+	seen := map[string]bool{}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			// Merged keys would shadow the ones set here in the struct view; leave it to
+			// the full marshal
+			return fmt.Errorf("merge key in rule")
+		}
+		v, isScalar := scalars[key.Value]
+		m, isMap := maps[key.Value]
+		switch {
+		case isScalar && v == "" && key.Value != "expr", isMap && len(m) == 0:
+			continue
+		case isScalar:
+			value = updateScalar(value, v)
+		case isMap:
+			value = updateMap(value, m)
+		}
+		seen[key.Value] = true
+		content = append(content, key, value)
+	}
+	for _, k := range order {
+		if seen[k] {
+			continue
+		}
+		if v, ok := scalars[k]; ok && v != "" {
+			content = append(content, strNode(k), strNode(v))
+		}
+		if m, ok := maps[k]; ok && len(m) > 0 {
+			content = append(content, strNode(k), updateMap(nil, m))
+		}
+	}
+	node.Content = content
+	return nil
+}
+
+func updateScalar(node *yaml.Node, v string) *yaml.Node {
+	if node.Kind != yaml.ScalarNode {
+		return strNode(v)
+	}
+	if node.Value == v && node.ShortTag() == "!!str" {
+		return node
+	}
+	node.Value, node.Tag = v, "!!str"
+	if strings.Contains(v, "\n") && node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+// updateMap makes a string map node hold m, keeping the order of surviving keys and
+// appending new ones sorted
+func updateMap(node *yaml.Node, m map[string]string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	seen := map[string]bool{}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		v, ok := m[key.Value]
+		if !ok {
+			continue
+		}
+		seen[key.Value] = true
+		content = append(content, key, updateScalar(node.Content[i+1], v))
+	}
+	var added []string
+	for k := range m {
+		if !seen[k] {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+	for _, k := range added {
+		content = append(content, strNode(k), strNode(m[k]))
+	}
+	node.Content = content
+	return node
+}
+
+func strNode(v string) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	if strings.Contains(v, "\n") {
+		n.Style = yaml.LiteralStyle
+	}
+	return n
+}
+
+// mappingValue is the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// detectIndent is the file's indentation step, taken from its first nested block
+// mapping; 2 if it has none
+func detectIndent(node *yaml.Node) int {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 && value.Line > key.Line {
+				if step := value.Column - key.Column; step > 0 {
+					return step
+				}
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if step := detectIndent(child); step != 2 {
+			return step
+		}
+	}
+	return 2
+}
+
+// removedIndex finds the one item of n that was removed, given same(i, j) comparing
+// item i before with item j after; -1 if no single removal explains the change
+func removedIndex(n int, same func(i, j int) bool) int {
+	i := 0
+	for i < n-1 && same(i, i) {
+		i++
+	}
+	for j := i; j < n-1; j++ {
+		if !same(j+1, j) {
+			return -1
+		}
+	}
+	return i
+}
+
+// yamlEqual compares two values as they would be written, so nil and empty maps match
+func yamlEqual(a, b interface{}) bool {
+	da, errA := yaml.Marshal(a)
+	db, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+func splitLinesKeepEnds(data []byte) []string {
+	var lines []string
+	for _, l := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(l) > 0 {
+			lines = append(lines, string(l))
+		}
+	}
+	return lines
+}
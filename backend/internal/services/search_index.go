@@ -0,0 +1,390 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+const (
+	SearchReindexLockName = "search_reindex"
+	SearchReindexLockTTL  = 30 * time.Minute
+	searchIndexBatchSize  = 500
+	// SearchMaxHits caps the issue IDs a search returns (the default index.max_result_window)
+	SearchMaxHits = 10000
+)
+
+// SearchIndex mirrors the text fields of issues into an Elasticsearch/OpenSearch index so
+// full-text and label queries don't scan the issues table. Aggregates stay in SQL.
+type SearchIndex struct {
+	client   *http.Client
+	baseURL  string
+	index    string
+	username string
+	password string
+}
+
+// SearchDocument is the indexed form of an issue
+type SearchDocument struct {
+	ID             string   `json:"-"`
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	AlertSignature string   `json:"alert_signature"`
+	Labels         []string `json:"labels"`
+	Created        string   `json:"created"` // "2006-01-02 15:04:05", UTC
+	IsAlert        bool     `json:"is_alert"`
+}
+
+// searchIndexMapping keeps labels exact-match and created as a date for range filters
+const searchIndexMapping = `{
+	"mappings": {
+		"properties": {
+			"title":           {"type": "text"},
+			"description":     {"type": "text"},
+			"alert_signature": {"type": "text", "fields": {"keyword": {"type": "keyword", "ignore_above": 512}}},
+			"labels":          {"type": "keyword"},
+			"created":         {"type": "date", "format": "yyyy-MM-dd HH:mm:ss"},
+			"is_alert":        {"type": "boolean"}
+		}
+	}
+}`
+
+var (
+	searchIndex     *SearchIndex
+	searchIndexOnce sync.Once
+)
+
+// GetSearchIndex returns the configured index, or nil when SEARCH_URL is not set
+func GetSearchIndex() *SearchIndex {
+	searchIndexOnce.Do(func() {
+		searchIndex = NewSearchIndex()
+	})
+	return searchIndex
+}
+
+// NewSearchIndex reads SEARCH_URL, SEARCH_INDEX (default "alert-issues") and the optional
+// SEARCH_USERNAME / SEARCH_PASSWORD basic auth. Returns nil when SEARCH_URL is not set.
+func NewSearchIndex() *SearchIndex {
+	base := strings.TrimSuffix(config.Lookup("SEARCH_URL"), "/")
+	if base == "" {
+		return nil
+	}
+	index := config.Lookup("SEARCH_INDEX")
+	if index == "" {
+		index = "alert-issues"
+	}
+	return &SearchIndex{
+		client:   NewOutboundClient(30 * time.Second),
+		baseURL:  base,
+		index:    index,
+		username: config.Lookup("SEARCH_USERNAME"),
+		password: config.Lookup("SEARCH_PASSWORD"),
+	}
+}
+
+// Name is the index name
+func (s *SearchIndex) Name() string {
+	return s.index
+}
+
+func (s *SearchIndex) do(ctx context.Context, method, path, contentType string, body []byte, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg := string(data)
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		return resp.StatusCode, fmt.Errorf("search %s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(msg))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("invalid search response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// EnsureIndex creates the index with its mapping if it does not exist yet and reports
+// whether it did, in which case the caller should backfill it
+func (s *SearchIndex) EnsureIndex(ctx context.Context) (bool, error) {
+	status, err := s.do(ctx, http.MethodHead, "/"+url.PathEscape(s.index), "", nil, nil)
+	if err == nil {
+		return false, nil
+	}
+	if status != http.StatusNotFound {
+		return false, err
+	}
+	if _, err := s.do(ctx, http.MethodPut, "/"+url.PathEscape(s.index), "application/json", []byte(searchIndexMapping), nil); err != nil {
+		return false, fmt.Errorf("failed to create index %s: %w", s.index, err)
+	}
+	return true, nil
+}
+
+// Count returns the number of indexed documents
+func (s *SearchIndex) Count(ctx context.Context) (int64, error) {
+	var resp struct {
+		Count int64 `json:"count"`
+	}
+	_, err := s.do(ctx, http.MethodGet, "/"+url.PathEscape(s.index)+"/_count", "", nil, &resp)
+	return resp.Count, err
+}
+
+// HasDocument reports whether an issue is in the index
+func (s *SearchIndex) HasDocument(ctx context.Context, id string) (bool, error) {
+	status, err := s.do(ctx, http.MethodGet, "/"+url.PathEscape(s.index)+"/_doc/"+url.PathEscape(id), "", nil, nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteDocument removes an issue from the index; a missing document is not an error
+func (s *SearchIndex) DeleteDocument(ctx context.Context, id string) error {
+	status, err := s.do(ctx, http.MethodDelete, "/"+url.PathEscape(s.index)+"/_doc/"+url.PathEscape(id), "", nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// IndexDocuments upserts documents with one bulk request
+func (s *SearchIndex) IndexDocuments(ctx context.Context, docs []SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, d := range docs {
+		enc.Encode(map[string]interface{}{"index": map[string]string{"_index": s.index, "_id": d.ID}})
+		enc.Encode(d)
+	}
+
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if _, err := s.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes(), &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		failed, first := 0, ""
+		for _, item := range resp.Items {
+			for _, r := range item {
+				if len(r.Error) > 0 && string(r.Error) != "null" {
+					if failed == 0 {
+						first = r.ID + ": " + string(r.Error)
+					}
+					failed++
+				}
+			}
+		}
+		return fmt.Errorf("%d of %d documents failed to index (first: %s)", failed, len(docs), first)
+	}
+	return nil
+}
+
+// SearchQuery selects issues by text and labels within a created range (inclusive,
+// "2006-01-02 15:04:05"). Text uses simple_query_string syntax; all labels must match.
+type SearchQuery struct {
+	Text   string
+	Labels []string
+	Start  string
+	End    string
+}
+
+// Search returns the IDs of matching issues, newest first, at most SearchMaxHits
+func (s *SearchIndex) Search(ctx context.Context, q SearchQuery) ([]string, error) {
+	must, filter := []interface{}{}, []interface{}{}
+	if q.Text != "" {
+		must = append(must, map[string]interface{}{
+			"simple_query_string": map[string]interface{}{
+				"query":            q.Text,
+				"fields":           []string{"title^2", "alert_signature", "description"},
+				"default_operator": "and",
+			},
+		})
+	}
+	for _, l := range q.Labels {
+		filter = append(filter, map[string]interface{}{"term": map[string]string{"labels": l}})
+	}
+	if q.Start != "" || q.End != "" {
+		r := map[string]string{}
+		if q.Start != "" {
+			r["gte"] = q.Start
+		}
+		if q.End != "" {
+			r["lte"] = q.End
+		}
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"created": r}})
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"size":    SearchMaxHits,
+		"_source": false,
+		"query":   map[string]interface{}{"bool": map[string]interface{}{"must": must, "filter": filter}},
+		"sort":    []interface{}{map[string]string{"created": "desc"}},
+	})
+
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if _, err := s.do(ctx, http.MethodPost, "/"+url.PathEscape(s.index)+"/_search", "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resp.Hits.Hits))
+	for _, h := range resp.Hits.Hits {
+		ids = append(ids, h.ID)
+	}
+	return ids, nil
+}
+
+// loadSearchDocuments reads the indexed fields of the given issues
+func loadSearchDocuments(ctx context.Context, sqlDB *sql.DB, ids []string) ([]SearchDocument, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := sqlDB.QueryContext(ctx, db.Rebind(`
+		SELECT id, COALESCE(title, ''), COALESCE(description, ''), COALESCE(alert_signature, ''),
+			COALESCE(labels, ''), REPLACE(created, ' UTC', ''), is_alert
+		FROM issues WHERE id IN (?`+strings.Repeat(",?", len(ids)-1)+`)`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []SearchDocument
+	for rows.Next() {
+		var d SearchDocument
+		var labels string
+		if err := rows.Scan(&d.ID, &d.Title, &d.Description, &d.AlertSignature, &labels, &d.Created, &d.IsAlert); err != nil {
+			return nil, err
+		}
+		d.Labels = []string{}
+		json.Unmarshal([]byte(labels), &d.Labels)
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// IndexIssues mirrors the given issues from the database into the index
+func (s *SearchIndex) IndexIssues(ctx context.Context, sqlDB *sql.DB, ids []string) error {
+	for start := 0; start < len(ids); start += searchIndexBatchSize {
+		end := start + searchIndexBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		docs, err := loadSearchDocuments(ctx, sqlDB, ids[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to load issues for indexing: %w", err)
+		}
+		if err := s.IndexDocuments(ctx, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reindex mirrors every stored issue, paging by id. onProgress gets the running count.
+func (s *SearchIndex) Reindex(ctx context.Context, sqlDB *sql.DB, onProgress func(indexed int)) (int, error) {
+	if _, err := s.EnsureIndex(ctx); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	lastID := ""
+	for {
+		rows, err := sqlDB.QueryContext(ctx, db.Rebind("SELECT id FROM issues WHERE id > ? ORDER BY id LIMIT ?"), lastID, searchIndexBatchSize)
+		if err != nil {
+			return indexed, err
+		}
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return indexed, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			break
+		}
+
+		if err := s.IndexIssues(ctx, sqlDB, ids); err != nil {
+			return indexed, err
+		}
+		indexed += len(ids)
+		lastID = ids[len(ids)-1]
+		onProgress(indexed)
+	}
+	slog.Info("Search index rebuilt", "index", s.index, "issues", indexed)
+	return indexed, nil
+}
+
+// queueSearchIndex marks a stored issue for mirroring, flushing once a batch is full
+func (u *DataUpdater) queueSearchIndex(id string) {
+	if GetSearchIndex() == nil {
+		return
+	}
+	u.indexQueue = append(u.indexQueue, id)
+	if len(u.indexQueue) >= searchIndexBatchSize {
+		u.flushSearchIndex()
+	}
+}
+
+// flushSearchIndex mirrors queued issues. Failures are logged only: SQL stays the source
+// of truth and POST /api/admin/search/reindex repairs the index.
+func (u *DataUpdater) flushSearchIndex() {
+	idx := GetSearchIndex()
+	if idx == nil || len(u.indexQueue) == 0 {
+		return
+	}
+	ids := u.indexQueue
+	u.indexQueue = nil
+	if err := idx.IndexIssues(context.Background(), u.db, ids); err != nil {
+		u.logger.Warn("Failed to index issues for search", "issues", len(ids), "err", err)
+	}
+}
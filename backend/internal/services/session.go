@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// SignSession signs v into a cookie value of the form "<expiry>.<payload>.<hmac>",
+// the same layout as share tokens
+func SignSession(secret string, v interface{}, ttl time.Duration) (string, time.Time, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().UTC().Add(ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	sig := strings.TrimPrefix(ComputeSignature(secret, exp, []byte(encoded)), "sha256=")
+	return exp + "." + encoded + "." + sig, expires, nil
+}
+
+// ParseSession verifies a value from SignSession and decodes it into v
+func ParseSession(secret, value string, v interface{}) error {
+	parts := strings.Split(value, ".")
+	if secret == "" || len(parts) != 3 {
+		return ErrInvalidSession
+	}
+	exp, encoded, sig := parts[0], parts[1], parts[2]
+	if !VerifySignature(secret, exp, []byte(encoded), "sha256="+sig) {
+		return ErrInvalidSession
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return ErrInvalidSession
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, v) != nil {
+		return ErrInvalidSession
+	}
+	return nil
+}
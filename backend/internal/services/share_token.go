@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Share scope kinds
+const (
+	ShareKindSnapshot = "snapshot"
+	ShareKindView     = "view"
+)
+
+var (
+	ErrShareLinksDisabled = errors.New("share links are disabled (SHARE_LINK_SECRET not configured)")
+	ErrInvalidShareToken  = errors.New("invalid share token")
+	ErrShareTokenExpired  = errors.New("share token has expired")
+)
+
+// ShareScope is what a share token grants read-only access to
+type ShareScope struct {
+	Kind       string `json:"kind"`
+	SnapshotID uint   `json:"snapshot_id,omitempty"`
+	Query      string `json:"query,omitempty"` // dashboard query string for view links
+	Anonymize  bool   `json:"anonymize,omitempty"`
+}
+
+func shareSecret() string {
+	return config.Lookup("SHARE_LINK_SECRET")
+}
+
+// NewShareToken signs scope into a token of the form "<expiry>.<payload>.<hmac>"
+func NewShareToken(scope ShareScope, ttl time.Duration) (string, time.Time, error) {
+	secret := shareSecret()
+	if secret == "" {
+		return "", time.Time{}, ErrShareLinksDisabled
+	}
+
+	payload, err := json.Marshal(scope)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().UTC().Add(ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	sig := strings.TrimPrefix(ComputeSignature(secret, exp, []byte(encoded)), "sha256=")
+
+	return exp + "." + encoded + "." + sig, expires, nil
+}
+
+// ParseShareToken verifies a token's signature and expiry and returns its scope
+func ParseShareToken(token string) (ShareScope, time.Time, error) {
+	var scope ShareScope
+	secret := shareSecret()
+	if secret == "" {
+		return scope, time.Time{}, ErrShareLinksDisabled
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return scope, time.Time{}, ErrInvalidShareToken
+	}
+	exp, encoded, sig := parts[0], parts[1], parts[2]
+	if !VerifySignature(secret, exp, []byte(encoded), "sha256="+sig) {
+		return scope, time.Time{}, ErrInvalidShareToken
+	}
+
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return scope, time.Time{}, ErrInvalidShareToken
+	}
+	expires := time.Unix(unix, 0).UTC()
+	if time.Now().After(expires) {
+		return scope, expires, ErrShareTokenExpired
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, &scope) != nil {
+		return scope, time.Time{}, ErrInvalidShareToken
+	}
+	return scope, expires, nil
+}
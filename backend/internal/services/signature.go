@@ -0,0 +1,23 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ComputeSignature returns the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Binding the timestamp into the MAC lets receivers reject replays.
+func ComputeSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature compares a received signature against the expected one in constant time
+func VerifySignature(secret, timestamp string, body []byte, signature string) bool {
+	expected := ComputeSignature(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// SuppressedByMuteRule is reported by the simulation besides the rules notify config's
+// suppression reasons
+const SuppressedByMuteRule = "mute_rule"
+
+// MuteRule suppresses alerts matching every non-empty field. Signature matches as a
+// case-insensitive substring, the rest exactly (components: any of the alert's).
+type MuteRule struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	ClusterID string `json:"cluster_id"`
+	TenantID  string `json:"tenant_id"`
+	Component string `json:"component"`
+	Priority  string `json:"priority"`
+}
+
+func (r MuteRule) empty() bool {
+	return r.Signature == "" && r.ClusterID == "" && r.TenantID == "" && r.Component == "" && r.Priority == ""
+}
+
+func (r MuteRule) matches(a simulatedAlert) bool {
+	if r.Signature != "" && !strings.Contains(strings.ToLower(a.AlertSignature), strings.ToLower(r.Signature)) {
+		return false
+	}
+	if r.ClusterID != "" && r.ClusterID != a.ClusterID {
+		return false
+	}
+	if r.TenantID != "" && r.TenantID != a.TenantID {
+		return false
+	}
+	if r.Priority != "" && !strings.EqualFold(r.Priority, a.Priority) {
+		return false
+	}
+	if r.Component != "" {
+		for _, c := range a.components {
+			if strings.EqualFold(c, r.Component) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// NotificationPolicy is one side of a simulation. A nil NotifyConfig is not enforced,
+// otherwise it suppresses alerts as it does at ingestion.
+type NotificationPolicy struct {
+	NotifyConfig      *RulesNotifyConfig
+	NotificationRules []models.NotificationRule
+	MuteRules         []MuteRule
+}
+
+// ValidateMuteRules checks proposed mute rules, returning one problem per invalid rule
+func ValidateMuteRules(rules []MuteRule) []string {
+	var problems []string
+	for i, r := range rules {
+		if r.empty() {
+			problems = append(problems, fmt.Sprintf("mute rule %d has no conditions and would mute every alert", i+1))
+		}
+	}
+	return problems
+}
+
+type simulatedAlert struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Created        string `json:"created"`
+	AlertSignature string `json:"alert_signature"`
+	ClusterID      string `json:"cluster_id"`
+	TenantID       string `json:"tenant_id"`
+	Priority       string `json:"priority"`
+	BizType        string `json:"-"`
+	ComponentsJSON string `gorm:"column:components" json:"-"`
+
+	components []string
+	at         time.Time
+}
+
+// suppression returns why policy suppresses the alert, or "" when it is notified
+func (p *NotificationPolicy) suppression(a simulatedAlert) string {
+	for _, r := range p.MuteRules {
+		if r.matches(a) {
+			return SuppressedByMuteRule
+		}
+	}
+	return p.NotifyConfig.Suppression(a.BizType, a.TenantID, a.ClusterID)
+}
+
+// SimulatedChange is an alert whose outcome differs between the two policies
+type SimulatedChange struct {
+	simulatedAlert
+	Reason string `json:"reason"`
+}
+
+// SimulationSide summarizes one policy over the replayed alerts
+type SimulationSide struct {
+	Suppressed    int            `json:"suppressed"`
+	BySuppression map[string]int `json:"by_suppression"`
+	Notifications int            `json:"notifications"`
+	ByRule        map[string]int `json:"by_rule"`
+}
+
+// RuleNotificationDelta compares one notification rule's sends
+type RuleNotificationDelta struct {
+	Rule     string `json:"rule"`
+	Current  int    `json:"current"`
+	Proposed int    `json:"proposed"`
+	Delta    int    `json:"delta"`
+}
+
+// SimulationResult compares the current and proposed policies over the same alerts
+type SimulationResult struct {
+	Days            int                     `json:"days"`
+	Alerts          int                     `json:"alerts"`
+	AlreadyMuted    int                     `json:"already_muted"` // muted by hand, left out of both sides
+	Current         SimulationSide          `json:"current"`
+	Proposed        SimulationSide          `json:"proposed"`
+	NewlySuppressed int                     `json:"newly_suppressed"`
+	NoLongerMuted   int                     `json:"no_longer_suppressed"`
+	Rules           []RuleNotificationDelta `json:"rules"`
+	Samples         []SimulatedChange       `json:"samples"`
+}
+
+const simulationSampleLimit = 50
+
+// SimulateNotifications replays the last days of ingested alerts, oldest first, through
+// both policies: suppression first, then each enabled notification rule, which fires
+// once Threshold matching alerts arrive within WindowMinutes and then starts over.
+func SimulateNotifications(ctx context.Context, db *gorm.DB, days int, current, proposed *NotificationPolicy) (*SimulationResult, error) {
+	start := time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+
+	var alerts []simulatedAlert
+	err := db.WithContext(ctx).Table("issues").
+		Select("id, title, created, alert_signature, cluster_id, tenant_id, priority, biz_type, components").
+		Where("is_alert = TRUE AND created_at_utc >= ?", start).
+		Where("id NOT IN (SELECT issue_id FROM muted_issues WHERE "+MutedIssueActive+")", time.Now().UTC()).
+		Order("created, id").
+		Scan(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulationResult{Days: days, Alerts: len(alerts), Samples: []SimulatedChange{}}
+	var muted int64
+	db.WithContext(ctx).Table("muted_issues").
+		Joins("JOIN issues ON issues.id = muted_issues.issue_id").
+		Where("issues.is_alert = TRUE AND issues.created_at_utc >= ?", start).
+		Where(MutedIssueActive, time.Now().UTC()).
+		Count(&muted)
+	result.AlreadyMuted = int(muted)
+
+	curRun := newRuleReplay(current.NotificationRules)
+	propRun := newRuleReplay(proposed.NotificationRules)
+	result.Current = SimulationSide{BySuppression: map[string]int{}}
+	result.Proposed = SimulationSide{BySuppression: map[string]int{}}
+
+	for i := range alerts {
+		a := &alerts[i]
+		json.Unmarshal([]byte(a.ComponentsJSON), &a.components)
+		a.at, _ = time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(a.Created, " UTC"))
+
+		curReason := current.suppression(*a)
+		propReason := proposed.suppression(*a)
+		if curReason != "" {
+			result.Current.Suppressed++
+			result.Current.BySuppression[curReason]++
+		} else {
+			curRun.observe(*a)
+		}
+		if propReason != "" {
+			result.Proposed.Suppressed++
+			result.Proposed.BySuppression[propReason]++
+		} else {
+			propRun.observe(*a)
+		}
+
+		if (curReason == "") == (propReason == "") {
+			continue
+		}
+		change := SimulatedChange{simulatedAlert: *a, Reason: propReason}
+		if propReason != "" {
+			result.NewlySuppressed++
+		} else {
+			result.NoLongerMuted++
+			change.Reason = "unsuppressed:" + curReason
+		}
+		if len(result.Samples) < simulationSampleLimit {
+			result.Samples = append(result.Samples, change)
+		}
+	}
+
+	result.Current.ByRule, result.Current.Notifications = curRun.fired, curRun.total()
+	result.Proposed.ByRule, result.Proposed.Notifications = propRun.fired, propRun.total()
+
+	names := map[string]bool{}
+	for name := range curRun.fired {
+		names[name] = true
+	}
+	for name := range propRun.fired {
+		names[name] = true
+	}
+	for name := range names {
+		c, p := curRun.fired[name], propRun.fired[name]
+		result.Rules = append(result.Rules, RuleNotificationDelta{Rule: name, Current: c, Proposed: p, Delta: p - c})
+	}
+	sort.Slice(result.Rules, func(i, j int) bool { return result.Rules[i].Rule < result.Rules[j].Rule })
+	return result, ctx.Err()
+}
+
+// ruleReplay counts how often each enabled notification rule would have fired
+type ruleReplay struct {
+	rules  []models.NotificationRule
+	window map[string][]time.Time
+	fired  map[string]int
+}
+
+func newRuleReplay(rules []models.NotificationRule) *ruleReplay {
+	r := &ruleReplay{window: map[string][]time.Time{}, fired: map[string]int{}}
+	for _, rule := range rules {
+		if rule.Enabled {
+			r.rules = append(r.rules, rule)
+			r.fired[rule.Name] = 0
+		}
+	}
+	return r
+}
+
+func (r *ruleReplay) observe(a simulatedAlert) {
+	for _, rule := range r.rules {
+		if !notificationRuleMatches(rule, a.components, a.Priority) {
+			continue
+		}
+		var fired bool
+		if r.window[rule.Name], fired = advanceNotificationWindow(r.window[rule.Name], a.at, rule); fired {
+			r.fired[rule.Name]++
+		}
+	}
+}
+
+func (r *ruleReplay) total() int {
+	n := 0
+	for _, c := range r.fired {
+		n += c
+	}
+	return n
+}
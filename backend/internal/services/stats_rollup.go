@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	StatsRollupLockName = "stats_rollup"
+	StatsRollupLockTTL  = 10 * time.Minute
+	// statsRollupRecentDays are rolled up again after every sync: their alerts are the
+	// ones whose priority and components still change as they are refreshed
+	statsRollupRecentDays = 3
+	// statsRollupChunkDays bounds the days replaced in one transaction
+	statsRollupChunkDays = 31
+)
+
+// statsDay is the stored date of an issue, YYYY-MM-DD
+const statsDay = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 10)"
+
+// StatsRollup maintains daily_stats and component_stats, the per-day alert counts behind
+// the dashboard trend and top components. Counts are taken under one issue condition
+// (the unfiltered dashboard's); rows carry its hash, so when the condition changes
+// (test clusters, mute rules) readers see the rollups as stale and use raw data.
+type StatsRollup struct {
+	db     *gorm.DB
+	where  string
+	hash   string
+	logger *slog.Logger
+}
+
+func NewStatsRollup(db *gorm.DB, where string) *StatsRollup {
+	return &StatsRollup{db: db, where: where, hash: StatsFilterHash(where), logger: slog.Default().With("service", "stats_rollup")}
+}
+
+// StatsFilterHash identifies the issue condition rollups were taken under
+func StatsFilterHash(where string) string {
+	sum := sha256.Sum256([]byte(where))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Hash is the hash of the rollup's condition, as stored on its rows
+func (r *StatsRollup) Hash() string {
+	return r.hash
+}
+
+// Update rolls up the given days (the created dates of issues a sync stored), the last
+// few days and any day since the last rolled-up one. With nothing rolled up under the
+// condition yet it rebuilds instead. Local changes to older alerts (component overrides,
+// re-enrichment) show once those days are rolled up again, e.g. by Rebuild.
+func (r *StatsRollup) Update(ctx context.Context, days []string) (int, error) {
+	var last string
+	if err := r.db.WithContext(ctx).Model(&models.DailyStat{}).Where("filter_hash = ?", r.hash).
+		Select("COALESCE(MAX(date), '')").Scan(&last).Error; err != nil {
+		return 0, err
+	}
+	if last == "" {
+		return r.Rebuild(ctx)
+	}
+
+	today := time.Now().UTC()
+	from := today.AddDate(0, 0, -statsRollupRecentDays+1)
+	if d, err := time.Parse("2006-01-02", last); err == nil && d.Before(from) {
+		from = d
+	}
+	dirty := map[string]bool{}
+	for _, day := range days {
+		dirty[day] = true
+	}
+	for d := from; !d.After(today); d = d.AddDate(0, 0, 1) {
+		dirty[d.Format("2006-01-02")] = true
+	}
+	return r.rollDays(ctx, dirty)
+}
+
+// Rebuild rolls up every day from the first alert to today and drops rows taken under
+// other conditions
+func (r *StatsRollup) Rebuild(ctx context.Context) (int, error) {
+	dbc := r.db.WithContext(ctx)
+	var first string
+	if err := dbc.Raw(`SELECT COALESCE(MIN(` + statsDay + `), '') FROM issues WHERE ` + r.where).Scan(&first).Error; err != nil {
+		return 0, err
+	}
+
+	if err := dbc.Where("filter_hash <> ?", r.hash).Delete(&models.DailyStat{}).Error; err != nil {
+		return 0, err
+	}
+	if err := dbc.Where("filter_hash <> ?", r.hash).Delete(&models.ComponentStat{}).Error; err != nil {
+		return 0, err
+	}
+
+	today := time.Now().UTC()
+	start, err := time.Parse("2006-01-02", first)
+	if err != nil || start.After(today) {
+		start = today
+	}
+	dirty := map[string]bool{}
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		dirty[d.Format("2006-01-02")] = true
+	}
+	return r.rollDays(ctx, dirty)
+}
+
+// Covers reports whether every day from start to end (YYYY-MM-DD) is rolled up under
+// the condition
+func (r *StatsRollup) Covers(ctx context.Context, start, end string) (bool, error) {
+	from, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return false, err
+	}
+	to, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return false, err
+	}
+	want := int64(to.Sub(from).Hours()/24) + 1
+
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&models.DailyStat{}).
+		Where("filter_hash = ? AND date BETWEEN ? AND ?", r.hash, start, end).Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n == want, nil
+}
+
+// rollDays recomputes the given days, statsRollupChunkDays per transaction
+func (r *StatsRollup) rollDays(ctx context.Context, dirty map[string]bool) (int, error) {
+	days := make([]string, 0, len(dirty))
+	for day := range dirty {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	for start := 0; start < len(days); start += statsRollupChunkDays {
+		end := start + statsRollupChunkDays
+		if end > len(days) {
+			end = len(days)
+		}
+		if err := r.rollChunk(ctx, days[start:end]); err != nil {
+			return start, fmt.Errorf("rolling up %s to %s: %w", days[start], days[end-1], err)
+		}
+	}
+	r.logger.Debug("Rolled up alert stats", "days", len(days))
+	return len(days), nil
+}
+
+func (r *StatsRollup) rollChunk(ctx context.Context, days []string) error {
+	dbc := r.db.WithContext(ctx)
+	now := time.Now().UTC()
+	// days is sorted; the range lets the created_at_utc index narrow the scan
+	from, to := days[0]+" 00:00:00", days[len(days)-1]+" 23:59:59"
+
+	var daily []models.DailyStat
+	err := dbc.Raw(`
+		SELECT `+statsDay+` as date,
+			COUNT(*) as total_alerts,
+			SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical_count,
+			SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END) as major_count,
+			SUM(CASE WHEN priority = 'Warning' THEN 1 ELSE 0 END) as warning_count,
+			SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END) as prod_alerts,
+			SUM(CASE WHEN alert_signature LIKE '[PROD]%' AND priority = 'Critical' THEN 1 ELSE 0 END) as prod_critical,
+			SUM(CASE WHEN alert_signature LIKE '[PROD]%' AND priority = 'Major' THEN 1 ELSE 0 END) as prod_major,
+			SUM(CASE WHEN alert_signature LIKE '[PROD]%' AND priority = 'Warning' THEN 1 ELSE 0 END) as prod_warning
+		FROM issues WHERE `+r.where+` AND created_at_utc BETWEEN ? AND ? AND `+statsDay+` IN ?
+		GROUP BY date`, from, to, days).Scan(&daily).Error
+	if err != nil {
+		return err
+	}
+	// Days without alerts get a zero row, so readers can tell them from days not rolled up
+	byDay := make(map[string]models.DailyStat, len(daily))
+	for _, d := range daily {
+		byDay[d.Date] = d
+	}
+	rows := make([]models.DailyStat, 0, len(days))
+	for _, day := range days {
+		d := byDay[day]
+		d.Date, d.FilterHash, d.UpdatedAt = day, r.hash, now
+		rows = append(rows, d)
+	}
+
+	// Primary counts rank alerts by their first component, like the raw dashboard query
+	var primary []models.ComponentStat
+	err = dbc.Raw(`
+		SELECT `+statsDay+` as date,
+			CASE
+				WHEN components IS NULL OR components = '[]' OR components = '' THEN 'No Component'
+				ELSE json_extract(components, '$[0]')
+			END as component,
+			COUNT(*) as primary_count,
+			SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END) as primary_prod_count
+		FROM issues WHERE `+r.where+` AND created_at_utc BETWEEN ? AND ? AND `+statsDay+` IN ?
+		GROUP BY date, component`, from, to, days).Scan(&primary).Error
+	if err != nil {
+		return err
+	}
+	var listed []models.ComponentStat
+	err = dbc.Raw(`
+		SELECT `+statsDay+` as date, j.value as component, COUNT(*) as alert_count
+		FROM issues, `+db.JSONEach("issues.components", "j")+`
+		WHERE `+r.where+` AND created_at_utc BETWEEN ? AND ? AND `+statsDay+` IN ?
+		GROUP BY date, j.value`, from, to, days).Scan(&listed).Error
+	if err != nil {
+		return err
+	}
+	type key struct{ component, date string }
+	byComponent := map[key]*models.ComponentStat{}
+	for i := range listed {
+		byComponent[key{listed[i].Component, listed[i].Date}] = &listed[i]
+	}
+	for _, p := range primary {
+		if s, ok := byComponent[key{p.Component, p.Date}]; ok {
+			s.PrimaryCount, s.PrimaryProdCount = p.PrimaryCount, p.PrimaryProdCount
+		} else {
+			p := p
+			byComponent[key{p.Component, p.Date}] = &p
+		}
+	}
+	components := make([]models.ComponentStat, 0, len(byComponent))
+	for _, s := range byComponent {
+		s.FilterHash, s.UpdatedAt = r.hash, now
+		components = append(components, *s)
+	}
+
+	return dbc.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("date IN ?", days).Delete(&models.DailyStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("date IN ?", days).Delete(&models.ComponentStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(rows, 100).Error; err != nil {
+			return err
+		}
+		if len(components) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(components, 100).Error
+	})
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Synthetic alerts are marked so they are easy to spot if kept: the key uses this
+// project and the title this prefix, and they carry SyntheticAlertLabel
+const (
+	SyntheticAlertProject = "SYNTH"
+	SyntheticAlertPrefix  = "[SYNTHETIC]"
+	SyntheticAlertLabel   = "synthetic"
+)
+
+// Synthetic alert stage outcomes
+const (
+	StagePassed  = "passed"
+	StageFailed  = "failed"
+	StageSkipped = "skipped"
+)
+
+// SyntheticAlertRequest describes the alert to inject; empty fields get test defaults
+type SyntheticAlertRequest struct {
+	Component string `json:"component"` // expected attribution; sent as JIRA component and alert label
+	Priority  string `json:"priority"`  // as JIRA would send it, default Critical
+	ClusterID string `json:"cluster_id"`
+	TenantID  string `json:"tenant_id"`
+	BizType   string `json:"biz_type"`
+	Deliver   bool   `json:"deliver"` // send a test message through every matched notification rule
+	Keep      bool   `json:"keep"`    // leave the alert stored; dashboards will count it
+}
+
+// SyntheticStage is the outcome of one pipeline stage
+type SyntheticStage struct {
+	Stage      string `json:"stage"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// SyntheticAlertResult reports every stage; Passed is false if any stage failed
+type SyntheticAlertResult struct {
+	IssueID string           `json:"issue_id"`
+	Passed  bool             `json:"passed"`
+	Kept    bool             `json:"kept"`
+	Stages  []SyntheticStage `json:"stages"`
+}
+
+func (r *SyntheticAlertResult) stage(name string, started time.Time, status, detail string) {
+	r.Stages = append(r.Stages, SyntheticStage{
+		Stage:      name,
+		Status:     status,
+		Detail:     detail,
+		DurationMs: time.Since(started).Milliseconds(),
+	})
+	if status == StageFailed {
+		r.Passed = false
+	}
+}
+
+// InjectSyntheticAlert pushes a marked test alert through the JIRA ingester (extract and
+// upsert), checks the enrichment, the search index mirror and notification routing, and
+// optionally delivers a test message. Unless req.Keep is set the alert is removed again.
+func InjectSyntheticAlert(ctx context.Context, gdb *gorm.DB, req SyntheticAlertRequest) (*SyntheticAlertResult, error) {
+	if req.Priority == "" {
+		req.Priority = "Critical"
+	}
+	if req.ClusterID == "" {
+		req.ClusterID = "synthetic-cluster"
+	}
+	if req.TenantID == "" {
+		req.TenantID = "synthetic-tenant"
+	}
+
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, err
+	}
+	u := NewOfflineDataUpdater(sqlDB)
+	src, ok := u.sources[IngestSourceJira]
+	if !ok {
+		return nil, fmt.Errorf("ingestion source %s is not registered", IngestSourceJira)
+	}
+
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	id := SyntheticAlertProject + "-" + hex.EncodeToString(buf)
+	record, err := json.Marshal(syntheticJiraIssue(id, req))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyntheticAlertResult{IssueID: id, Passed: true, Kept: req.Keep}
+	if !req.Keep {
+		defer func() {
+			started := time.Now()
+			if err := removeSyntheticAlert(ctx, gdb, id); err != nil {
+				result.stage("cleanup", started, StageFailed, err.Error())
+				return
+			}
+			result.stage("cleanup", started, StagePassed, "removed "+id)
+		}()
+	}
+
+	// Ingestion: the same extract/upsert path a JIRA sync takes
+	started := time.Now()
+	if !u.processRecord(src, record) {
+		var failure string
+		gdb.WithContext(ctx).Model(&models.FailedIssue{}).Where("issue_id = ?", id).Pluck("error", &failure)
+		if failure == "" {
+			failure = "record could not be extracted, see server log"
+		}
+		result.stage("ingest", started, StageFailed, failure)
+		return result, nil
+	}
+	var stored models.Issue
+	if err := gdb.WithContext(ctx).Where("id = ?", id).First(&stored).Error; err != nil {
+		result.stage("ingest", started, StageFailed, "alert not found after upsert: "+err.Error())
+		return result, nil
+	}
+	result.stage("ingest", started, StagePassed, "stored "+id+" through the "+src.Name()+" source")
+
+	// Enrichment: extraction, normalization and attribution
+	started = time.Now()
+	var components []string
+	json.Unmarshal([]byte(stored.ComponentsJSON), &components)
+	if problems := syntheticEnrichmentProblems(stored, components, req); len(problems) > 0 {
+		result.stage("enrich", started, StageFailed, strings.Join(problems, "; "))
+	} else {
+		attributedBy := stored.AttributedBy
+		if attributedBy == "" {
+			attributedBy = "none"
+		}
+		result.stage("enrich", started, StagePassed, fmt.Sprintf("priority %s, components %v attributed by %s, cluster %s, tenant %s",
+			stored.Priority, components, attributedBy, stored.ClusterID, stored.TenantID))
+	}
+
+	// Search mirror, flushed by processRecord's caller in a normal sync
+	started = time.Now()
+	if idx := GetSearchIndex(); idx == nil {
+		result.stage("search_index", started, StageSkipped, "SEARCH_URL not configured")
+	} else {
+		u.flushSearchIndex()
+		found, err := idx.HasDocument(ctx, id)
+		switch {
+		case err != nil:
+			result.stage("search_index", started, StageFailed, err.Error())
+		case !found:
+			result.stage("search_index", started, StageFailed, "alert missing from index "+idx.Name())
+		default:
+			result.stage("search_index", started, StagePassed, "indexed in "+idx.Name())
+		}
+	}
+
+	// Routing: whether the rules notify config lets the alert through, and which
+	// notification rules pick it up
+	started = time.Now()
+	if reason := GetRulesNotifyManager().Suppression(stored.BizType, stored.TenantID, stored.ClusterID); reason != "" {
+		result.stage("route", started, StageFailed,
+			fmt.Sprintf("suppressed by the rules notify config (%s) for tenant %s, cluster %s", reason, stored.TenantID, stored.ClusterID))
+		return result, nil
+	}
+	rules, err := MatchNotificationRules(gdb.WithContext(ctx), components, stored.Priority)
+	if err != nil {
+		result.stage("route", started, StageFailed, err.Error())
+		return result, nil
+	}
+	if len(rules) == 0 {
+		result.stage("route", started, StageFailed,
+			fmt.Sprintf("no enabled notification rule routes %s alerts for %v", stored.Priority, components))
+		return result, nil
+	}
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, fmt.Sprintf("%s (%s, fires at %d in %dm)", rule.Name, rule.Channel, rule.Threshold, rule.WindowMinutes))
+	}
+	result.stage("route", started, StagePassed, "matched "+strings.Join(names, ", "))
+
+	// Delivery: one test message per matched rule, bypassing its threshold
+	started = time.Now()
+	if !req.Deliver {
+		result.stage("deliver", started, StageSkipped, "set deliver to send a test message to each matched rule")
+		return result, nil
+	}
+	client := NewOutboundClient(10 * time.Second)
+	msg := Message{
+		Title: SyntheticAlertPrefix + " Alert pipeline test",
+		Text:  fmt.Sprintf("Test alert %s (%s, %v) reached this channel. No action needed.", id, stored.Priority, components),
+		Data:  map[string]interface{}{"issue_id": id, "synthetic": true, "components": components, "priority": stored.Priority},
+	}
+	var failures, delivered []string
+	for _, rule := range rules {
+		if err := SendSignedMessage(ctx, client, rule.Channel, rule.Target, rule.Secret, msg); err != nil {
+			failures = append(failures, rule.Name+": "+err.Error())
+		} else {
+			delivered = append(delivered, rule.Name)
+		}
+	}
+	if len(failures) > 0 {
+		result.stage("deliver", started, StageFailed, strings.Join(failures, "; "))
+	} else {
+		result.stage("deliver", started, StagePassed, "delivered via "+strings.Join(delivered, ", "))
+	}
+	return result, nil
+}
+
+// syntheticJiraIssue builds the issue as the JIRA client would return it
+func syntheticJiraIssue(id string, req SyntheticAlertRequest) JiraIssue {
+	labels := map[string]interface{}{
+		"alertname":       "SyntheticPipelineTest",
+		"tidb_cluster_id": req.ClusterID,
+		"o11y_tenant_id":  req.TenantID,
+	}
+	if req.BizType != "" {
+		labels["o11y_biz_type"] = req.BizType
+	}
+	var jiraComponents []JiraComponent
+	if req.Component != "" {
+		labels["component"] = req.Component
+		jiraComponents = append(jiraComponents, JiraComponent{Name: req.Component})
+	}
+
+	return JiraIssue{
+		Key: id,
+		Fields: JiraIssueFields{
+			Summary:      fmt.Sprintf("%s Pipeline test alert %s", SyntheticAlertPrefix, id),
+			Description:  "Synthetic alert injected through POST /api/admin/synthetic-alert to verify the alert pipeline.",
+			Created:      time.Now().Format("2006-01-02T15:04:05.000-0700"),
+			Priority:     &JiraPriority{Name: req.Priority},
+			Labels:       []string{SyntheticAlertLabel},
+			IssueType:    &JiraIssueType{Name: "Alert"},
+			Component:    jiraComponents,
+			Project:      JiraProject{Key: SyntheticAlertProject},
+			Status:       &JiraStatus{Name: "Open"},
+			RawAlertData: map[string]interface{}{"labels": labels},
+		},
+	}
+}
+
+// syntheticEnrichmentProblems compares the stored alert with what was injected
+func syntheticEnrichmentProblems(stored models.Issue, components []string, req SyntheticAlertRequest) []string {
+	var problems []string
+	if !stored.IsAlert {
+		problems = append(problems, "not detected as an alert")
+	}
+	if want, _ := GetNormalizationConfig().Priority.Normalize(req.Priority); stored.Priority != want {
+		problems = append(problems, fmt.Sprintf("priority %q, want %q", stored.Priority, want))
+	}
+	if stored.ClusterID != req.ClusterID {
+		problems = append(problems, fmt.Sprintf("cluster_id %q, want %q", stored.ClusterID, req.ClusterID))
+	}
+	if stored.TenantID != req.TenantID {
+		problems = append(problems, fmt.Sprintf("tenant_id %q, want %q", stored.TenantID, req.TenantID))
+	}
+	if req.Component != "" {
+		found := false
+		for _, c := range components {
+			found = found || strings.EqualFold(c, req.Component)
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("attributed to %v by %q, want %s", components, stored.AttributedBy, req.Component))
+		}
+	}
+	return problems
+}
+
+// removeSyntheticAlert deletes a test alert everywhere the pipeline put it
+func removeSyntheticAlert(ctx context.Context, gdb *gorm.DB, id string) error {
+	dbc := gdb.WithContext(ctx)
+	if err := dbc.Where("id = ?", id).Delete(&models.Issue{}).Error; err != nil {
+		return fmt.Errorf("failed to delete %s: %w", id, err)
+	}
+	dbc.Where("issue_id = ?", id).Delete(&models.IssueComponent{})
+	dbc.Where("issue_id = ?", id).Delete(&models.FailedIssue{})
+	if idx := GetSearchIndex(); idx != nil {
+		if err := idx.DeleteDocument(ctx, id); err != nil {
+			return fmt.Errorf("failed to remove %s from the search index: %w", id, err)
+		}
+	}
+	return nil
+}
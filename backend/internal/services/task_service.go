@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"os"
 	"os/exec"
@@ -15,31 +17,35 @@ import (
 type TaskService struct {
 	DB           *gorm.DB
 	RulesService *RulesService
+	logger       *slog.Logger
 }
 
 func NewTaskService(db *gorm.DB, rulesService *RulesService) *TaskService {
 	return &TaskService{
 		DB:           db,
 		RulesService: rulesService,
+		logger:       slog.Default().With("service", "tasks"),
 	}
 }
 
-// CreateTask saves a new task and starts the simulation worker
-func (s *TaskService) CreateTask(task *models.Task) error {
+// CreateTask saves a new task and starts the simulation worker.
+// ctx only bounds the insert; the worker runs detached from the request.
+func (s *TaskService) CreateTask(ctx context.Context, task *models.Task) error {
 	task.Status = "submitted"
-	if err := s.DB.Create(task).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Create(task).Error; err != nil {
 		return err
 	}
+	publishTaskStatus(*task)
 
 	// Trigger simulation for "Claude Code" processing
 	go s.simulateProcessing(task.ID)
 	return nil
 }
 
-func (s *TaskService) GetTasksByComponent(component string) ([]models.Task, error) {
+func (s *TaskService) GetTasksByComponent(ctx context.Context, component string) ([]models.Task, error) {
 	var tasks []models.Task
 	// Order by newest first
-	if err := s.DB.Where("component = ?", component).Order("created_at desc").Find(&tasks).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("component = ?", component).Order("created_at desc").Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 	return tasks, nil
@@ -59,11 +65,17 @@ func (s *TaskService) simulateProcessing(taskID uint) {
 	// Retrieve the task to get details
 	var task models.Task
 	if err := s.DB.First(&task, taskID).Error; err != nil {
-		fmt.Printf("❌ Failed to load task %d: %v\n", taskID, err)
+		s.logger.Error("Failed to load task", "task_id", taskID, "err", err)
 		return
 	}
 
-	fmt.Printf("🔍 Agent looking for rule '%s' in component '%s'...\n", task.RuleName, task.Component)
+	// Tasks submitted with a ready diff (bundle imports, template instances) skip the agent
+	if task.Diff != "" {
+		s.openPullRequest(taskID)
+		return
+	}
+
+	s.logger.Info("Agent looking for rule", "task_id", taskID, "rule", task.RuleName, "component", task.Component)
 
 	existingRules, err := s.RulesService.GetRulesForComponent(task.Component)
 	var existingRuleContent string
@@ -88,7 +100,7 @@ func (s *TaskService) simulateProcessing(taskID uint) {
 			}
 		}
 	} else {
-		fmt.Printf("⚠️ Failed to fetch rules: %v\n", err)
+		s.logger.Warn("Failed to fetch rules", "task_id", taskID, "err", err)
 	}
 
 	// Try running Claude Code
@@ -99,13 +111,13 @@ func (s *TaskService) simulateProcessing(taskID uint) {
 		// Construct prompt
 		prompt := fmt.Sprintf("Edit %s to match this new rule definition: %s", relativePath, task.RuleContent)
 
-		fmt.Printf("🤖 invoking 'claude code --headless' in %s\n", s.RulesService.RepoPath)
+		s.logger.Info("Invoking headless agent", "task_id", taskID, "repo", s.RulesService.RepoPath)
 		cmd := exec.Command("claude", "code", "--headless", "-p", prompt)
 		cmd.Dir = s.RulesService.RepoPath
 
 		output, err := cmd.CombinedOutput()
 		if err == nil {
-			fmt.Printf("✅ Claude Code executed successfully\n")
+			s.logger.Info("Agent finished", "task_id", taskID)
 			// In a real scenario, we'd PARSE the output or `git diff` to get the diff.
 			// Re-read file to see if it changed
 			newData, _ := os.ReadFile(filePath)
@@ -117,16 +129,16 @@ func (s *TaskService) simulateProcessing(taskID uint) {
 				claudeSuccess = true
 			} else {
 				// Command success but no change?
-				fmt.Println("⚠️ Claude finished but file didn't change.")
+				s.logger.Warn("Agent finished but the file did not change", "task_id", taskID)
 			}
 		} else {
-			fmt.Printf("⚠️ Claude Code failed or not found: %v. Output: %s\n", err, string(output))
+			s.logger.Warn("Agent failed or not found", "task_id", taskID, "err", err, "output", string(output))
 		}
 	}
 
 	// Fallback simulation if Claude didn't run or didn't change anything
 	if !claudeSuccess {
-		fmt.Println("🔄 Falling back to simulated diff")
+		s.logger.Info("Falling back to simulated diff", "task_id", taskID)
 		if filePath != "" {
 			diff = fmt.Sprintf("--- %s\n+++ %s (PROPOSED)\n@@ -1 +1 @@\n", filePath, filePath)
 			diff += fmt.Sprintf("- Original Content Length: %d bytes\n", len(existingRuleContent))
@@ -140,6 +152,11 @@ func (s *TaskService) simulateProcessing(taskID uint) {
 	// Update Task with Diff
 	s.DB.Model(&task).Update("diff", diff)
 
+	s.openPullRequest(taskID)
+}
+
+// openPullRequest moves a task with its diff ready to waiting_for_review
+func (s *TaskService) openPullRequest(taskID uint) {
 	// Step 3: Wait a bit more
 	time.Sleep(3 * time.Second)
 
@@ -147,7 +164,7 @@ func (s *TaskService) simulateProcessing(taskID uint) {
 	prLink := fmt.Sprintf("https://github.com/org/repo/pull/%d", rand.Intn(1000)+1000)
 	s.updateStatus(taskID, "waiting_for_review", prLink)
 
-	fmt.Printf("🔔 [Notification] Task %d ready. PR: %s\n", taskID, prLink)
+	s.logger.Info("Task ready", "task_id", taskID, "pr", prLink)
 }
 
 func (s *TaskService) updateStatus(taskID uint, status string, prLink string) {
@@ -156,4 +173,30 @@ func (s *TaskService) updateStatus(taskID uint, status string, prLink string) {
 		updates["pr_link"] = prLink
 	}
 	s.DB.Model(&models.Task{}).Where("id = ?", taskID).Updates(updates)
+
+	var task models.Task
+	if err := s.DB.First(&task, taskID).Error; err != nil {
+		return
+	}
+	publishTaskStatus(task)
+	if status == "waiting_for_review" {
+		if sqlDB, err := s.DB.DB(); err == nil {
+			NewNotifier(sqlDB).NotifyTaskReview(taskStatusEvent(task))
+			NewWebhookDispatcher(sqlDB).Fire(WebhookTaskReview, taskStatusEvent(task))
+		}
+	}
+}
+
+func publishTaskStatus(task models.Task) {
+	PublishEvent(EventTaskStatus, taskStatusEvent(task))
+}
+
+func taskStatusEvent(task models.Task) TaskStatusEvent {
+	return TaskStatusEvent{
+		ID:        task.ID,
+		Component: task.Component,
+		RuleName:  task.RuleName,
+		Status:    task.Status,
+		PRLink:    task.PRLink,
+	}
 }
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tenant digest defaults, overridable via TENANT_DIGEST_MIN_ALERTS / TENANT_DIGEST_MAX_TENANTS
+const (
+	TenantDigestLockName      = "tenant_digest"
+	TenantDigestLockTTL       = 5 * time.Minute
+	tenantDigestMinAlertsDef  = 50
+	tenantDigestMaxTenantsDef = 20
+	tenantDigestTopN          = 5
+)
+
+// DigestCount is a named count in a digest's top lists
+type DigestCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// TenantDigest summarizes one noisy tenant's week
+type TenantDigest struct {
+	TenantID      string        `json:"tenant_id"`
+	TenantName    string        `json:"tenant_name"`
+	Tier          string        `json:"tier,omitempty"`
+	Alerts        int64         `json:"alerts"`
+	Previous      int64         `json:"previous"`
+	Change        float64       `json:"change"` // percent vs the week before
+	Critical      int64         `json:"critical"`
+	TopSignatures []DigestCount `json:"top_signatures"`
+	TopClusters   []DigestCount `json:"top_clusters"`
+	DailyTrend    []DigestCount `json:"daily_trend"` // one entry per day, Name is the date
+}
+
+// TenantDigestService builds the weekly digest of tenants above the alert threshold and
+// posts one message per tenant to TENANT_DIGEST_CHANNEL / TENANT_DIGEST_TARGET
+type TenantDigestService struct {
+	DB     *gorm.DB
+	client *http.Client
+}
+
+func NewTenantDigestService(db *gorm.DB) *TenantDigestService {
+	return &TenantDigestService{
+		DB:     db,
+		client: NewOutboundClient(10 * time.Second),
+	}
+}
+
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(config.Lookup(name)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// LastCompleteWeek returns the Monday (UTC) starting the most recent full week
+func LastCompleteWeek(now time.Time) time.Time {
+	day := now.UTC().Truncate(24 * time.Hour)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset-7)
+}
+
+// Build computes the digests for the week starting at weekStart without sending anything
+func (s *TenantDigestService) Build(ctx context.Context, weekStart time.Time) ([]TenantDigest, error) {
+	dbc := s.DB.WithContext(ctx)
+	start := weekStart.Format("2006-01-02 15:04:05")
+	end := weekStart.AddDate(0, 0, 7).Add(-time.Second).Format("2006-01-02 15:04:05")
+	prevStart := weekStart.AddDate(0, 0, -7).Format("2006-01-02 15:04:05")
+	prevEnd := weekStart.Add(-time.Second).Format("2006-01-02 15:04:05")
+	minAlerts := envInt("TENANT_DIGEST_MIN_ALERTS", tenantDigestMinAlertsDef)
+	maxTenants := envInt("TENANT_DIGEST_MAX_TENANTS", tenantDigestMaxTenantsDef)
+
+	var noisy []struct {
+		TenantID string
+		Alerts   int64
+		Critical int64
+		Name     string
+		Tier     string
+	}
+	err := dbc.Raw(`
+		SELECT i.tenant_id, COUNT(*) as alerts,
+			SUM(CASE WHEN i.priority = 'Critical' THEN 1 ELSE 0 END) as critical,
+			COALESCE(t.name, '') as name, COALESCE(t.tier, '') as tier
+		FROM issues i LEFT JOIN tenants t ON t.id = i.tenant_id
+		WHERE i.is_alert = TRUE AND i.tenant_id != '' AND i.created_at_utc BETWEEN ? AND ?
+		GROUP BY i.tenant_id
+		HAVING COUNT(*) >= ?
+		ORDER BY alerts DESC
+		LIMIT ?`, start, end, minAlerts, maxTenants).Scan(&noisy).Error
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]TenantDigest, 0, len(noisy))
+	for _, n := range noisy {
+		d := TenantDigest{
+			TenantID:   n.TenantID,
+			TenantName: n.Name,
+			Tier:       n.Tier,
+			Alerts:     n.Alerts,
+			Critical:   n.Critical,
+		}
+		if d.TenantName == "" {
+			d.TenantName = n.TenantID
+		}
+
+		dbc.Raw(`SELECT COUNT(*) FROM issues WHERE is_alert = TRUE AND tenant_id = ?
+			AND created_at_utc BETWEEN ? AND ?`, n.TenantID, prevStart, prevEnd).Scan(&d.Previous)
+		if d.Previous > 0 {
+			d.Change = float64(d.Alerts-d.Previous) / float64(d.Previous) * 100
+		} else {
+			d.Change = 100
+		}
+
+		d.TopSignatures = s.topCounts(dbc, "alert_signature", n.TenantID, start, end)
+		d.TopClusters = s.topCounts(dbc, "cluster_id", n.TenantID, start, end)
+		for i := range d.TopClusters {
+			if info, err := GetNameResolver().ResolveContext(ctx, d.TopClusters[i].Name); err == nil && info.Name != "" && info.Name != d.TopClusters[i].Name {
+				d.TopClusters[i].Name = info.Name + " (" + d.TopClusters[i].Name + ")"
+			}
+		}
+
+		perDay := map[string]int64{}
+		var days []DigestCount
+		dbc.Raw(`SELECT SUBSTR(created, 1, 10) as name, COUNT(*) as count FROM issues
+			WHERE is_alert = TRUE AND tenant_id = ? AND created_at_utc BETWEEN ? AND ?
+			GROUP BY 1`, n.TenantID, start, end).Scan(&days)
+		for _, day := range days {
+			perDay[day.Name] = day.Count
+		}
+		for i := 0; i < 7; i++ {
+			date := weekStart.AddDate(0, 0, i).Format("2006-01-02")
+			d.DailyTrend = append(d.DailyTrend, DigestCount{Name: date, Count: perDay[date]})
+		}
+
+		digests = append(digests, d)
+	}
+	return digests, ctx.Err()
+}
+
+func (s *TenantDigestService) topCounts(dbc *gorm.DB, column, tenantID, start, end string) []DigestCount {
+	top := []DigestCount{}
+	dbc.Raw(`SELECT `+column+` as name, COUNT(*) as count FROM issues
+		WHERE is_alert = TRUE AND tenant_id = ? AND `+column+` != '' AND created_at_utc BETWEEN ? AND ?
+		GROUP BY 1 ORDER BY count DESC LIMIT ?`, tenantID, start, end, tenantDigestTopN).Scan(&top)
+	return top
+}
+
+// Run builds and delivers the digest for weekStart. A week is delivered once: tenants
+// already sent are skipped, so a failed delivery can be retried without duplicates.
+// With force, the week is rebuilt and sent to every tenant again.
+func (s *TenantDigestService) Run(ctx context.Context, weekStart time.Time, force bool) (*models.TenantDigestRun, error) {
+	week := weekStart.Format("2006-01-02")
+	channel := strings.ToLower(config.Lookup("TENANT_DIGEST_CHANNEL"))
+	target := config.Lookup("TENANT_DIGEST_TARGET")
+	secret := config.Lookup("TENANT_DIGEST_SECRET")
+
+	var run models.TenantDigestRun
+	exists := s.DB.Where("week_start = ?", week).First(&run).Error == nil
+	if exists && run.Status == "sent" && !force {
+		return &run, nil
+	}
+
+	digests, err := s.Build(ctx, weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tenant digest: %w", err)
+	}
+
+	sent := map[string]bool{}
+	if exists && !force && run.SentTenants != "" {
+		var ids []string
+		json.Unmarshal([]byte(run.SentTenants), &ids)
+		for _, id := range ids {
+			sent[id] = true
+		}
+	}
+
+	run.WeekStart = week
+	run.TenantCount = len(digests)
+	run.Channel = channel
+	run.Error = ""
+	run.UpdatedAt = time.Now()
+
+	var failures []string
+	if channel == "" || target == "" {
+		run.Status = "skipped"
+	} else {
+		for _, d := range digests {
+			if sent[d.TenantID] {
+				continue
+			}
+			if err := SendSignedMessage(ctx, s.client, channel, target, secret, tenantDigestMessage(d, week)); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", d.TenantID, err))
+				continue
+			}
+			sent[d.TenantID] = true
+		}
+		run.Status = "sent"
+		if len(failures) > 0 {
+			run.Status = "partial"
+			run.Error = strings.Join(failures, "; ")
+		}
+	}
+
+	sentIDs := make([]string, 0, len(sent))
+	for id := range sent {
+		sentIDs = append(sentIDs, id)
+	}
+	sentJSON, _ := json.Marshal(sentIDs)
+	reportJSON, _ := json.Marshal(digests)
+	run.SentTenants = string(sentJSON)
+	run.Report = string(reportJSON)
+
+	err = s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "week_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tenant_count", "channel", "status", "error", "sent_tenants", "report", "updated_at"}),
+	}).Create(&run).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save tenant digest run: %w", err)
+	}
+	s.DB.Where("week_start = ?", week).First(&run)
+
+	if len(failures) > 0 {
+		return &run, fmt.Errorf("%d of %d tenant digests failed to send", len(failures), len(digests))
+	}
+	return &run, nil
+}
+
+// tenantDigestMessage renders one tenant's digest as text
+func tenantDigestMessage(d TenantDigest, week string) Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d alerts (%d critical), %+.0f%% vs the previous week (%d)\n", d.Alerts, d.Critical, d.Change, d.Previous)
+	if d.Tier != "" {
+		fmt.Fprintf(&b, "Tier: %s\n", d.Tier)
+	}
+	b.WriteString("\nTop signatures:\n")
+	for _, s := range d.TopSignatures {
+		fmt.Fprintf(&b, "  %5d  %s\n", s.Count, s.Name)
+	}
+	b.WriteString("\nTop clusters:\n")
+	for _, c := range d.TopClusters {
+		fmt.Fprintf(&b, "  %5d  %s\n", c.Count, c.Name)
+	}
+	b.WriteString("\nDaily alerts:\n")
+	for _, p := range d.DailyTrend {
+		fmt.Fprintf(&b, "  %s  %d\n", p.Name, p.Count)
+	}
+
+	return Message{
+		Title: fmt.Sprintf("Weekly alert digest for %s (week of %s)", d.TenantName, week),
+		Text:  b.String(),
+		Level: LevelWarning,
+		Data:  d,
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/config"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTenantAPINotConfigured is returned by SyncFromAPI when TENANT_API_URL is unset
+var ErrTenantAPINotConfigured = errors.New("TENANT_API_URL is not configured")
+
+// TenantService maintains tenant tier/plan metadata used for tier-based breakdowns
+type TenantService struct {
+	DB     *gorm.DB
+	client *http.Client
+}
+
+func NewTenantService(db *gorm.DB) *TenantService {
+	return &TenantService{
+		DB:     db,
+		client: NewOutboundClient(30 * time.Second),
+	}
+}
+
+// tenantRecord is the tenant API payload; both "id" and "tenant_id" are accepted
+type tenantRecord struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Name     string `json:"name"`
+	Tier     string `json:"tier"`
+	Plan     string `json:"plan"`
+}
+
+// SyncFromAPI fetches tenants from TENANT_API_URL (a JSON array, or {"data": [...]})
+func (s *TenantService) SyncFromAPI(ctx context.Context) (int, error) {
+	url := config.Lookup("TENANT_API_URL")
+	if url == "" {
+		return 0, ErrTenantAPINotConfigured
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if token := config.Lookup("TENANT_API_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("tenant api returned status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var records []tenantRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		var wrapped struct {
+			Data []tenantRecord `json:"data"`
+		}
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return 0, fmt.Errorf("failed to decode tenant api response: %w", err)
+		}
+		records = wrapped.Data
+	}
+
+	tenants := make([]models.Tenant, 0, len(records))
+	for _, r := range records {
+		id := r.TenantID
+		if id == "" {
+			id = r.ID
+		}
+		if id == "" {
+			continue
+		}
+		tenants = append(tenants, models.Tenant{ID: id, Name: r.Name, Tier: normalizeTier(r.Tier), Plan: r.Plan, Source: "api"})
+	}
+
+	return s.upsert(ctx, tenants)
+}
+
+// ImportCSV loads tenants from CSV with a header row. Required columns: tenant_id (or id)
+// and tier; name and plan are optional.
+func (s *TenantService) ImportCSV(ctx context.Context, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	idCol, ok := cols["tenant_id"]
+	if !ok {
+		if idCol, ok = cols["id"]; !ok {
+			return 0, errors.New("csv is missing a tenant_id column")
+		}
+	}
+	if _, ok := cols["tier"]; !ok {
+		return 0, errors.New("csv is missing a tier column")
+	}
+
+	get := func(row []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var tenants []models.Tenant
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		if idCol >= len(row) || strings.TrimSpace(row[idCol]) == "" {
+			continue
+		}
+		tenants = append(tenants, models.Tenant{
+			ID:     strings.TrimSpace(row[idCol]),
+			Name:   get(row, "name"),
+			Tier:   normalizeTier(get(row, "tier")),
+			Plan:   get(row, "plan"),
+			Source: "csv",
+		})
+	}
+
+	return s.upsert(ctx, tenants)
+}
+
+// List returns tenants, optionally filtered by tier
+func (s *TenantService) List(ctx context.Context, tier string) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	query := s.DB.WithContext(ctx).Order("id")
+	if tier != "" {
+		query = query.Where("tier = ?", normalizeTier(tier))
+	}
+	if err := query.Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func (s *TenantService) upsert(ctx context.Context, tenants []models.Tenant) (int, error) {
+	if len(tenants) == 0 {
+		return 0, nil
+	}
+	err := s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "tier", "plan", "source", "updated_at"}),
+	}).CreateInBatches(tenants, 500).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(tenants), nil
+}
+
+func normalizeTier(tier string) string {
+	return strings.ToLower(strings.TrimSpace(tier))
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ThresholdExpr is an alert expression split into "<query> <op> <threshold>"
+type ThresholdExpr struct {
+	Query     string  `json:"query"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+}
+
+// ParseThresholdExpr splits an alert expression at its top-level comparison against a
+// numeric literal, e.g. `rate(x[5m]) > 0.5`. Expressions without one can't be tuned.
+func ParseThresholdExpr(expr string) (*ThresholdExpr, error) {
+	depth := 0
+	var quote rune
+	lastOp, lastPos := "", -1
+	runes := []rune(expr)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		if quote != 0 {
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'', '`':
+			quote = ch
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '>', '<', '=', '!':
+			if depth != 0 {
+				continue
+			}
+			op := string(ch)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				continue
+			}
+			lastOp, lastPos = op, i
+			i += len(op) - 1
+		}
+	}
+	if lastPos < 0 {
+		return nil, fmt.Errorf("expr has no top-level comparison")
+	}
+
+	query := strings.TrimSpace(string(runes[:lastPos]))
+	rest := strings.TrimSpace(string(runes[lastPos+len(lastOp):]))
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, "bool"))
+	threshold, err := strconv.ParseFloat(rest, 64)
+	if err != nil || query == "" {
+		return nil, fmt.Errorf("expr does not compare against a numeric threshold")
+	}
+	return &ThresholdExpr{Query: query, Operator: lastOp, Threshold: threshold}, nil
+}
+
+// Percentile returns the p-th percentile (0-100) of sorted values using linear interpolation
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// FiringRatio is the share of samples for which "value <op> threshold" holds
+func FiringRatio(values []float64, op string, threshold float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	n := 0
+	for _, v := range values {
+		var fires bool
+		switch op {
+		case ">":
+			fires = v > threshold
+		case ">=":
+			fires = v >= threshold
+		case "<":
+			fires = v < threshold
+		case "<=":
+			fires = v <= threshold
+		case "==":
+			fires = v == threshold
+		case "!=":
+			fires = v != threshold
+		}
+		if fires {
+			n++
+		}
+	}
+	return float64(n) / float64(len(values))
+}
+
+// SortedCopy returns values sorted ascending without modifying the input
+func SortedCopy(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted
+}
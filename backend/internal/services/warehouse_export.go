@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WarehouseExportLockName = "warehouse_export"
+	WarehouseExportLockTTL  = 10 * time.Minute
+	// How far back a fresh or long-stopped exporter catches up
+	warehouseExportBackfillDays = 7
+)
+
+// WarehouseExporter writes one UTC day of issues and aggregates per run as CSV files
+// partitioned by date=YYYY-MM-DD
+type WarehouseExporter struct {
+	DB     *gorm.DB
+	Target string
+	sink   ExportSink
+}
+
+func NewWarehouseExporter(db *gorm.DB, target string) (*WarehouseExporter, error) {
+	if target == "" {
+		return nil, fmt.Errorf("warehouse export is disabled (EXPORT_TARGET is not set)")
+	}
+	sink, err := NewExportSink(target)
+	if err != nil {
+		return nil, err
+	}
+	return &WarehouseExporter{DB: db, Target: target, sink: sink}, nil
+}
+
+// RunPending exports every finished day since the last successful export, going back at
+// most warehouseExportBackfillDays. Failed days are retried on the next run.
+func (e *WarehouseExporter) RunPending(ctx context.Context) ([]models.WarehouseExport, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -warehouseExportBackfillDays)
+
+	var last models.WarehouseExport
+	if err := e.DB.Where("status = ?", "success").Order("date DESC").First(&last).Error; err == nil {
+		if d, err := time.Parse("2006-01-02", last.Date); err == nil && d.AddDate(0, 0, 1).After(start) {
+			start = d.AddDate(0, 0, 1)
+		}
+	}
+
+	var runs []models.WarehouseExport
+	for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+		run, err := e.ExportDay(ctx, day)
+		if run != nil {
+			runs = append(runs, *run)
+		}
+		if err != nil {
+			return runs, err
+		}
+	}
+	return runs, nil
+}
+
+// ExportDay writes the given day's files and records the outcome. Re-exporting a day
+// overwrites its files.
+func (e *WarehouseExporter) ExportDay(ctx context.Context, day time.Time) (*models.WarehouseExport, error) {
+	date := day.UTC().Format("2006-01-02")
+	run := &models.WarehouseExport{Date: date, Target: e.Target, Status: "success"}
+
+	files, rows, exportErr := e.exportFiles(ctx, date)
+	run.Files, run.Rows = files, rows
+	if exportErr != nil {
+		run.Status = "failed"
+		run.Error = exportErr.Error()
+	}
+
+	err := e.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target", "files", "rows", "status", "error", "updated_at"}),
+	}).Create(run).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to record export of %s: %w", date, err)
+	}
+	// The upsert leaves the ID unset when an existing row was updated
+	e.DB.Where("date = ?", date).First(run)
+
+	if exportErr != nil {
+		return run, fmt.Errorf("export of %s failed: %w", date, exportErr)
+	}
+	return run, nil
+}
+
+func (e *WarehouseExporter) exportFiles(ctx context.Context, date string) (files, rows int, err error) {
+	dbc := e.DB.WithContext(ctx)
+	start, end := date+" 00:00:00", date+" 23:59:59"
+
+	// Issues, minus the description and raw payload which are too large for analytics
+	issueColumns := []string{
+		"id", "created", "project", "issue_type", "priority", "status", "is_alert", "is_subtask",
+		"alert_signature", "cluster_id", "tenant_id", "biz_type", "components", "stability_governance",
+		"visibility", "component_name", "source_component", "alert_group", "duplicate_of", "occurrence_count",
+		"attributed_by",
+	}
+	issueRows, err := queryRows(dbc, `SELECT `+strings.Join(issueColumns, ", ")+` FROM issues
+		WHERE created_at_utc BETWEEN ? AND ? ORDER BY created, id`, start, end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	totalColumns := []string{"date", "alerts", "prod_alerts", "critical", "major", "fake_alarms", "handled", "signatures", "clusters"}
+	totalRows, err := queryRows(dbc, `SELECT ? as date,
+			COUNT(*) as alerts,
+			COALESCE(SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END), 0) as prod_alerts,
+			COALESCE(SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END), 0) as critical,
+			COALESCE(SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END), 0) as major,
+			COALESCE(SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END), 0) as fake_alarms,
+			COALESCE(SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END), 0) as handled,
+			COUNT(DISTINCT alert_signature) as signatures,
+			COUNT(DISTINCT NULLIF(cluster_id, '')) as clusters
+		FROM issues WHERE is_alert = TRUE AND created_at_utc BETWEEN ? AND ?`, date, start, end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	componentColumns := []string{"date", "component", "alerts", "critical", "major", "fake_alarms", "handled", "signatures"}
+	componentRows, err := queryRows(dbc, `SELECT ? as date, j.value as component,
+			COUNT(*) as alerts,
+			SUM(CASE WHEN priority = 'Critical' THEN 1 ELSE 0 END) as critical,
+			SUM(CASE WHEN priority = 'Major' THEN 1 ELSE 0 END) as major,
+			SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END) as fake_alarms,
+			SUM(CASE WHEN status != 'Created' THEN 1 ELSE 0 END) as handled,
+			COUNT(DISTINCT alert_signature) as signatures
+		FROM issues, `+db.JSONEach("issues.components", "j")+`
+		WHERE is_alert = TRUE AND created_at_utc BETWEEN ? AND ?
+		GROUP BY j.value ORDER BY alerts DESC`, date, start, end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	outputs := []struct {
+		key     string
+		columns []string
+		rows    [][]string
+	}{
+		{"issues/date=" + date + "/issues.csv", issueColumns, issueRows},
+		{"aggregates/date=" + date + "/daily_totals.csv", totalColumns, totalRows},
+		{"aggregates/date=" + date + "/component_daily.csv", componentColumns, componentRows},
+	}
+	for _, out := range outputs {
+		body, err := encodeCSV(out.columns, out.rows)
+		if err != nil {
+			return files, rows, err
+		}
+		if err := e.sink.Put(ctx, out.key, body, "text/csv"); err != nil {
+			return files, rows, fmt.Errorf("failed to write %s: %w", out.key, err)
+		}
+		files++
+		rows += len(out.rows)
+	}
+	return files, rows, nil
+}
+
+// queryRows runs a raw query and returns every row as strings, NULL as empty
+func queryRows(dbc *gorm.DB, query string, args ...interface{}) ([][]string, error) {
+	rs, err := dbc.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	cols, err := rs.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var out [][]string
+	for rs.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rs.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = csvValue(v)
+		}
+		out = append(out, row)
+	}
+	return out, rs.Err()
+}
+
+func csvValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(x)
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func encodeCSV(columns []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Outbound webhook event types
+const (
+	WebhookCriticalAlert = "alert.critical"     // a new Critical prod alert
+	WebhookFakeRate      = "fake_rate.exceeded" // the weekly fake alarm rate rose above the webhook's threshold
+	WebhookTaskReview    = "task.review"        // a task waiting for review
+	WebhookPing          = "ping"               // a test delivery, never subscribed to
+)
+
+var webhookEvents = []string{WebhookCriticalAlert, WebhookFakeRate, WebhookTaskReview}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Deliveries are attempted up to webhookMaxAttempts times, waiting webhookBackoff before
+// the first retry and doubling it after every other
+const (
+	webhookMaxAttempts = 5
+	webhookBackoff     = 2 * time.Second
+)
+
+// fakeRateWindow is the window the fake alarm rate event is evaluated over
+const fakeRateWindow = 7 * 24 * time.Hour
+
+// WebhookPayload is the body of every delivery
+type WebhookPayload struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	At    time.Time   `json:"at"`
+	Data  interface{} `json:"data"`
+}
+
+// FakeRateEvent is the data of a fake_rate.exceeded delivery
+type FakeRateEvent struct {
+	Since      string  `json:"since"`
+	Alerts     int64   `json:"alerts"`
+	FakeAlarms int64   `json:"fake_alarms"`
+	FakeRate   float64 `json:"fake_rate"` // percent
+	Threshold  float64 `json:"threshold"` // percent
+}
+
+// ValidateWebhook checks a webhook before it is stored and normalizes its events
+func ValidateWebhook(hook *models.Webhook) []string {
+	var problems []string
+
+	hook.Name = strings.TrimSpace(hook.Name)
+	if hook.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	hook.URL = strings.TrimSpace(hook.URL)
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		problems = append(problems, "url must be an http(s) URL")
+	}
+	hook.Secret = strings.TrimSpace(hook.Secret)
+
+	events := []string{}
+	for _, e := range hook.Events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		switch {
+		case !containsString(webhookEvents, e):
+			problems = append(problems, fmt.Sprintf("unknown event %q (expected %s)", e, strings.Join(webhookEvents, ", ")))
+		case !containsString(events, e):
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		problems = append(problems, fmt.Sprintf("at least one event is required (%s)", strings.Join(webhookEvents, ", ")))
+	}
+	hook.Events = events
+
+	if containsString(hook.Events, WebhookFakeRate) && (hook.FakeRateThreshold <= 0 || hook.FakeRateThreshold >= 100) {
+		problems = append(problems, "fake_rate_threshold must be a percentage between 0 and 100 for "+WebhookFakeRate)
+	}
+	return problems
+}
+
+// WebhookDispatcher sends events to the webhooks subscribed to them and logs every
+// delivery in webhook_deliveries. Like the Notifier it is off the caller's path.
+type WebhookDispatcher struct {
+	db     *sql.DB
+	client *http.Client
+	logger *slog.Logger
+}
+
+func NewWebhookDispatcher(db *sql.DB) *WebhookDispatcher {
+	return &WebhookDispatcher{db: db, client: NewOutboundClient(10 * time.Second), logger: slog.Default().With("service", "webhooks")}
+}
+
+// fakeRateExceeded holds, per webhook, whether the last evaluation was above its
+// threshold, so the event fires once per crossing rather than after every sync
+var fakeRateExceeded struct {
+	sync.Mutex
+	above map[uint]bool
+}
+
+// Fire delivers data to every enabled webhook subscribed to event
+func (d *WebhookDispatcher) Fire(event string, data interface{}) {
+	go func() {
+		hooks, err := d.enabledWebhooks()
+		if err != nil {
+			d.logger.Warn("Failed to load webhooks", "event", event, "err", err)
+			return
+		}
+		for _, hook := range hooks {
+			if containsString(hook.Events, event) {
+				go d.Deliver(context.Background(), hook, event, data)
+			}
+		}
+	}()
+}
+
+// CheckFakeRate fires the fake alarm rate event for the webhooks whose threshold the
+// alerts of the last week went above since the previous check
+func (d *WebhookDispatcher) CheckFakeRate() {
+	go func() {
+		hooks, err := d.enabledWebhooks()
+		if err != nil {
+			d.logger.Warn("Failed to load webhooks", "event", WebhookFakeRate, "err", err)
+			return
+		}
+		var subscribed []models.Webhook
+		for _, hook := range hooks {
+			if containsString(hook.Events, WebhookFakeRate) {
+				subscribed = append(subscribed, hook)
+			}
+		}
+		if len(subscribed) == 0 {
+			return
+		}
+
+		since := time.Now().UTC().Add(-fakeRateWindow).Format("2006-01-02 15:04:05")
+		event := FakeRateEvent{Since: since}
+		err = d.db.QueryRow(db.Rebind(`
+			SELECT COUNT(*), COALESCE(SUM(CASE WHEN status = 'FAKE ALARM' THEN 1 ELSE 0 END), 0)
+			FROM issues WHERE is_alert = TRUE AND created_at_utc >= ?`), since).Scan(&event.Alerts, &event.FakeAlarms)
+		if err != nil {
+			d.logger.Warn("Failed to compute the fake alarm rate", "err", err)
+			return
+		}
+		if event.Alerts > 0 {
+			event.FakeRate = float64(event.FakeAlarms) / float64(event.Alerts) * 100
+		}
+
+		fakeRateExceeded.Lock()
+		defer fakeRateExceeded.Unlock()
+		if fakeRateExceeded.above == nil {
+			fakeRateExceeded.above = map[uint]bool{}
+		}
+		for _, hook := range subscribed {
+			above := event.FakeRate > hook.FakeRateThreshold
+			if above && !fakeRateExceeded.above[hook.ID] {
+				event.Threshold = hook.FakeRateThreshold
+				go d.Deliver(context.Background(), hook, WebhookFakeRate, event)
+			}
+			fakeRateExceeded.above[hook.ID] = above
+		}
+	}()
+}
+
+// Deliver sends one event to hook, retrying with backoff on network errors, 5xx, 408
+// and 429 answers. Every attempt is recorded on the returned delivery.
+func (d *WebhookDispatcher) Deliver(ctx context.Context, hook models.Webhook, event string, data interface{}) (*models.WebhookDelivery, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	delivery := &models.WebhookDelivery{
+		ID:        hex.EncodeToString(buf),
+		WebhookID: hook.ID,
+		Event:     event,
+		Status:    DeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	body, err := json.Marshal(WebhookPayload{ID: delivery.ID, Event: event, At: now, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(body)
+
+	_, err = d.db.Exec(db.Rebind(`
+		INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status, attempts, response_status, error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, 0, 0, '', ?, ?)`),
+		delivery.ID, delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status, now, now)
+	if err != nil {
+		d.logger.Warn("Failed to log webhook delivery", "delivery_id", delivery.ID, "webhook", hook.Name, "err", err)
+	}
+
+	backoff := webhookBackoff
+	for {
+		delivery.Attempts++
+		retry, err := d.post(ctx, hook, delivery, body)
+		if err == nil {
+			delivered := time.Now().UTC()
+			delivery.Status, delivery.Error, delivery.DeliveredAt = DeliverySucceeded, "", &delivered
+			d.recordAttempt(delivery)
+			return delivery, nil
+		}
+		delivery.Error = err.Error()
+		if !retry || delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = DeliveryFailed
+			d.recordAttempt(delivery)
+			d.logger.Warn("Webhook delivery gave up", "webhook", hook.Name, "event", event, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "err", err)
+			return delivery, err
+		}
+		d.recordAttempt(delivery)
+
+		select {
+		case <-ctx.Done():
+			delivery.Status, delivery.Error = DeliveryFailed, ctx.Err().Error()
+			d.recordAttempt(delivery)
+			return delivery, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth retrying
+func (d *WebhookDispatcher) post(ctx context.Context, hook models.Webhook, delivery *models.WebhookDelivery, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	if hook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Webhook-Timestamp", timestamp)
+		req.Header.Set("X-Webhook-Signature", SignWebhook(hook.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	delivery.ResponseStatus = resp.StatusCode
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
+
+// SignWebhook is the X-Webhook-Signature of a delivery, the same scheme inbound webhooks
+// are verified with: sha256=hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *WebhookDispatcher) recordAttempt(delivery *models.WebhookDelivery) {
+	delivery.UpdatedAt = time.Now().UTC()
+	_, err := d.db.Exec(db.Rebind(`
+		UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = ?, error = ?, delivered_at = ?, updated_at = ?
+		WHERE id = ?`),
+		delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.Error, delivery.DeliveredAt, delivery.UpdatedAt, delivery.ID)
+	if err != nil {
+		d.logger.Warn("Failed to log webhook delivery", "delivery_id", delivery.ID, "err", err)
+	}
+}
+
+// enabledWebhooks loads the enabled webhooks
+func (d *WebhookDispatcher) enabledWebhooks() ([]models.Webhook, error) {
+	rows, err := d.db.Query(db.Rebind(`
+		SELECT id, name, url, secret, events, fake_rate_threshold
+		FROM webhooks WHERE enabled = ? ORDER BY id`), true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []models.Webhook
+	for rows.Next() {
+		var hook models.Webhook
+		var secret, events sql.NullString
+		if err := rows.Scan(&hook.ID, &hook.Name, &hook.URL, &secret, &events, &hook.FakeRateThreshold); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(events.String), &hook.Events)
+		hook.Secret, hook.Enabled = secret.String, true
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
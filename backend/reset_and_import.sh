@@ -13,7 +13,7 @@ cd "$(dirname "$0")"
 
 # Step 1: Stop server if running
 echo "[STEP 1] Checking if server is running..."
-if pgrep -f "go run cmd/server/main.go" > /dev/null; then
+if pgrep -f "cmd/server/main.go" > /dev/null; then
     echo "[WARN] Server is currently running. Please stop it first."
     echo "Press Ctrl+C to cancel, or Enter to continue anyway..."
     read
@@ -59,7 +59,7 @@ echo ""
 
 # Step 5: Start server in background
 echo "[STEP 5] Starting server..."
-go run cmd/server/main.go > server.log 2>&1 &
+go run -tags sqlite_fts5 cmd/server/main.go > server.log 2>&1 &
 SERVER_PID=$!
 echo "[INFO] Server started with PID: $SERVER_PID"
 
@@ -64,7 +64,7 @@ export const IssueList = ({
             }
 
             const res = await axios.get(url);
-            return res.data as Issue[];
+            return res.data.items as Issue[];
         }
     });
 
@@ -17,6 +17,14 @@ interface Issue {
     cluster_id?: string;
 }
 
+interface IssuePage {
+    items: Issue[];
+    total: number;
+    page: number;
+    page_size: number;
+    total_pages: number;
+}
+
 interface RecentIssuesPanelProps {
     componentName: string;
     env: string;
@@ -27,7 +35,7 @@ export const RecentIssuesPanel = ({ componentName, env, category }: RecentIssues
     const [page, setPage] = useState(1);
     const pageSize = 10;
 
-    const { data: issues, isLoading } = useQuery<Issue[]>({
+    const { data, isLoading } = useQuery<IssuePage>({
         queryKey: ['recentIssues', componentName, env, category, page],
         queryFn: async () => {
             const res = await axios.get(`${API_BASE_URL}/dashboard/issues`, {
@@ -44,6 +52,8 @@ export const RecentIssuesPanel = ({ componentName, env, category }: RecentIssues
         },
         placeholderData: keepPreviousData
     });
+    const issues = data?.items;
+    const totalPages = data?.total_pages ?? 0;
 
     return (
         <div className="bg-white border border-gray-200 rounded-xl shadow-sm overflow-hidden mb-8" >
@@ -63,10 +73,10 @@ export const RecentIssuesPanel = ({ componentName, env, category }: RecentIssues
                     >
                         Previous
                     </button>
-                    <span className="text-xs text-gray-500 font-medium px-2">Page {page}</span>
+                    <span className="text-xs text-gray-500 font-medium px-2">Page {page} of {Math.max(totalPages, 1)}</span>
                     <button
                         onClick={() => setPage(p => p + 1)}
-                        disabled={!issues || issues.length < pageSize}
+                        disabled={page >= totalPages}
                         className="px-3 py-1.5 text-xs font-medium text-gray-700 bg-white border border-gray-200 rounded-md hover:bg-gray-50 disabled:opacity-50 disabled:cursor-not-allowed"
                     >
                         Next
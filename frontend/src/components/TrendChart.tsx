@@ -106,7 +106,7 @@ export const TrendChart = ({
             // For now, we list all matching the scope.
 
             const res = await axios.get(url);
-            return res.data as Issue[];
+            return res.data.items as Issue[];
         }
     });
 
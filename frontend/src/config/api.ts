@@ -1 +1,22 @@
+import axios from 'axios';
+
 export const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:8818/api';
+
+// API key for backends with API_KEYS configured, from the build env or set at runtime with
+// localStorage.setItem('apiKey', '<key>')
+const API_KEY = import.meta.env.VITE_API_KEY || localStorage.getItem('apiKey');
+if (API_KEY) {
+    axios.defaults.headers.common['X-API-Key'] = API_KEY;
+}
+
+// With OIDC login enabled the backend answers 401 with a login_url; send the browser there
+// and come back to the current page afterwards
+axios.interceptors.response.use(undefined, (error) => {
+    const loginUrl = error?.response?.status === 401 && error.response.data?.login_url;
+    if (loginUrl) {
+        const origin = API_BASE_URL.replace(/\/api\/?$/, '');
+        const back = window.location.pathname + window.location.search;
+        window.location.href = `${origin}${loginUrl}?redirect=${encodeURIComponent(back)}`;
+    }
+    return Promise.reject(error);
+});